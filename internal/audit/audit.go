@@ -0,0 +1,90 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit records review decisions for compliance.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileName is the file, within the audit directory, that records are
+// appended to.
+const FileName = "audit.jsonl"
+
+// Record is one review decision. For privacy it holds no diff content or
+// review comments, only their sizes.
+type Record struct {
+	Timestamp time.Time `json:"timestamp"`
+	// Repo is the base name of the repository directory, or empty for a
+	// patch reviewed without one.
+	Repo          string `json:"repo"`
+	ChangedFiles  int    `json:"changed_files"`
+	Status        string `json:"status"`
+	LGTM          bool   `json:"lgtm"`
+	CommentLength int    `json:"comment_length"`
+}
+
+// Writer appends records as JSON lines to [FileName] in a directory. It is
+// safe for concurrent use.
+type Writer struct {
+	mu   sync.Mutex
+	path string
+}
+
+// New returns a Writer for dir, creating the directory if needed. dir should
+// already have been validated by the caller.
+func New(dir string) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+
+	return &Writer{path: filepath.Join(dir, FileName)}, nil
+}
+
+// Write appends rec as one line, stamping it with the current time if its
+// Timestamp is zero. The file is opened for each record, so it may be
+// rotated or removed between writes.
+func (w *Writer) Write(rec Record) error {
+	if rec.Timestamp.IsZero() {
+		rec.Timestamp = time.Now().UTC()
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := file.Write(line); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close audit log: %w", err)
+	}
+
+	return nil
+}
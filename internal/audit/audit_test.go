@@ -0,0 +1,106 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readRecords(t *testing.T, dir string) []Record {
+	t.Helper()
+	file, err := os.Open(filepath.Join(dir, FileName))
+	require.NoError(t, err)
+	defer file.Close() //nolint:errcheck // read-only test file
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var rec Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec), "line %q", scanner.Text())
+		records = append(records, rec)
+	}
+	require.NoError(t, scanner.Err())
+
+	return records
+}
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("appends one line per record", func(t *testing.T) {
+		t.Parallel()
+		dir := filepath.Join(t.TempDir(), "nested", "audit")
+		w, err := New(dir)
+		require.NoError(t, err)
+
+		stamp := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		first := Record{
+			Timestamp: stamp, Repo: "project", ChangedFiles: 3, Status: "approved", LGTM: true, CommentLength: 42,
+		}
+		require.NoError(t, w.Write(first))
+		before := time.Now()
+		require.NoError(t, w.Write(Record{Repo: "other", Status: "changes_requested"}))
+
+		records := readRecords(t, dir)
+		require.Len(t, records, 2)
+		assert.Equal(t, first, records[0])
+		assert.Equal(t, "other", records[1].Repo)
+		assert.False(t, records[1].Timestamp.Before(before.Truncate(time.Second)), "zero timestamps are stamped")
+
+		info, err := os.Stat(filepath.Join(dir, FileName))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	})
+
+	t.Run("keeps lines whole under concurrent writes", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		w, err := New(dir)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for range 20 {
+			wg.Go(func() {
+				assert.NoError(t, w.Write(Record{Repo: "project", Status: "approved", LGTM: true}))
+			})
+		}
+		wg.Wait()
+
+		assert.Len(t, readRecords(t, dir), 20)
+	})
+
+	t.Run("recreates a removed file", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		w, err := New(dir)
+		require.NoError(t, err)
+		require.NoError(t, w.Write(Record{Repo: "before"}))
+		require.NoError(t, os.Remove(filepath.Join(dir, FileName)))
+		require.NoError(t, w.Write(Record{Repo: "after"}))
+
+		records := readRecords(t, dir)
+		require.Len(t, records, 1)
+		assert.Equal(t, "after", records[0].Repo)
+	})
+}
@@ -18,9 +18,14 @@ package config
 import (
 	"errors"
 	"fmt"
+	"math"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
+	"unicode"
 
 	"sigs.k8s.io/yaml"
 )
@@ -30,6 +35,20 @@ var ErrNoCredentials = errors.New(
 	"google.api_key or google.use_adc must be set",
 )
 
+// ErrVertexConfig indicates an incomplete or conflicting Vertex AI backend
+// configuration.
+var ErrVertexConfig = errors.New(
+	"google.backend vertex requires google.use_adc, google.project, and google.location, and no google.api_key",
+)
+
+// ErrInvalidBaseURL indicates google.base_url is not an absolute http or
+// https URL.
+var ErrInvalidBaseURL = errors.New("must be an absolute http or https URL")
+
+// ErrInvalidProxyURL indicates google.proxy_url is not an absolute http,
+// https, or socks5 URL.
+var ErrInvalidProxyURL = errors.New("must be an absolute http, https, or socks5 URL")
+
 // ErrPathTraversal indicates a config-supplied path contains "..".
 var ErrPathTraversal = errors.New("path contains parent-directory segments")
 
@@ -54,18 +73,173 @@ type GoogleConfig struct {
 	APIKey string `json:"api_key,omitempty"`
 	// UseADC indicates whether to use Application Default Credentials.
 	UseADC bool `json:"use_adc,omitempty"`
+	// Backend selects the API serving Gemini: BackendGemini (the default),
+	// the Gemini Developer API, or BackendVertex, Vertex AI, which
+	// authenticates with ADC and requires Project and Location.
+	Backend string `json:"backend,omitempty"`
+	// Project is the Google Cloud project for the Vertex AI backend.
+	Project string `json:"project,omitempty"`
+	// Location is the Google Cloud region (e.g. "us-central1", or "global")
+	// for the Vertex AI backend.
+	Location string `json:"location,omitempty"`
+	// BaseURL overrides the API endpoint, e.g. to route requests through a
+	// proxy or compatible gateway. Empty uses Google's default endpoint.
+	BaseURL string `json:"base_url,omitempty"`
+	// ProxyURL is the proxy for all Gemini API requests, e.g.
+	// "http://proxy.corp.example:3128". Empty uses the standard
+	// HTTPS_PROXY/HTTP_PROXY/NO_PROXY environment variables.
+	ProxyURL string `json:"proxy_url,omitempty"`
 }
 
+// Backends for GoogleConfig.Backend.
+const (
+	BackendGemini = "gemini"
+	BackendVertex = "vertex"
+)
+
 // GitConfig represents Git configuration.
 type GitConfig struct {
 	// DiffContextLines is the number of context lines to include in git diff output.
 	// Use pointer to distinguish between unset (nil = default 20) and explicitly set to 0.
 	DiffContextLines *int `json:"diff_context_lines,omitempty"`
+	// NoVerify passes --no-verify to git commit, skipping the pre-commit and
+	// commit-msg hooks. Off by default: hooks often enforce checks (linters,
+	// secret scanners, sign-off) that the review does not replace.
+	NoVerify bool `json:"no_verify,omitempty"`
+	// IgnoreWhitespace shows Gemini the workspace changes with whitespace
+	// ignored (git diff --ignore-all-space), so formatting-only hunks do not
+	// crowd out substantive ones. The secret scan and the commit still cover
+	// the full changes. Off by default, so intentional whitespace fixes are
+	// reviewed.
+	IgnoreWhitespace bool `json:"ignore_whitespace,omitempty"`
+	// CommandTimeout bounds each git command, as a Go duration string.
+	// Empty means the default (30s).
+	CommandTimeout string `json:"command_timeout,omitempty"`
+	// AgentFilenames lists the file names of agent instruction files, such
+	// as AGENTS.md or CLAUDE.md, discovered alongside the changed files. Nil
+	// means [DefaultAgentFilenames]; an explicit empty list disables them.
+	AgentFilenames []string `json:"agent_filenames,omitempty"`
+	// MaxAgentFileBytes caps the size of each agent instruction or REVIEW.md
+	// file; larger files are skipped with a warning. Nil means
+	// [DefaultMaxAgentFileBytes].
+	MaxAgentFileBytes *int64 `json:"max_agent_file_bytes,omitempty"`
+	// MaxFileReadBytes caps the size of a file read from the working tree,
+	// whether to build the diff of an untracked file or for the secret
+	// scan. A review whose diff needs a larger untracked file
+	// fails rather than leaving it out, but the secret scan skips larger
+	// tracked files, so keep the limit above any file that may hold secrets.
+	// 0 (the default) means no limit.
+	MaxFileReadBytes int64 `json:"max_file_read_bytes,omitempty"`
+	// CommitMessageTemplate, when set, is a Go text/template that every
+	// commit message is rendered through, for example to add a required
+	// ticket prefix: "[{{.Ticket}}] {{.Message}}". It sees the client's
+	// {{.Message}}, the current {{.Branch}}, and the first issue key like
+	// PROJ-123 in the branch name as {{.Ticket}}; its output must include
+	// the message. A template without actions other than {{.Message}} adds a
+	// static prefix or suffix.
+	CommitMessageTemplate string `json:"commit_message_template,omitempty"`
+	// DefaultCommitMessage, when set, is a Go text/template for the commit
+	// message review_and_commit uses when the client gives none, such as
+	// "lgtmcp: {{.Files}} files reviewed and approved". {{.Files}} is the
+	// number of files committed. Without it, commit_message is required.
+	DefaultCommitMessage string `json:"default_commit_message,omitempty"`
+	// TextExtensions and BinaryExtensions override the content-based binary
+	// detection for new files, whose diff lgtmcp synthesizes: a file with a
+	// listed extension is shown as text, or as a "Binary files differ" line,
+	// whatever its content. Extensions match case-insensitively, with or
+	// without the leading dot; unlisted ones fall back to detection.
+	TextExtensions   []string `json:"text_extensions,omitempty"`
+	BinaryExtensions []string `json:"binary_extensions,omitempty"`
 }
 
+// NormalizeExtension returns ext, as listed in git.text_extensions or
+// git.binary_extensions, in the form filepath.Ext returns lowered: with a
+// leading dot and in lower case.
+func NormalizeExtension(ext string) string {
+	return "." + strings.ToLower(strings.TrimPrefix(ext, "."))
+}
+
+// DefaultMaxAgentFileBytes is the instruction file size cap used when
+// git.max_agent_file_bytes is not set (50KB).
+const DefaultMaxAgentFileBytes int64 = 50 * 1024
+
+// DefaultAgentFilenames lists the agent instruction files discovered when
+// git.agent_filenames is not set.
+var DefaultAgentFilenames = []string{"AGENTS.md"}
+
 // GitleaksConfig represents Gitleaks configuration.
 type GitleaksConfig struct {
 	Config string `json:"config,omitempty"`
+	// SkipFiles lists files the secret scanner never reads, in addition to
+	// go.sum and go.work.sum. Entries match either the file's basename or its
+	// repo-relative path, and may be globs (path.Match syntax). Nil means
+	// [DefaultSkipFiles]; an explicit empty list disables the defaults.
+	SkipFiles []string `json:"skip_files,omitempty"`
+	// ScanAddedLines scans only the lines the diff adds, attributed to their
+	// line numbers in the new file, instead of each changed file's full
+	// current content. Off by default.
+	ScanAddedLines bool `json:"scan_added_lines,omitempty"`
+	// RevealChars is how many characters of a detected secret are shown at
+	// each end in findings. Nil uses the default (3); 0 masks secrets fully.
+	RevealChars *int `json:"reveal_chars,omitempty"`
+	// VerboseFindings adds each match's column and entropy to findings, to
+	// help judge whether a hit is a real secret. Off by default.
+	VerboseFindings bool `json:"verbose_findings,omitempty"`
+	// DenyReadGlobs lists files the model may never retrieve for context,
+	// whether or not they are gitignored. Entries match either the file's
+	// basename or its repo-relative path, and may be globs (path.Match
+	// syntax). Nil means [DefaultDenyReadGlobs]; an explicit empty list
+	// disables the defaults.
+	DenyReadGlobs []string `json:"deny_read_globs,omitempty"`
+	// MaxScanBytes caps the size of a file the secret scan reads in full;
+	// larger files are skipped with a warning instead of read, so that an
+	// accidentally committed huge file cannot exhaust memory. Nil means
+	// [DefaultMaxScanBytes]; 0 disables the limit.
+	MaxScanBytes *int64 `json:"max_scan_bytes,omitempty"`
+	// Mode is what a review does when the secret scan finds something:
+	// GitleaksModeBlock (the default) stops it before Gemini sees the
+	// changes, and GitleaksModeWarn reports the findings prominently but
+	// lets the review, and a commit on approval, go ahead. The scan_secrets
+	// and scan_repo tools report findings as errors either way.
+	Mode string `json:"mode,omitempty"`
+}
+
+// Modes for GitleaksConfig.Mode.
+const (
+	GitleaksModeBlock = "block"
+	GitleaksModeWarn  = "warn"
+)
+
+// DefaultMaxScanBytes is the secret scan's file size cap used when
+// gitleaks.max_scan_bytes is not set.
+const DefaultMaxScanBytes int64 = 5 * 1024 * 1024
+
+// DefaultDenyReadGlobs lists the credential files the model is refused when
+// gitleaks.deny_read_globs is not set.
+var DefaultDenyReadGlobs = []string{
+	".env",
+	".env.*",
+	".netrc",
+	".pgpass",
+	"id_rsa",
+	"id_dsa",
+	"id_ecdsa",
+	"id_ed25519",
+	"*.pem",
+	"*.key",
+}
+
+// DefaultSkipFiles lists the generated lockfiles whose integrity hashes
+// routinely trip gitleaks' generic-api-key rule.
+var DefaultSkipFiles = []string{
+	"package-lock.json",
+	"npm-shrinkwrap.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"Cargo.lock",
+	"Gemfile.lock",
+	"composer.lock",
+	"poetry.lock",
 }
 
 // LoggingConfig represents logging configuration.
@@ -88,12 +262,73 @@ type LoggingConfig struct {
 	// - Linux: ~/.local/share/lgtmcp/logs/
 	// - Windows: %LOCALAPPDATA%\lgtmcp\logs\.
 	Directory string `json:"directory,omitempty"`
+
+	// MCPMaxMessageSize caps the size in bytes of each log message sent to
+	// the client when Output is "mcp"; longer messages are truncated. Nil
+	// uses the logging package default (8KB); 0 disables truncation.
+	MCPMaxMessageSize *int `json:"mcp_max_message_size,omitempty"`
 }
 
 // PromptsConfig holds prompt file configuration.
 type PromptsConfig struct {
 	ReviewPromptPath           string `json:"review_prompt_path,omitempty"`
 	ContextGatheringPromptPath string `json:"context_gathering_prompt_path,omitempty"`
+	// ReviewPromptPaths maps file extensions ("go", "py") to review prompt
+	// files used instead of ReviewPromptPath when that extension is the most
+	// common, among those listed here, in a change's files.
+	ReviewPromptPaths map[string]string `json:"review_prompt_paths,omitempty"`
+	// ExtraContextFiles are files of review conventions, such as a shared
+	// style guide, whose contents are added to the review prompt. Like the
+	// prompt files they are read from the config directory, never from the
+	// repository under review.
+	ExtraContextFiles []string `json:"extra_context_files,omitempty"`
+}
+
+// ReviewConfig holds review policy configuration.
+type ReviewConfig struct {
+	// HumanReviewFiles lists files whose changes always require a human
+	// reviewer: when a diff touches any of them the result is forced to NOT
+	// APPROVED regardless of the model's verdict. Entries match either the
+	// file's basename or its repo-relative path, and may be globs (path.Match
+	// syntax), e.g. ".github/workflows/*" or "SECURITY.md".
+	HumanReviewFiles []string `json:"human_review_files,omitempty"`
+	// IncludeEnvironment adds a summary of the tech stack detected from
+	// go.mod and package.json at the repository root (module path, language
+	// versions, notable frameworks) to the review prompts. Off by default.
+	IncludeEnvironment bool `json:"include_environment,omitempty"`
+	// EscalateRejections makes a resubmitted, byte-identical diff carry the
+	// earlier rejection comments into the prompts, asking the model for more
+	// specific and actionable feedback. Rejections are remembered in memory
+	// for the lifetime of the server. Off by default.
+	EscalateRejections bool `json:"escalate_rejections,omitempty"`
+	// ReportPath, when set, makes review_and_commit write the approved
+	// review to this repo-relative, slash-separated path (for example
+	// ".lgtmcp/last-review.md") and include it in the commit. The file is
+	// excluded from the diff under review so it never reviews itself.
+	ReportPath string `json:"report_path,omitempty"`
+	// RecentChanges is how many of the most recent commits touching the
+	// changed files have their patches (limited to those files and bounded
+	// in size) added to the prompts as recent related changes. 0 (the
+	// default) disables it.
+	RecentChanges int `json:"recent_changes,omitempty"`
+	// CommitRequiresConfirmation makes review_and_commit hold an approved
+	// commit and return a one-time confirmation token instead; the commit
+	// happens only when confirm_commit is called with that token, giving a
+	// person the final say. Off by default.
+	CommitRequiresConfirmation bool `json:"commit_requires_confirmation,omitempty"`
+}
+
+// DefaultMaxConcurrentReviews is the number of reviews that may run at once
+// when server.max_concurrent_reviews is not set.
+const DefaultMaxConcurrentReviews = 4
+
+// ServerConfig holds MCP server configuration.
+type ServerConfig struct {
+	// MaxConcurrentReviews bounds how many review_only and review_and_commit
+	// calls run at once; further calls wait for a slot. Use a pointer to
+	// distinguish unset (nil = DefaultMaxConcurrentReviews) from an explicit
+	// 0, which removes the limit.
+	MaxConcurrentReviews *int `json:"max_concurrent_reviews,omitempty"`
 }
 
 // RetryConfig represents retry configuration for API calls.
@@ -105,8 +340,63 @@ type RetryConfig struct {
 	// which disables retries.
 	MaxRetries        *int    `json:"max_retries,omitempty"`
 	BackoffMultiplier float64 `json:"backoff_multiplier"`
+	// JitterFraction is the relative spread of "equal" jitter: each backoff
+	// is scaled by a random factor in [1-JitterFraction, 1+JitterFraction].
+	// Use a pointer to distinguish unset (nil = default 0.2) from an explicit
+	// 0, which disables jitter. Must be in [0, 1].
+	JitterFraction *float64 `json:"jitter_fraction,omitempty"`
+	// JitterMode selects how backoffs are randomized: JitterModeEqual (the
+	// default) applies JitterFraction around the computed backoff;
+	// JitterModeFull picks uniformly in [0, backoff], ignoring
+	// JitterFraction.
+	JitterMode string `json:"jitter_mode,omitempty"`
+	// CircuitBreakerThreshold is how many consecutive retryable failures
+	// within CircuitBreakerWindow open the circuit breaker, after which
+	// reviews fail immediately until CircuitBreakerCooldown elapses. Use a
+	// pointer to distinguish unset (nil = DefaultCircuitBreakerThreshold)
+	// from an explicit 0, which disables the breaker.
+	CircuitBreakerThreshold *int   `json:"circuit_breaker_threshold,omitempty"`
+	CircuitBreakerWindow    string `json:"circuit_breaker_window,omitempty"`
+	CircuitBreakerCooldown  string `json:"circuit_breaker_cooldown,omitempty"`
+	// MaxTotalDuration bounds the wall-clock time one operation may spend
+	// across all its attempts and backoffs, as a Go duration string. A retry
+	// whose backoff would overrun it is not attempted. Empty or "0" means
+	// no limit beyond MaxRetries.
+	MaxTotalDuration string `json:"max_total_duration,omitempty"`
 }
 
+// Retry jitter modes for RetryConfig.JitterMode.
+const (
+	JitterModeEqual = "equal"
+	JitterModeFull  = "full"
+)
+
+// DefaultJitterFraction is the retry jitter fraction used when
+// gemini.retry.jitter_fraction is not set.
+const DefaultJitterFraction = 0.2
+
+// DefaultCircuitBreakerThreshold is the number of consecutive retryable
+// Gemini failures that opens the circuit breaker when
+// gemini.retry.circuit_breaker_threshold is not set.
+const DefaultCircuitBreakerThreshold = 10
+
+// Default circuit breaker window and cooldown.
+const (
+	defaultCircuitBreakerWindow   = "2m"
+	defaultCircuitBreakerCooldown = "1m"
+)
+
+// DefaultMaxFileBytes is the file-retrieval size cap used when
+// gemini.max_file_bytes is not set (256KB, roughly 64k tokens).
+const DefaultMaxFileBytes int64 = 256 * 1024
+
+// DefaultMaxToolCalls is the context-gathering function call cap used when
+// gemini.max_tool_calls is not set.
+const DefaultMaxToolCalls = 20
+
+// MaxIncludeBlame is the most changed hunks gemini.include_blame may blame.
+const MaxIncludeBlame = 50
+
 // FallbackModelNone disables quota fallback when set as FallbackModel.
 const FallbackModelNone = "none"
 
@@ -115,10 +405,79 @@ type GeminiConfig struct {
 	Retry         *RetryConfig `json:"retry,omitempty"`
 	Model         string       `json:"model"`
 	FallbackModel string       `json:"fallback_model,omitempty"`
+	// ContextModel and ReviewModel, when set, replace Model for the
+	// context-gathering phase, where the model retrieves files, and for the
+	// structured verdict respectively, so that a cheaper model can gather
+	// context for a stronger one. Empty (the default) means Model. A quota
+	// fallback runs both phases on FallbackModel.
+	ContextModel string `json:"context_model,omitempty"`
+	ReviewModel  string `json:"review_model,omitempty"`
 	// Temperature is the sampling temperature. Use a pointer to distinguish
 	// unset (nil = default 0.2) from an explicit 0, which requests fully
 	// deterministic output.
 	Temperature *float32 `json:"temperature,omitempty"`
+	// TopP and TopK, when set, restrict sampling to the most probable
+	// tokens: those within cumulative probability TopP, and the TopK most
+	// likely. Unset (nil, the default) leaves the API defaults in place.
+	TopP *float32 `json:"top_p,omitempty"`
+	TopK *int32   `json:"top_k,omitempty"`
+	// Seed, when set, is sent with both Gemini calls so repeated reviews of
+	// the same diff tend to produce the same output, for prompt tuning and
+	// CI comparisons. Determinism is best-effort: the API does not
+	// guarantee it, and model updates change the output regardless. Unset
+	// (nil, the default) sends no seed.
+	Seed *int `json:"seed,omitempty"`
+	// MaxEstimatedCost is the ceiling, in USD, on a review's estimated cost.
+	// Reviews estimated above it are refused before any API call; 0 (the
+	// default) disables the check.
+	MaxEstimatedCost float64 `json:"max_estimated_cost,omitempty"`
+	// MaxFileBytes caps how much of a file the model receives when it
+	// fetches one for context; longer files are truncated and flagged. Use
+	// a pointer to distinguish unset (nil = DefaultMaxFileBytes) from an
+	// explicit 0, which sends whole files up to the built-in 16MB limit.
+	MaxFileBytes *int64 `json:"max_file_bytes,omitempty"`
+	// IncludeRecentCommits is how many of the repository's most recent
+	// commit subjects are given to the model as background during context
+	// gathering. 0 (the default) disables it.
+	IncludeRecentCommits int `json:"include_recent_commits,omitempty"`
+	// IncludeBlame is how many changed hunks have the lines they modify or
+	// remove blamed, giving the model the commit, date, and author that last
+	// touched each. Each hunk costs a git blame, so it is at most
+	// [MaxIncludeBlame]. 0 (the default) disables it.
+	IncludeBlame int `json:"include_blame,omitempty"`
+	// MaxToolCalls bounds how many files the model may request while
+	// gathering context; once it is reached, the review proceeds with the
+	// context gathered so far. 0 (unset) means [DefaultMaxToolCalls].
+	MaxToolCalls int `json:"max_tool_calls,omitempty"`
+	// IncludeDiffStat gives the model a "git diff --stat" summary of the
+	// change ahead of the diff, as a quick sense of its scope. Off by
+	// default.
+	IncludeDiffStat bool `json:"include_diffstat,omitempty"`
+	// RequireTests lists, in the review prompt, the changed source files
+	// that no changed test file corresponds to by name, and asks the model
+	// to request tests where the change warrants them. It is a nudge, not a
+	// gate. Off by default.
+	RequireTests bool `json:"require_tests,omitempty"`
+	// CategorizeComments asks the model to also file each issue it raises
+	// under security, correctness, performance, or style, so results can
+	// group comments by category. The freeform comments are still returned.
+	// Off by default.
+	CategorizeComments bool `json:"categorize_comments,omitempty"`
+	// FileLineNumbers makes files the model fetches for context come back
+	// with each line prefixed by its number, unless the model asks
+	// otherwise, for accurate line references. Off by default.
+	FileLineNumbers bool `json:"file_line_numbers,omitempty"`
+}
+
+// AuditConfig holds audit log configuration.
+type AuditConfig struct {
+	// Directory, when set, makes the server append a JSON line to
+	// audit.jsonl in this directory for every review decision, recording
+	// the repository name, changed file count, verdict, and comment length
+	// but never the diff or the comments themselves. Like logging.directory
+	// it must be inside the lgtmcp config directory; relative paths are
+	// resolved against it.
+	Directory string `json:"directory,omitempty"`
 }
 
 // Config represents the application configuration.
@@ -129,6 +488,71 @@ type Config struct {
 	Gitleaks GitleaksConfig `json:"gitleaks,omitzero"`
 	Logging  LoggingConfig  `json:"logging"`
 	Prompts  PromptsConfig  `json:"prompts,omitzero"`
+	Review   ReviewConfig   `json:"review,omitzero"`
+	Server   ServerConfig   `json:"server,omitzero"`
+	Audit    AuditConfig    `json:"audit,omitzero"`
+
+	// Path is the file Load read this configuration from. It is empty for
+	// configurations built in code, such as [NewTestConfig].
+	Path string `json:"-"`
+}
+
+// RedactedPlaceholder replaces secret values in [Config.Redacted].
+const RedactedPlaceholder = "********"
+
+// Redacted returns a copy of c that is safe to show to a client: a non-empty
+// API key is replaced with [RedactedPlaceholder], and any password in the
+// proxy URL is masked. The copy is shallow, so callers must not modify values
+// reachable through its pointer and map fields.
+func (c *Config) Redacted() *Config {
+	out := *c
+	if out.Google.APIKey != "" {
+		out.Google.APIKey = RedactedPlaceholder
+	}
+	if u, err := url.Parse(out.Google.ProxyURL); err == nil && u.User != nil {
+		out.Google.ProxyURL = u.Redacted()
+	}
+
+	return &out
+}
+
+// validateURL checks that raw is an absolute URL with a host and one of the
+// given schemes, returning invalid otherwise. The parse error is dropped
+// because it quotes the URL, which may carry credentials.
+func validateURL(raw string, invalid error, schemes ...string) error {
+	u, err := url.Parse(raw)
+	if err != nil || !slices.Contains(schemes, u.Scheme) || u.Host == "" {
+		return invalid
+	}
+
+	return nil
+}
+
+// validateModel trims surrounding whitespace from name, the value of the
+// named model setting, and checks that what remains is a plausible model
+// name: non-empty and without inner whitespace.
+func validateModel(key, name string) (string, error) {
+	trimmed := strings.TrimSpace(name)
+	if trimmed == "" {
+		return "", fmt.Errorf("invalid %s %q: must not be blank", key, name)
+	}
+	if strings.ContainsFunc(trimmed, unicode.IsSpace) {
+		return "", fmt.Errorf("invalid %s %q: model names do not contain spaces", key, name)
+	}
+
+	return trimmed, nil
+}
+
+// validateExtension checks that ext, an entry of the named extension list,
+// is a file extension: non-empty, and without a path separator or an inner
+// dot.
+func validateExtension(list, ext string) error {
+	name := strings.TrimPrefix(ext, ".")
+	if name == "" || strings.ContainsAny(name, `./\`) {
+		return fmt.Errorf("invalid %s entry %q: must be a file extension such as .txt", list, ext)
+	}
+
+	return nil
 }
 
 // Load loads the configuration from the YAML file.
@@ -165,12 +589,111 @@ func Load() (*Config, error) {
 	if cfg.Gemini.FallbackModel == "" {
 		cfg.Gemini.FallbackModel = FallbackModelNone
 	}
+	// Catch typos here rather than as an API error mid-review. The phase
+	// models may be left unset.
+	for _, model := range []struct {
+		key      string
+		name     *string
+		optional bool
+	}{
+		{"gemini.model", &cfg.Gemini.Model, false},
+		{"gemini.fallback_model", &cfg.Gemini.FallbackModel, false},
+		{"gemini.context_model", &cfg.Gemini.ContextModel, true},
+		{"gemini.review_model", &cfg.Gemini.ReviewModel, true},
+	} {
+		if model.optional && *model.name == "" {
+			continue
+		}
+		if *model.name, err = validateModel(model.key, *model.name); err != nil {
+			return nil, err
+		}
+	}
 	if cfg.Gemini.Temperature == nil {
 		cfg.Gemini.Temperature = new(float32(0.2))
 	}
+	if p := cfg.Gemini.TopP; p != nil && (*p < 0 || *p > 1) {
+		return nil, fmt.Errorf("invalid gemini.top_p %v: must be between 0 and 1", *p)
+	}
+	if k := cfg.Gemini.TopK; k != nil && *k <= 0 {
+		return nil, fmt.Errorf("invalid gemini.top_k %d: must be positive", *k)
+	}
+	if seed := cfg.Gemini.Seed; seed != nil && (*seed < math.MinInt32 || *seed > math.MaxInt32) {
+		return nil, fmt.Errorf("invalid gemini.seed %d: must fit in 32 bits", *seed)
+	}
+	if cfg.Gemini.MaxFileBytes == nil {
+		cfg.Gemini.MaxFileBytes = new(DefaultMaxFileBytes)
+	}
+	if *cfg.Gemini.MaxFileBytes < 0 {
+		return nil, fmt.Errorf("invalid gemini.max_file_bytes %d: must not be negative", *cfg.Gemini.MaxFileBytes)
+	}
+	if b := cfg.Gemini.IncludeBlame; b < 0 || b > MaxIncludeBlame {
+		return nil, fmt.Errorf("invalid gemini.include_blame %d: must be between 0 and %d", b, MaxIncludeBlame)
+	}
+	if cfg.Gemini.MaxToolCalls < 0 {
+		return nil, fmt.Errorf("invalid gemini.max_tool_calls %d: must not be negative", cfg.Gemini.MaxToolCalls)
+	}
 	if cfg.Logging.Level == "" {
 		cfg.Logging.Level = "info"
 	}
+	if cfg.Google.Backend == "" {
+		cfg.Google.Backend = BackendGemini
+	}
+	if cfg.Gitleaks.SkipFiles == nil {
+		cfg.Gitleaks.SkipFiles = slices.Clone(DefaultSkipFiles)
+	}
+	if cfg.Gitleaks.DenyReadGlobs == nil {
+		cfg.Gitleaks.DenyReadGlobs = slices.Clone(DefaultDenyReadGlobs)
+	}
+	if cfg.Git.AgentFilenames == nil {
+		cfg.Git.AgentFilenames = slices.Clone(DefaultAgentFilenames)
+	}
+	if cfg.Git.MaxAgentFileBytes == nil {
+		cfg.Git.MaxAgentFileBytes = new(DefaultMaxAgentFileBytes)
+	}
+	if cfg.Git.MaxFileReadBytes < 0 {
+		return nil, fmt.Errorf("invalid git.max_file_read_bytes %d: must not be negative", cfg.Git.MaxFileReadBytes)
+	}
+	if *cfg.Git.MaxAgentFileBytes <= 0 {
+		return nil, fmt.Errorf("invalid git.max_agent_file_bytes %d: must be positive", *cfg.Git.MaxAgentFileBytes)
+	}
+	for _, name := range cfg.Git.AgentFilenames {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return nil, fmt.Errorf("invalid git.agent_filenames entry %q: must be a file name, not a path", name)
+		}
+	}
+	textExtensions := make(map[string]bool, len(cfg.Git.TextExtensions))
+	for _, ext := range cfg.Git.TextExtensions {
+		if err := validateExtension("git.text_extensions", ext); err != nil {
+			return nil, err
+		}
+		textExtensions[NormalizeExtension(ext)] = true
+	}
+	for _, ext := range cfg.Git.BinaryExtensions {
+		if err := validateExtension("git.binary_extensions", ext); err != nil {
+			return nil, err
+		}
+		if textExtensions[NormalizeExtension(ext)] {
+			return nil, fmt.Errorf("invalid git.binary_extensions entry %q: also listed in git.text_extensions", ext)
+		}
+	}
+	if cfg.Gitleaks.RevealChars == nil {
+		cfg.Gitleaks.RevealChars = new(3)
+	}
+	if cfg.Gitleaks.Mode == "" {
+		cfg.Gitleaks.Mode = GitleaksModeBlock
+	}
+	if m := cfg.Gitleaks.Mode; m != GitleaksModeBlock && m != GitleaksModeWarn {
+		return nil, fmt.Errorf("invalid gitleaks.mode %q: must be %q or %q", m, GitleaksModeBlock, GitleaksModeWarn)
+	}
+	if cfg.Gitleaks.MaxScanBytes == nil {
+		cfg.Gitleaks.MaxScanBytes = new(DefaultMaxScanBytes)
+	}
+	if *cfg.Gitleaks.MaxScanBytes < 0 {
+		return nil, fmt.Errorf("invalid gitleaks.max_scan_bytes %d: must not be negative", *cfg.Gitleaks.MaxScanBytes)
+	}
+	if cfg.Server.MaxConcurrentReviews == nil {
+		cfg.Server.MaxConcurrentReviews = new(DefaultMaxConcurrentReviews)
+	}
 
 	// Set retry defaults if not specified.
 	if cfg.Gemini.Retry == nil {
@@ -179,6 +702,12 @@ func Load() (*Config, error) {
 			InitialBackoff:    "1s",
 			MaxBackoff:        defaultMaxBackoff,
 			BackoffMultiplier: 1.4,
+			JitterFraction:    new(DefaultJitterFraction),
+			JitterMode:        JitterModeEqual,
+
+			CircuitBreakerThreshold: new(DefaultCircuitBreakerThreshold),
+			CircuitBreakerWindow:    defaultCircuitBreakerWindow,
+			CircuitBreakerCooldown:  defaultCircuitBreakerCooldown,
 		}
 	} else {
 		// Set individual retry defaults if not specified. MaxRetries is a
@@ -195,12 +724,58 @@ func Load() (*Config, error) {
 		if cfg.Gemini.Retry.BackoffMultiplier == 0 {
 			cfg.Gemini.Retry.BackoffMultiplier = 1.4
 		}
+		if cfg.Gemini.Retry.JitterFraction == nil {
+			cfg.Gemini.Retry.JitterFraction = new(DefaultJitterFraction)
+		}
+		if cfg.Gemini.Retry.JitterMode == "" {
+			cfg.Gemini.Retry.JitterMode = JitterModeEqual
+		}
+		if cfg.Gemini.Retry.CircuitBreakerThreshold == nil {
+			cfg.Gemini.Retry.CircuitBreakerThreshold = new(DefaultCircuitBreakerThreshold)
+		}
+		if cfg.Gemini.Retry.CircuitBreakerWindow == "" {
+			cfg.Gemini.Retry.CircuitBreakerWindow = defaultCircuitBreakerWindow
+		}
+		if cfg.Gemini.Retry.CircuitBreakerCooldown == "" {
+			cfg.Gemini.Retry.CircuitBreakerCooldown = defaultCircuitBreakerCooldown
+		}
+	}
+	if f := *cfg.Gemini.Retry.JitterFraction; f < 0 || f > 1 {
+		return nil, fmt.Errorf("invalid gemini.retry.jitter_fraction %v: must be between 0 and 1", f)
+	}
+	if m := cfg.Gemini.Retry.JitterMode; m != JitterModeEqual && m != JitterModeFull {
+		return nil, fmt.Errorf("invalid gemini.retry.jitter_mode %q: must be %q or %q",
+			m, JitterModeEqual, JitterModeFull)
 	}
 
 	// Validate credentials: either API key or ADC must be configured.
 	if cfg.Google.APIKey == "" && !cfg.Google.UseADC {
 		return nil, ErrNoCredentials
 	}
+	switch cfg.Google.Backend {
+	case BackendGemini:
+	case BackendVertex:
+		if !cfg.Google.UseADC || cfg.Google.APIKey != "" || cfg.Google.Project == "" || cfg.Google.Location == "" {
+			return nil, ErrVertexConfig
+		}
+	default:
+		return nil, fmt.Errorf("invalid google.backend %q: must be %q or %q",
+			cfg.Google.Backend, BackendGemini, BackendVertex)
+	}
+	if cfg.Google.BaseURL != "" {
+		if err := validateURL(cfg.Google.BaseURL, ErrInvalidBaseURL, "http", "https"); err != nil {
+			return nil, fmt.Errorf("invalid google.base_url %q: %w", cfg.Google.BaseURL, err)
+		}
+	}
+	if cfg.Google.ProxyURL != "" {
+		err := validateURL(cfg.Google.ProxyURL, ErrInvalidProxyURL, "http", "https", "socks5", "socks5h")
+		if err != nil {
+			// The URL may carry proxy credentials; keep them out of the error.
+			return nil, fmt.Errorf("invalid google.proxy_url: %w", err)
+		}
+	}
+
+	cfg.Path = configPath
 
 	// If both are set, API key takes precedence (logged during client creation).
 	return &cfg, nil
@@ -0,0 +1,193 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidLineRange indicates a blame was requested for lines that do not
+// form a range of 1-based line numbers.
+var ErrInvalidLineRange = errors.New("invalid line range")
+
+// BlameRegion is a run of consecutive lines last modified by the same
+// commit.
+type BlameRegion struct {
+	// Start and End are the 1-based, inclusive line numbers of the run.
+	Start, End int
+	Commit     string
+	Author     string
+	Date       time.Time
+	// Summary is the first line of the commit message.
+	Summary string
+}
+
+// FileBlame is the blame of one range of lines of a file.
+type FileBlame struct {
+	File    string
+	Regions []BlameRegion
+}
+
+// BlameLines returns which commits last modified lines start through end,
+// 1-based and inclusive, of file as of rev, as runs of consecutive lines in
+// line order.
+func (g *Git) BlameLines(ctx context.Context, rev, file string, start, end int) ([]BlameRegion, error) {
+	if start < 1 || end < start {
+		return nil, fmt.Errorf("%w: %d-%d", ErrInvalidLineRange, start, end)
+	}
+	if file == "" {
+		return nil, fmt.Errorf("%w: empty path", ErrInvalidPath)
+	}
+	if _, err := g.repoPathFor(file); err != nil {
+		return nil, err
+	}
+
+	out, err := g.runGitCommand(ctx, "blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", start, end),
+		rev, "--", filepath.ToSlash(filepath.Clean(file)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to blame %s: %w", file, err)
+	}
+
+	return parseBlamePorcelain(out), nil
+}
+
+// blameCommit is the commit information "git blame --porcelain" gives the
+// first time a commit appears.
+type blameCommit struct {
+	author  string
+	date    time.Time
+	summary string
+}
+
+// parseBlamePorcelain parses the output of "git blame --porcelain" into runs
+// of consecutive lines from the same commit.
+func parseBlamePorcelain(out string) []BlameRegion {
+	commits := make(map[string]*blameCommit)
+	var regions []BlameRegion
+	// cur receives the commit information lines that follow a header; it
+	// starts as a placeholder so that malformed output cannot crash.
+	cur := &blameCommit{}
+
+	for line := range strings.SplitSeq(out, "\n") {
+		if line == "" || strings.HasPrefix(line, "\t") {
+			// The content of the line, which is not needed.
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			cur.author = value
+		case "author-time":
+			authorTime, _ := strconv.ParseInt(value, 10, 64)
+			cur.date = time.Unix(authorTime, 0).UTC()
+		case "summary":
+			cur.summary = value
+		default:
+			// A line header: "<sha> <orig-line> <final-line> [<lines>]".
+			fields := strings.Fields(line)
+			if len(fields) < 3 || !isHexObjectName(fields[0]) {
+				continue
+			}
+			lineNo, err := strconv.Atoi(fields[2])
+			if err != nil {
+				continue
+			}
+			sha := fields[0]
+			if commits[sha] == nil {
+				commits[sha] = &blameCommit{}
+			}
+			cur = commits[sha]
+			if n := len(regions); n > 0 && regions[n-1].Commit == sha && regions[n-1].End == lineNo-1 {
+				regions[n-1].End = lineNo
+			} else {
+				regions = append(regions, BlameRegion{Start: lineNo, End: lineNo, Commit: sha})
+			}
+		}
+	}
+
+	// Commit information follows the first header of each commit, so it is
+	// filled in once all of it has been read.
+	for i := range regions {
+		c := commits[regions[i].Commit]
+		regions[i].Author = c.author
+		regions[i].Date = c.date
+		regions[i].Summary = c.summary
+	}
+
+	return regions
+}
+
+// isHexObjectName reports whether s is a full hexadecimal object name.
+func isHexObjectName(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FormatBlame formats blames of changed lines into a prompt section. Author
+// names and commit summaries are repository content, so they are fenced
+// like instruction files. Returns an empty string when there is nothing to
+// show.
+func FormatBlame(blames []FileBlame) string {
+	var body strings.Builder
+	for _, b := range blames {
+		for _, r := range b.Regions {
+			lines := strconv.Itoa(r.Start)
+			if r.End != r.Start {
+				lines += "-" + strconv.Itoa(r.End)
+			}
+			_, _ = fmt.Fprintf(&body, "%s:%s %s %s %s: %s\n", b.File, lines,
+				shortCommit(r.Commit), r.Date.Format(time.DateOnly), r.Author, r.Summary)
+		}
+	}
+	if body.Len() == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb,
+		"## Blame of Changed Lines\n\n"+
+			"For lines this change modifies or removes, the commit that last modified them before "+
+			"this change, as file:lines, commit, date, author, and commit subject. Use it to judge "+
+			"risk: recently touched code may still be settling, while old code may be load-bearing."+
+			"\n\n%s\n\n", untrustedContentWarning)
+	_, _ = fmt.Fprintf(&sb, "<untrusted_user_content>\n%s\n</untrusted_user_content>\n\n",
+		escapeUntrustedFence(strings.TrimSpace(body.String())))
+
+	return sb.String()
+}
+
+// shortCommit abbreviates a full object name for display.
+func shortCommit(sha string) string {
+	const shortLen = 7
+	if len(sha) > shortLen {
+		return sha[:shortLen]
+	}
+
+	return sha
+}
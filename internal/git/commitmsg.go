@@ -0,0 +1,185 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// ErrTemplateDropsMessage indicates a commit message template whose output
+// does not include the commit message.
+var ErrTemplateDropsMessage = errors.New("template output must include {{.Message}}")
+
+// ErrNoDefaultCommitMessage indicates that no git.default_commit_message is
+// configured.
+var ErrNoDefaultCommitMessage = errors.New("no git.default_commit_message is configured")
+
+// ticketPattern matches an issue tracker key such as "PROJ-123" in a branch
+// name.
+var ticketPattern = regexp.MustCompile(`[A-Z][A-Z0-9]+-[0-9]+`)
+
+// CommitMessageData is the data for the git.commit_message_template
+// template.
+type CommitMessageData struct {
+	// Message is the commit message the client supplied.
+	Message string
+	// Branch is the current branch name, or "" on a detached HEAD.
+	Branch string
+	// Ticket is the first issue key such as "PROJ-123" in Branch, or "".
+	Ticket string
+}
+
+// DefaultCommitMessageData is the data for the git.default_commit_message
+// template.
+type DefaultCommitMessageData struct {
+	// Files is the number of files being committed.
+	Files int
+}
+
+// ParseDefaultCommitMessage parses a git.default_commit_message and checks
+// that it renders to a non-empty message.
+func ParseDefaultCommitMessage(text string) (*template.Template, error) {
+	tmpl, err := template.New("default_commit_message").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse default commit message: %w", err)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, DefaultCommitMessageData{Files: 1}); err != nil {
+		return nil, fmt.Errorf("failed to render default commit message: %w", err)
+	}
+	if strings.TrimSpace(sb.String()) == "" {
+		return nil, ErrEmptyCommitMsg
+	}
+
+	return tmpl, nil
+}
+
+// DefaultCommitMessage renders git.default_commit_message for a commit of
+// the given number of files. It returns ErrNoDefaultCommitMessage when none
+// is configured.
+func (g *Git) DefaultCommitMessage(files int) (string, error) {
+	if g.defaultMessage == nil {
+		return "", ErrNoDefaultCommitMessage
+	}
+
+	var sb strings.Builder
+	if err := g.defaultMessage.Execute(&sb, DefaultCommitMessageData{Files: files}); err != nil {
+		return "", fmt.Errorf("failed to render default commit message: %w", err)
+	}
+
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// ParseCommitMessageTemplate parses a git.commit_message_template and checks
+// that it renders and keeps the message, so a broken template is reported at
+// startup rather than on the first approved commit.
+func ParseCommitMessageTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("commit_message").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse commit message template: %w", err)
+	}
+
+	const probe = "\x00message\x00"
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, CommitMessageData{Message: probe}); err != nil {
+		return nil, fmt.Errorf("failed to render commit message template: %w", err)
+	}
+	if !strings.Contains(sb.String(), probe) {
+		return nil, ErrTemplateDropsMessage
+	}
+
+	return tmpl, nil
+}
+
+// ResolveCommitMessage expands the commit message conventions used by editor
+// integrations: "@<path>" reads the message from a repo-relative file, read
+// as by [Git.GetFileContent], and "COMMIT_EDITMSG" reads the message git
+// prepared in .git/COMMIT_EDITMSG, dropping its comment lines. Whitespace is
+// cleaned up as git commit does, and an empty result is ErrEmptyCommitMsg.
+// Any other message is returned unchanged.
+func (g *Git) ResolveCommitMessage(ctx context.Context, message string) (string, error) {
+	var text string
+	stripArgs := []string{"stripspace"}
+	switch {
+	case message == "COMMIT_EDITMSG":
+		gitPath, err := g.runGitCommand(ctx, "rev-parse", "--git-path", "COMMIT_EDITMSG")
+		if err != nil {
+			return "", fmt.Errorf("failed to locate COMMIT_EDITMSG: %w", err)
+		}
+		gitPath = strings.TrimSpace(gitPath)
+		if !filepath.IsAbs(gitPath) {
+			gitPath = filepath.Join(g.repoPath, gitPath)
+		}
+		data, err := os.ReadFile(gitPath) //nolint:gosec // Path is git's own, not user input
+		if err != nil {
+			return "", fmt.Errorf("failed to read COMMIT_EDITMSG: %w", err)
+		}
+		text = string(data)
+		stripArgs = append(stripArgs, "--strip-comments")
+	case strings.HasPrefix(message, "@"):
+		content, err := g.GetFileContent(ctx, strings.TrimPrefix(message, "@"))
+		if err != nil {
+			return "", fmt.Errorf("failed to read commit message file: %w", err)
+		}
+		text = content
+	default:
+		return message, nil
+	}
+
+	cleaned, err := g.runGitCommandStdin(ctx, strings.NewReader(text), nil, stripArgs...)
+	if err != nil {
+		return "", fmt.Errorf("failed to clean up commit message: %w", err)
+	}
+	cleaned = strings.TrimRight(cleaned, "\n")
+	if cleaned == "" {
+		return "", ErrEmptyCommitMsg
+	}
+
+	return cleaned, nil
+}
+
+// commitMessage renders message through the configured template, if any,
+// with the current branch and the ticket found in its name.
+func (g *Git) commitMessage(ctx context.Context, message string) (string, error) {
+	if g.commitTemplate == nil {
+		return message, nil
+	}
+
+	// symbolic-ref -q exits 1, printing nothing, on a detached HEAD.
+	res, err := runGit(ctx, g.repoPath, g.commandTimeout, nil, nil, "symbolic-ref", "--short", "-q", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to get current branch: %w", err)
+	}
+	branch := ""
+	if res.exitCode == 0 {
+		branch = strings.TrimSpace(res.stdout)
+	}
+
+	var sb strings.Builder
+	data := CommitMessageData{Message: message, Branch: branch, Ticket: ticketPattern.FindString(branch)}
+	if err := g.commitTemplate.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render commit message template: %w", err)
+	}
+
+	return sb.String(), nil
+}
@@ -0,0 +1,147 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"msrl.dev/lgtmcp/internal/config"
+	"msrl.dev/lgtmcp/internal/testutil"
+)
+
+func TestParseCommitMessageTemplate(t *testing.T) {
+	t.Parallel()
+
+	for _, text := range []string{"PROJ: {{.Message}}", "[{{.Ticket}}] {{.Message}}", "{{.Message}} ({{.Branch}})"} {
+		_, err := ParseCommitMessageTemplate(text)
+		require.NoError(t, err, text)
+	}
+
+	_, err := ParseCommitMessageTemplate("{{.Message")
+	require.Error(t, err)
+	_, err = ParseCommitMessageTemplate("{{.Issue}} {{.Message}}")
+	require.Error(t, err, "unknown fields fail at startup")
+	_, err = ParseCommitMessageTemplate("[{{.Ticket}}]")
+	require.ErrorIs(t, err, ErrTemplateDropsMessage)
+	_, err = ParseCommitMessageTemplate("")
+	require.ErrorIs(t, err, ErrTemplateDropsMessage)
+}
+
+func TestDefaultCommitMessage(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseDefaultCommitMessage("{{.Files")
+	require.Error(t, err)
+	_, err = ParseDefaultCommitMessage("{{.Message}}")
+	require.Error(t, err, "unknown fields fail at startup")
+	_, err = ParseDefaultCommitMessage("  ")
+	require.ErrorIs(t, err, ErrEmptyCommitMsg)
+
+	tmpDir := testutil.CreateTempGitRepo(t)
+	g, err := New(tmpDir, nil)
+	require.NoError(t, err)
+	_, err = g.DefaultCommitMessage(3)
+	require.ErrorIs(t, err, ErrNoDefaultCommitMessage)
+
+	g, err = New(tmpDir, &config.GitConfig{DefaultCommitMessage: "lgtmcp: {{.Files}} files reviewed\n"})
+	require.NoError(t, err)
+	message, err := g.DefaultCommitMessage(3)
+	require.NoError(t, err)
+	assert.Equal(t, "lgtmcp: 3 files reviewed", message)
+}
+
+func TestCommit_MessageTemplate(t *testing.T) {
+	t.Parallel()
+
+	commitWith := func(t *testing.T, tmpl string, setup func(dir string)) string {
+		t.Helper()
+		tmpDir := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, tmpDir, "a.go", "package a\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+		setup(tmpDir)
+		testutil.CreateFile(t, tmpDir, "a.go", "package a\n\nvar A = 1\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+
+		g, err := New(tmpDir, &config.GitConfig{CommitMessageTemplate: tmpl})
+		require.NoError(t, err)
+		_, err = g.Commit(t.Context(), "Add A")
+		require.NoError(t, err)
+
+		return testutil.RunGitCmd(t, tmpDir, "log", "-1", "--format=%s")
+	}
+
+	t.Run("ticket from branch", func(t *testing.T) {
+		t.Parallel()
+		got := commitWith(t, "[{{.Ticket}}] {{.Message}}", func(dir string) {
+			testutil.RunGitCmd(t, dir, "checkout", "-b", "feature/PROJ-42-add-a")
+		})
+		assert.Equal(t, "[PROJ-42] Add A", got)
+	})
+
+	t.Run("static prefix", func(t *testing.T) {
+		t.Parallel()
+		got := commitWith(t, "chore: {{.Message}}", func(string) {})
+		assert.Equal(t, "chore: Add A", got)
+	})
+
+	t.Run("detached HEAD", func(t *testing.T) {
+		t.Parallel()
+		got := commitWith(t, "{{.Message}} [{{.Branch}}]", func(dir string) {
+			testutil.RunGitCmd(t, dir, "checkout", "--detach")
+		})
+		assert.Equal(t, "Add A []", got)
+	})
+
+	t.Run("invalid template", func(t *testing.T) {
+		t.Parallel()
+		_, err := New(testutil.CreateTempGitRepo(t), &config.GitConfig{CommitMessageTemplate: "{{.Message"})
+		require.Error(t, err)
+	})
+}
+
+func TestResolveCommitMessage(t *testing.T) {
+	t.Parallel()
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "msg.txt", "\n\nAdd A\n\n\nWith a body.  \n\n")
+	testutil.CreateFile(t, tmpDir, "blank.txt", " \n\n")
+	testutil.CreateFile(t, tmpDir, ".git/COMMIT_EDITMSG",
+		"Prepared message\n\n# Please enter the commit message for your changes.\n#\tmodified: a.go\n")
+	g, err := New(tmpDir, nil)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		message string
+		want    string
+	}{
+		{"Add A", "Add A"},
+		{"  inline messages are untouched ", "  inline messages are untouched "},
+		{"@msg.txt", "Add A\n\nWith a body."},
+		{"COMMIT_EDITMSG", "Prepared message"},
+	} {
+		got, err := g.ResolveCommitMessage(t.Context(), tc.message)
+		require.NoError(t, err, tc.message)
+		assert.Equal(t, tc.want, got, tc.message)
+	}
+
+	_, err = g.ResolveCommitMessage(t.Context(), "@blank.txt")
+	require.ErrorIs(t, err, ErrEmptyCommitMsg)
+	_, err = g.ResolveCommitMessage(t.Context(), "@missing.txt")
+	require.ErrorIs(t, err, ErrFileNotFound)
+	_, err = g.ResolveCommitMessage(t.Context(), "@../outside.txt")
+	require.Error(t, err)
+}
@@ -0,0 +1,215 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxManifestFileSize is the largest manifest file DetectEnvironment parses
+// (256KB); larger files are skipped.
+const maxManifestFileSize = 256 * 1024
+
+// goFrameworks maps Go module paths (or path prefixes ending in "/") to the
+// framework name reported in the environment summary.
+var goFrameworks = map[string]string{
+	"github.com/gin-gonic/gin":     "Gin",
+	"github.com/labstack/echo/":    "Echo",
+	"github.com/gofiber/fiber/":    "Fiber",
+	"github.com/go-chi/chi/":       "chi",
+	"github.com/gorilla/mux":       "Gorilla mux",
+	"google.golang.org/grpc":       "gRPC",
+	"github.com/spf13/cobra":       "Cobra",
+	"gorm.io/gorm":                 "GORM",
+	"github.com/mark3labs/mcp-go":  "mcp-go",
+	"google.golang.org/genai":      "Google Gen AI SDK",
+	"k8s.io/client-go":             "Kubernetes client-go",
+	"github.com/stretchr/testify":  "testify",
+	"github.com/jackc/pgx/":        "pgx",
+	"github.com/aws/aws-sdk-go-v2": "AWS SDK for Go v2",
+}
+
+// nodeFrameworks maps npm package names to the framework name reported in
+// the environment summary.
+var nodeFrameworks = map[string]string{
+	"react":         "React",
+	"next":          "Next.js",
+	"vue":           "Vue",
+	"nuxt":          "Nuxt",
+	"svelte":        "Svelte",
+	"@angular/core": "Angular",
+	"express":       "Express",
+	"fastify":       "Fastify",
+	"@nestjs/core":  "NestJS",
+	"typescript":    "TypeScript",
+	"vite":          "Vite",
+	"jest":          "Jest",
+	"vitest":        "Vitest",
+}
+
+// Environment describes the tech stack detected from manifest files at the
+// repository root.
+type Environment struct {
+	GoModule       string   // Module path from go.mod
+	GoVersion      string   // "go" directive from go.mod
+	GoToolchain    string   // "toolchain" directive from go.mod
+	NodePackage    string   // "name" from package.json
+	NodeVersion    string   // "engines.node" from package.json
+	Frameworks     []string // Notable dependencies, sorted
+	ManifestsFound []string // Manifest files that were parsed
+}
+
+// DetectEnvironment inspects go.mod and package.json at the repository root
+// and summarizes the tech stack they declare. Missing, oversized, or
+// unparseable manifests are skipped; the result is nil when nothing was
+// detected.
+func (g *Git) DetectEnvironment(ctx context.Context) *Environment {
+	env := &Environment{}
+	frameworks := make(map[string]bool)
+
+	if content, ok := g.readManifest(ctx, "go.mod"); ok {
+		env.ManifestsFound = append(env.ManifestsFound, "go.mod")
+		parseGoMod(content, env, frameworks)
+	}
+	if content, ok := g.readManifest(ctx, "package.json"); ok && parsePackageJSON(content, env, frameworks) {
+		env.ManifestsFound = append(env.ManifestsFound, "package.json")
+	}
+
+	if len(env.ManifestsFound) == 0 {
+		return nil
+	}
+	for name := range frameworks {
+		env.Frameworks = append(env.Frameworks, name)
+	}
+	slices.Sort(env.Frameworks)
+
+	return env
+}
+
+// readManifest reads a manifest through the same path checks as
+// GetFileContent, reporting false if it is absent, unreadable, or too large.
+func (g *Git) readManifest(ctx context.Context, name string) (string, bool) {
+	content, err := g.GetFileContent(ctx, name)
+	if err != nil || len(content) > maxManifestFileSize {
+		return "", false
+	}
+
+	return content, true
+}
+
+// parseGoMod extracts the module path, Go version, toolchain, and known
+// framework requirements from go.mod content. It is a line-oriented scan
+// rather than a full parser: only the directives it needs are recognized.
+func parseGoMod(content string, env *Environment, frameworks map[string]bool) {
+	inRequire := false
+	for rawLine := range strings.SplitSeq(content, "\n") {
+		line := strings.TrimSpace(rawLine)
+		if i := strings.Index(line, "//"); i != -1 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		var modPath string
+		switch {
+		case inRequire && line == ")":
+			inRequire = false
+		case inRequire:
+			modPath, _, _ = strings.Cut(line, " ")
+		case line == "require (":
+			inRequire = true
+		case strings.HasPrefix(line, "require "):
+			modPath, _, _ = strings.Cut(strings.TrimPrefix(line, "require "), " ")
+		case strings.HasPrefix(line, "module "):
+			env.GoModule = strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+		case strings.HasPrefix(line, "go "):
+			env.GoVersion = strings.TrimSpace(strings.TrimPrefix(line, "go "))
+		case strings.HasPrefix(line, "toolchain "):
+			env.GoToolchain = strings.TrimSpace(strings.TrimPrefix(line, "toolchain "))
+		}
+
+		if modPath == "" {
+			continue
+		}
+		for prefix, name := range goFrameworks {
+			if modPath == prefix || (strings.HasSuffix(prefix, "/") && strings.HasPrefix(modPath, prefix)) {
+				frameworks[name] = true
+			}
+		}
+	}
+}
+
+// parsePackageJSON extracts the package name, Node.js engine constraint, and
+// known framework dependencies from package.json content. It reports false
+// if the content is not valid JSON.
+func parsePackageJSON(content string, env *Environment, frameworks map[string]bool) bool {
+	var pkg struct {
+		Name    string `json:"name"`
+		Engines struct {
+			Node string `json:"node"`
+		} `json:"engines"`
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal([]byte(content), &pkg); err != nil {
+		return false
+	}
+
+	env.NodePackage = pkg.Name
+	env.NodeVersion = pkg.Engines.Node
+	for _, deps := range []map[string]string{pkg.Dependencies, pkg.DevDependencies} {
+		for dep := range deps {
+			if name, ok := nodeFrameworks[dep]; ok {
+				frameworks[name] = true
+			}
+		}
+	}
+
+	return true
+}
+
+// FormatEnvironment formats a detected environment into a prompt section.
+// Manifest values are repository content, so they are fenced like
+// instruction files. Returns an empty string for a nil environment.
+func FormatEnvironment(env *Environment) string {
+	if env == nil {
+		return ""
+	}
+
+	var details strings.Builder
+	for _, field := range []struct{ label, value string }{
+		{"Go module", env.GoModule},
+		{"Go version", env.GoVersion},
+		{"Go toolchain", env.GoToolchain},
+		{"Node.js package", env.NodePackage},
+		{"Node.js engine", env.NodeVersion},
+		{"Frameworks and libraries", strings.Join(env.Frameworks, ", ")},
+	} {
+		if field.value != "" {
+			_, _ = fmt.Fprintf(&details, "- %s: %s\n", field.label, field.value)
+		}
+	}
+
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb,
+		"## Repository Environment\n\nThe following tech stack was detected from %s at the repository root:\n\n%s\n\n",
+		strings.Join(env.ManifestsFound, " and "), untrustedContentWarning)
+	_, _ = fmt.Fprintf(&sb, "<untrusted_user_content>\n%s</untrusted_user_content>\n\n",
+		escapeUntrustedFence(details.String()))
+
+	return sb.String()
+}
@@ -0,0 +1,85 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"strings"
+)
+
+// gitLineEndings returns content as git would record it for the untracked
+// file at relativePath: with CRLF line endings normalized to LF when the
+// file's text/eol attributes or core.autocrlf call for it, and unchanged
+// otherwise. git applies that conversion before diffing tracked files, so
+// without it a synthesized block for a Windows-authored file would show a
+// stray CR on every line where git's own diff of the same file shows none.
+// If git cannot be asked, content is returned unchanged.
+func (g *Git) gitLineEndings(ctx context.Context, relativePath, content string) string {
+	if !strings.Contains(content, "\r\n") {
+		return content
+	}
+	normalize, err := g.normalizesCRLF(ctx, relativePath, content)
+	if err != nil || !normalize {
+		return content
+	}
+
+	return strings.ReplaceAll(content, "\r\n", "\n")
+}
+
+// normalizesCRLF reports whether git converts CRLF to LF when adding the
+// new file at relativePath, following convert.c: text unset never converts,
+// text set or an eol attribute always does, and text=auto, or an unspecified
+// text attribute with core.autocrlf true or input, converts files git does
+// not judge binary.
+func (g *Git) normalizesCRLF(ctx context.Context, relativePath, content string) (bool, error) {
+	out, err := g.runGitCommand(ctx, "check-attr", "-z", "text", "eol", "--", relativePath)
+	if err != nil {
+		return false, err
+	}
+	// Output is a sequence of NUL-terminated path, attribute, value triples.
+	attrs := make(map[string]string)
+	fields := strings.Split(out, "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		attrs[fields[i+1]] = fields[i+2]
+	}
+
+	switch text, eol := attrs["text"], attrs["eol"]; {
+	case text == "unset":
+		return false, nil
+	case text == "set", eol == "lf", eol == "crlf":
+		return true, nil
+	case text == "auto":
+		return !looksBinary(content), nil
+	}
+
+	res, err := runGit(ctx, g.repoPath, g.commandTimeout, nil, nil, "config", "--get", "core.autocrlf")
+	if err != nil {
+		return false, err
+	}
+	// Exit status 1 means the setting is absent, which is false.
+	switch strings.ToLower(strings.TrimSpace(res.stdout)) {
+	case "true", "yes", "on", "1", "input":
+		return !looksBinary(content), nil
+	default:
+		return false, nil
+	}
+}
+
+// looksBinary approximates git's convert_is_binary check for automatic line
+// ending conversion: a NUL, or a carriage return not followed by a line feed.
+func looksBinary(content string) bool {
+	return strings.Contains(content, "\x00") ||
+		strings.Count(content, "\r") != strings.Count(content, "\r\n")
+}
@@ -0,0 +1,89 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"msrl.dev/lgtmcp/internal/testutil"
+)
+
+// TestGetDiff_UntrackedLineEndings checks that the synthesized block for an
+// untracked file shows the same lines as git's own diff of that file, across
+// the settings that decide whether git normalizes CRLF line endings.
+func TestGetDiff_UntrackedLineEndings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		autocrlf    string
+		attributes  string
+		content     string
+		initial     bool
+		wantCRLines bool
+	}{
+		{name: "no conversion configured", content: "alpha\r\nbeta\r\n", wantCRLines: true},
+		{name: "autocrlf true", autocrlf: "true", content: "alpha\r\nbeta\r\n"},
+		{name: "autocrlf input", autocrlf: "input", content: "alpha\r\nbeta\r\n"},
+		{name: "autocrlf on initial commit", autocrlf: "true", content: "alpha\r\nbeta\r\n", initial: true},
+		{name: "text attribute", attributes: "*.txt text\n", content: "alpha\r\nbeta\r\n"},
+		{name: "eol attribute", attributes: "*.txt eol=lf\n", content: "alpha\r\nbeta\r\n"},
+		{
+			name: "-text overrides autocrlf", autocrlf: "true", attributes: "*.txt -text\n",
+			content: "alpha\r\nbeta\r\n", wantCRLines: true,
+		},
+		{
+			name: "text=auto skips lone carriage returns", attributes: "*.txt text=auto\n",
+			content: "alpha\r\nbeta\rgamma\r\n", wantCRLines: true,
+		},
+		{name: "mixed endings with autocrlf", autocrlf: "true", content: "alpha\r\nbeta\ngamma\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tmpDir := testutil.CreateTempGitRepo(t)
+			if tt.autocrlf != "" {
+				testutil.RunGitCmd(t, tmpDir, "config", "core.autocrlf", tt.autocrlf)
+			}
+			if tt.attributes != "" {
+				testutil.CreateFile(t, tmpDir, ".gitattributes", tt.attributes)
+			}
+			if !tt.initial {
+				testutil.CreateFile(t, tmpDir, "README", "readme\n")
+				testutil.RunGitCmd(t, tmpDir, "add", ".")
+				testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+			}
+			testutil.CreateFile(t, tmpDir, "file.txt", tt.content)
+
+			g, err := New(tmpDir, nil)
+			require.NoError(t, err)
+			diff, err := g.GetDiff(t.Context(), "file.txt")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCRLines, strings.Contains(diff, "alpha\r\n"))
+
+			// git's own diff of the file, once it is known to the index.
+			testutil.RunGitCmd(t, tmpDir, "add", "--intent-to-add", "file.txt")
+			gitOut := testutil.RunGitCmd(t, tmpDir, "diff", "--no-color", "--", "file.txt")
+			_, want, ok := strings.Cut(gitOut, "--- /dev/null\n")
+			require.True(t, ok, "unexpected git output:\n%s", gitOut)
+			_, got, ok := strings.Cut(diff, "--- /dev/null\n")
+			require.True(t, ok, "unexpected synthesized output:\n%s", diff)
+			assert.Equal(t, want, strings.TrimSpace(got))
+		})
+	}
+}
@@ -26,14 +26,17 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"msrl.dev/lgtmcp/internal/config"
 )
 
 const (
-	// gitCommandTimeout is the maximum duration for a git command to complete.
-	gitCommandTimeout = 30 * time.Second
+	// DefaultCommandTimeout is the maximum duration for a git command to
+	// complete when the configuration does not set git.command_timeout.
+	DefaultCommandTimeout = 30 * time.Second
 )
 
 var (
@@ -55,12 +58,38 @@ var (
 	ErrPathOutsideRepo = errors.New("path is outside repository")
 	// ErrNotRegularFile indicates the path is not a regular file.
 	ErrNotRegularFile = errors.New("not a regular file")
+	// ErrFileTooLarge indicates a file exceeds git.max_file_read_bytes.
+	ErrFileTooLarge = errors.New("file exceeds git.max_file_read_bytes")
+	// ErrNoCommitToAmend indicates an amend was requested in a repository
+	// with no commits.
+	ErrNoCommitToAmend = errors.New("no previous commit to amend")
 )
 
+// DefaultDiffContextLines is the number of diff context lines used when the
+// configuration does not set git.diff_context_lines.
+const DefaultDiffContextLines = 20
+
 // Git provides git repository operations.
 type Git struct {
 	repoPath         string
 	diffContextLines int
+	noVerify         bool
+	commandTimeout   time.Duration
+	// agentFilenames are the file names FindAgentFiles looks for.
+	agentFilenames []string
+	// maxAgentFileBytes is the size above which instruction files are skipped.
+	maxAgentFileBytes int64
+	// commitTemplate, if set, wraps every commit message.
+	commitTemplate *template.Template
+	// defaultMessage, if set, renders the commit message used when the
+	// client gives none.
+	defaultMessage *template.Template
+	// maxFileReadBytes bounds readRepoFile; 0 means no limit.
+	maxFileReadBytes int64
+	// textExtensions and binaryExtensions, normalized, decide whether a new
+	// file is shown as text without looking at its content.
+	textExtensions   map[string]bool
+	binaryExtensions map[string]bool
 }
 
 // New creates a new Git instance for the given repository path.
@@ -74,38 +103,99 @@ func New(repoPath string, cfg *config.GitConfig) (*Git, error) {
 		return nil, ErrNotGitRepo
 	}
 
-	// Default to 20 lines of context if not specified
-	contextLines := 20
+	contextLines := DefaultDiffContextLines
 	if cfg != nil && cfg.DiffContextLines != nil {
 		contextLines = *cfg.DiffContextLines
 	}
 
+	// Like the retry durations, an unparseable or non-positive timeout falls
+	// back to the default rather than failing every review.
+	commandTimeout := DefaultCommandTimeout
+	if cfg != nil && cfg.CommandTimeout != "" {
+		if d, err := time.ParseDuration(cfg.CommandTimeout); err == nil && d > 0 {
+			commandTimeout = d
+		}
+	}
+
+	agentFilenames := config.DefaultAgentFilenames
+	if cfg != nil && cfg.AgentFilenames != nil {
+		agentFilenames = cfg.AgentFilenames
+	}
+	maxAgentFileBytes := config.DefaultMaxAgentFileBytes
+	if cfg != nil && cfg.MaxAgentFileBytes != nil && *cfg.MaxAgentFileBytes > 0 {
+		maxAgentFileBytes = *cfg.MaxAgentFileBytes
+	}
+
+	var maxFileReadBytes int64
+	if cfg != nil && cfg.MaxFileReadBytes > 0 {
+		maxFileReadBytes = cfg.MaxFileReadBytes
+	}
+
+	var commitTemplate *template.Template
+	if cfg != nil && cfg.CommitMessageTemplate != "" {
+		if commitTemplate, err = ParseCommitMessageTemplate(cfg.CommitMessageTemplate); err != nil {
+			return nil, fmt.Errorf("invalid git.commit_message_template: %w", err)
+		}
+	}
+	var defaultMessage *template.Template
+	if cfg != nil && cfg.DefaultCommitMessage != "" {
+		if defaultMessage, err = ParseDefaultCommitMessage(cfg.DefaultCommitMessage); err != nil {
+			return nil, fmt.Errorf("invalid git.default_commit_message: %w", err)
+		}
+	}
+
+	var textExtensions, binaryExtensions map[string]bool
+	if cfg != nil {
+		textExtensions = extensionSet(cfg.TextExtensions)
+		binaryExtensions = extensionSet(cfg.BinaryExtensions)
+	}
+
 	return &Git{
-		repoPath:         absPath,
-		diffContextLines: contextLines,
+		repoPath:          absPath,
+		diffContextLines:  contextLines,
+		noVerify:          cfg != nil && cfg.NoVerify,
+		commandTimeout:    commandTimeout,
+		agentFilenames:    agentFilenames,
+		maxAgentFileBytes: maxAgentFileBytes,
+		commitTemplate:    commitTemplate,
+		defaultMessage:    defaultMessage,
+		maxFileReadBytes:  maxFileReadBytes,
+		textExtensions:    textExtensions,
+		binaryExtensions:  binaryExtensions,
 	}, nil
 }
 
-// GetDiff returns the diff of all changes in the repository.
-func (g *Git) GetDiff(ctx context.Context) (string, error) {
-	// Check if this is an initial commit (no HEAD exists). --verify --quiet
-	// makes the check precise: exit 0 means HEAD resolves, exit 1 means it
-	// does not (unborn branch). Anything else — a real git failure, not an
-	// initial commit — must surface as an error rather than mislabel the
-	// whole repository as new files.
-	res, err := runGit(ctx, g.repoPath, nil, nil, "rev-parse", "--verify", "--quiet", "HEAD")
+// GetDiff returns the diff of all changes in the repository. If paths are
+// given, the diff is limited to them (see literalPathspecs); a directory
+// selects everything beneath it.
+func (g *Git) GetDiff(ctx context.Context, paths ...string) (string, error) {
+	return g.getDiff(ctx, false, paths)
+}
+
+// GetDiffIgnoringWhitespace is GetDiff with changes in whitespace ignored
+// (git diff --ignore-all-space): hunks that only change whitespace are left
+// out, and so are files whose changes are all whitespace. New files are
+// shown in full.
+func (g *Git) GetDiffIgnoringWhitespace(ctx context.Context, paths ...string) (string, error) {
+	return g.getDiff(ctx, true, paths)
+}
+
+// getDiff implements GetDiff and GetDiffIgnoringWhitespace.
+func (g *Git) getDiff(ctx context.Context, ignoreWhitespace bool, paths []string) (string, error) {
+	pathspecs, err := g.literalPathspecs(paths)
 	if err != nil {
-		return "", fmt.Errorf("failed to check for HEAD: %w", err)
+		return "", err
+	}
+	if len(pathspecs) == 0 {
+		pathspecs = []string{"."}
 	}
-	if res.exitCode != 0 && res.exitCode != 1 {
-		msg := strings.TrimSpace(res.stderr)
-		if msg == "" {
-			msg = fmt.Sprintf("exit status %d", res.exitCode)
-		}
 
-		return "", fmt.Errorf("failed to check for HEAD: %w: %s", ErrCommandFailed, msg)
+	// Check if this is an initial commit (no HEAD exists).
+	hasHead, err := g.HasCommits(ctx)
+	if err != nil {
+		return "", err
 	}
-	isInitialCommit := res.exitCode == 1
+	isInitialCommit := !hasHead
 
 	var diff string
 
@@ -114,13 +204,15 @@ func (g *Git) GetDiff(ctx context.Context) (string, error) {
 		// yields raw NUL-terminated paths; without it git C-quotes names with
 		// special or non-ASCII bytes (per core.quotePath), and the quoted form
 		// would fail the stat in newFileForDiff and silently drop the file.
-		files, filesErr := g.runGitCommand(ctx, "ls-files", "-z", "--others", "--exclude-standard")
+		files, filesErr := g.runGitCommand(ctx,
+			append([]string{"ls-files", "-z", "--others", "--exclude-standard", "--"}, pathspecs...)...)
 		if filesErr != nil {
 			return "", fmt.Errorf("failed to get files for initial commit: %w", filesErr)
 		}
 
 		// Also check for any files that might be staged.
-		stagedFiles, stageErr := g.runGitCommand(ctx, "diff", "--cached", "--name-only", "-z")
+		stagedFiles, stageErr := g.runGitCommand(ctx,
+			append([]string{"diff", "--cached", "--name-only", "-z", "--"}, pathspecs...)...)
 		if stageErr != nil {
 			// Ignore error, just use untracked files.
 			stagedFiles = ""
@@ -132,34 +224,19 @@ func (g *Git) GetDiff(ctx context.Context) (string, error) {
 			return "", ErrNoChanges
 		}
 
-		var diffOutput bytes.Buffer
-		uniqueFiles := make(map[string]bool)
-		for file := range strings.SplitSeq(files, "\x00") {
-			if file != "" && !uniqueFiles[file] {
-				uniqueFiles[file] = true
-				content, mode, contentErr := g.newFileForDiff(file)
-				if contentErr == nil {
-					writeNewFileDiff(&diffOutput, file, content, mode)
-				}
-			}
+		diff, err = g.newFilesDiff(ctx, files)
+		if err != nil {
+			return "", err
 		}
-		diff = diffOutput.String()
 	} else {
 		// Normal case: diff between HEAD and working directory (including untracked files).
 		// This shows all changes regardless of staging status.
-		// Use the configured context lines (default 20).
-		// Pin output to a parseable unified diff regardless of user git config:
-		// force canonical a/ and b/ prefixes (diff.mnemonicPrefix would emit
-		// c/ and w/), disable external diff drivers (diff.external replaces
-		// the unified format with arbitrary tool output), and disable color
-		// (color.diff=always would inject ANSI escapes). Pin core.quotePath=true
-		// so non-ASCII path bytes are C-quoted in the headers: that is git's
-		// default and the form writeNewFileDiff/gitQuotePath synthesize for the
-		// untracked-file blocks appended below, so a user's core.quotePath=false
-		// cannot make the tracked and synthesized halves of the diff disagree.
-		contextFlag := fmt.Sprintf("--unified=%d", g.diffContextLines)
-		diff, err = g.runGitCommand(ctx, "-c", "core.quotePath=true", "diff", contextFlag,
-			"--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/", "HEAD", "--", ".")
+		diffArgs := g.unifiedDiffArgs()
+		if ignoreWhitespace {
+			diffArgs = append(diffArgs, "--ignore-all-space")
+		}
+		diffArgs = append(diffArgs, "HEAD", "--")
+		diff, err = g.runGitCommand(ctx, append(diffArgs, pathspecs...)...)
 		if err != nil {
 			return "", fmt.Errorf("failed to get diff against HEAD: %w", err)
 		}
@@ -168,25 +245,21 @@ func (g *Git) GetDiff(ctx context.Context) (string, error) {
 		// paths; the default C-quoting of special or non-ASCII names (per
 		// core.quotePath) would fail the stat in newFileForDiff and silently
 		// drop the file from the diff.
-		untrackedFiles, err := g.runGitCommand(ctx, "ls-files", "-z", "--others", "--exclude-standard")
+		untrackedFiles, err := g.runGitCommand(ctx,
+			append([]string{"ls-files", "-z", "--others", "--exclude-standard", "--"}, pathspecs...)...)
 		if err != nil {
 			return "", fmt.Errorf("failed to get untracked files: %w", err)
 		}
 
 		if untrackedFiles != "" {
-			var untrackedDiff bytes.Buffer
-			for file := range strings.SplitSeq(untrackedFiles, "\x00") {
-				if file != "" {
-					content, mode, err := g.newFileForDiff(file)
-					if err == nil {
-						writeNewFileDiff(&untrackedDiff, file, content, mode)
-					}
-				}
+			untrackedDiff, err := g.newFilesDiff(ctx, untrackedFiles)
+			if err != nil {
+				return "", err
 			}
 			// Append the synthesized blocks directly: git emits file blocks
 			// back to back, and a separating blank line would be a stray
 			// non-diff line in the output.
-			diff += untrackedDiff.String()
+			diff += untrackedDiff
 		}
 	}
 
@@ -197,10 +270,205 @@ func (g *Git) GetDiff(ctx context.Context) (string, error) {
 	return diff, nil
 }
 
+// maxConcurrentFileReads bounds how many files newFilesDiff reads at once.
+const maxConcurrentFileReads = 8
+
+// newFilesDiff synthesizes "new file" diff blocks for the NUL-separated
+// paths in files, in sorted order and each once. Files are read
+// concurrently, up to maxConcurrentFileReads at a time, since a new
+// repository or generated tree can add thousands. Files that cannot be read
+// are left out, as git would not show them either, except that a file too
+// large to show or a done ctx fails the whole diff.
+func (g *Git) newFilesDiff(ctx context.Context, files string) (string, error) {
+	paths := slices.DeleteFunc(strings.Split(files, "\x00"), func(p string) bool { return p == "" })
+	slices.Sort(paths)
+	paths = slices.Compact(paths)
+
+	type newFile struct {
+		content string
+		mode    os.FileMode
+		err     error
+	}
+	results := make([]newFile, len(paths))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(maxConcurrentFileReads, len(paths)) {
+		wg.Go(func() {
+			for i := range next {
+				content, mode, err := g.newFileForDiff(ctx, paths[i])
+				if err == nil && mode.IsRegular() {
+					content = g.gitLineEndings(ctx, paths[i], content)
+				}
+				results[i] = newFile{content: content, mode: mode, err: err}
+			}
+		})
+	}
+feed:
+	for i := range paths {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	for i, file := range paths {
+		result := results[i]
+		if fatalReadError(result.err) {
+			return "", result.err
+		}
+		if result.err == nil {
+			binary := result.mode.IsRegular() && g.isBinary(file, result.content)
+			writeNewFileDiff(&buf, file, result.content, result.mode, binary)
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// GetDiffStat returns a "git diff --stat" style summary of diff, as returned
+// by GetDiff or DiffRange. It is computed from the diff text itself rather
+// than the repository, so it covers exactly what is reviewed, including
+// untracked files.
+func (g *Git) GetDiffStat(ctx context.Context, diff string) (string, error) {
+	// apply --stat only reads the patch; nothing is applied.
+	out, err := g.runGitCommandStdin(ctx, strings.NewReader(diff), nil, "apply", "--stat", "-")
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize diff: %w", err)
+	}
+
+	return strings.TrimRight(out, "\n"), nil
+}
+
+// UntrackedFiles returns the files in the working tree that git does not
+// track and that are not excluded (by .gitignore and the like): the new files
+// GetDiff synthesizes blocks for.
+func (g *Git) UntrackedFiles(ctx context.Context) ([]string, error) {
+	out, err := g.runGitCommand(ctx, "ls-files", "-z", "--others", "--exclude-standard", "--", ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get untracked files: %w", err)
+	}
+
+	var files []string
+	for file := range strings.SplitSeq(out, "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
+
+// unifiedDiffArgs returns a git diff command line, up to its revisions, with
+// the configured context lines (default 20) and output pinned to a parseable
+// unified diff regardless of user git config: force canonical a/ and b/
+// prefixes (diff.mnemonicPrefix would emit c/ and w/), disable external diff
+// drivers (diff.external replaces the unified format with arbitrary tool
+// output), and disable color (color.diff=always would inject ANSI escapes).
+// Pin core.quotePath=true so non-ASCII path bytes are C-quoted in the
+// headers: that is git's default and the form writeNewFileDiff/gitQuotePath
+// synthesize for untracked-file blocks, so a user's core.quotePath=false
+// cannot make the tracked and synthesized halves of a diff disagree.
+// --find-renames likewise overrides diff.renames=false, so a renamed and
+// edited file shows as a "rename from"/"rename to" block carrying only the
+// edit hunks rather than as a full deletion plus a full addition.
+func (g *Git) unifiedDiffArgs() []string {
+	return []string{
+		"-c", "core.quotePath=true", "diff", fmt.Sprintf("--unified=%d", g.diffContextLines),
+		"--no-color", "--no-ext-diff", "--find-renames", "--src-prefix=a/", "--dst-prefix=b/",
+	}
+}
+
+// HasCommits reports whether HEAD resolves to a commit, i.e. the current
+// branch is not unborn. --verify --quiet makes the check precise: exit 0
+// means HEAD resolves, exit 1 means it does not. Anything else is a real git
+// failure and is returned as an error rather than mistaken for an empty
+// repository.
+func (g *Git) HasCommits(ctx context.Context) (bool, error) {
+	res, err := runGit(ctx, g.repoPath, g.commandTimeout, nil, nil, "rev-parse", "--verify", "--quiet", "HEAD")
+	if err != nil {
+		return false, fmt.Errorf("failed to check for HEAD: %w", err)
+	}
+	if res.exitCode != 0 && res.exitCode != 1 {
+		msg := strings.TrimSpace(res.stderr)
+		if msg == "" {
+			msg = fmt.Sprintf("exit status %d", res.exitCode)
+		}
+
+		return false, fmt.Errorf("failed to check for HEAD: %w: %s", ErrCommandFailed, msg)
+	}
+
+	return res.exitCode == 0, nil
+}
+
+// literalPathspecs validates repo-relative paths and converts them to literal
+// git pathspecs, so names containing glob characters match only themselves.
+// Absolute paths, paths that escape the repository, and empty paths are
+// rejected. It returns nil for no paths.
+func (g *Git) literalPathspecs(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	pathspecs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		if p == "" {
+			return nil, fmt.Errorf("%w: empty path", ErrInvalidPath)
+		}
+		if _, err := g.repoPathFor(p); err != nil {
+			return nil, err
+		}
+		pathspecs = append(pathspecs, ":(literal)"+filepath.ToSlash(filepath.Clean(p)))
+	}
+
+	return pathspecs, nil
+}
+
 // binaryDetectionLimit is how many leading bytes are searched for a NUL to
 // classify content as binary, matching git's FIRST_FEW_BYTES heuristic.
 const binaryDetectionLimit = 8000
 
+// extensionSet returns the set of exts, normalized with
+// config.NormalizeExtension, or nil when there are none.
+func extensionSet(exts []string) map[string]bool {
+	if len(exts) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(exts))
+	for _, ext := range exts {
+		set[config.NormalizeExtension(ext)] = true
+	}
+
+	return set
+}
+
+// detectBinary reports whether content is binary by the heuristic git uses
+// for diffs: a NUL among its leading bytes.
+func detectBinary(content string) bool {
+	return strings.Contains(content[:min(len(content), binaryDetectionLimit)], "\x00")
+}
+
+// isBinary reports whether the new file at repo-relative path file, with
+// content, is shown as binary in a synthesized diff: as its extension says
+// when git.text_extensions or git.binary_extensions lists it, and by
+// detectBinary otherwise.
+func (g *Git) isBinary(file, content string) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+	switch {
+	case g.textExtensions[ext]:
+		return false
+	case g.binaryExtensions[ext]:
+		return true
+	default:
+		return detectBinary(content)
+	}
+}
+
 // writeNewFileDiff renders content as a synthetic "new file" diff block (used
 // for untracked files and initial commits, which have no blob to diff against),
 // mirroring git's own rendering: a "new file mode" line whose value reflects the
@@ -215,16 +483,16 @@ const binaryDetectionLimit = 8000
 // (content ending in "\n\n" keeps a genuine blank final line). An empty file
 // yields only the header lines and no hunk, also matching git, so a newly added
 // empty file is still surfaced to the reviewer rather than dropped. Binary
-// content (a NUL among the leading bytes, git's heuristic) yields a "Binary
-// files ... differ" line instead of hunks of raw bytes; secret scanning is
-// unaffected, since it reads file contents from disk rather than from the diff.
-func writeNewFileDiff(buf *bytes.Buffer, file, content string, mode os.FileMode) {
+// content (see Git.isBinary) yields a "Binary files ... differ" line instead
+// of hunks of raw bytes; secret scanning is unaffected, since it reads file
+// contents from disk rather than from the diff.
+func writeNewFileDiff(buf *bytes.Buffer, file, content string, mode os.FileMode, binary bool) {
 	_, _ = fmt.Fprintf(buf, "diff --git %s %s\n", gitQuotePath("a/", file), gitQuotePath("b/", file))
 	_, _ = fmt.Fprintf(buf, "new file mode %s\n", gitFileMode(mode))
 	if content == "" {
 		return
 	}
-	if strings.Contains(content[:min(len(content), binaryDetectionLimit)], "\x00") {
+	if binary {
 		_, _ = fmt.Fprintf(buf, "Binary files /dev/null and %s differ\n", gitQuotePath("b/", file))
 
 		return
@@ -368,11 +636,72 @@ func (g *Git) StageFiles(ctx context.Context, files []string) error {
 	return nil
 }
 
-// Commit creates a commit with the given message.
-func (g *Git) Commit(ctx context.Context, message string) (string, error) {
+// WriteFile writes content to a repo-relative path, creating any missing
+// parent directories. The write goes through [os.Root], so neither ".."
+// segments nor symlinks (in the path or at the target) can redirect it
+// outside the repository. Paths inside .git are rejected.
+func (g *Git) WriteFile(relativePath string, content []byte) error {
+	clean := filepath.Clean(relativePath)
+	if clean == "." || !filepath.IsLocal(clean) {
+		return fmt.Errorf("%w: %s", ErrInvalidPath, relativePath)
+	}
+	if first, _, _ := strings.Cut(filepath.ToSlash(clean), "/"); first == ".git" {
+		return fmt.Errorf("%w: refusing to write inside .git: %s", ErrInvalidPath, relativePath)
+	}
+
+	root, err := os.OpenRoot(g.repoPath)
+	if err != nil {
+		return fmt.Errorf("failed to open repository root: %w", err)
+	}
+	defer func() { _ = root.Close() }()
+
+	if dir := filepath.Dir(clean); dir != "." {
+		if err := root.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", relativePath, err)
+		}
+	}
+	if err := root.WriteFile(clean, content, 0o644); err != nil { //nolint:gosec // Committed repo file, not secret
+		return fmt.Errorf("failed to write %s: %w", relativePath, err)
+	}
+
+	return nil
+}
+
+// Commit creates a commit with the given message. If paths are given, only
+// those paths are committed ("git commit --only"), leaving any other staged
+// changes staged but uncommitted; paths must already be known to git, so new
+// files are staged first.
+func (g *Git) Commit(ctx context.Context, message string, paths ...string) (string, error) {
+	return g.commit(ctx, message, false, paths)
+}
+
+// CommitAmend folds the changes into the previous commit ("git commit
+// --amend"), replacing its message, and returns the new commit hash. Paths
+// limit the commit as in [Git.Commit]. It fails with ErrNoCommitToAmend if
+// the repository has no commits.
+func (g *Git) CommitAmend(ctx context.Context, message string, paths ...string) (string, error) {
+	return g.commit(ctx, message, true, paths)
+}
+
+// commit implements Commit and CommitAmend.
+func (g *Git) commit(ctx context.Context, message string, amend bool, paths []string) (string, error) {
 	if message == "" {
 		return "", ErrEmptyCommitMsg
 	}
+	message, err := g.commitMessage(ctx, message)
+	if err != nil {
+		return "", err
+	}
+
+	if amend {
+		hasHead, err := g.HasCommits(ctx)
+		if err != nil {
+			return "", err
+		}
+		if !hasHead {
+			return "", ErrNoCommitToAmend
+		}
+	}
 
 	// Check if there are changes to commit.
 	status, err := g.runGitCommand(ctx, "status", "--porcelain")
@@ -384,9 +713,33 @@ func (g *Git) Commit(ctx context.Context, message string) (string, error) {
 		return "", ErrNoChanges
 	}
 
+	args := []string{"commit", "-m", message}
+	if amend {
+		args = append(args, "--amend")
+	}
+	if g.noVerify {
+		args = append(args, "--no-verify")
+	}
+
 	// Commit with the provided message.
-	if _, commitErr := g.runGitCommand(ctx, "commit", "-m", message); commitErr != nil {
-		return "", fmt.Errorf("failed to commit: %w", commitErr)
+	if len(paths) == 0 {
+		if _, commitErr := g.runGitCommand(ctx, args...); commitErr != nil {
+			return "", fmt.Errorf("failed to commit: %w", commitErr)
+		}
+	} else {
+		// As in StageFiles, pass paths NUL-separated on stdin and treat them
+		// as literal pathspecs.
+		var stdin bytes.Buffer
+		for _, p := range paths {
+			_, _ = stdin.WriteString(p)
+			_ = stdin.WriteByte(0)
+		}
+		args = append(args, "--only", "--pathspec-from-file=-", "--pathspec-file-nul")
+		if _, commitErr := g.runGitCommandStdin(
+			ctx, &stdin, []string{"GIT_LITERAL_PATHSPECS=1"}, args...,
+		); commitErr != nil {
+			return "", fmt.Errorf("failed to commit: %w", commitErr)
+		}
 	}
 
 	// Get the commit hash.
@@ -399,12 +752,57 @@ func (g *Git) Commit(ctx context.Context, message string) (string, error) {
 }
 
 // GetFileContent returns the content of a file at the given relative path.
-func (g *Git) GetFileContent(_ context.Context, relativePath string) (string, error) {
-	content, _, err := g.readRepoFile(relativePath)
+// The read stops early with ctx's error if ctx is done, and fails with
+// ErrFileTooLarge for a file over git.max_file_read_bytes.
+func (g *Git) GetFileContent(ctx context.Context, relativePath string) (string, error) {
+	content, _, err := g.readRepoFile(ctx, relativePath)
 
 	return content, err
 }
 
+// FileSize returns the size in bytes of a repo-relative file in the working
+// tree, following symlinks, without reading it.
+func (g *Git) FileSize(relativePath string) (int64, error) {
+	fullPath, err := g.repoPathFor(relativePath)
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	return info.Size(), nil
+}
+
+// TrackedFiles returns the regular files tracked in the index, sorted, for
+// scanning a whole repository. Symlinks and submodules are left out, so
+// nothing outside the repository is reached and no file is listed twice
+// under another name.
+func (g *Git) TrackedFiles(ctx context.Context) ([]string, error) {
+	out, err := g.runGitCommand(ctx, "ls-files", "--stage", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tracked files: %w", err)
+	}
+	var files []string
+	for entry := range strings.SplitSeq(out, "\x00") {
+		// Each entry is "<mode> <object> <stage>\t<path>".
+		info, path, ok := strings.Cut(entry, "\t")
+		if !ok {
+			continue
+		}
+		mode, _, _ := strings.Cut(info, " ")
+		// A conflicted path has one entry per stage, listed together; keep
+		// just the first.
+		if mode != "100644" && mode != "100755" || len(files) > 0 && files[len(files)-1] == path {
+			continue
+		}
+		files = append(files, path)
+	}
+
+	return files, nil
+}
+
 // repoPathFor joins a repo-relative path onto the repository root and verifies
 // it stays within the repo lexically — before any symlink resolution. It rejects
 // absolute paths and paths that escape the repo (e.g. via ".."). The returned
@@ -501,7 +899,10 @@ func (g *Git) listedPaths(ctx context.Context, args ...string) (map[string]bool,
 // resolved so a link cannot escape the repository; the final target must be a
 // regular file. This is the security-sensitive reader backing the MCP
 // get_file_content tool, which must never expose files outside the repo.
-func (g *Git) readRepoFile(relativePath string) (string, os.FileMode, error) {
+func (g *Git) readRepoFile(ctx context.Context, relativePath string) (string, os.FileMode, error) {
+	if err := ctx.Err(); err != nil {
+		return "", 0, err
+	}
 	fullPath, err := g.repoPathFor(relativePath)
 	if err != nil {
 		return "", 0, err
@@ -546,13 +947,60 @@ func (g *Git) readRepoFile(relativePath string) (string, os.FileMode, error) {
 	if !resolvedInfo.Mode().IsRegular() {
 		return "", 0, fmt.Errorf("%w: %s", ErrNotRegularFile, relativePath)
 	}
+	if g.maxFileReadBytes > 0 && resolvedInfo.Size() > g.maxFileReadBytes {
+		return "", 0, fmt.Errorf("%w: %s is %d bytes, over %d", ErrFileTooLarge, relativePath,
+			resolvedInfo.Size(), g.maxFileReadBytes)
+	}
+
+	content, err := g.readFileContext(ctx, resolved, relativePath)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return content, resolvedInfo.Mode(), nil
+}
+
+// readFileChunkSize is how much readFileContext reads between checks of
+// its context.
+const readFileChunkSize = 1024 * 1024
 
-	content, err := os.ReadFile(resolved)
+// readFileContext reads the file at path in chunks, checking ctx between
+// them so that a canceled review does not wait out a large read, and
+// enforcing maxFileReadBytes against a file that grew after it was
+// statted. relativePath names the file in errors.
+func (g *Git) readFileContext(ctx context.Context, path, relativePath string) (string, error) {
+	f, err := os.Open(path) //nolint:gosec // Path resolved and checked by readRepoFile.
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to read file: %w", err)
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var buf bytes.Buffer
+	chunk := make([]byte, readFileChunkSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		n, readErr := f.Read(chunk)
+		_, _ = buf.Write(chunk[:n])
+		if g.maxFileReadBytes > 0 && int64(buf.Len()) > g.maxFileReadBytes {
+			return "", fmt.Errorf("%w: %s is over %d bytes", ErrFileTooLarge, relativePath, g.maxFileReadBytes)
+		}
+		if errors.Is(readErr, io.EOF) {
+			return buf.String(), nil
+		}
+		if readErr != nil {
+			return "", fmt.Errorf("failed to read file: %w", readErr)
+		}
 	}
+}
 
-	return string(content), resolvedInfo.Mode(), nil
+// fatalReadError reports whether a failure to read a file for a synthesized
+// diff must fail the diff instead of leaving the file out: the caller's
+// context is done, or the file is too large to show.
+func fatalReadError(err error) bool {
+	return errors.Is(err, ErrFileTooLarge) || errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
 }
 
 // newFileForDiff returns the content and mode used to synthesize a "new file"
@@ -562,7 +1010,7 @@ func (g *Git) readRepoFile(relativePath string) (string, os.FileMode, error) {
 // records symlinks and ensuring escaping or dangling symlinks are surfaced to
 // the reviewer rather than silently dropped. Regular files delegate to
 // readRepoFile, returning their content and a 100644/100755 mode.
-func (g *Git) newFileForDiff(relativePath string) (string, os.FileMode, error) {
+func (g *Git) newFileForDiff(ctx context.Context, relativePath string) (string, os.FileMode, error) {
 	fullPath, err := g.repoPathFor(relativePath)
 	if err != nil {
 		return "", 0, err
@@ -586,7 +1034,7 @@ func (g *Git) newFileForDiff(relativePath string) (string, os.FileMode, error) {
 		return target, info.Mode(), nil
 	}
 
-	return g.readRepoFile(relativePath)
+	return g.readRepoFile(ctx, relativePath)
 }
 
 func (g *Git) runGitCommand(ctx context.Context, args ...string) (string, error) {
@@ -596,7 +1044,7 @@ func (g *Git) runGitCommand(ctx context.Context, args ...string) (string, error)
 func (g *Git) runGitCommandStdin(
 	ctx context.Context, stdin io.Reader, extraEnv []string, args ...string,
 ) (string, error) {
-	res, err := runGit(ctx, g.repoPath, stdin, extraEnv, args...)
+	res, err := runGit(ctx, g.repoPath, g.commandTimeout, stdin, extraEnv, args...)
 	if err != nil {
 		if errors.Is(err, ErrCommandTimeout) {
 			return "", err
@@ -633,7 +1081,7 @@ func (g *Git) runGitCommandStdin(
 // repository or ignore file when lgtmcp runs inside another git process such as a
 // pre-commit hook.
 func IsIgnored(ctx context.Context, repoPath, relativePath string) (bool, error) {
-	res, err := runGit(ctx, repoPath, nil, nil, "check-ignore", "--", relativePath)
+	res, err := runGit(ctx, repoPath, DefaultCommandTimeout, nil, nil, "check-ignore", "--", relativePath)
 	if err != nil {
 		return false, fmt.Errorf("failed to execute git check-ignore: %w", err)
 	}
@@ -660,8 +1108,8 @@ type gitResult struct {
 	exitCode int
 }
 
-// runGit runs git in repoPath with a sanitized environment and the standard
-// timeout, returning the command's stdout, stderr, and process exit code. All
+// runGit runs git in repoPath with a sanitized environment, killing it after
+// timeout, and returns the command's stdout, stderr, and process exit code. All
 // GIT_* variables are stripped so the command operates on repoPath rather than
 // being redirected by an inherited GIT_DIR/GIT_INDEX_FILE/GIT_AUTHOR_* (which
 // happens when lgtmcp is exercised from inside a git pre-commit hook). A
@@ -670,9 +1118,9 @@ type gitResult struct {
 // on deadline, the underlying exec error when git cannot be started, or the
 // signal error when git is killed instead of exiting (exitCode -1).
 func runGit(
-	ctx context.Context, repoPath string, stdin io.Reader, extraEnv []string, args ...string,
+	ctx context.Context, repoPath string, timeout time.Duration, stdin io.Reader, extraEnv []string, args ...string,
 ) (gitResult, error) {
-	ctx, cancel := context.WithTimeout(ctx, gitCommandTimeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, "git", args...) //nolint:gosec // args are constructed internally, not from user input
@@ -20,8 +20,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -34,7 +36,7 @@ func TestWriteNewFileDiffEmptyContent(t *testing.T) {
 	// Callers skip empty content, but in isolation an empty file must render
 	// like git: header lines only, with no hunk or no-newline marker.
 	var buf bytes.Buffer
-	writeNewFileDiff(&buf, "empty.txt", "", 0o644)
+	writeNewFileDiff(&buf, "empty.txt", "", 0o644, false)
 	out := buf.String()
 	assert.Contains(t, out, "diff --git a/empty.txt b/empty.txt")
 	assert.Contains(t, out, "new file mode 100644")
@@ -48,7 +50,7 @@ func TestWriteNewFileDiffMode(t *testing.T) {
 	t.Run("executable regular file uses 100755", func(t *testing.T) {
 		t.Parallel()
 		var buf bytes.Buffer
-		writeNewFileDiff(&buf, "run.sh", "#!/bin/sh\n", 0o755)
+		writeNewFileDiff(&buf, "run.sh", "#!/bin/sh\n", 0o755, false)
 		out := buf.String()
 		assert.Contains(t, out, "new file mode 100755")
 		assert.NotContains(t, out, "100644")
@@ -57,7 +59,7 @@ func TestWriteNewFileDiffMode(t *testing.T) {
 	t.Run("non-executable regular file uses 100644", func(t *testing.T) {
 		t.Parallel()
 		var buf bytes.Buffer
-		writeNewFileDiff(&buf, "notes.txt", "hello\n", 0o644)
+		writeNewFileDiff(&buf, "notes.txt", "hello\n", 0o644, false)
 		out := buf.String()
 		assert.Contains(t, out, "new file mode 100644")
 		assert.NotContains(t, out, "100755")
@@ -69,7 +71,7 @@ func TestWriteNewFileDiffMode(t *testing.T) {
 		// A symlink's mode carries ModeSymlink plus exec perm bits; the 120000
 		// branch must win over the executable-bit branch, and the target string
 		// (no trailing newline) is the content.
-		writeNewFileDiff(&buf, "link", "target.txt", os.ModeSymlink|0o777)
+		writeNewFileDiff(&buf, "link", "target.txt", os.ModeSymlink|0o777, false)
 		out := buf.String()
 		assert.Contains(t, out, "new file mode 120000")
 		assert.Contains(t, out, "+target.txt")
@@ -81,7 +83,7 @@ func TestWriteNewFileDiffMode(t *testing.T) {
 		var buf bytes.Buffer
 		// The mode line is written before the empty-content early return, so an
 		// empty executable still records 100755 — with no hunk.
-		writeNewFileDiff(&buf, "empty.sh", "", 0o755)
+		writeNewFileDiff(&buf, "empty.sh", "", 0o755, false)
 		out := buf.String()
 		assert.Contains(t, out, "new file mode 100755")
 		assert.NotContains(t, out, "@@")
@@ -92,7 +94,7 @@ func TestWriteNewFileDiffSingleLineHunkHeader(t *testing.T) {
 	t.Parallel()
 	// git omits the ",1" count for a single-line hunk: "@@ -0,0 +1 @@".
 	var buf bytes.Buffer
-	writeNewFileDiff(&buf, "one.txt", "only line\n", 0o644)
+	writeNewFileDiff(&buf, "one.txt", "only line\n", 0o644, false)
 	out := buf.String()
 	assert.Contains(t, out, "@@ -0,0 +1 @@\n")
 	assert.NotContains(t, out, "+1,1")
@@ -103,7 +105,7 @@ func TestWriteNewFileDiffBinaryContent(t *testing.T) {
 	t.Run("binary content yields a Binary files marker", func(t *testing.T) {
 		t.Parallel()
 		var buf bytes.Buffer
-		writeNewFileDiff(&buf, "blob.bin", "\x00\x01binary", 0o644)
+		writeNewFileDiff(&buf, "blob.bin", "\x00\x01binary", 0o644, true)
 		out := buf.String()
 		assert.Contains(t, out, "diff --git a/blob.bin b/blob.bin\n")
 		assert.Contains(t, out, "new file mode 100644\n")
@@ -113,18 +115,16 @@ func TestWriteNewFileDiffBinaryContent(t *testing.T) {
 		assert.NotContains(t, out, "\x00")
 	})
 
-	t.Run("NUL past the detection limit stays text, like git", func(t *testing.T) {
+	t.Run("only a NUL within the detection limit means binary, like git", func(t *testing.T) {
 		t.Parallel()
-		var buf bytes.Buffer
-		content := strings.Repeat("a", binaryDetectionLimit) + "\x00\n"
-		writeNewFileDiff(&buf, "late-nul.txt", content, 0o644)
-		assert.NotContains(t, buf.String(), "Binary files")
+		assert.True(t, detectBinary("text\x00"))
+		assert.False(t, detectBinary(strings.Repeat("a", binaryDetectionLimit)+"\x00\n"))
 	})
 
 	t.Run("binary marker path is quoted when needed", func(t *testing.T) {
 		t.Parallel()
 		var buf bytes.Buffer
-		writeNewFileDiff(&buf, `bin"q.dat`, "\x00bin", 0o644)
+		writeNewFileDiff(&buf, `bin"q.dat`, "\x00bin", 0o644, true)
 		assert.Contains(t, buf.String(), "Binary files /dev/null and \"b/bin\\\"q.dat\" differ\n")
 	})
 }
@@ -135,7 +135,7 @@ func TestWriteNewFileDiffQuotedPath(t *testing.T) {
 	// exactly as git renders them, so a hostile filename cannot corrupt or
 	// spoof header lines.
 	var buf bytes.Buffer
-	writeNewFileDiff(&buf, "new\nline.txt", "x\n", 0o644)
+	writeNewFileDiff(&buf, "new\nline.txt", "x\n", 0o644, false)
 	out := buf.String()
 	assert.Contains(t, out, "diff --git \"a/new\\nline.txt\" \"b/new\\nline.txt\"\n")
 	assert.Contains(t, out, "+++ \"b/new\\nline.txt\"\n")
@@ -147,7 +147,7 @@ func TestWriteNewFileDiffSpaceNameTrailingTab(t *testing.T) {
 	t.Run("space in name appends git's trailing tab on +++", func(t *testing.T) {
 		t.Parallel()
 		var buf bytes.Buffer
-		writeNewFileDiff(&buf, "sp ace.txt", "x\n", 0o644)
+		writeNewFileDiff(&buf, "sp ace.txt", "x\n", 0o644, false)
 		out := buf.String()
 		assert.Contains(t, out, "+++ b/sp ace.txt\t\n")
 		// The tab applies only to the ---/+++ lines, not the diff --git header.
@@ -157,14 +157,14 @@ func TestWriteNewFileDiffSpaceNameTrailingTab(t *testing.T) {
 	t.Run("space inside a C-quoted form also gets the tab", func(t *testing.T) {
 		t.Parallel()
 		var buf bytes.Buffer
-		writeNewFileDiff(&buf, `sp "ace.txt`, "x\n", 0o644)
+		writeNewFileDiff(&buf, `sp "ace.txt`, "x\n", 0o644, false)
 		assert.Contains(t, buf.String(), "+++ \"b/sp \\\"ace.txt\"\t\n")
 	})
 
 	t.Run("escaped tab in name has no literal space, so no trailing tab", func(t *testing.T) {
 		t.Parallel()
 		var buf bytes.Buffer
-		writeNewFileDiff(&buf, "ta\tb.txt", "x\n", 0o644)
+		writeNewFileDiff(&buf, "ta\tb.txt", "x\n", 0o644, false)
 		assert.Contains(t, buf.String(), "+++ \"b/ta\\tb.txt\"\n")
 		assert.NotContains(t, buf.String(), "\t\n")
 	})
@@ -246,6 +246,19 @@ func TestNew(t *testing.T) {
 		assert.NotNil(t, g)
 		assert.Equal(t, worktreeDir, g.repoPath)
 	})
+
+	t.Run("submodule", func(t *testing.T) {
+		t.Parallel()
+		subDir := createTempSubmodule(t)
+		g, err := New(subDir, nil)
+		require.NoError(t, err)
+
+		testutil.CreateFile(t, subDir, "lib.go", "package lib\n\nvar X = 1\n")
+		diff, err := g.GetDiff(t.Context())
+		require.NoError(t, err)
+		assert.Contains(t, diff, "diff --git a/lib.go b/lib.go")
+		assert.Contains(t, diff, "+var X = 1")
+	})
 }
 
 func TestGetDiff(t *testing.T) { //nolint:maintidx // many subtests in one test function
@@ -557,6 +570,32 @@ func TestGetDiff(t *testing.T) { //nolint:maintidx // many subtests in one test
 		assert.NotContains(t, diff, "\x00")
 	})
 
+	t.Run("listed extensions override binary detection", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := testutil.CreateTempGitRepo(t)
+
+		testutil.CreateFile(t, tmpDir, "existing.txt", "existing")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+
+		testutil.CreateFile(t, tmpDir, "fixture.RAW", "header\x00payload\n")
+		testutil.CreateFile(t, tmpDir, "dump.txt", "rows of data\n")
+		testutil.CreateFile(t, tmpDir, "notes.md", "notes\n")
+
+		g, err := New(tmpDir, &config.GitConfig{
+			TextExtensions:   []string{"raw"},
+			BinaryExtensions: []string{".TXT"},
+		})
+		require.NoError(t, err)
+
+		diff, err := g.GetDiff(t.Context())
+		require.NoError(t, err)
+		assert.Contains(t, diff, "+header\x00payload")
+		assert.Contains(t, diff, "Binary files /dev/null and b/dump.txt differ")
+		assert.NotContains(t, diff, "rows of data")
+		assert.Contains(t, diff, "+notes", "unlisted extensions fall back to detection")
+	})
+
 	t.Run("single-line untracked file omits the hunk count like git", func(t *testing.T) {
 		t.Parallel()
 		tmpDir := testutil.CreateTempGitRepo(t)
@@ -628,6 +667,36 @@ func TestGetDiff(t *testing.T) { //nolint:maintidx // many subtests in one test
 		assert.NotContains(t, diff, "w/main.go")
 	})
 
+	t.Run("renamed and edited file diffs as a rename with hunks", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := testutil.CreateTempGitRepo(t)
+
+		original := "package main\n\nfunc one() int { return 1 }\n\nfunc two() int { return 2 }\n\n" +
+			"func three() int { return 3 }\n"
+		testutil.CreateFile(t, tmpDir, "old.go", original)
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+		// With diff.renames=false git would report the rename as a full
+		// deletion plus a full addition, hiding the edit among unchanged
+		// lines. GetDiff must detect the rename regardless.
+		testutil.RunGitCmd(t, tmpDir, "config", "diff.renames", "false")
+
+		testutil.RunGitCmd(t, tmpDir, "mv", "old.go", "new.go")
+		testutil.CreateFile(t, tmpDir, "new.go", original+"\nfunc four() int { return 4 }\n")
+
+		g, err := New(tmpDir, nil)
+		require.NoError(t, err)
+
+		diff, err := g.GetDiff(t.Context())
+		require.NoError(t, err)
+		assert.Contains(t, diff, "diff --git a/old.go b/new.go")
+		assert.Contains(t, diff, "rename from old.go")
+		assert.Contains(t, diff, "rename to new.go")
+		assert.Contains(t, diff, "+func four() int { return 4 }")
+		assert.NotContains(t, diff, "deleted file mode")
+		assert.NotContains(t, diff, "-func one() int { return 1 }")
+	})
+
 	t.Run("mixed changes - staged and unstaged", func(t *testing.T) {
 		t.Parallel()
 		tmpDir := testutil.CreateTempGitRepo(t)
@@ -1072,6 +1141,51 @@ func TestCommit(t *testing.T) {
 	})
 }
 
+func TestWriteFile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("creates parent directories", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := testutil.CreateTempGitRepo(t)
+		g, err := New(tmpDir, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, g.WriteFile(".lgtmcp/last-review.md", []byte("report\n")))
+		content, err := os.ReadFile(filepath.Join(tmpDir, ".lgtmcp", "last-review.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "report\n", string(content))
+
+		// Overwrites an existing file.
+		require.NoError(t, g.WriteFile(".lgtmcp/last-review.md", []byte("again\n")))
+		content, err = os.ReadFile(filepath.Join(tmpDir, ".lgtmcp", "last-review.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "again\n", string(content))
+	})
+
+	t.Run("rejects paths outside the repository", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := testutil.CreateTempGitRepo(t)
+		g, err := New(tmpDir, nil)
+		require.NoError(t, err)
+
+		for _, p := range []string{"../escape.md", "/tmp/abs.md", ".git/hooks/pre-commit", ""} {
+			require.ErrorIs(t, g.WriteFile(p, []byte("x")), ErrInvalidPath, p)
+		}
+	})
+
+	t.Run("does not follow a symlink out of the repository", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := testutil.CreateTempGitRepo(t)
+		outside := t.TempDir()
+		require.NoError(t, os.Symlink(outside, filepath.Join(tmpDir, "link")))
+		g, err := New(tmpDir, nil)
+		require.NoError(t, err)
+
+		require.Error(t, g.WriteFile("link/report.md", []byte("x")))
+		assert.NoFileExists(t, filepath.Join(outside, "report.md"))
+	})
+}
+
 func TestGetFileContent(t *testing.T) {
 	t.Parallel()
 	t.Run("read existing file", func(t *testing.T) {
@@ -1206,6 +1320,69 @@ func TestGetFileContent(t *testing.T) {
 	})
 }
 
+func TestTrackedFiles(t *testing.T) {
+	t.Parallel()
+	tmpDir := testutil.CreateTempGitRepo(t)
+	g, err := New(tmpDir, nil)
+	require.NoError(t, err)
+
+	testutil.CreateFile(t, tmpDir, "b.go", "package b\n")
+	testutil.CreateFile(t, tmpDir, "dir/a.sh", "#!/bin/sh\n")
+	require.NoError(t, os.Symlink("b.go", filepath.Join(tmpDir, "link.go")))
+	testutil.CreateFile(t, tmpDir, ".gitignore", "ignored.txt\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.CreateFile(t, tmpDir, "ignored.txt", "ignored\n")
+	testutil.CreateFile(t, tmpDir, "untracked.go", "package untracked\n")
+
+	files, err := g.TrackedFiles(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []string{".gitignore", "b.go", "dir/a.sh"}, files,
+		"symlinks, untracked and ignored files are left out")
+}
+
+func TestUntrackedFiles(t *testing.T) {
+	t.Parallel()
+	tmpDir := testutil.CreateTempGitRepo(t)
+	g, err := New(tmpDir, nil)
+	require.NoError(t, err)
+
+	testutil.CreateFile(t, tmpDir, "tracked.go", "package a\n")
+	testutil.CreateFile(t, tmpDir, ".gitignore", "*.log\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.CreateFile(t, tmpDir, "tracked.go", "package a\n\nvar A = 1\n")
+	testutil.CreateFile(t, tmpDir, "new file.go", "package a\n")
+	testutil.CreateFile(t, tmpDir, "dir/new.go", "package dir\n")
+	testutil.CreateFile(t, tmpDir, "debug.log", "ignored\n")
+
+	files, err := g.UntrackedFiles(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dir/new.go", "new file.go"}, files)
+}
+
+func TestGetDiffStat(t *testing.T) {
+	t.Parallel()
+	tmpDir := testutil.CreateTempGitRepo(t)
+	g, err := New(tmpDir, nil)
+	require.NoError(t, err)
+
+	testutil.CreateFile(t, tmpDir, "tracked.go", "package a\n\nvar A = 1\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+	testutil.CreateFile(t, tmpDir, "tracked.go", "package a\n\nvar A = 2\n")
+	testutil.CreateFile(t, tmpDir, "new.go", "package a\n\nvar B = 1\n")
+
+	diff, err := g.GetDiff(t.Context())
+	require.NoError(t, err)
+	stat, err := g.GetDiffStat(t.Context(), diff)
+	require.NoError(t, err)
+	assert.Equal(t, " tracked.go |    2 +-\n"+
+		" new.go     |    3 +++\n"+
+		" 2 files changed, 4 insertions(+), 1 deletion(-)", stat)
+
+	_, err = g.GetDiffStat(t.Context(), "diff --git a/x b/x\n@@ garbage\n")
+	require.Error(t, err)
+}
+
 func TestCheckGitRepo(t *testing.T) {
 	t.Parallel()
 	t.Run("valid git repo", func(t *testing.T) {
@@ -1238,6 +1415,18 @@ func TestCheckGitRepo(t *testing.T) {
 		worktreeDir := createTempWorktree(t)
 		assert.True(t, CheckGitRepo(worktreeDir))
 	})
+
+	t.Run("worktree-style .git file", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := t.TempDir()
+		testutil.CreateFile(t, tmpDir, ".git", "gitdir: /srv/repo/.git/worktrees/ci\n")
+		assert.True(t, CheckGitRepo(tmpDir))
+	})
+
+	t.Run("valid git submodule", func(t *testing.T) {
+		t.Parallel()
+		assert.True(t, CheckGitRepo(createTempSubmodule(t)))
+	})
 }
 
 func TestRunGitCommand(t *testing.T) {
@@ -1257,6 +1446,29 @@ func TestRunGitCommand(t *testing.T) {
 		assert.Contains(t, err.Error(), "timed out")
 	})
 
+	t.Run("configured command timeout", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := testutil.CreateTempGitRepo(t)
+
+		g, err := New(tmpDir, &config.GitConfig{CommandTimeout: "1ns"})
+		require.NoError(t, err)
+		assert.Equal(t, time.Nanosecond, g.commandTimeout)
+
+		_, err = g.GetDiff(t.Context())
+		require.ErrorIs(t, err, ErrCommandTimeout)
+	})
+
+	t.Run("unset or invalid command timeout uses the default", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := testutil.CreateTempGitRepo(t)
+
+		for _, timeout := range []string{"", "soon", "-1s"} {
+			g, err := New(tmpDir, &config.GitConfig{CommandTimeout: timeout})
+			require.NoError(t, err)
+			assert.Equal(t, DefaultCommandTimeout, g.commandTimeout, timeout)
+		}
+	})
+
 	t.Run("invalid command", func(t *testing.T) {
 		t.Parallel()
 		tmpDir := testutil.CreateTempGitRepo(t)
@@ -1334,6 +1546,130 @@ func TestGetFileContent_NotRegularFile(t *testing.T) {
 	require.ErrorIs(t, err, ErrNotRegularFile)
 }
 
+func TestGetFileContent_CanceledContext(t *testing.T) {
+	t.Parallel()
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "file.txt", "content")
+	g, err := New(tmpDir, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err = g.GetFileContent(ctx, "file.txt")
+	require.ErrorIs(t, err, context.Canceled)
+	_, err = g.GetDiff(ctx)
+	require.Error(t, err)
+}
+
+func TestGetFileContent_MaxFileReadBytes(t *testing.T) {
+	t.Parallel()
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "small.txt", "0123456789")
+	testutil.CreateFile(t, tmpDir, "large.txt", strings.Repeat("x", 11))
+	g, err := New(tmpDir, &config.GitConfig{MaxFileReadBytes: 10})
+	require.NoError(t, err)
+
+	content, err := g.GetFileContent(t.Context(), "small.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", content)
+	_, err = g.GetFileContent(t.Context(), "large.txt")
+	require.ErrorIs(t, err, ErrFileTooLarge)
+	assert.Contains(t, err.Error(), "large.txt")
+
+	_, err = g.GetDiff(t.Context())
+	require.ErrorIs(t, err, ErrFileTooLarge, "an untracked file too large to show fails the diff")
+	diff, err := g.GetDiff(t.Context(), "small.txt")
+	require.NoError(t, err)
+	assert.Contains(t, diff, "+0123456789")
+}
+
+func TestFileSize(t *testing.T) {
+	t.Parallel()
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "a.txt", "0123456789")
+	g, err := New(tmpDir, nil)
+	require.NoError(t, err)
+
+	size, err := g.FileSize("a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), size)
+	_, err = g.FileSize("missing.txt")
+	require.Error(t, err)
+	_, err = g.FileSize("../a.txt")
+	require.Error(t, err)
+}
+
+func TestGetDiff_ManyNewFilesInSortedOrder(t *testing.T) {
+	t.Parallel()
+	for _, withHead := range []bool{false, true} {
+		t.Run(fmt.Sprintf("withHead=%t", withHead), func(t *testing.T) {
+			t.Parallel()
+			tmpDir := testutil.CreateTempGitRepo(t)
+			if withHead {
+				testutil.CreateFile(t, tmpDir, "README", "readme\n")
+				testutil.RunGitCmd(t, tmpDir, "add", ".")
+				testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+			}
+			var want []string
+			for i := 3 * maxConcurrentFileReads; i > 0; i-- {
+				name := fmt.Sprintf("pkg%d/file%02d.go", i%3, i)
+				testutil.CreateFile(t, tmpDir, name, fmt.Sprintf("package p // %d\n", i))
+				want = append(want, "diff --git a/"+name+" b/"+name)
+			}
+			if !withHead {
+				// Staged files are synthesized with the untracked ones
+				// before the first commit.
+				testutil.RunGitCmd(t, tmpDir, "add", "pkg1")
+			}
+			slices.Sort(want)
+
+			g, err := New(tmpDir, nil)
+			require.NoError(t, err)
+			for range 3 {
+				diff, err := g.GetDiff(t.Context())
+				require.NoError(t, err)
+				var got []string
+				for line := range strings.Lines(diff) {
+					if strings.HasPrefix(line, "diff --git ") {
+						got = append(got, strings.TrimSuffix(line, "\n"))
+					}
+				}
+				assert.Equal(t, want, got)
+			}
+		})
+	}
+}
+
+func TestGetDiffIgnoringWhitespace(t *testing.T) {
+	t.Parallel()
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "format.go", "package p\nfunc f() {\nreturn\n}\n")
+	testutil.CreateFile(t, tmpDir, "logic.go", "package p\nconst x = 1\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+	testutil.CreateFile(t, tmpDir, "format.go", "package p\nfunc f() {\n\treturn\n}\n")
+	testutil.CreateFile(t, tmpDir, "logic.go", "package p\nconst x = 2\n")
+	testutil.CreateFile(t, tmpDir, "new.go", "package p\n")
+
+	g, err := New(tmpDir, nil)
+	require.NoError(t, err)
+
+	diff, err := g.GetDiffIgnoringWhitespace(t.Context())
+	require.NoError(t, err)
+	assert.NotContains(t, diff, "format.go", "whitespace-only changes are left out")
+	assert.Contains(t, diff, "+const x = 2")
+	assert.Contains(t, diff, "new file mode")
+
+	full, err := g.GetDiff(t.Context())
+	require.NoError(t, err)
+	assert.Contains(t, full, "+\treturn", "GetDiff still shows whitespace changes")
+
+	testutil.RunGitCmd(t, tmpDir, "checkout", "--", "logic.go")
+	require.NoError(t, os.Remove(filepath.Join(tmpDir, "new.go")))
+	_, err = g.GetDiffIgnoringWhitespace(t.Context())
+	require.ErrorIs(t, err, ErrNoChanges)
+}
+
 func TestHasGitdirPrefix_ShortFile(t *testing.T) {
 	t.Parallel()
 	tmpDir := t.TempDir()
@@ -1387,6 +1723,29 @@ func createTempWorktree(t *testing.T) string {
 	return worktreeDir
 }
 
+// createTempSubmodule returns the working directory of a submodule checked
+// out in a new superproject. Its .git is a file pointing into the
+// superproject's .git/modules.
+func createTempSubmodule(t *testing.T) string {
+	t.Helper()
+	libRepo := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, libRepo, "lib.go", "package lib\n")
+	testutil.RunGitCmd(t, libRepo, "add", ".")
+	testutil.RunGitCmd(t, libRepo, "commit", "-m", "initial")
+
+	superRepo := testutil.CreateTempGitRepo(t)
+	// Local-path submodules need the file transport, which git disables for
+	// submodules by default.
+	testutil.RunGitCmd(t, superRepo, "-c", "protocol.file.allow=always", "submodule", "add", libRepo, "lib")
+	subDir := filepath.Join(superRepo, "lib")
+
+	info, err := os.Stat(filepath.Join(subDir, ".git"))
+	require.NoError(t, err)
+	require.True(t, info.Mode().IsRegular(), "submodule .git should be a file")
+
+	return subDir
+}
+
 func TestIsIgnored(t *testing.T) {
 	t.Parallel()
 	repoDir := testutil.CreateTempGitRepo(t)
@@ -1492,3 +1851,246 @@ func TestIsIgnored_GitConfigGlobalLeak(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, ignored, "leaked GIT_CONFIG_GLOBAL must not influence the result")
 }
+
+func TestGetDiff_Paths(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) (string, *Git) {
+		t.Helper()
+		tmpDir := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, tmpDir, "a.go", "package a\n")
+		testutil.CreateFile(t, tmpDir, "b.go", "package b\n")
+		testutil.CreateFile(t, tmpDir, "sub/c.go", "package c\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+
+		testutil.CreateFile(t, tmpDir, "a.go", "package a\n\nvar A = 1\n")
+		testutil.CreateFile(t, tmpDir, "b.go", "package b\n\nvar B = 1\n")
+		testutil.CreateFile(t, tmpDir, "sub/c.go", "package c\n\nvar C = 1\n")
+		testutil.CreateFile(t, tmpDir, "sub/new.go", "package c\n")
+		testutil.CreateFile(t, tmpDir, "*.go", "package star\n")
+
+		g, err := New(tmpDir, nil)
+		require.NoError(t, err)
+
+		return tmpDir, g
+	}
+
+	t.Run("limits tracked and untracked files", func(t *testing.T) {
+		t.Parallel()
+		_, g := setup(t)
+
+		diff, err := g.GetDiff(t.Context(), "a.go", "sub")
+		require.NoError(t, err)
+		assert.Contains(t, diff, "diff --git a/a.go b/a.go")
+		assert.Contains(t, diff, "diff --git a/sub/c.go b/sub/c.go")
+		assert.Contains(t, diff, "diff --git a/sub/new.go b/sub/new.go")
+		assert.NotContains(t, diff, "b.go")
+		assert.NotContains(t, diff, "package star")
+	})
+
+	t.Run("paths are literal, not globs", func(t *testing.T) {
+		t.Parallel()
+		_, g := setup(t)
+
+		diff, err := g.GetDiff(t.Context(), "*.go")
+		require.NoError(t, err)
+		assert.Contains(t, diff, "package star")
+		assert.NotContains(t, diff, "a/a.go")
+		assert.NotContains(t, diff, "a/b.go")
+	})
+
+	t.Run("unchanged path has no changes", func(t *testing.T) {
+		t.Parallel()
+		tmpDir, g := setup(t)
+		testutil.CreateFile(t, tmpDir, "same.txt", "same\n")
+		testutil.RunGitCmd(t, tmpDir, "add", "same.txt")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "same")
+
+		_, err := g.GetDiff(t.Context(), "same.txt")
+		require.ErrorIs(t, err, ErrNoChanges)
+	})
+
+	t.Run("rejects paths outside the repository", func(t *testing.T) {
+		t.Parallel()
+		_, g := setup(t)
+
+		for _, p := range []string{"../outside.go", "/etc/passwd", ""} {
+			_, err := g.GetDiff(t.Context(), p)
+			require.Error(t, err, "path %q", p)
+		}
+	})
+
+	t.Run("initial commit", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, tmpDir, "keep.go", "package keep\n")
+		testutil.CreateFile(t, tmpDir, "skip.go", "package skip\n")
+		testutil.CreateFile(t, tmpDir, "staged.go", "package staged\n")
+		testutil.RunGitCmd(t, tmpDir, "add", "staged.go")
+		g, err := New(tmpDir, nil)
+		require.NoError(t, err)
+
+		diff, err := g.GetDiff(t.Context(), "keep.go", "staged.go")
+		require.NoError(t, err)
+		assert.Contains(t, diff, "package keep")
+		assert.Contains(t, diff, "package staged")
+		assert.NotContains(t, diff, "package skip")
+	})
+}
+
+func TestCommit_Paths(t *testing.T) {
+	t.Parallel()
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "base.txt", "base\n")
+	testutil.RunGitCmd(t, tmpDir, "add", "base.txt")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+
+	testutil.CreateFile(t, tmpDir, "reviewed.txt", "reviewed\n")
+	testutil.CreateFile(t, tmpDir, "other.txt", "other\n")
+	testutil.RunGitCmd(t, tmpDir, "add", "reviewed.txt", "other.txt")
+
+	g, err := New(tmpDir, nil)
+	require.NoError(t, err)
+
+	_, err = g.Commit(t.Context(), "partial", "reviewed.txt")
+	require.NoError(t, err)
+
+	committed := testutil.RunGitCmd(t, tmpDir, "show", "--name-only", "--format=", "HEAD")
+	assert.Equal(t, "reviewed.txt", strings.TrimSpace(committed))
+	// The other staged file stays staged but uncommitted.
+	staged := testutil.RunGitCmd(t, tmpDir, "diff", "--cached", "--name-only")
+	assert.Equal(t, "other.txt", strings.TrimSpace(staged))
+}
+
+// TestWriteNewFileDiffMatchesGit compares the synthesized new-file block with
+// git's own rendering of the same file (via an intent-to-add entry), so the
+// trailing-newline handling cannot drift from git's.
+func TestWriteNewFileDiffMatchesGit(t *testing.T) {
+	t.Parallel()
+	for name, content := range map[string]string{
+		"trailing newline":     "alpha\nbeta\n",
+		"no trailing newline":  "alpha\nbeta",
+		"single line":          "alpha",
+		"blank final line":     "alpha\n\n",
+		"only a newline":       "\n",
+		"crlf":                 "alpha\r\nbeta\r\n",
+		"crlf no newline":      "alpha\r\nbeta",
+		"mixed endings":        "alpha\r\nbeta\ngamma\r\n",
+		"lone carriage return": "alpha\rbeta\n",
+		"trailing blank lines": "alpha\n\n\n",
+		"interior blank lines": "alpha\n\n\nbeta",
+		"only newlines":        "\n\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			tmpDir := testutil.CreateTempGitRepo(t)
+			testutil.CreateFile(t, tmpDir, "file.txt", content)
+			testutil.RunGitCmd(t, tmpDir, "add", "--intent-to-add", "file.txt")
+			gitOut := testutil.RunGitCmd(t, tmpDir, "diff", "--no-color", "--", "file.txt")
+
+			var buf bytes.Buffer
+			writeNewFileDiff(&buf, "file.txt", content, 0o644, false)
+
+			// Compare from the "---" line on; git's header adds an index line
+			// that the synthesized block has no blob to fill in. RunGitCmd
+			// trims the output, so trim ours to match.
+			_, want, ok := strings.Cut(gitOut, "--- /dev/null\n")
+			require.True(t, ok, "unexpected git output:\n%s", gitOut)
+			_, got, ok := strings.Cut(buf.String(), "--- /dev/null\n")
+			require.True(t, ok, "unexpected synthesized output:\n%s", buf.String())
+			assert.Equal(t, want, strings.TrimSpace(got))
+		})
+	}
+}
+
+// TestGetDiff_DeletionShowsRemovedContent pins the behavior the review
+// prompts rely on when they tell the model that the diff shows the full
+// removed content of each deleted file.
+func TestGetDiff_DeletionShowsRemovedContent(t *testing.T) {
+	t.Parallel()
+	tmpDir := testutil.CreateTempGitRepo(t)
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	testutil.CreateFile(t, tmpDir, "gone.txt", strings.Join(lines, "\n")+"\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+	require.NoError(t, os.Remove(filepath.Join(tmpDir, "gone.txt")))
+
+	zero := 0
+	g, err := New(tmpDir, &config.GitConfig{DiffContextLines: &zero})
+	require.NoError(t, err)
+	diff, err := g.GetDiff(t.Context())
+	require.NoError(t, err)
+	assert.Contains(t, diff, "deleted file mode")
+	for _, line := range lines {
+		assert.Contains(t, diff, "\n-"+line+"\n")
+	}
+}
+
+// TestGetDiff_UntrackedTrailingNewline checks that untracked files reach the
+// diff with their trailing-newline state intact: no extra empty line when the
+// file ends with a newline, and git's marker when it does not.
+func TestGetDiff_UntrackedTrailingNewline(t *testing.T) {
+	t.Parallel()
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "README", "readme\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+	testutil.CreateFile(t, tmpDir, "with.txt", "alpha\nbeta\n")
+	testutil.CreateFile(t, tmpDir, "without.txt", "alpha\nbeta")
+
+	g, err := New(tmpDir, nil)
+	require.NoError(t, err)
+
+	diff, err := g.GetDiff(t.Context(), "with.txt")
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(diff, "@@ -0,0 +1,2 @@\n+alpha\n+beta\n"), diff)
+
+	diff, err = g.GetDiff(t.Context(), "without.txt")
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(diff, "@@ -0,0 +1,2 @@\n+alpha\n+beta\n\\ No newline at end of file\n"), diff)
+}
+
+func TestCommitAmend(t *testing.T) {
+	t.Parallel()
+	t.Run("folds changes into the previous commit", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, tmpDir, "base.txt", "base\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+		testutil.CreateFile(t, tmpDir, "feature.txt", "v1\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "Add feature")
+		parent := testutil.RunGitCmd(t, tmpDir, "rev-parse", "HEAD~1")
+		before := testutil.RunGitCmd(t, tmpDir, "rev-parse", "HEAD")
+
+		testutil.CreateFile(t, tmpDir, "feature.txt", "v2\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		g, err := New(tmpDir, nil)
+		require.NoError(t, err)
+
+		sha, err := g.CommitAmend(t.Context(), "Add feature, v2")
+		require.NoError(t, err)
+		assert.NotEqual(t, before, sha)
+		assert.Equal(t, sha, testutil.RunGitCmd(t, tmpDir, "rev-parse", "HEAD"))
+		assert.Equal(t, parent, testutil.RunGitCmd(t, tmpDir, "rev-parse", "HEAD~1"))
+		assert.Equal(t, "Add feature, v2", testutil.RunGitCmd(t, tmpDir, "log", "-1", "--format=%s"))
+		assert.Equal(t, "v2", testutil.RunGitCmd(t, tmpDir, "show", "HEAD:feature.txt"))
+	})
+
+	t.Run("no previous commit", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, tmpDir, "file.txt", "content")
+		testutil.RunGitCmd(t, tmpDir, "add", "file.txt")
+		g, err := New(tmpDir, nil)
+		require.NoError(t, err)
+
+		_, err = g.CommitAmend(t.Context(), "amend")
+		require.ErrorIs(t, err, ErrNoCommitToAmend)
+	})
+}
@@ -17,6 +17,7 @@
 package git
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"syscall"
@@ -24,6 +25,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"msrl.dev/lgtmcp/internal/config"
 	"msrl.dev/lgtmcp/internal/testutil"
 )
 
@@ -178,7 +180,7 @@ func TestRunGit_SignalKilledSurfacesSignal(t *testing.T) {
 	require.NoError(t, os.WriteFile(fakeGit, []byte("#!/bin/sh\nkill -SEGV $$\n"), 0o755))
 	t.Setenv("PATH", binDir)
 
-	res, err := runGit(t.Context(), t.TempDir(), nil, nil, "status")
+	res, err := runGit(t.Context(), t.TempDir(), DefaultCommandTimeout, nil, nil, "status")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "signal")
 	assert.Equal(t, -1, res.exitCode)
@@ -234,3 +236,30 @@ func TestGetFileContent_SymlinkToSiblingDirectory(t *testing.T) {
 	require.ErrorIs(t, err, ErrPathOutsideRepo)
 	assert.Empty(t, content)
 }
+
+func TestCommit_NoVerify(t *testing.T) {
+	t.Parallel()
+	for _, noVerify := range []bool{false, true} {
+		t.Run(fmt.Sprintf("no_verify=%t", noVerify), func(t *testing.T) {
+			t.Parallel()
+			tmpDir := testutil.CreateTempGitRepo(t)
+			hook := filepath.Join(tmpDir, ".git", "hooks", "pre-commit")
+			require.NoError(t, os.MkdirAll(filepath.Dir(hook), 0o750))
+			//nolint:gosec // The hook must be executable.
+			require.NoError(t, os.WriteFile(hook, []byte("#!/bin/sh\necho 'hook rejected' >&2\nexit 1\n"), 0o755))
+
+			testutil.CreateFile(t, tmpDir, "file.txt", "content")
+			testutil.RunGitCmd(t, tmpDir, "add", "file.txt")
+			g, err := New(tmpDir, &config.GitConfig{NoVerify: noVerify})
+			require.NoError(t, err)
+
+			_, err = g.Commit(t.Context(), "test commit")
+			if noVerify {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "hook rejected")
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// maxRecentChangesSize bounds the recent-changes log RecentChanges returns
+// (32KB), so a few large commits cannot crowd the diff under review out of
+// the prompt.
+const maxRecentChangesSize = 32 * 1024
+
+// RecentChanges returns the patches of the last n commits that touched any
+// of paths, newest first, limited to those paths and truncated to
+// maxRecentChangesSize. It returns "" when n is not positive, no paths are
+// given, or the repository has no commits yet.
+func (g *Git) RecentChanges(ctx context.Context, paths []string, n int) (string, error) {
+	if n <= 0 || len(paths) == 0 {
+		return "", nil
+	}
+	pathspecs, err := g.literalPathspecs(paths)
+	if err != nil {
+		return "", err
+	}
+
+	hasHead, err := g.HasCommits(ctx)
+	if err != nil || !hasHead {
+		// No commits yet, so no history to show.
+		return "", err
+	}
+
+	// Pin the patch format as GetDiff does, so user git config cannot
+	// change prefixes, add color, or substitute an external diff tool.
+	args := []string{
+		"-c", "core.quotePath=true", "log", fmt.Sprintf("--max-count=%d", n), "--no-merges",
+		"--patch", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/",
+		"--date=short", "--format=commit %h (%ad)%n%n    %s%n",
+		"HEAD", "--",
+	}
+	log, err := g.runGitCommand(ctx, append(args, pathspecs...)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get recent changes: %w", err)
+	}
+
+	return truncateAtLine(log, maxRecentChangesSize), nil
+}
+
+// RecentCommitSubjects returns the subjects of the last n commits on HEAD,
+// newest first. It returns nil when n is not positive or the repository has
+// no commits yet.
+func (g *Git) RecentCommitSubjects(ctx context.Context, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	hasHead, err := g.HasCommits(ctx)
+	if err != nil || !hasHead {
+		return nil, err
+	}
+
+	out, err := g.runGitCommand(ctx, "log", fmt.Sprintf("--max-count=%d", n), "--format=%s", "HEAD", "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent commits: %w", err)
+	}
+	out = strings.TrimRight(out, "\n")
+	if out == "" {
+		return nil, nil
+	}
+
+	return strings.Split(out, "\n"), nil
+}
+
+// truncateAtLine shortens s to at most limit bytes, cutting at the last line
+// break that fits and noting the omission.
+func truncateAtLine(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	cut := s[:limit]
+	if i := strings.LastIndexByte(cut, '\n'); i != -1 {
+		cut = cut[:i+1]
+	}
+
+	return cut + fmt.Sprintf("[... %d bytes of older changes omitted]\n", len(s)-len(cut))
+}
+
+// FormatRecentChanges formats a recent-changes log into a prompt section.
+// Commit messages and patches are repository content, so they are fenced
+// like instruction files. Returns an empty string for an empty log.
+func FormatRecentChanges(log string) string {
+	if strings.TrimSpace(log) == "" {
+		return ""
+	}
+
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb,
+		"## Recent Related Changes\n\n"+
+			"These are the most recent commits that touched the files in this change, newest first, "+
+			"limited to those files. They are already committed and are not under review; use them "+
+			"to understand recent work and to spot regressions, such as a change reintroducing a "+
+			"bug a recent commit fixed.\n\n%s\n\n", untrustedContentWarning)
+	_, _ = fmt.Fprintf(&sb, "<untrusted_user_content>\n%s\n</untrusted_user_content>\n\n",
+		escapeUntrustedFence(strings.TrimSpace(log)))
+
+	return sb.String()
+}
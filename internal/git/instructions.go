@@ -22,43 +22,55 @@ import (
 	"strings"
 )
 
-// InstructionFile represents an AGENTS.md or REVIEW.md file found in the repository.
+// InstructionFile represents an agent instruction file (such as AGENTS.md) or
+// a REVIEW.md file found in the repository.
 type InstructionFile struct {
-	Path    string // Relative path from repo root (e.g., "AGENTS.md" or "src/AGENTS.md")
+	Path    string // Relative path from repo root (e.g., "AGENTS.md" or "src/CLAUDE.md")
 	Content string
 }
 
-// maxInstructionFileSize is the maximum size of an instruction file (50KB).
-const maxInstructionFileSize = 50 * 1024
+// SkippedFile is an instruction file that was found but left out of the
+// review, with the reason why.
+type SkippedFile struct {
+	Path   string // Relative path from repo root
+	Reason string
+}
 
-// FindAgentFiles discovers AGENTS.md files relevant to the changed files.
-// For each changed file, it walks from the file's directory up to the repo root,
-// collecting unique AGENTS.md files. Results are sorted root-first (fewest path
-// separators), then alphabetically. Files larger than 50KB are skipped.
-func (g *Git) FindAgentFiles(changedFiles []string) ([]InstructionFile, error) {
-	return g.findFiles("AGENTS.md", changedFiles)
+// FindAgentFiles discovers agent instruction files relevant to the changed
+// files: those named by git.agent_filenames, AGENTS.md by default. For each
+// changed file, it walks from the file's directory up to the repo root,
+// collecting unique files with any of those names. Results are sorted
+// root-first (fewest path separators), then alphabetically. Files that are
+// larger than git.max_agent_file_bytes (50KB by default), resolve outside the
+// repository, are not regular files, or cannot be read are skipped and
+// returned as SkippedFiles, in the same order.
+func (g *Git) FindAgentFiles(changedFiles []string) ([]InstructionFile, []SkippedFile, error) {
+	return g.findFiles(g.agentFilenames, changedFiles)
 }
 
 // FindReviewFiles discovers REVIEW.md files relevant to the changed files.
 // Behaves identically to FindAgentFiles but searches for REVIEW.md.
-func (g *Git) FindReviewFiles(changedFiles []string) ([]InstructionFile, error) {
-	return g.findFiles("REVIEW.md", changedFiles)
+func (g *Git) FindReviewFiles(changedFiles []string) ([]InstructionFile, []SkippedFile, error) {
+	return g.findFiles([]string{"REVIEW.md"}, changedFiles)
 }
 
-// findFiles discovers files with the given filename relevant to the changed files.
-// For each changed file, it walks from the file's directory up to the repo root,
-// collecting unique matches. Results are sorted root-first (fewest path
-// separators), then alphabetically. Files larger than 50KB are skipped.
-func (g *Git) findFiles(filename string, changedFiles []string) ([]InstructionFile, error) {
-	if len(changedFiles) == 0 {
-		return nil, nil
+// findFiles discovers files with any of the given filenames relevant to the
+// changed files. For each changed file, it walks from the file's directory up
+// to the repo root, collecting unique matches. A file reachable under more
+// than one name, such as a CLAUDE.md symlinked to AGENTS.md, is collected
+// once, under the path that sorts first. Results are sorted root-first
+// (fewest path separators), then alphabetically. Files that cannot be used
+// are skipped and reported, with the reason, as SkippedFiles.
+func (g *Git) findFiles(filenames, changedFiles []string) ([]InstructionFile, []SkippedFile, error) {
+	if len(changedFiles) == 0 || len(filenames) == 0 {
+		return nil, nil, nil
 	}
 
 	// Resolve repo path once to handle system-level symlinks
 	// (e.g., macOS /var -> /private/var).
 	canonicalRepo, err := filepath.EvalSymlinks(g.repoPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve repo path: %w", err)
+		return nil, nil, fmt.Errorf("failed to resolve repo path: %w", err)
 	}
 
 	// Collect unique directories to check.
@@ -78,75 +90,57 @@ func (g *Git) findFiles(filename string, changedFiles []string) ([]InstructionFi
 		}
 	}
 
-	// Check each directory for the target file and collect unique files by path.
-	found := make(map[string]string) // path -> content
+	// Check each directory for the target files and collect unique files by path.
+	found := make(map[string]string)    // path -> content
+	byTarget := make(map[string]string) // resolved path -> path
+	var skipped []SkippedFile
 	for dir := range dirs {
-		relPath := filepath.Join(dir, filename)
-		fullPath := filepath.Join(g.repoPath, relPath)
-
-		if _, err := os.Lstat(fullPath); err != nil {
-			continue // File doesn't exist
-		}
-
-		// Resolve the full path to catch both file-level and
-		// directory-level symlinks that might escape the repo.
-		resolved, evalErr := filepath.EvalSymlinks(fullPath)
-		if evalErr != nil {
-			continue
-		}
-		if !strings.HasPrefix(resolved, canonicalRepo+string(filepath.Separator)) && resolved != canonicalRepo {
-			continue
-		}
-
-		// After resolution, verify it's a regular file (not a directory, etc.).
-		resolvedInfo, statErr := os.Stat(resolved)
-		if statErr != nil || !resolvedInfo.Mode().IsRegular() {
-			continue
-		}
-
-		if resolvedInfo.Size() > maxInstructionFileSize {
-			continue
-		}
-
-		content, err := os.ReadFile(resolved)
-		if err != nil {
-			continue // Skip unreadable files rather than failing entirely
+		for _, filename := range filenames {
+			relPath := filepath.Join(dir, filename)
+			content, resolved, skipReason, ok := g.readInstructionFile(canonicalRepo, relPath)
+			if !ok {
+				if skipReason != "" {
+					skipped = append(skipped, SkippedFile{Path: relPath, Reason: skipReason})
+				}
+				continue
+			}
+			if prev, dup := byTarget[resolved]; dup {
+				if compareInstructionPaths(prev, relPath) <= 0 {
+					continue
+				}
+				delete(found, prev)
+			}
+			byTarget[resolved] = relPath
+			found[relPath] = content
 		}
-
-		found[relPath] = string(content)
 	}
 
+	slices.SortFunc(skipped, func(a, b SkippedFile) int { return compareInstructionPaths(a.Path, b.Path) })
 	if len(found) == 0 {
-		return nil, nil
+		return nil, skipped, nil
 	}
 
-	// Sort by depth (fewest separators first), then alphabetically.
 	paths := make([]string, 0, len(found))
 	for p := range found {
 		paths = append(paths, p)
 	}
-	slices.SortFunc(paths, func(a, b string) int {
-		da := strings.Count(a, string(filepath.Separator))
-		db := strings.Count(b, string(filepath.Separator))
-		if da != db {
-			return da - db
-		}
-		return strings.Compare(a, b)
-	})
+	slices.SortFunc(paths, compareInstructionPaths)
 
 	result := make([]InstructionFile, len(paths))
 	for i, p := range paths {
 		result[i] = InstructionFile{Path: p, Content: found[p]}
 	}
-	return result, nil
+	return result, skipped, nil
 }
 
-// FormatAgentInstructions formats discovered AGENTS.md files into a prompt section.
+// FormatAgentInstructions formats discovered agent instruction files into a
+// prompt section.
 // Returns an empty string if no files are provided.
 func FormatAgentInstructions(files []InstructionFile) string {
 	return formatInstructions(files,
 		"Repository Agent Instructions",
-		"The following AGENTS.md files were found in the repository and contain project-specific review guidelines:")
+		"The following agent instruction files (such as AGENTS.md) were found in the repository "+
+			"and contain project-specific review guidelines:")
 }
 
 // FormatReviewInstructions formats discovered REVIEW.md files into a prompt section.
@@ -189,3 +183,58 @@ func formatInstructions(files []InstructionFile, heading, description string) st
 
 	return sb.String()
 }
+
+// readInstructionFile reads the instruction file at relPath, returning its
+// content and resolved path. ok is false when the file does not exist, is
+// not a regular file, resolves outside canonicalRepo, exceeds
+// g.maxAgentFileBytes, or cannot be read; skipReason then explains why, and
+// is empty only when the file does not exist.
+func (g *Git) readInstructionFile(canonicalRepo, relPath string) (content, resolved, skipReason string, ok bool) {
+	fullPath := filepath.Join(g.repoPath, relPath)
+	if _, err := os.Lstat(fullPath); err != nil {
+		return "", "", "", false // File doesn't exist
+	}
+
+	// Resolve the full path to catch both file-level and
+	// directory-level symlinks that might escape the repo.
+	resolved, err := filepath.EvalSymlinks(fullPath)
+	if err != nil {
+		return "", "", "broken symlink", false
+	}
+	if !strings.HasPrefix(resolved, canonicalRepo+string(filepath.Separator)) && resolved != canonicalRepo {
+		return "", "", "symlink resolves outside the repository", false
+	}
+
+	// After resolution, verify it's a regular file (not a directory, etc.).
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", "", fmt.Sprintf("cannot stat: %v", err), false
+	}
+	if !info.Mode().IsRegular() {
+		return "", "", "not a regular file", false
+	}
+
+	if info.Size() > g.maxAgentFileBytes {
+		return "", "", fmt.Sprintf("%d bytes exceeds git.max_agent_file_bytes (%d)", info.Size(), g.maxAgentFileBytes),
+			false
+	}
+
+	data, err := os.ReadFile(resolved)
+	if err != nil {
+		// Skip unreadable files rather than failing entirely.
+		return "", "", fmt.Sprintf("cannot read: %v", err), false
+	}
+
+	return string(data), resolved, "", true
+}
+
+// compareInstructionPaths orders instruction file paths by depth (fewest
+// separators first), then alphabetically.
+func compareInstructionPaths(a, b string) int {
+	da := strings.Count(a, string(filepath.Separator))
+	db := strings.Count(b, string(filepath.Separator))
+	if da != db {
+		return da - db
+	}
+	return strings.Compare(a, b)
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"msrl.dev/lgtmcp/internal/config"
 	"msrl.dev/lgtmcp/internal/testutil"
 )
 
@@ -37,9 +38,10 @@ func TestFindAgentFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindAgentFiles([]string{"main.go"})
+		files, skipped, err := g.FindAgentFiles([]string{"main.go"})
 		require.NoError(t, err)
 		assert.Nil(t, files)
+		assert.Nil(t, skipped, "absent files are not skips")
 	})
 
 	t.Run("root AGENTS.md only", func(t *testing.T) {
@@ -52,7 +54,7 @@ func TestFindAgentFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindAgentFiles([]string{"main.go"})
+		files, _, err := g.FindAgentFiles([]string{"main.go"})
 		require.NoError(t, err)
 		require.Len(t, files, 1)
 		assert.Equal(t, "AGENTS.md", files[0].Path)
@@ -69,7 +71,7 @@ func TestFindAgentFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindAgentFiles([]string{"src/main.go"})
+		files, _, err := g.FindAgentFiles([]string{"src/main.go"})
 		require.NoError(t, err)
 		require.Len(t, files, 1)
 		assert.Equal(t, filepath.Join("src", "AGENTS.md"), files[0].Path)
@@ -87,7 +89,7 @@ func TestFindAgentFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindAgentFiles([]string{"src/main.go"})
+		files, _, err := g.FindAgentFiles([]string{"src/main.go"})
 		require.NoError(t, err)
 		require.Len(t, files, 2)
 		// Root should come first (fewer separators).
@@ -108,7 +110,7 @@ func TestFindAgentFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindAgentFiles([]string{"src/a.go", "src/b.go"})
+		files, _, err := g.FindAgentFiles([]string{"src/a.go", "src/b.go"})
 		require.NoError(t, err)
 		require.Len(t, files, 1)
 		assert.Equal(t, "AGENTS.md", files[0].Path)
@@ -124,7 +126,7 @@ func TestFindAgentFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindAgentFiles([]string{"a/b/c/d/file.go"})
+		files, _, err := g.FindAgentFiles([]string{"a/b/c/d/file.go"})
 		require.NoError(t, err)
 		require.Len(t, files, 1)
 		assert.Equal(t, "AGENTS.md", files[0].Path)
@@ -140,7 +142,7 @@ func TestFindAgentFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindAgentFiles([]string{"main.go"})
+		files, _, err := g.FindAgentFiles([]string{"main.go"})
 		require.NoError(t, err)
 		require.Len(t, files, 1)
 		assert.Equal(t, "AGENTS.md", files[0].Path)
@@ -166,9 +168,26 @@ func TestFindAgentFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindAgentFiles([]string{"main.go"})
+		files, skipped, err := g.FindAgentFiles([]string{"main.go"})
 		require.NoError(t, err)
 		assert.Nil(t, files)
+		assert.Equal(t, []SkippedFile{{Path: "AGENTS.md", Reason: "symlink resolves outside the repository"}}, skipped)
+	})
+
+	t.Run("broken symlink is skipped", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := testutil.CreateTempGitRepo(t)
+
+		require.NoError(t, os.Symlink("missing.md", filepath.Join(tmpDir, "AGENTS.md")))
+		testutil.CreateFile(t, tmpDir, "main.go", "package main")
+
+		g, err := New(tmpDir, nil)
+		require.NoError(t, err)
+
+		files, skipped, err := g.FindAgentFiles([]string{"main.go"})
+		require.NoError(t, err)
+		assert.Nil(t, files)
+		assert.Equal(t, []SkippedFile{{Path: "AGENTS.md", Reason: "broken symlink"}}, skipped)
 	})
 
 	t.Run("directory named AGENTS.md is skipped", func(t *testing.T) {
@@ -182,9 +201,10 @@ func TestFindAgentFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindAgentFiles([]string{"main.go"})
+		files, skipped, err := g.FindAgentFiles([]string{"main.go"})
 		require.NoError(t, err)
 		assert.Nil(t, files)
+		assert.Equal(t, []SkippedFile{{Path: "AGENTS.md", Reason: "not a regular file"}}, skipped)
 	})
 
 	t.Run("empty changed files", func(t *testing.T) {
@@ -194,7 +214,7 @@ func TestFindAgentFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindAgentFiles([]string{})
+		files, _, err := g.FindAgentFiles([]string{})
 		require.NoError(t, err)
 		assert.Nil(t, files)
 	})
@@ -206,7 +226,7 @@ func TestFindAgentFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindAgentFiles(nil)
+		files, _, err := g.FindAgentFiles(nil)
 		require.NoError(t, err)
 		assert.Nil(t, files)
 	})
@@ -215,17 +235,28 @@ func TestFindAgentFiles(t *testing.T) {
 		t.Parallel()
 		tmpDir := testutil.CreateTempGitRepo(t)
 
-		// Create an AGENTS.md larger than maxInstructionFileSize (50KB).
-		largeContent := strings.Repeat("x", maxInstructionFileSize+1)
+		// Create an AGENTS.md larger than the default cap (50KB).
+		largeContent := strings.Repeat("x", int(config.DefaultMaxAgentFileBytes)+1)
 		testutil.CreateFile(t, tmpDir, "AGENTS.md", largeContent)
 		testutil.CreateFile(t, tmpDir, "main.go", "package main")
 
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindAgentFiles([]string{"main.go"})
+		files, skipped, err := g.FindAgentFiles([]string{"main.go"})
 		require.NoError(t, err)
 		assert.Nil(t, files)
+		require.Len(t, skipped, 1)
+		assert.Equal(t, "AGENTS.md", skipped[0].Path)
+		assert.Contains(t, skipped[0].Reason, "exceeds git.max_agent_file_bytes (51200)")
+
+		// A raised cap admits it.
+		g, err = New(tmpDir, &config.GitConfig{MaxAgentFileBytes: new(int64(100 * 1024))})
+		require.NoError(t, err)
+		files, skipped, err = g.FindAgentFiles([]string{"main.go"})
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		assert.Empty(t, skipped)
 	})
 
 	t.Run("depth sorting with multiple directories", func(t *testing.T) {
@@ -242,7 +273,7 @@ func TestFindAgentFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindAgentFiles([]string{"a/sub/file.go", "b/file.go"})
+		files, _, err := g.FindAgentFiles([]string{"a/sub/file.go", "b/file.go"})
 		require.NoError(t, err)
 		require.Len(t, files, 4)
 
@@ -252,6 +283,56 @@ func TestFindAgentFiles(t *testing.T) {
 		assert.Equal(t, filepath.Join("b", "AGENTS.md"), files[2].Path)
 		assert.Equal(t, filepath.Join("a", "sub", "AGENTS.md"), files[3].Path)
 	})
+
+	t.Run("configured filenames", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := testutil.CreateTempGitRepo(t)
+
+		testutil.CreateFile(t, tmpDir, "AGENTS.md", "Agents")
+		testutil.CreateFile(t, tmpDir, "CLAUDE.md", "Claude")
+		testutil.CreateFile(t, tmpDir, "src/.cursorrules", "Cursor")
+		testutil.CreateFile(t, tmpDir, "src/main.go", "package main")
+
+		g, err := New(tmpDir, &config.GitConfig{AgentFilenames: []string{"CLAUDE.md", ".cursorrules"}})
+		require.NoError(t, err)
+
+		files, _, err := g.FindAgentFiles([]string{"src/main.go"})
+		require.NoError(t, err)
+		require.Len(t, files, 2)
+		assert.Equal(t, InstructionFile{Path: "CLAUDE.md", Content: "Claude"}, files[0])
+		assert.Equal(t, InstructionFile{Path: filepath.Join("src", ".cursorrules"), Content: "Cursor"}, files[1])
+	})
+
+	t.Run("file under two names is collected once", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := testutil.CreateTempGitRepo(t)
+
+		testutil.CreateFile(t, tmpDir, "AGENTS.md", "Shared")
+		require.NoError(t, os.Symlink("AGENTS.md", filepath.Join(tmpDir, "CLAUDE.md")))
+		testutil.CreateFile(t, tmpDir, "main.go", "package main")
+
+		g, err := New(tmpDir, &config.GitConfig{AgentFilenames: []string{"CLAUDE.md", "AGENTS.md"}})
+		require.NoError(t, err)
+
+		files, _, err := g.FindAgentFiles([]string{"main.go"})
+		require.NoError(t, err)
+		assert.Equal(t, []InstructionFile{{Path: "AGENTS.md", Content: "Shared"}}, files)
+	})
+
+	t.Run("empty filename list disables discovery", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := testutil.CreateTempGitRepo(t)
+
+		testutil.CreateFile(t, tmpDir, "AGENTS.md", "Root instructions")
+		testutil.CreateFile(t, tmpDir, "main.go", "package main")
+
+		g, err := New(tmpDir, &config.GitConfig{AgentFilenames: []string{}})
+		require.NoError(t, err)
+
+		files, _, err := g.FindAgentFiles([]string{"main.go"})
+		require.NoError(t, err)
+		assert.Nil(t, files)
+	})
 }
 
 func TestFormatAgentInstructions(t *testing.T) {
@@ -345,7 +426,7 @@ func TestFindReviewFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindReviewFiles([]string{"main.go"})
+		files, _, err := g.FindReviewFiles([]string{"main.go"})
 		require.NoError(t, err)
 		assert.Nil(t, files)
 	})
@@ -360,7 +441,7 @@ func TestFindReviewFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindReviewFiles([]string{"main.go"})
+		files, _, err := g.FindReviewFiles([]string{"main.go"})
 		require.NoError(t, err)
 		require.Len(t, files, 1)
 		assert.Equal(t, "REVIEW.md", files[0].Path)
@@ -377,7 +458,7 @@ func TestFindReviewFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindReviewFiles([]string{"src/main.go"})
+		files, _, err := g.FindReviewFiles([]string{"src/main.go"})
 		require.NoError(t, err)
 		require.Len(t, files, 1)
 		assert.Equal(t, filepath.Join("src", "REVIEW.md"), files[0].Path)
@@ -395,12 +476,12 @@ func TestFindReviewFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		agentFiles, err := g.FindAgentFiles([]string{"main.go"})
+		agentFiles, _, err := g.FindAgentFiles([]string{"main.go"})
 		require.NoError(t, err)
 		require.Len(t, agentFiles, 1)
 		assert.Equal(t, "AGENTS.md", agentFiles[0].Path)
 
-		reviewFiles, err := g.FindReviewFiles([]string{"main.go"})
+		reviewFiles, _, err := g.FindReviewFiles([]string{"main.go"})
 		require.NoError(t, err)
 		require.Len(t, reviewFiles, 1)
 		assert.Equal(t, "REVIEW.md", reviewFiles[0].Path)
@@ -413,7 +494,7 @@ func TestFindReviewFiles(t *testing.T) {
 		g, err := New(tmpDir, nil)
 		require.NoError(t, err)
 
-		files, err := g.FindReviewFiles([]string{})
+		files, _, err := g.FindReviewFiles([]string{})
 		require.NoError(t, err)
 		assert.Nil(t, files)
 	})
@@ -0,0 +1,180 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ReviewIgnoreFile is the file at the repository root listing paths to leave
+// out of review, in gitignore syntax. Unlike .gitignore it does not affect
+// what is committed: excluded files are still scanned for secrets, staged,
+// and committed; only the diff sent to Gemini omits them.
+const ReviewIgnoreFile = ".lgtmcpignore"
+
+// ReviewIgnore matches repo-relative paths against the patterns of a
+// .lgtmcpignore file. The zero value, and a nil pointer, match nothing.
+type ReviewIgnore struct {
+	rules []ignoreRule
+}
+
+// ignoreRule is one parsed pattern line.
+type ignoreRule struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// ParseReviewIgnore parses .lgtmcpignore content. It follows gitignore
+// semantics: blank lines and lines starting with "#" are skipped, "!"
+// re-includes a path, a trailing "/" matches only directories, a pattern
+// containing a "/" other than a trailing one is anchored at the repository
+// root, and "**" matches any number of directories. Patterns that are not
+// valid globs are ignored, as git does.
+func ParseReviewIgnore(content string) *ReviewIgnore {
+	ignore := &ReviewIgnore{}
+	for line := range strings.Lines(content) {
+		line = strings.TrimRight(line, " \t\r\n")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+		line = strings.TrimPrefix(line, "/")
+		if line == "" || !validSegments(strings.Split(line, "/")) {
+			continue
+		}
+		rule.segments = strings.Split(line, "/")
+		ignore.rules = append(ignore.rules, rule)
+	}
+
+	return ignore
+}
+
+// LoadReviewIgnore reads ReviewIgnoreFile from the root of commit rev. Callers
+// pass the commit the change under review starts from, never the working
+// tree, so that a change cannot exclude its own files by editing the file. A
+// missing file, or a repository with no commits yet, yields an empty matcher.
+func (g *Git) LoadReviewIgnore(ctx context.Context, rev string) (*ReviewIgnore, error) {
+	hasHead, err := g.HasCommits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !hasHead {
+		return &ReviewIgnore{}, nil
+	}
+	listed, err := g.runGitCommand(ctx, "ls-tree", "--name-only", rev, "--", ReviewIgnoreFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s at %s: %w", ReviewIgnoreFile, rev, err)
+	}
+	if strings.TrimSpace(listed) == "" {
+		return &ReviewIgnore{}, nil
+	}
+	content, err := g.FileContentAt(ctx, rev, ReviewIgnoreFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseReviewIgnore(content), nil
+}
+
+// Empty reports whether the matcher has no patterns.
+func (r *ReviewIgnore) Empty() bool {
+	return r == nil || len(r.rules) == 0
+}
+
+// Match reports whether the repo-relative, slash-separated path is excluded.
+// As in git, a file under an excluded directory stays excluded even if a
+// later "!" pattern names the file itself.
+func (r *ReviewIgnore) Match(file string) bool {
+	if r.Empty() {
+		return false
+	}
+	parts := strings.Split(file, "/")
+	for i := 1; i < len(parts); i++ {
+		if r.excluded(parts[:i], true) {
+			return true
+		}
+	}
+
+	return r.excluded(parts, false)
+}
+
+// excluded applies every rule to a path in order; the last match wins.
+func (r *ReviewIgnore) excluded(parts []string, isDir bool) bool {
+	excluded := false
+	for _, rule := range r.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if matchSegments(rule.segments, parts) {
+			excluded = !rule.negate
+		}
+	}
+
+	return excluded
+}
+
+// matchSegments matches path segments against pattern segments, where a
+// "**" segment matches zero or more path segments and any other segment is a
+// path.Match glob.
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := range len(parts) + 1 {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+
+	return matchSegments(pattern[1:], parts[1:])
+}
+
+// validSegments reports whether every segment is a valid path.Match pattern.
+func validSegments(segments []string) bool {
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,99 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"msrl.dev/lgtmcp/internal/testutil"
+)
+
+func TestReviewIgnore_Match(t *testing.T) {
+	t.Parallel()
+	ignore := ParseReviewIgnore(`# Generated code
+*.pb.go
+/vendor/
+docs/**/*.svg
+testdata/
+!keep.pb.go
+\#literal
+!testdata/golden.txt
+[invalid
+`)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"main.go", false},
+		{"api/service.pb.go", true},
+		{"service.pb.go", true},
+		{"keep.pb.go", false},
+		{"vendor/lib/lib.go", true},
+		{"pkg/vendor/lib.go", false},
+		{"vendor", false},
+		{"docs/img/logo.svg", true},
+		{"docs/logo.svg", true},
+		{"site/docs/logo.svg", false},
+		{"pkg/testdata/input.txt", true},
+		{"testdata/golden.txt", true},
+		{"#literal", true},
+		{"[invalid", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ignore.Match(tt.path), tt.path)
+	}
+
+	var empty *ReviewIgnore
+	assert.True(t, empty.Empty())
+	assert.False(t, empty.Match("main.go"))
+	assert.True(t, ParseReviewIgnore("# only a comment\n\n").Empty())
+}
+
+func TestLoadReviewIgnore(t *testing.T) {
+	t.Parallel()
+	tmpDir := testutil.CreateTempGitRepo(t)
+	g, err := New(tmpDir, nil)
+	require.NoError(t, err)
+
+	testutil.CreateFile(t, tmpDir, ReviewIgnoreFile, "gen/\n")
+	ignore, err := g.LoadReviewIgnore(t.Context(), "HEAD")
+	require.NoError(t, err)
+	assert.True(t, ignore.Empty(), "a repository with no commits excludes nothing")
+
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "Exclude gen")
+	ignore, err = g.LoadReviewIgnore(t.Context(), "HEAD")
+	require.NoError(t, err)
+	assert.True(t, ignore.Match("gen/types.go"))
+	assert.False(t, ignore.Match("main.go"))
+
+	testutil.CreateFile(t, tmpDir, ReviewIgnoreFile, "*.go\n")
+	ignore, err = g.LoadReviewIgnore(t.Context(), "HEAD")
+	require.NoError(t, err)
+	assert.False(t, ignore.Match("main.go"), "uncommitted rules must not apply")
+
+	testutil.RunGitCmd(t, tmpDir, "rm", "-qf", ReviewIgnoreFile)
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "Drop exclusions")
+	ignore, err = g.LoadReviewIgnore(t.Context(), "HEAD")
+	require.NoError(t, err)
+	assert.True(t, ignore.Empty(), "a missing file excludes nothing")
+
+	ignore, err = g.LoadReviewIgnore(t.Context(), "HEAD~1")
+	require.NoError(t, err)
+	assert.True(t, ignore.Match("gen/types.go"), "rules are read as of the given commit")
+}
@@ -0,0 +1,168 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidRef indicates a revision that does not name a commit.
+	ErrInvalidRef = errors.New("invalid ref")
+	// ErrNoMergeBase indicates commits that share no history.
+	ErrNoMergeBase = errors.New("no merge base")
+)
+
+// ResolveCommit resolves ref (a branch, tag, hash, or expression such as
+// "HEAD~3") to the full hash of the commit it names. Refs beginning with "-"
+// are rejected so they cannot be taken as git options.
+func (g *Git) ResolveCommit(ctx context.Context, ref string) (string, error) {
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidRef, ref)
+	}
+
+	// --verify --quiet exits 1, printing nothing, when ref does not resolve;
+	// the ^{commit} suffix also rejects refs naming trees or blobs.
+	res, err := runGit(ctx, g.repoPath, g.commandTimeout, nil, nil, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %q: %w", ref, err)
+	}
+	switch res.exitCode {
+	case 0:
+		return strings.TrimSpace(res.stdout), nil
+	case 1:
+		return "", fmt.Errorf("%w: %q does not name a commit", ErrInvalidRef, ref)
+	default:
+		msg := strings.TrimSpace(res.stderr)
+		if msg == "" {
+			msg = fmt.Sprintf("exit status %d", res.exitCode)
+		}
+
+		return "", fmt.Errorf("failed to resolve %q: %w: %s", ref, ErrCommandFailed, msg)
+	}
+}
+
+// ParentOf returns the first parent of commit, which should come from
+// ResolveCommit. For a root commit, which has no parent, it returns the empty
+// tree instead, so that DiffRange(ParentOf(c), c) shows everything commit c
+// introduced either way.
+func (g *Git) ParentOf(ctx context.Context, commit string) (string, error) {
+	res, err := runGit(ctx, g.repoPath, g.commandTimeout, nil, nil,
+		"rev-parse", "--verify", "--quiet", commit+"^1^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the parent of %s: %w", commit, err)
+	}
+	switch res.exitCode {
+	case 0:
+		return strings.TrimSpace(res.stdout), nil
+	case 1:
+		// Hash the empty tree rather than hard-coding its ID, which differs
+		// between SHA-1 and SHA-256 repositories.
+		tree, err := g.runGitCommandStdin(ctx, strings.NewReader(""), nil, "hash-object", "-t", "tree", "--stdin")
+		if err != nil {
+			return "", fmt.Errorf("failed to get the empty tree: %w", err)
+		}
+
+		return strings.TrimSpace(tree), nil
+	default:
+		msg := strings.TrimSpace(res.stderr)
+		if msg == "" {
+			msg = fmt.Sprintf("exit status %d", res.exitCode)
+		}
+
+		return "", fmt.Errorf("failed to resolve the parent of %s: %w: %s", commit, ErrCommandFailed, msg)
+	}
+}
+
+// MergeBase returns the commit where HEAD diverged from branch, the best
+// common ancestor `git merge-base HEAD <branch>` finds. It returns
+// ErrInvalidRef when branch does not name a commit, and ErrNoMergeBase when
+// the two share no history.
+func (g *Git) MergeBase(ctx context.Context, branch string) (string, error) {
+	commit, err := g.ResolveCommit(ctx, branch)
+	if err != nil {
+		return "", err
+	}
+	res, err := runGit(ctx, g.repoPath, g.commandTimeout, nil, nil, "merge-base", "HEAD", commit)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the merge base with %q: %w", branch, err)
+	}
+	switch res.exitCode {
+	case 0:
+		return strings.TrimSpace(res.stdout), nil
+	case 1:
+		return "", fmt.Errorf("%w: HEAD and %q have no common ancestor", ErrNoMergeBase, branch)
+	default:
+		msg := strings.TrimSpace(res.stderr)
+		if msg == "" {
+			msg = fmt.Sprintf("exit status %d", res.exitCode)
+		}
+
+		return "", fmt.Errorf("failed to find the merge base with %q: %w: %s", branch, ErrCommandFailed, msg)
+	}
+}
+
+// DiffRange returns the diff between two commits, as `git diff from..to`
+// would show it, in the same pinned format as GetDiff. from and to should
+// come from ResolveCommit or ParentOf. It returns ErrNoChanges when the commits' trees
+// are identical.
+func (g *Git) DiffRange(ctx context.Context, from, to string) (string, error) {
+	diff, err := g.runGitCommand(ctx, append(g.unifiedDiffArgs(), from, to, "--")...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff between %s and %s: %w", from, to, err)
+	}
+	if diff == "" {
+		return "", ErrNoChanges
+	}
+
+	return diff, nil
+}
+
+// FileContentAt returns the content of a repo-relative, slash-separated file
+// as of commit rev, which should come from ResolveCommit.
+func (g *Git) FileContentAt(ctx context.Context, rev, relativePath string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(relativePath)) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPath, relativePath)
+	}
+	content, err := g.runGitCommand(ctx, "cat-file", "blob", rev+":"+relativePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s at %s: %w", relativePath, rev, err)
+	}
+
+	return content, nil
+}
+
+// FileSizeAt returns the size in bytes of a repo-relative, slash-separated
+// file as of commit rev, without reading it.
+func (g *Git) FileSizeAt(ctx context.Context, rev, relativePath string) (int64, error) {
+	if !filepath.IsLocal(filepath.FromSlash(relativePath)) {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidPath, relativePath)
+	}
+	out, err := g.runGitCommand(ctx, "cat-file", "-s", rev+":"+relativePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get size of %s at %s: %w", relativePath, rev, err)
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse size of %s at %s: %w", relativePath, rev, err)
+	}
+
+	return size, nil
+}
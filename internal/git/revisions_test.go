@@ -0,0 +1,139 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"msrl.dev/lgtmcp/internal/testutil"
+)
+
+func TestCommitRange(t *testing.T) {
+	t.Parallel()
+	tmpDir := testutil.CreateTempGitRepo(t)
+	g, err := New(tmpDir, nil)
+	require.NoError(t, err)
+
+	testutil.CreateFile(t, tmpDir, "a.go", "package a\n")
+	testutil.RunGitCmd(t, tmpDir, "add", "a.go")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "Add a")
+	first := testutil.RunGitCmd(t, tmpDir, "rev-parse", "HEAD")
+	testutil.CreateFile(t, tmpDir, "a.go", "package a\n\nvar v = 1\n")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-am", "Add v")
+	second := testutil.RunGitCmd(t, tmpDir, "rev-parse", "HEAD")
+	testutil.CreateFile(t, tmpDir, "a.go", "package a\n\nvar v = 2\n")
+
+	t.Run("ResolveCommit", func(t *testing.T) {
+		t.Parallel()
+		got, err := g.ResolveCommit(t.Context(), "HEAD~1")
+		require.NoError(t, err)
+		assert.Equal(t, first, got)
+
+		for _, ref := range []string{"", "no-such-branch", "--output=/tmp/x", "HEAD^{tree}"} {
+			_, err := g.ResolveCommit(t.Context(), ref)
+			require.ErrorIs(t, err, ErrInvalidRef, "ref %q", ref)
+		}
+	})
+
+	t.Run("DiffRange", func(t *testing.T) {
+		t.Parallel()
+		diff, err := g.DiffRange(t.Context(), first, second)
+		require.NoError(t, err)
+		assert.Contains(t, diff, "diff --git a/a.go b/a.go")
+		assert.Contains(t, diff, "+var v = 1")
+		assert.NotContains(t, diff, "v = 2", "working tree changes are not part of a commit range")
+
+		_, err = g.DiffRange(t.Context(), second, second)
+		require.ErrorIs(t, err, ErrNoChanges)
+	})
+
+	t.Run("ParentOf", func(t *testing.T) {
+		t.Parallel()
+		parent, err := g.ParentOf(t.Context(), second)
+		require.NoError(t, err)
+		assert.Equal(t, first, parent)
+
+		// The root commit is diffed against the empty tree.
+		parent, err = g.ParentOf(t.Context(), first)
+		require.NoError(t, err)
+		assert.Equal(t, "tree", testutil.RunGitCmd(t, tmpDir, "cat-file", "-t", parent))
+		assert.Empty(t, testutil.RunGitCmd(t, tmpDir, "ls-tree", parent))
+		diff, err := g.DiffRange(t.Context(), parent, first)
+		require.NoError(t, err)
+		assert.Contains(t, diff, "+package a")
+	})
+
+	t.Run("FileContentAt", func(t *testing.T) {
+		t.Parallel()
+		content, err := g.FileContentAt(t.Context(), second, "a.go")
+		require.NoError(t, err)
+		assert.Equal(t, "package a\n\nvar v = 1\n", content)
+
+		_, err = g.FileContentAt(t.Context(), second, "../a.go")
+		require.ErrorIs(t, err, ErrInvalidPath)
+		_, err = g.FileContentAt(t.Context(), first, "missing.go")
+		require.Error(t, err)
+	})
+
+	t.Run("FileSizeAt", func(t *testing.T) {
+		t.Parallel()
+		size, err := g.FileSizeAt(t.Context(), second, "a.go")
+		require.NoError(t, err)
+		assert.Equal(t, int64(len("package a\n\nvar v = 1\n")), size)
+
+		_, err = g.FileSizeAt(t.Context(), second, "../a.go")
+		require.ErrorIs(t, err, ErrInvalidPath)
+		_, err = g.FileSizeAt(t.Context(), first, "missing.go")
+		require.Error(t, err)
+	})
+}
+
+func TestMergeBase(t *testing.T) {
+	t.Parallel()
+	tmpDir := testutil.CreateTempGitRepo(t)
+	g, err := New(tmpDir, nil)
+	require.NoError(t, err)
+
+	testutil.CreateFile(t, tmpDir, "a.go", "package a\n")
+	testutil.RunGitCmd(t, tmpDir, "add", "a.go")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "Add a")
+	base := testutil.RunGitCmd(t, tmpDir, "rev-parse", "HEAD")
+	testutil.RunGitCmd(t, tmpDir, "branch", "upstream")
+
+	// The base branch moves on after the feature branch diverges.
+	testutil.RunGitCmd(t, tmpDir, "checkout", "-q", "-b", "feature")
+	testutil.CreateFile(t, tmpDir, "feature.go", "package a\n\nvar feature = 1\n")
+	testutil.RunGitCmd(t, tmpDir, "add", "feature.go")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "Add feature")
+	testutil.RunGitCmd(t, tmpDir, "checkout", "-q", "upstream")
+	testutil.CreateFile(t, tmpDir, "upstream.go", "package a\n\nvar upstream = 1\n")
+	testutil.RunGitCmd(t, tmpDir, "add", "upstream.go")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "Add upstream")
+	testutil.RunGitCmd(t, tmpDir, "checkout", "-q", "feature")
+
+	got, err := g.MergeBase(t.Context(), "upstream")
+	require.NoError(t, err)
+	assert.Equal(t, base, got)
+
+	_, err = g.MergeBase(t.Context(), "no-such-branch")
+	require.ErrorIs(t, err, ErrInvalidRef)
+
+	testutil.RunGitCmd(t, tmpDir, "checkout", "-q", "--orphan", "unrelated")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-q", "-m", "Unrelated history")
+	_, err = g.MergeBase(t.Context(), "feature")
+	require.ErrorIs(t, err, ErrNoMergeBase)
+}
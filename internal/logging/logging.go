@@ -16,6 +16,7 @@
 package logging
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -24,6 +25,9 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
 
 	cfgpkg "msrl.dev/lgtmcp/internal/config"
 )
@@ -49,12 +53,27 @@ var ErrUnknownOutput = errors.New(
 // the recognized values. A typo'd level is a configuration error and fails
 // fast at startup rather than silently logging at info.
 var ErrUnknownLevel = errors.New(
-	`unknown logging level; valid values are "debug", "info", "warn", "error"`,
+	`unknown logging level; valid values are "trace", "debug", "info", "warn", "error"`,
 )
 
+// LevelTrace is the level of [Logger.Trace] records, below slog.LevelDebug.
+const LevelTrace = slog.LevelDebug - 4
+
+// ErrMCPLogDropped is returned by the "mcp" output's queue when a message is
+// dropped because the queue is full or closed.
+var ErrMCPLogDropped = errors.New("MCP log message dropped")
+
+// DefaultMCPMaxMessageSize is the default cap, in bytes, on each message the
+// "mcp" output sends to the client.
+const DefaultMCPMaxMessageSize = 8 * 1024
+
+// mcpQueueSize is how many messages the "mcp" output holds for the client
+// before it starts dropping them.
+const mcpQueueSize = 1024
+
 // Config represents logging configuration.
 type Config struct {
-	// Level is the minimum log level (debug, info, warn, error).
+	// Level is the minimum log level (trace, debug, info, warn, error).
 	Level string `json:"level"`
 
 	// Output specifies where logs should be written:
@@ -73,6 +92,11 @@ type Config struct {
 	// MCPSender is used to send logs to MCP client (when Output is "mcp").
 	MCPSender MCPLogSender `json:"-"`
 
+	// MCPMaxMessageSize caps each message sent via MCPSender, in bytes;
+	// longer messages are truncated with a marker noting how much was cut.
+	// Nil means [DefaultMCPMaxMessageSize]; 0 or less disables truncation.
+	MCPMaxMessageSize *int `json:"mcp_max_message_size,omitempty"`
+
 	// ConfigDir is the lgtmcp config directory used to validate Directory
 	// when it is set. When empty, [cfgpkg.Dir] is used. Tests may set
 	// it to a temporary directory; production code should leave it empty.
@@ -86,6 +110,9 @@ type MCPLogSender interface {
 
 // Logger interface for structured logging.
 type Logger interface {
+	// Trace logs at [LevelTrace], for bulky records such as full prompts
+	// that are too noisy even for debug.
+	Trace(msg string, args ...any)
 	Debug(msg string, args ...any)
 	Info(msg string, args ...any)
 	Warn(msg string, args ...any)
@@ -123,7 +150,7 @@ func New(config Config) (Logger, error) {
 // level is accepted and resolves to info (see [parseLevel]).
 func validateLevel(level string) error {
 	switch level {
-	case "", "debug", "info", "warn", "error":
+	case "", "trace", "debug", "info", "warn", "error":
 		return nil
 	default:
 		return fmt.Errorf("%w: %q", ErrUnknownLevel, level)
@@ -214,21 +241,39 @@ func newMCPLogger(config Config) (Logger, error) {
 		return nil, ErrMCPSenderRequired
 	}
 
+	maxMessageSize := DefaultMCPMaxMessageSize
+	if config.MCPMaxMessageSize != nil {
+		maxMessageSize = *config.MCPMaxMessageSize
+	}
+
+	queue := newMCPQueue(config.MCPSender, mcpQueueSize)
+
 	return &mcpLogger{
-		sender:  config.MCPSender,
-		level:   parseLevel(config.Level),
-		context: nil,
+		sender:         queue,
+		queue:          queue,
+		level:          parseLevel(config.Level),
+		context:        nil,
+		maxMessageSize: maxMessageSize,
 	}, nil
 }
 
 func newTextHandler(w io.Writer, level string) slog.Handler {
 	return slog.NewTextHandler(w, &slog.HandlerOptions{
 		Level: parseLevel(level),
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			// slog would print LevelTrace as "DEBUG-4".
+			if a.Key == slog.LevelKey && a.Value.Any() == LevelTrace {
+				a.Value = slog.StringValue("TRACE")
+			}
+			return a
+		},
 	})
 }
 
 func parseLevel(level string) slog.Level {
 	switch level {
+	case "trace":
+		return LevelTrace
 	case "debug":
 		return slog.LevelDebug
 	case "warn":
@@ -266,6 +311,26 @@ func formatMessage(msg string, args ...any) string {
 	return msg
 }
 
+// truncateMessage shortens msg to at most limit bytes, cut on a UTF-8
+// boundary, and appends a marker recording how many bytes were dropped. A
+// limit of 0 or less returns msg unchanged.
+func truncateMessage(msg string, limit int) string {
+	if limit <= 0 || len(msg) <= limit {
+		return msg
+	}
+
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(msg[cut]) {
+		cut--
+	}
+
+	return fmt.Sprintf("%s... [truncated %d bytes]", msg[:cut], len(msg)-cut)
+}
+
+func (l *standardLogger) Trace(msg string, args ...any) {
+	l.logger.Log(context.Background(), LevelTrace, msg, args...)
+}
+
 func (l *standardLogger) Debug(msg string, args ...any) {
 	l.logger.Debug(msg, args...)
 }
@@ -300,9 +365,28 @@ func (l *standardLogger) Close() error {
 
 // mcpLogger sends logs to MCP client.
 type mcpLogger struct {
-	sender  MCPLogSender
-	level   slog.Level
-	context []any // Store context key-value pairs
+	sender MCPLogSender
+	// queue, set only on the root logger, is closed by Close. Children share
+	// it through sender.
+	queue          *mcpQueue
+	level          slog.Level
+	context        []any // Store context key-value pairs
+	maxMessageSize int   // Truncation limit in bytes; 0 or less disables it
+}
+
+// format renders msg with the logger's context and args for the client,
+// truncated to the configured maximum size.
+func (m *mcpLogger) format(msg string, args ...any) string {
+	return truncateMessage(formatMessage(msg, append(m.context, args...)...), m.maxMessageSize)
+}
+
+func (m *mcpLogger) Trace(msg string, args ...any) {
+	if m.level > LevelTrace {
+		return
+	}
+	// MCP has no trace level; debug is the closest.
+	//nolint:errcheck // MCP logging is best-effort
+	_ = m.sender.SendLog("debug", m.format(msg, args...))
 }
 
 func (m *mcpLogger) Debug(msg string, args ...any) {
@@ -311,7 +395,7 @@ func (m *mcpLogger) Debug(msg string, args ...any) {
 	}
 	// Best effort logging to MCP client.
 	//nolint:errcheck // MCP logging is best-effort
-	_ = m.sender.SendLog("debug", formatMessage(msg, append(m.context, args...)...))
+	_ = m.sender.SendLog("debug", m.format(msg, args...))
 }
 
 func (m *mcpLogger) Info(msg string, args ...any) {
@@ -320,7 +404,7 @@ func (m *mcpLogger) Info(msg string, args ...any) {
 	}
 	// Best effort logging to MCP client.
 	//nolint:errcheck // MCP logging is best-effort
-	_ = m.sender.SendLog("info", formatMessage(msg, append(m.context, args...)...))
+	_ = m.sender.SendLog("info", m.format(msg, args...))
 }
 
 func (m *mcpLogger) Warn(msg string, args ...any) {
@@ -329,7 +413,7 @@ func (m *mcpLogger) Warn(msg string, args ...any) {
 	}
 	// Best effort logging to MCP client.
 	//nolint:errcheck // MCP logging is best-effort
-	_ = m.sender.SendLog("warn", formatMessage(msg, append(m.context, args...)...))
+	_ = m.sender.SendLog("warn", m.format(msg, args...))
 }
 
 func (m *mcpLogger) Error(msg string, args ...any) {
@@ -338,7 +422,7 @@ func (m *mcpLogger) Error(msg string, args ...any) {
 	}
 	// Best effort logging to MCP client.
 	//nolint:errcheck // MCP logging is best-effort
-	_ = m.sender.SendLog("error", formatMessage(msg, append(m.context, args...)...))
+	_ = m.sender.SendLog("error", m.format(msg, args...))
 }
 
 func (m *mcpLogger) With(args ...any) Logger {
@@ -348,16 +432,96 @@ func (m *mcpLogger) With(args ...any) Logger {
 	newContext = append(newContext, args...)
 
 	return &mcpLogger{
-		sender:  m.sender,
-		level:   m.level,
-		context: newContext,
+		sender:         m.sender,
+		level:          m.level,
+		context:        newContext,
+		maxMessageSize: m.maxMessageSize,
 	}
 }
 
-func (*mcpLogger) Close() error {
+func (m *mcpLogger) Close() error {
+	if m.queue != nil {
+		m.queue.close()
+	}
+
 	return nil
 }
 
+// mcpQueue is an MCPLogSender that hands messages to another sender from a
+// background goroutine, so a client that is slow to drain notifications
+// cannot stall the caller. When the queue is full, messages are dropped and
+// counted rather than waited on.
+type mcpQueue struct {
+	sender  MCPLogSender
+	entries chan mcpEntry
+	done    chan struct{}
+	dropped atomic.Uint64
+
+	mu     sync.RWMutex // Guards closed against sends on a closed channel
+	closed bool
+}
+
+type mcpEntry struct {
+	level, message string
+}
+
+func newMCPQueue(sender MCPLogSender, size int) *mcpQueue {
+	q := &mcpQueue{
+		sender:  sender,
+		entries: make(chan mcpEntry, size),
+		done:    make(chan struct{}),
+	}
+	go q.run()
+
+	return q
+}
+
+// SendLog enqueues the message without blocking, returning
+// [ErrMCPLogDropped] if the queue is full or closed.
+func (q *mcpQueue) SendLog(level, message string) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if !q.closed {
+		select {
+		case q.entries <- mcpEntry{level: level, message: message}:
+			return nil
+		default:
+		}
+	}
+	q.dropped.Add(1)
+
+	return ErrMCPLogDropped
+}
+
+func (q *mcpQueue) run() {
+	defer close(q.done)
+	for e := range q.entries {
+		//nolint:errcheck // MCP logging is best-effort
+		_ = q.sender.SendLog(e.level, e.message)
+	}
+}
+
+// close stops accepting messages, waits for those already queued to be
+// sent, and then reports how many were dropped, if any. It is idempotent.
+func (q *mcpQueue) close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+
+		return
+	}
+	q.closed = true
+	close(q.entries)
+	q.mu.Unlock()
+
+	<-q.done
+	if n := q.dropped.Load(); n > 0 {
+		//nolint:errcheck // MCP logging is best-effort
+		_ = q.sender.SendLog("warn", fmt.Sprintf("%d log messages were dropped because the MCP client fell behind", n))
+	}
+}
+
 // nopLogger is a no-op logger for when logging is disabled.
 type nopLogger struct{}
 
@@ -381,6 +545,7 @@ func newBufferLogger(level string) *bufferLogger {
 	}
 }
 
+func (*nopLogger) Trace(_ string, _ ...any) {}
 func (*nopLogger) Debug(_ string, _ ...any) {}
 func (*nopLogger) Info(_ string, _ ...any)  {}
 func (*nopLogger) Warn(_ string, _ ...any)  {}
@@ -165,6 +165,40 @@ func TestLogger_LogLevels(t *testing.T) {
 	assert.Contains(t, output, "error message")
 }
 
+func TestLogger_TraceLevel(t *testing.T) {
+	t.Parallel()
+
+	t.Run("standard logger", func(t *testing.T) {
+		t.Parallel()
+		logger := newBufferLogger("trace")
+		logger.Trace("full prompt", "text", "diff")
+		logger.Debug("debug message")
+		assert.Contains(t, logger.String(), "level=TRACE msg=\"full prompt\" text=diff")
+		assert.Contains(t, logger.String(), "debug message")
+
+		quiet := newBufferLogger("debug")
+		quiet.Trace("full prompt")
+		assert.NotContains(t, quiet.String(), "full prompt")
+	})
+
+	t.Run("mcp logger", func(t *testing.T) {
+		t.Parallel()
+		for _, level := range []string{"trace", "debug"} {
+			sender := &recordingMCPSender{}
+			logger, err := New(Config{Output: "mcp", Level: level, MCPSender: sender})
+			require.NoError(t, err)
+			logger.Trace("full prompt")
+			require.NoError(t, logger.Close())
+
+			if level == "trace" {
+				assert.Equal(t, []string{"debug: full prompt"}, sender.entries, "MCP has no trace level")
+			} else {
+				assert.Empty(t, sender.entries)
+			}
+		}
+	})
+}
+
 func TestLogger_LogLevelFiltering(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -321,13 +355,13 @@ func TestMCPLogger(t *testing.T) {
 
 	logger, err := New(config)
 	require.NoError(t, err)
-	defer logger.Close() //nolint:errcheck // Test cleanup
 
 	// Log messages.
 	logger.Info("test info", "key", "value")
 	logger.Error("test error", "key", "value")
 	// Test odd number of arguments - the unpaired arg should be ignored
 	logger.Warn("test warn", "key1", "value1", "unpaired")
+	require.NoError(t, logger.Close()) // Flush the queue
 
 	// Check that messages were sent.
 	assert.Len(t, sender.messages, 3)
@@ -341,6 +375,77 @@ func TestMCPLogger(t *testing.T) {
 	assert.NotContains(t, sender.messages[2], "unpaired")
 }
 
+func TestMCPLogger_MaxMessageSize(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("x", 100)
+
+	t.Run("truncates long messages to the configured size", func(t *testing.T) {
+		t.Parallel()
+		sender := &mockMCPSender{}
+		logger, err := New(Config{Output: "mcp", Level: "info", MCPSender: sender, MCPMaxMessageSize: new(32)})
+		require.NoError(t, err)
+
+		logger.Info("diff", "content", long)
+		logger.With("request_id", "abc").Info("short")
+		require.NoError(t, logger.Close())
+
+		require.Len(t, sender.messages, 2)
+		assert.Equal(t, "diff [content=xxxxxxxxxxxxxxxxxx... [truncated 83 bytes]", sender.messages[0])
+		assert.Equal(t, "short [request_id=abc]", sender.messages[1])
+	})
+
+	t.Run("child loggers keep the limit", func(t *testing.T) {
+		t.Parallel()
+		sender := &mockMCPSender{}
+		logger, err := New(Config{Output: "mcp", Level: "info", MCPSender: sender, MCPMaxMessageSize: new(10)})
+		require.NoError(t, err)
+
+		logger.With("k", "v").Warn(long)
+		require.NoError(t, logger.Close())
+
+		require.Len(t, sender.messages, 1)
+		assert.True(t, strings.HasPrefix(sender.messages[0], "xxxxxxxxxx... [truncated "))
+	})
+
+	t.Run("zero disables truncation", func(t *testing.T) {
+		t.Parallel()
+		sender := &mockMCPSender{}
+		logger, err := New(Config{Output: "mcp", Level: "info", MCPSender: sender, MCPMaxMessageSize: new(0)})
+		require.NoError(t, err)
+
+		logger.Info(long)
+		require.NoError(t, logger.Close())
+
+		require.Len(t, sender.messages, 1)
+		assert.Equal(t, long, sender.messages[0])
+	})
+
+	t.Run("unset uses the default", func(t *testing.T) {
+		t.Parallel()
+		sender := &mockMCPSender{}
+		logger, err := New(Config{Output: "mcp", Level: "info", MCPSender: sender})
+		require.NoError(t, err)
+
+		logger.Info(strings.Repeat("y", DefaultMCPMaxMessageSize+500))
+		require.NoError(t, logger.Close())
+
+		require.Len(t, sender.messages, 1)
+		assert.Contains(t, sender.messages[0], "... [truncated 500 bytes]")
+	})
+}
+
+func TestTruncateMessage(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "short", truncateMessage("short", 10))
+	assert.Equal(t, "exactly10!", truncateMessage("exactly10!", 10))
+	assert.Equal(t, "abc... [truncated 3 bytes]", truncateMessage("abcdef", 3))
+	// "é" is two bytes; a cut inside it backs off to the rune boundary.
+	assert.Equal(t, "a... [truncated 3 bytes]", truncateMessage("aéb", 2))
+	assert.Equal(t, "anything", truncateMessage("anything", 0))
+}
+
 func TestNopLogger(t *testing.T) {
 	t.Parallel()
 	config := Config{
@@ -391,16 +496,18 @@ func TestWith(t *testing.T) {
 
 		logger, err := New(config)
 		require.NoError(t, err)
-		defer logger.Close() //nolint:errcheck // Test cleanup
 
 		// Create context logger with multiple attributes.
 		ctxLogger := logger.With("request_id", "456", "user", "alice")
 
 		// Log with context.
 		ctxLogger.Info("operation completed", "status", "success")
+		require.NoError(t, ctxLogger.Close(), "a child does not close the shared queue")
+		ctxLogger.Info("still delivered")
+		require.NoError(t, logger.Close())
 
 		// Check that context was included in the message.
-		require.Len(t, sender.messages, 1)
+		require.Len(t, sender.messages, 2)
 		msg := sender.messages[0]
 		assert.Contains(t, msg, "operation completed")
 		assert.Contains(t, msg, "request_id=456")
@@ -412,6 +519,7 @@ func TestWith(t *testing.T) {
 func TestNopLogger_AllMethods(t *testing.T) {
 	t.Parallel()
 	logger := &nopLogger{}
+	logger.Trace("trace")
 	logger.Debug("debug")
 	logger.Info("info")
 	logger.Warn("warn")
@@ -431,9 +539,9 @@ func TestMCPLogger_DebugLevel(t *testing.T) {
 		MCPSender: sender,
 	})
 	require.NoError(t, err)
-	defer logger.Close() //nolint:errcheck // Test cleanup
 
 	logger.Debug("debug message")
+	require.NoError(t, logger.Close())
 	require.Len(t, sender.messages, 1)
 	assert.Contains(t, sender.messages[0], "debug message")
 }
@@ -447,14 +555,13 @@ func TestMCPLogger_LevelFiltering(t *testing.T) {
 		MCPSender: sender,
 	})
 	require.NoError(t, err)
-	defer logger.Close() //nolint:errcheck // Test cleanup
 
 	logger.Debug("filtered debug")
 	logger.Info("filtered info")
 	logger.Warn("filtered warn")
-	assert.Empty(t, sender.messages)
-
 	logger.Error("passed error")
+	require.NoError(t, logger.Close())
+
 	require.Len(t, sender.messages, 1)
 	assert.Contains(t, sender.messages[0], "passed error")
 }
@@ -612,6 +719,71 @@ func TestDirectoryLogger_PathValidation(t *testing.T) {
 	})
 }
 
+func TestMCPQueue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("drops and counts messages when full", func(t *testing.T) {
+		t.Parallel()
+		sender := &blockingMCPSender{started: make(chan struct{}), release: make(chan struct{})}
+		q := newMCPQueue(sender, 2)
+
+		require.NoError(t, q.SendLog("info", "m0"))
+		<-sender.started // m0 is in flight, so the queue is empty again.
+		require.NoError(t, q.SendLog("info", "m1"))
+		require.NoError(t, q.SendLog("info", "m2"))
+		require.ErrorIs(t, q.SendLog("info", "m3"), ErrMCPLogDropped)
+		assert.Equal(t, uint64(1), q.dropped.Load())
+
+		close(sender.release)
+		q.close()
+		assert.Equal(t, []string{
+			"m0", "m1", "m2",
+			"1 log messages were dropped because the MCP client fell behind",
+		}, sender.messages)
+	})
+
+	t.Run("close is idempotent and later messages are dropped", func(t *testing.T) {
+		t.Parallel()
+		sender := &mockMCPSender{}
+		q := newMCPQueue(sender, 4)
+		require.NoError(t, q.SendLog("info", "before"))
+		q.close()
+		q.close()
+
+		require.ErrorIs(t, q.SendLog("info", "after"), ErrMCPLogDropped)
+		assert.Equal(t, []string{"before"}, sender.messages)
+	})
+}
+
+// blockingMCPSender holds its first message until release is closed,
+// signaling started once it has received it.
+type blockingMCPSender struct {
+	started  chan struct{}
+	release  chan struct{}
+	messages []string
+}
+
+func (b *blockingMCPSender) SendLog(_, message string) error {
+	if len(b.messages) == 0 {
+		close(b.started)
+		<-b.release
+	}
+	b.messages = append(b.messages, message)
+
+	return nil
+}
+
+// recordingMCPSender records each message with its level.
+type recordingMCPSender struct {
+	entries []string
+}
+
+func (r *recordingMCPSender) SendLog(level, message string) error {
+	r.entries = append(r.entries, level+": "+message)
+
+	return nil
+}
+
 // Mock MCP sender for testing.
 type mockMCPSender struct {
 	messages []string
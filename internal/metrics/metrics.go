@@ -0,0 +1,126 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics keeps in-process counters of review activity for
+// operational visibility. Counters are cumulative since server start and are
+// safe for concurrent use.
+package metrics
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Metrics holds the counters. The zero value is ready to use, and every
+// method is a no-op on a nil *Metrics, so components built without one (as in
+// tests) need no special casing.
+type Metrics struct {
+	reviews        atomic.Int64
+	approvals      atomic.Int64
+	rejections     atomic.Int64
+	failures       atomic.Int64
+	securityBlocks atomic.Int64
+	retries        atomic.Int64
+	reviewNanos    atomic.Int64
+}
+
+// Snapshot is a point-in-time copy of the counters.
+type Snapshot struct {
+	// Reviews counts reviews Gemini completed, whatever the verdict.
+	Reviews int64 `json:"reviews"`
+	// Approvals and Rejections split Reviews by whether the final verdict
+	// allowed a commit.
+	Approvals  int64 `json:"approvals"`
+	Rejections int64 `json:"rejections"`
+	// Failures counts reviews that ended in an error, such as an API failure
+	// or timeout.
+	Failures int64 `json:"failures"`
+	// SecurityBlocks counts reviews stopped by the secret scan before
+	// reaching Gemini.
+	SecurityBlocks int64 `json:"security_blocks"`
+	// Retries counts Gemini API calls retried after a transient error.
+	Retries int64 `json:"retries"`
+	// AverageReviewDuration is the mean duration of the completed reviews,
+	// or zero when there are none.
+	AverageReviewDuration time.Duration `json:"-"`
+	// AverageReviewMS is AverageReviewDuration in milliseconds.
+	AverageReviewMS int64 `json:"average_review_ms"`
+}
+
+// New returns a Metrics with every counter at zero.
+func New() *Metrics {
+	return &Metrics{}
+}
+
+// RecordReview counts a completed review with its final verdict and
+// duration.
+func (m *Metrics) RecordReview(approved bool, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.reviews.Add(1)
+	if approved {
+		m.approvals.Add(1)
+	} else {
+		m.rejections.Add(1)
+	}
+	m.reviewNanos.Add(int64(duration))
+}
+
+// RecordFailure counts a review that ended in an error.
+func (m *Metrics) RecordFailure() {
+	if m == nil {
+		return
+	}
+	m.failures.Add(1)
+}
+
+// RecordSecurityBlock counts a review blocked by the secret scan.
+func (m *Metrics) RecordSecurityBlock() {
+	if m == nil {
+		return
+	}
+	m.securityBlocks.Add(1)
+}
+
+// RecordRetry counts a retried Gemini API call.
+func (m *Metrics) RecordRetry() {
+	if m == nil {
+		return
+	}
+	m.retries.Add(1)
+}
+
+// Snapshot returns the current counter values. Counters are read one at a
+// time, so a snapshot taken while a review finishes may be off by that
+// review; it is for monitoring, not accounting.
+func (m *Metrics) Snapshot() Snapshot {
+	if m == nil {
+		return Snapshot{}
+	}
+	s := Snapshot{
+		Reviews:        m.reviews.Load(),
+		Approvals:      m.approvals.Load(),
+		Rejections:     m.rejections.Load(),
+		Failures:       m.failures.Load(),
+		SecurityBlocks: m.securityBlocks.Load(),
+		Retries:        m.retries.Load(),
+	}
+	if s.Reviews > 0 {
+		s.AverageReviewDuration = time.Duration(m.reviewNanos.Load() / s.Reviews)
+		s.AverageReviewMS = s.AverageReviewDuration.Milliseconds()
+	}
+
+	return s
+}
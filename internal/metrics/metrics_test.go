@@ -0,0 +1,62 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	m := New()
+	assert.Equal(t, Snapshot{}, m.Snapshot())
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			m.RecordReview(true, 100*time.Millisecond)
+			m.RecordReview(false, 300*time.Millisecond)
+			m.RecordFailure()
+			m.RecordSecurityBlock()
+			m.RecordRetry()
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, Snapshot{
+		Reviews:               20,
+		Approvals:             10,
+		Rejections:            10,
+		Failures:              10,
+		SecurityBlocks:        10,
+		Retries:               10,
+		AverageReviewDuration: 200 * time.Millisecond,
+		AverageReviewMS:       200,
+	}, m.Snapshot())
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	t.Parallel()
+	var m *Metrics
+	m.RecordReview(true, time.Second)
+	m.RecordFailure()
+	m.RecordSecurityBlock()
+	m.RecordRetry()
+	assert.Equal(t, Snapshot{}, m.Snapshot())
+}
@@ -0,0 +1,104 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompts
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// untestableExts are source extensions not expected to have tests of their
+// own: headers, build scripts, shell scripts, and SQL.
+var untestableExts = map[string]bool{"h": true, "hpp": true, "kts": true, "sh": true, "bash": true, "sql": true}
+
+// testDirs are directory names whose files are all tests.
+var testDirs = map[string]bool{"test": true, "tests": true, "__tests__": true, "spec": true}
+
+// testSubject reports whether the slash-separated file is a test, judged by
+// the naming conventions of common languages (foo_test.go, test_foo.py,
+// foo.test.ts, foo.spec.js, foo_spec.rb, FooTest.java, FooTests.cs) or by
+// lying under a test directory, and returns the base name, without
+// extension, of the source file it tests.
+func testSubject(file string) (string, bool) {
+	base := path.Base(file)
+	stem := strings.TrimSuffix(base, path.Ext(base))
+	for _, suffix := range []string{"_test", ".test", ".spec", "_spec", "Tests", "Test"} {
+		if subject, ok := strings.CutSuffix(stem, suffix); ok && subject != "" {
+			return subject, true
+		}
+	}
+	if subject, ok := strings.CutPrefix(stem, "test_"); ok && subject != "" {
+		return subject, true
+	}
+	for dir := range strings.SplitSeq(path.Dir(file), "/") {
+		if testDirs[dir] {
+			return stem, true
+		}
+	}
+
+	return "", false
+}
+
+// UntestedFiles returns, in order, the source files among changed, which
+// should not include deleted files, that no test file among changed
+// corresponds to. A test corresponds to a source file with the same base
+// name, in any directory, as in foo.go and foo_test.go or src/foo.py and
+// tests/test_foo.py. Files in languages not expected to have tests of
+// their own, and files that are not source code, are left out.
+func UntestedFiles(changed []string) []string {
+	tested := make(map[string]bool)
+	var sources []string
+	for _, file := range changed {
+		if subject, ok := testSubject(file); ok {
+			tested[subject] = true
+
+			continue
+		}
+		ext := normalizeExt(path.Ext(file))
+		if _, ok := languageByExt[ext]; ok && !untestableExts[ext] {
+			sources = append(sources, file)
+		}
+	}
+
+	return slices.DeleteFunc(sources, func(file string) bool {
+		base := path.Base(file)
+		return tested[strings.TrimSuffix(base, path.Ext(base))]
+	})
+}
+
+// FormatMissingTests formats files, as returned by UntestedFiles, into a
+// prompt section asking the model to flag missing tests. Returns an empty
+// string when files is empty.
+func FormatMissingTests(files []string) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	_, _ = sb.WriteString("## Test Coverage\n\n" +
+		"This change modifies the following source files without changing a test file that " +
+		"corresponds to them by name. Check whether the change adds or alters behavior that " +
+		"its tests should cover; if it does and you find no tests for it, in the diff or in " +
+		"files you fetch (some languages keep tests in the same file), request them. Do not " +
+		"request tests for changes that need none, such as comments, renames, or logging.\n\n")
+	for _, file := range files {
+		_, _ = fmt.Fprintf(&sb, "- %s\n", file)
+	}
+	_, _ = sb.WriteString("\n")
+
+	return sb.String()
+}
@@ -0,0 +1,72 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestSubject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		file    string
+		subject string
+		isTest  bool
+	}{
+		{"pkg/server_test.go", "server", true},
+		{"tests/test_parser.py", "parser", true},
+		{"src/app.test.ts", "app", true},
+		{"src/app.spec.js", "app", true},
+		{"spec/user_spec.rb", "user", true},
+		{"src/test/java/FooTest.java", "Foo", true},
+		{"Service.Tests/ServiceTests.cs", "Service", true},
+		{"tests/helpers.py", "helpers", true},
+		{"pkg/server.go", "", false},
+		{"pkg/contest.go", "", false},
+		{"Test.java", "", false},
+	}
+	for _, tt := range tests {
+		subject, ok := testSubject(tt.file)
+		assert.Equal(t, tt.isTest, ok, tt.file)
+		assert.Equal(t, tt.subject, subject, tt.file)
+	}
+}
+
+func TestUntestedFiles(t *testing.T) {
+	t.Parallel()
+
+	changed := []string{
+		"pkg/server.go", "pkg/server_test.go",
+		"pkg/args.go",
+		"src/parser.py", "tests/test_parser.py",
+		"web/app.ts",
+		"README.md", "include/api.h", "scripts/deploy.sh", "migrations/001.sql",
+	}
+	assert.Equal(t, []string{"pkg/args.go", "web/app.ts"}, UntestedFiles(changed))
+	assert.Empty(t, UntestedFiles([]string{"README.md"}))
+	assert.Empty(t, UntestedFiles(nil))
+}
+
+func TestFormatMissingTests(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, FormatMissingTests(nil))
+	section := FormatMissingTests([]string{"pkg/args.go", "web/app.ts"})
+	assert.Contains(t, section, "## Test Coverage")
+	assert.Contains(t, section, "- pkg/args.go\n- web/app.ts\n")
+}
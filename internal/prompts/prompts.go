@@ -20,10 +20,15 @@ import (
 	_ "embed"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"text/template"
 	"time"
+	"unicode/utf8"
 
 	"msrl.dev/lgtmcp/internal/config"
 )
@@ -35,6 +40,9 @@ var (
 
 	//go:embed context_gathering.md
 	defaultContextGatheringPrompt string
+
+	//go:embed system.md
+	defaultSystemInstruction string
 )
 
 // PromptType represents the type of prompt.
@@ -47,6 +55,10 @@ const (
 	ContextGatheringPrompt PromptType = "context_gathering"
 )
 
+// MaxExtraContextBytes caps the combined size of the extra context files
+// included in the review prompt.
+const MaxExtraContextBytes = 32 * 1024
+
 // ErrUnknownPromptType is returned when an unknown prompt type is requested.
 var ErrUnknownPromptType = errors.New("unknown prompt type")
 
@@ -54,17 +66,36 @@ var ErrUnknownPromptType = errors.New("unknown prompt type")
 type Manager struct {
 	reviewPromptPath           string
 	contextGatheringPromptPath string
+	// reviewPromptPathsByExt maps a lowercase file extension, without the
+	// dot, to the review prompt used when it dominates the change.
+	reviewPromptPathsByExt map[string]string
+	// extraContextFiles are read, relative to the config directory, into
+	// the review prompt's ExtraContext.
+	extraContextFiles []string
 	// configDir is the lgtmcp config directory used to validate custom
 	// prompt paths. When empty, [config.Dir] is used at validation
 	// time. Tests may override it via [Manager.SetConfigDir].
 	configDir string
 }
 
-// New creates a new prompt manager.
-func New(reviewPromptPath, contextGatheringPromptPath string) *Manager {
+// New creates a new prompt manager. reviewPromptsByExt maps file extensions
+// ("go", ".py"; case and a leading dot are ignored) to review prompt paths
+// that replace reviewPromptPath for changes dominated by that extension; it
+// may be nil. extraContextFiles are files of team conventions whose contents
+// are given to the review prompt as ExtraContext.
+//
+//nolint:lll // Long function signature
+func New(reviewPromptPath, contextGatheringPromptPath string, reviewPromptsByExt map[string]string, extraContextFiles []string) *Manager {
+	byExt := make(map[string]string, len(reviewPromptsByExt))
+	for ext, p := range reviewPromptsByExt {
+		byExt[normalizeExt(ext)] = p
+	}
+
 	return &Manager{
 		reviewPromptPath:           reviewPromptPath,
 		contextGatheringPromptPath: contextGatheringPromptPath,
+		reviewPromptPathsByExt:     byExt,
+		extraContextFiles:          extraContextFiles,
 	}
 }
 
@@ -91,37 +122,55 @@ func (m *Manager) LoadPrompt(promptType PromptType) (string, error) {
 		return "", fmt.Errorf("%w: %s", ErrUnknownPromptType, promptType)
 	}
 
-	// If no custom path specified, use the default. The embedded defaults
-	// carry an Apache license header as a leading HTML comment for source-file
-	// compliance; strip it here so the boilerplate never reaches the model and
-	// wastes context tokens. Custom prompt files are returned verbatim — a
-	// leading comment there may be intentional.
-	if path == "" {
-		return stripLeadingComment(defaultPrompt), nil
-	}
+	return m.loadPromptFile(path, defaultPrompt)
+}
 
-	// Reject traversal and absolute paths outside the lgtmcp config directory
-	// so that a compromised config cannot exfiltrate arbitrary files via the
-	// review prompt.
-	configDir := m.configDir
-	if configDir == "" {
-		dir, err := config.Dir()
-		if err != nil {
-			return "", fmt.Errorf("cannot determine config directory: %w", err)
-		}
-		configDir = dir
+// LoadSystemInstruction returns the system instruction sent with both review
+// phases: the static reviewer persona and output rules, kept apart from the
+// per-review prompts so that custom prompt templates need not repeat them.
+func (m *Manager) LoadSystemInstruction() string {
+	return stripLeadingComment(defaultSystemInstruction)
+}
+
+// Validate reads the extra context files and loads and renders every
+// configured custom prompt template with empty data, so that an unreadable
+// file, a syntax error, or a reference to an unknown field is reported at
+// startup rather than on the first review.
+func (m *Manager) Validate() error {
+	type check struct {
+		name, path string
+		data       any
 	}
-	safePath, err := config.ValidatePathIn(path, configDir)
-	if err != nil {
-		return "", fmt.Errorf("invalid prompt path: %w", err)
+	checks := []check{
+		{"review", m.reviewPromptPath, ReviewPromptData{}},
+		{"context gathering", m.contextGatheringPromptPath, ContextGatheringPromptData{}},
+	}
+	for _, ext := range slices.Sorted(maps.Keys(m.reviewPromptPathsByExt)) {
+		checks = append(checks, check{"review (" + ext + ")", m.reviewPromptPathsByExt[ext], ReviewPromptData{}})
 	}
 
-	content, err := os.ReadFile(safePath) //nolint:gosec // Path validated by config.ValidatePathIn
-	if err != nil {
-		return "", fmt.Errorf("failed to read prompt file %s: %w", path, err)
+	if _, err := m.extraContext(); err != nil {
+		return err
 	}
 
-	return string(content), nil
+	for _, c := range checks {
+		if c.path == "" {
+			continue
+		}
+		text, err := m.loadPromptFile(c.path, "")
+		if err != nil {
+			return fmt.Errorf("invalid %s prompt: %w", c.name, err)
+		}
+		tmpl, err := newTemplate(c.name).Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid %s prompt %s: %w", c.name, c.path, err)
+		}
+		if err := tmpl.Execute(io.Discard, c.data); err != nil {
+			return fmt.Errorf("invalid %s prompt %s: %w", c.name, c.path, err)
+		}
+	}
+
+	return nil
 }
 
 // ReviewPromptData contains the data for the review prompt template.
@@ -135,19 +184,47 @@ type ReviewPromptData struct {
 	DeletedFilesList  string
 	Diff              string
 	CurrentDate       string
+	// RepoName is the base name of the repository's directory.
+	RepoName string
+	// FileCount is the number of changed paths, including deletions.
+	FileCount int
+	// Language names the programming language most of the changed files are
+	// written in, inferred from their extensions; empty when none is known.
+	Language string
+	// Languages lists every language among the changed files, as returned by
+	// [DetectLanguages], joined by ", "; empty when none is known.
+	Languages string
+	// ExtraContext holds the contents of the configured extra context files,
+	// such as a team style guide; empty when none are configured.
+	ExtraContext string
+	// UserInstructions holds instructions the caller gave for this review
+	// only, such as what to focus on; empty when none were given.
+	UserInstructions string
+	// DiffStat is a "git diff --stat" summary of the change; empty unless
+	// gemini.include_diffstat is set.
+	DiffStat string
 }
 
 // BuildReviewPrompt builds the review prompt from template with the given data.
 // deletedFiles must be a subset of changedFiles; paths in it are listed as
-// deletions and excluded from the existing-files section.
+// deletions and excluded from the existing-files section. When a review
+// prompt is configured for the extension most common among changedFiles, it
+// is used in place of the general one. userInstructions holds the caller's
+// instructions for this review only. repoName is the base name of the
+// repository's directory. diffStat is a summary of the diff, or empty.
 //
 //nolint:lll // Long function signature
-func (m *Manager) BuildReviewPrompt(diff string, changedFiles, deletedFiles []string, analysisText, instructions string) (string, error) {
-	promptTemplate, err := m.LoadPrompt(ReviewPrompt)
+func (m *Manager) BuildReviewPrompt(diff string, changedFiles, deletedFiles []string, analysisText, instructions, userInstructions, repoName, diffStat string) (string, error) {
+	promptTemplate, err := m.loadPromptFile(m.reviewPromptPathFor(changedFiles), defaultReviewPrompt)
 	if err != nil {
 		return "", fmt.Errorf("failed to load review prompt: %w", err)
 	}
 
+	extra, err := m.extraContext()
+	if err != nil {
+		return "", err
+	}
+
 	existing, deleted := splitFiles(changedFiles, deletedFiles)
 
 	// Include the analysis from the first phase if available.
@@ -164,9 +241,16 @@ func (m *Manager) BuildReviewPrompt(diff string, changedFiles, deletedFiles []st
 		DeletedFilesList:    strings.Join(deleted, "\n- "),
 		Diff:                diff,
 		CurrentDate:         time.Now().Format("January 2, 2006"),
+		RepoName:            repoName,
+		FileCount:           len(changedFiles),
+		Language:            inferLanguage(changedFiles),
+		Languages:           strings.Join(DetectLanguages(changedFiles), ", "),
+		ExtraContext:        extra,
+		UserInstructions:    userInstructions,
+		DiffStat:            diffStat,
 	}
 
-	tmpl, err := template.New("review").Parse(promptTemplate)
+	tmpl, err := newTemplate("review").Parse(promptTemplate)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse review prompt template: %w", err)
 	}
@@ -188,14 +272,29 @@ type ContextGatheringPromptData struct {
 	ExistingFilesList string
 	DeletedFilesList  string
 	Diff              string
+	// RecentCommits lists the subjects of the repository's most recent
+	// commits, newest first, joined like FilesList; empty when disabled.
+	RecentCommits string
+	// RepoName, FileCount, Language, Languages, UserInstructions, and
+	// DiffStat are as in [ReviewPromptData].
+	RepoName         string
+	FileCount        int
+	Language         string
+	Languages        string
+	UserInstructions string
+	DiffStat         string
 }
 
 // BuildContextGatheringPrompt builds the context gathering prompt from template with the given data.
 // deletedFiles must be a subset of changedFiles; paths in it are listed as
-// deletions and excluded from the existing-files section.
+// deletions and excluded from the existing-files section. recentCommits holds
+// recent commit subjects, newest first, given as background. userInstructions
+// holds the caller's instructions for this review only. repoName is the base
+// name of the repository's directory. diffStat is a summary of the diff, or
+// empty.
 //
 //nolint:lll // Long function signature
-func (m *Manager) BuildContextGatheringPrompt(diff string, changedFiles, deletedFiles []string, instructions string) (string, error) {
+func (m *Manager) BuildContextGatheringPrompt(diff string, changedFiles, deletedFiles []string, instructions, userInstructions string, recentCommits []string, repoName, diffStat string) (string, error) {
 	promptTemplate, err := m.LoadPrompt(ContextGatheringPrompt)
 	if err != nil {
 		return "", fmt.Errorf("failed to load context gathering prompt: %w", err)
@@ -209,9 +308,16 @@ func (m *Manager) BuildContextGatheringPrompt(diff string, changedFiles, deleted
 		ExistingFilesList:   strings.Join(existing, "\n- "),
 		DeletedFilesList:    strings.Join(deleted, "\n- "),
 		Diff:                diff,
+		RecentCommits:       strings.Join(recentCommits, "\n- "),
+		RepoName:            repoName,
+		FileCount:           len(changedFiles),
+		Language:            inferLanguage(changedFiles),
+		Languages:           strings.Join(DetectLanguages(changedFiles), ", "),
+		UserInstructions:    userInstructions,
+		DiffStat:            diffStat,
 	}
 
-	tmpl, err := template.New("context").Parse(promptTemplate)
+	tmpl, err := newTemplate("context").Parse(promptTemplate)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse context gathering prompt template: %w", err)
 	}
@@ -266,3 +372,232 @@ func splitFiles(changedFiles, deletedFiles []string) (existing, deleted []string
 	}
 	return existing, deleted
 }
+
+// loadPromptFile reads the prompt at path, which must lie in the lgtmcp
+// config directory, or returns defaultPrompt when path is empty.
+func (m *Manager) loadPromptFile(path, defaultPrompt string) (string, error) {
+	// If no custom path specified, use the default. The embedded defaults
+	// carry an Apache license header as a leading HTML comment for source-file
+	// compliance; strip it here so the boilerplate never reaches the model and
+	// wastes context tokens. Custom prompt files are returned verbatim — a
+	// leading comment there may be intentional.
+	if path == "" {
+		return stripLeadingComment(defaultPrompt), nil
+	}
+
+	safePath, err := m.resolvePath(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid prompt path: %w", err)
+	}
+
+	content, err := os.ReadFile(safePath) //nolint:gosec // Path validated by config.ValidatePathIn
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt file %s: %w", path, err)
+	}
+
+	return string(content), nil
+}
+
+// resolvePath returns the absolute form of a config-supplied path, which
+// must lie in the lgtmcp config directory. Traversal and absolute paths
+// outside it are rejected so that a compromised config cannot exfiltrate
+// arbitrary files via the prompts.
+func (m *Manager) resolvePath(path string) (string, error) {
+	configDir := m.configDir
+	if configDir == "" {
+		dir, err := config.Dir()
+		if err != nil {
+			return "", fmt.Errorf("cannot determine config directory: %w", err)
+		}
+		configDir = dir
+	}
+
+	return config.ValidatePathIn(path, configDir)
+}
+
+// extraContext returns the concatenated contents of the extra context files,
+// each under a heading naming it, cut to MaxExtraContextBytes.
+func (m *Manager) extraContext() (string, error) {
+	var sb strings.Builder
+	for _, path := range m.extraContextFiles {
+		safePath, err := m.resolvePath(path)
+		if err != nil {
+			return "", fmt.Errorf("invalid extra context path: %w", err)
+		}
+		content, err := os.ReadFile(safePath) //nolint:gosec // Path validated by config.ValidatePathIn
+		if err != nil {
+			return "", fmt.Errorf("failed to read extra context file %s: %w", path, err)
+		}
+		if sb.Len() > 0 {
+			_, _ = sb.WriteString("\n\n")
+		}
+		_, _ = fmt.Fprintf(&sb, "### %s\n\n%s", path, strings.TrimSpace(string(content)))
+	}
+
+	out := sb.String()
+	if len(out) > MaxExtraContextBytes {
+		// Drop any rune split by the cut.
+		out = strings.ToValidUTF8(out[:MaxExtraContextBytes], "") + truncatedMarker
+	}
+
+	return out, nil
+}
+
+// truncatedMarker is appended by the truncate template function to text it
+// shortened.
+const truncatedMarker = "\n... (truncated)"
+
+// templateFuncs are the helper functions available to prompt templates:
+//
+//   - truncate s n: s cut to at most n characters, followed by a marker
+//     line if anything was removed
+//   - indent s n: s with every non-empty line prefixed by n spaces
+//   - split s sep: the substrings of s separated by sep
+//   - join list sep: the elements of list joined by sep
+var templateFuncs = template.FuncMap{
+	"truncate": truncate,
+	"indent":   indent,
+	"split":    strings.Split,
+	"join":     strings.Join,
+}
+
+// newTemplate returns an empty prompt template with templateFuncs defined.
+func newTemplate(name string) *template.Template {
+	return template.New(name).Funcs(templateFuncs)
+}
+
+// truncate returns s cut to at most n runes, followed by truncatedMarker
+// when anything was cut.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	n = max(n, 0)
+	i := 0
+	for range n {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+
+	return s[:i] + truncatedMarker
+}
+
+// indent prefixes every non-empty line of s with n spaces.
+func indent(s string, n int) string {
+	pad := strings.Repeat(" ", max(n, 0))
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = pad + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// languageByExt names the language of source files by lowercase extension,
+// for the Language template variable.
+var languageByExt = map[string]string{
+	"c":     "C",
+	"h":     "C",
+	"cc":    "C++",
+	"cpp":   "C++",
+	"cxx":   "C++",
+	"hpp":   "C++",
+	"cs":    "C#",
+	"dart":  "Dart",
+	"ex":    "Elixir",
+	"exs":   "Elixir",
+	"go":    "Go",
+	"hs":    "Haskell",
+	"java":  "Java",
+	"js":    "JavaScript",
+	"jsx":   "JavaScript",
+	"mjs":   "JavaScript",
+	"cjs":   "JavaScript",
+	"kt":    "Kotlin",
+	"kts":   "Kotlin",
+	"lua":   "Lua",
+	"m":     "Objective-C",
+	"php":   "PHP",
+	"pl":    "Perl",
+	"py":    "Python",
+	"rb":    "Ruby",
+	"rs":    "Rust",
+	"scala": "Scala",
+	"sh":    "Shell",
+	"bash":  "Shell",
+	"sql":   "SQL",
+	"swift": "Swift",
+	"ts":    "TypeScript",
+	"tsx":   "TypeScript",
+	"zig":   "Zig",
+}
+
+// inferLanguage returns the language most of files are written in, judged
+// by extension and breaking ties by name, or "" when no file has a known
+// source extension.
+func inferLanguage(files []string) string {
+	counts := make(map[string]int)
+	for _, f := range files {
+		if lang, ok := languageByExt[normalizeExt(filepath.Ext(f))]; ok {
+			counts[lang]++
+		}
+	}
+
+	return mostCommon(counts)
+}
+
+// DetectLanguages returns the distinct programming languages of files, judged
+// by extension, sorted by name. Files without a known source extension, such
+// as Markdown or a Makefile, are ignored, so the result may be empty.
+func DetectLanguages(files []string) []string {
+	var langs []string
+	for _, f := range files {
+		if lang, ok := languageByExt[normalizeExt(filepath.Ext(f))]; ok && !slices.Contains(langs, lang) {
+			langs = append(langs, lang)
+		}
+	}
+	slices.Sort(langs)
+
+	return langs
+}
+
+// mostCommon returns the key with the highest count, breaking ties by the
+// lesser key, or "" when counts is empty.
+func mostCommon(counts map[string]int) string {
+	best := ""
+	for k, n := range counts {
+		if best == "" || n > counts[best] || (n == counts[best] && k < best) {
+			best = k
+		}
+	}
+
+	return best
+}
+
+// normalizeExt lowercases ext and strips a leading dot.
+func normalizeExt(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(ext, "."))
+}
+
+// reviewPromptPathFor returns the review prompt path for a change to
+// changedFiles: the language-specific prompt for the extension with a
+// configured prompt that the most files share, breaking ties by extension
+// name, or the general review prompt path when no file has one.
+func (m *Manager) reviewPromptPathFor(changedFiles []string) string {
+	counts := make(map[string]int)
+	for _, f := range changedFiles {
+		ext := normalizeExt(filepath.Ext(f))
+		if _, ok := m.reviewPromptPathsByExt[ext]; ok && ext != "" {
+			counts[ext]++
+		}
+	}
+
+	best := mostCommon(counts)
+	if best == "" {
+		return m.reviewPromptPath
+	}
+
+	return m.reviewPromptPathsByExt[best]
+}
@@ -33,16 +33,17 @@ func TestManager_LoadPrompt(t *testing.T) {
 
 	t.Run("load default review prompt", func(t *testing.T) {
 		t.Parallel()
-		m := New("", "")
+		m := New("", "", nil, nil)
 		prompt, err := m.LoadPrompt(ReviewPrompt)
 		require.NoError(t, err)
-		assert.Contains(t, prompt, "strict code reviewer")
-		assert.Contains(t, prompt, "lgtm")
+		assert.Contains(t, prompt, "identify ALL issues")
+		assert.Contains(t, prompt, `"status": "approved"`)
+		assert.Contains(t, prompt, `"status": "needs_human"`)
 	})
 
 	t.Run("load default context gathering prompt", func(t *testing.T) {
 		t.Parallel()
-		m := New("", "")
+		m := New("", "", nil, nil)
 		prompt, err := m.LoadPrompt(ContextGatheringPrompt)
 		require.NoError(t, err)
 		assert.Contains(t, prompt, "analyzing code changes")
@@ -51,7 +52,7 @@ func TestManager_LoadPrompt(t *testing.T) {
 
 	t.Run("default prompts omit the embedded license header", func(t *testing.T) {
 		t.Parallel()
-		m := New("", "")
+		m := New("", "", nil, nil)
 
 		review, err := m.LoadPrompt(ReviewPrompt)
 		require.NoError(t, err)
@@ -65,11 +66,21 @@ func TestManager_LoadPrompt(t *testing.T) {
 				"prompt should not start with the license comment")
 		}
 		// Content after the stripped header survives intact.
-		assert.Contains(t, review, "strict code reviewer")
+		assert.Contains(t, review, "identify ALL issues")
 		assert.True(t, strings.HasPrefix(review, "# Code Review Prompt"),
 			"review prompt should begin at its first heading")
 	})
 
+	t.Run("system instruction carries the persona", func(t *testing.T) {
+		t.Parallel()
+		m := New("", "", nil, nil)
+		instruction := m.LoadSystemInstruction()
+		assert.Contains(t, instruction, "strict code reviewer")
+		assert.Contains(t, instruction, "single JSON object")
+		assert.NotContains(t, instruction, "Licensed under the Apache License")
+		assert.NotContains(t, instruction, "{{", "the system instruction is not a template")
+	})
+
 	t.Run("load custom review prompt from file", func(t *testing.T) {
 		t.Parallel()
 		tmpDir := t.TempDir()
@@ -78,7 +89,7 @@ func TestManager_LoadPrompt(t *testing.T) {
 		err := os.WriteFile(customPromptPath, []byte(customContent), 0o600)
 		require.NoError(t, err)
 
-		m := New(customPromptPath, "")
+		m := New(customPromptPath, "", nil, nil)
 		m.SetConfigDir(tmpDir)
 		prompt, err := m.LoadPrompt(ReviewPrompt)
 		require.NoError(t, err)
@@ -89,7 +100,7 @@ func TestManager_LoadPrompt(t *testing.T) {
 		t.Parallel()
 		tmpDir := t.TempDir()
 		missing := filepath.Join(tmpDir, "missing.md")
-		m := New(missing, "")
+		m := New(missing, "", nil, nil)
 		m.SetConfigDir(tmpDir)
 		_, err := m.LoadPrompt(ReviewPrompt)
 		require.Error(t, err)
@@ -98,7 +109,7 @@ func TestManager_LoadPrompt(t *testing.T) {
 
 	t.Run("unknown prompt type", func(t *testing.T) {
 		t.Parallel()
-		m := New("", "")
+		m := New("", "", nil, nil)
 		_, err := m.LoadPrompt(PromptType("unknown"))
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "unknown prompt type")
@@ -110,27 +121,27 @@ func TestManager_BuildReviewPrompt(t *testing.T) {
 
 	t.Run("build review prompt with analysis", func(t *testing.T) {
 		t.Parallel()
-		m := New("", "")
+		m := New("", "", nil, nil)
 		diff := testDiffGitHeader
 		changedFiles := []string{"main.go", "test.go"}
 		analysisText := "The code looks good overall"
 
-		prompt, err := m.BuildReviewPrompt(diff, changedFiles, nil, analysisText, "")
+		prompt, err := m.BuildReviewPrompt(diff, changedFiles, nil, analysisText, "", "", "", "")
 		require.NoError(t, err)
 		assert.Contains(t, prompt, diff)
 		assert.Contains(t, prompt, "main.go")
 		assert.Contains(t, prompt, "test.go")
 		assert.Contains(t, prompt, analysisText)
-		assert.Contains(t, prompt, "strict code reviewer")
+		assert.Contains(t, prompt, "identify ALL issues")
 	})
 
 	t.Run("build review prompt without analysis", func(t *testing.T) {
 		t.Parallel()
-		m := New("", "")
+		m := New("", "", nil, nil)
 		diff := testDiffGitHeader
 		changedFiles := []string{"main.go"}
 
-		prompt, err := m.BuildReviewPrompt(diff, changedFiles, nil, "", "")
+		prompt, err := m.BuildReviewPrompt(diff, changedFiles, nil, "", "", "", "", "")
 		require.NoError(t, err)
 		assert.Contains(t, prompt, diff)
 		assert.Contains(t, prompt, "main.go")
@@ -145,9 +156,9 @@ func TestManager_BuildReviewPrompt(t *testing.T) {
 		err := os.WriteFile(customPromptPath, []byte(customContent), 0o600)
 		require.NoError(t, err)
 
-		m := New(customPromptPath, "")
+		m := New(customPromptPath, "", nil, nil)
 		m.SetConfigDir(tmpDir)
-		prompt, err := m.BuildReviewPrompt("test diff", []string{"file1.go"}, nil, "", "")
+		prompt, err := m.BuildReviewPrompt("test diff", []string{"file1.go"}, nil, "", "", "", "", "")
 		require.NoError(t, err)
 		assert.Contains(t, prompt, "Custom: test diff")
 		assert.Contains(t, prompt, "Files: file1.go")
@@ -161,9 +172,9 @@ func TestManager_BuildReviewPrompt(t *testing.T) {
 		err := os.WriteFile(customPromptPath, []byte(customContent), 0o600)
 		require.NoError(t, err)
 
-		m := New(customPromptPath, "")
+		m := New(customPromptPath, "", nil, nil)
 		m.SetConfigDir(tmpDir)
-		_, err = m.BuildReviewPrompt("test", []string{"file.go"}, nil, "", "")
+		_, err = m.BuildReviewPrompt("test", []string{"file.go"}, nil, "", "", "", "", "")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to parse review prompt template")
 	})
@@ -174,11 +185,11 @@ func TestManager_BuildContextGatheringPrompt(t *testing.T) {
 
 	t.Run("build context gathering prompt", func(t *testing.T) {
 		t.Parallel()
-		m := New("", "")
+		m := New("", "", nil, nil)
 		diff := testDiffGitHeader
 		changedFiles := []string{"main.go", "lib.go"}
 
-		prompt, err := m.BuildContextGatheringPrompt(diff, changedFiles, nil, "")
+		prompt, err := m.BuildContextGatheringPrompt(diff, changedFiles, nil, "", "", nil, "", "")
 		require.NoError(t, err)
 		assert.Contains(t, prompt, diff)
 		assert.Contains(t, prompt, "main.go")
@@ -194,26 +205,216 @@ func TestManager_BuildContextGatheringPrompt(t *testing.T) {
 		err := os.WriteFile(customPromptPath, []byte(customContent), 0o600)
 		require.NoError(t, err)
 
-		m := New("", customPromptPath)
+		m := New("", customPromptPath, nil, nil)
 		m.SetConfigDir(tmpDir)
-		prompt, err := m.BuildContextGatheringPrompt("test diff", []string{"file1.go", "file2.go"}, nil, "")
+		prompt, err := m.BuildContextGatheringPrompt("test diff", []string{"file1.go", "file2.go"}, nil, "", "", nil, "", "")
 		require.NoError(t, err)
 		assert.Contains(t, prompt, "Analyze: test diff")
 		assert.Contains(t, prompt, "file1.go")
 	})
 }
 
+func TestManager_BuildReviewPrompt_ByExtension(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	for name, content := range map[string]string{
+		"general.md": "general {{.Diff}}",
+		"go.md":      "go {{.Diff}}",
+		"python.md":  "python {{.Diff}}",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o600))
+	}
+	m := New("general.md", "", map[string]string{"go": "go.md", ".PY": "python.md"}, nil)
+	m.SetConfigDir(tmpDir)
+
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"dominant extension", []string{"a.go", "b.go", "c.py"}, "go d"},
+		{"case and dot ignored in config", []string{"tool.py"}, "python d"},
+		{"unmapped extensions do not count", []string{"a.md", "b.md", "c.py"}, "python d"},
+		{"tie broken by extension name", []string{"a.py", "b.go"}, "go d"},
+		{"no match falls back", []string{"README.md", "Makefile"}, "general d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := m.BuildReviewPrompt("d", tt.files, nil, "", "", "", "", "")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestManager_RepoTemplateVariables(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	tmpl := "{{.RepoName}} {{.FileCount}} {{.Language}} ({{.Languages}})"
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "review.md"), []byte(tmpl), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "context.md"), []byte(tmpl), 0o600))
+	m := New("review.md", "context.md", nil, nil)
+	m.SetConfigDir(tmpDir)
+	files := []string{"main.go", "util.go", "script.py", "README.md"}
+
+	review, err := m.BuildReviewPrompt("d", files, nil, "", "", "", "lgtmcp", "")
+	require.NoError(t, err)
+	assert.Equal(t, "lgtmcp 4 Go (Go, Python)", review)
+
+	ctx, err := m.BuildContextGatheringPrompt("d", files, nil, "", "", nil, "lgtmcp", "")
+	require.NoError(t, err)
+	assert.Equal(t, "lgtmcp 4 Go (Go, Python)", ctx)
+}
+
+func TestManager_ExtraContext(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "STYLE.md"), []byte("Wrap errors with %w.\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "CONTRIBUTING.md"), []byte("Add tests."), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "huge.md"),
+		[]byte(strings.Repeat("x", MaxExtraContextBytes+1)), 0o600))
+
+	t.Run("default prompt includes the files in order", func(t *testing.T) {
+		t.Parallel()
+		m := New("", "", nil, []string{"STYLE.md", "CONTRIBUTING.md"})
+		m.SetConfigDir(tmpDir)
+		prompt, err := m.BuildReviewPrompt("diff", []string{"main.go"}, nil, "", "", "", "", "")
+		require.NoError(t, err)
+		assert.Contains(t, prompt, "## Team Review Conventions")
+		assert.Contains(t, prompt, "### STYLE.md\n\nWrap errors with %w.\n\n### CONTRIBUTING.md\n\nAdd tests.")
+	})
+
+	t.Run("section omitted when unset", func(t *testing.T) {
+		t.Parallel()
+		prompt, err := New("", "", nil, nil).BuildReviewPrompt("diff", []string{"main.go"}, nil, "", "", "", "", "")
+		require.NoError(t, err)
+		assert.NotContains(t, prompt, "Team Review Conventions")
+	})
+
+	t.Run("size capped", func(t *testing.T) {
+		t.Parallel()
+		m := New("", "", nil, []string{"huge.md"})
+		m.SetConfigDir(tmpDir)
+		extra, err := m.extraContext()
+		require.NoError(t, err)
+		assert.Len(t, extra, MaxExtraContextBytes+len(truncatedMarker))
+		assert.True(t, strings.HasSuffix(extra, truncatedMarker))
+	})
+
+	t.Run("paths confined to the config directory", func(t *testing.T) {
+		t.Parallel()
+		m := New("", "", nil, []string{"../outside.md"})
+		m.SetConfigDir(tmpDir)
+		require.ErrorIs(t, m.Validate(), config.ErrPathTraversal)
+		_, err := m.BuildReviewPrompt("diff", []string{"main.go"}, nil, "", "", "", "", "")
+		require.ErrorIs(t, err, config.ErrPathTraversal)
+	})
+
+	t.Run("missing file fails validation", func(t *testing.T) {
+		t.Parallel()
+		m := New("", "", nil, []string{"missing.md"})
+		m.SetConfigDir(tmpDir)
+		require.ErrorContains(t, m.Validate(), "failed to read extra context file missing.md")
+	})
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	t.Parallel()
+
+	t.Run("truncate", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, "short", truncate("short", 5))
+		assert.Equal(t, "shor"+truncatedMarker, truncate("short", 4))
+		assert.Equal(t, "héé"+truncatedMarker, truncate("héééé", 3), "counts characters, not bytes")
+		assert.Equal(t, truncatedMarker, truncate("abc", -1))
+	})
+
+	t.Run("indent", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, "  a\n\n  b", indent("a\n\nb", 2))
+		assert.Equal(t, "a", indent("a", 0))
+	})
+
+	t.Run("in a custom template", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := t.TempDir()
+		tmpl := `{{ truncate .Diff 4 }}|{{ indent .InstructionsSection 2 }}|{{ join (split .FilesList "\n- ") ", " }}`
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "review.md"), []byte(tmpl), 0o600))
+		m := New("review.md", "", nil, nil)
+		m.SetConfigDir(tmpDir)
+		require.NoError(t, m.Validate())
+
+		got, err := m.BuildReviewPrompt("0123456789", []string{"a.go", "b.go"}, nil, "", "rule", "", "", "")
+		require.NoError(t, err)
+		assert.Equal(t, "0123"+truncatedMarker+"|  rule|a.go, b.go", got)
+	})
+}
+
+func TestInferLanguage(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "Python", inferLanguage([]string{"a.py", "b.PY", "c.go"}))
+	assert.Equal(t, "TypeScript", inferLanguage([]string{"app.tsx", "lib.ts", "x.js"}))
+	assert.Equal(t, "Go", inferLanguage([]string{"a.py", "b.go"}), "ties break by name")
+	assert.Empty(t, inferLanguage([]string{"README.md", "Makefile"}))
+	assert.Empty(t, inferLanguage(nil))
+}
+
+func TestDetectLanguages(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"Go", "Python", "TypeScript"},
+		DetectLanguages([]string{"b.py", "a.go", "c.PY", "app.tsx", "lib.ts", "README.md"}))
+	assert.Empty(t, DetectLanguages([]string{"README.md", "Makefile"}))
+	assert.Empty(t, DetectLanguages(nil))
+}
+
+func TestBuildReviewPrompt_Languages(t *testing.T) {
+	t.Parallel()
+	m := New("", "", nil, nil)
+
+	prompt, err := m.BuildReviewPrompt("d", []string{"main.go", "tool.py"}, nil, "", "", "", "repo", "")
+	require.NoError(t, err)
+	assert.Contains(t, prompt, "Languages in this change: Go, Python.")
+
+	prompt, err = m.BuildReviewPrompt("d", []string{"README.md"}, nil, "", "", "", "repo", "")
+	require.NoError(t, err)
+	assert.NotContains(t, prompt, "Languages in this change")
+}
+
+func TestBuildPrompts_DiffStat(t *testing.T) {
+	t.Parallel()
+	m := New("", "", nil, nil)
+	const stat = " main.go | 2 +-\n 1 file changed, 1 insertion(+), 1 deletion(-)"
+
+	review, err := m.BuildReviewPrompt("d", []string{"main.go"}, nil, "", "", "", "repo", stat)
+	require.NoError(t, err)
+	ctx, err := m.BuildContextGatheringPrompt("d", []string{"main.go"}, nil, "", "", nil, "repo", stat)
+	require.NoError(t, err)
+	for _, prompt := range []string{review, ctx} {
+		assert.Contains(t, prompt, "git diff --stat):\n\n```\n"+stat+"\n```\n\nGit diff to")
+	}
+
+	review, err = m.BuildReviewPrompt("d", []string{"main.go"}, nil, "", "", "", "repo", "")
+	require.NoError(t, err)
+	assert.NotContains(t, review, "git diff --stat")
+}
+
 func TestManager_BuildReviewPromptWithInstructions(t *testing.T) {
 	t.Parallel()
 
 	t.Run("with agent instructions", func(t *testing.T) {
 		t.Parallel()
-		m := New("", "")
+		m := New("", "", nil, nil)
 		diff := testDiffGitHeader
 		changedFiles := []string{"main.go"}
 		instructions := "## Agent Instructions\n\nAlways check for tests."
 
-		prompt, err := m.BuildReviewPrompt(diff, changedFiles, nil, "", instructions)
+		prompt, err := m.BuildReviewPrompt(diff, changedFiles, nil, "", instructions, "", "", "")
 		require.NoError(t, err)
 		assert.Contains(t, prompt, "Agent Instructions")
 		assert.Contains(t, prompt, "Always check for tests")
@@ -221,11 +422,11 @@ func TestManager_BuildReviewPromptWithInstructions(t *testing.T) {
 
 	t.Run("without agent instructions", func(t *testing.T) {
 		t.Parallel()
-		m := New("", "")
+		m := New("", "", nil, nil)
 		diff := testDiffGitHeader
 		changedFiles := []string{"main.go"}
 
-		prompt, err := m.BuildReviewPrompt(diff, changedFiles, nil, "", "")
+		prompt, err := m.BuildReviewPrompt(diff, changedFiles, nil, "", "", "", "", "")
 		require.NoError(t, err)
 		assert.NotContains(t, prompt, "Agent Instructions")
 	})
@@ -236,12 +437,12 @@ func TestManager_BuildContextGatheringPromptWithInstructions(t *testing.T) {
 
 	t.Run("with agent instructions", func(t *testing.T) {
 		t.Parallel()
-		m := New("", "")
+		m := New("", "", nil, nil)
 		diff := testDiffGitHeader
 		changedFiles := []string{"main.go"}
 		instructions := "## Agent Instructions\n\nCheck security carefully."
 
-		prompt, err := m.BuildContextGatheringPrompt(diff, changedFiles, nil, instructions)
+		prompt, err := m.BuildContextGatheringPrompt(diff, changedFiles, nil, instructions, "", nil, "", "")
 		require.NoError(t, err)
 		assert.Contains(t, prompt, "Agent Instructions")
 		assert.Contains(t, prompt, "Check security carefully")
@@ -249,28 +450,61 @@ func TestManager_BuildContextGatheringPromptWithInstructions(t *testing.T) {
 
 	t.Run("without agent instructions", func(t *testing.T) {
 		t.Parallel()
-		m := New("", "")
+		m := New("", "", nil, nil)
 		diff := testDiffGitHeader
 		changedFiles := []string{"main.go"}
 
-		prompt, err := m.BuildContextGatheringPrompt(diff, changedFiles, nil, "")
+		prompt, err := m.BuildContextGatheringPrompt(diff, changedFiles, nil, "", "", nil, "", "")
 		require.NoError(t, err)
 		assert.NotContains(t, prompt, "Agent Instructions")
+		assert.NotContains(t, prompt, "most recent commits")
 	})
+
+	t.Run("with recent commits", func(t *testing.T) {
+		t.Parallel()
+		m := New("", "", nil, nil)
+		prompt, err := m.BuildContextGatheringPrompt("diff", []string{"main.go"}, nil, "", "",
+			[]string{"Fix retry loop", "Add config flag"}, "", "")
+		require.NoError(t, err)
+		assert.Contains(t, prompt, "most recent commits in this repository, newest first")
+		assert.Contains(t, prompt, "- Fix retry loop\n- Add config flag")
+	})
+}
+
+func TestManager_UserInstructions(t *testing.T) {
+	t.Parallel()
+	m := New("", "", nil, nil)
+	const focus = "Check error handling in the retry path."
+
+	review, err := m.BuildReviewPrompt("diff", []string{"main.go"}, nil, "", "", focus, "", "")
+	require.NoError(t, err)
+	assert.Contains(t, review, "## Instructions for This Review")
+	assert.Contains(t, review, focus)
+
+	ctx, err := m.BuildContextGatheringPrompt("diff", []string{"main.go"}, nil, "", focus, nil, "", "")
+	require.NoError(t, err)
+	assert.Contains(t, ctx, focus)
+
+	review, err = m.BuildReviewPrompt("diff", []string{"main.go"}, nil, "", "", "", "", "")
+	require.NoError(t, err)
+	assert.NotContains(t, review, "Instructions for This Review")
+	ctx, err = m.BuildContextGatheringPrompt("diff", []string{"main.go"}, nil, "", "", nil, "", "")
+	require.NoError(t, err)
+	assert.NotContains(t, ctx, "Whoever requested this review")
 }
 
 func TestBuildReviewPrompt_LoadPromptError(t *testing.T) {
 	t.Parallel()
-	m := New("/nonexistent/review.md", "")
-	_, err := m.BuildReviewPrompt("diff", []string{"file.go"}, nil, "", "")
+	m := New("/nonexistent/review.md", "", nil, nil)
+	_, err := m.BuildReviewPrompt("diff", []string{"file.go"}, nil, "", "", "", "", "")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to load review prompt")
 }
 
 func TestBuildContextGatheringPrompt_LoadPromptError(t *testing.T) {
 	t.Parallel()
-	m := New("", "/nonexistent/context.md")
-	_, err := m.BuildContextGatheringPrompt("diff", []string{"file.go"}, nil, "")
+	m := New("", "/nonexistent/context.md", nil, nil)
+	_, err := m.BuildContextGatheringPrompt("diff", []string{"file.go"}, nil, "", "", nil, "", "")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to load context gathering prompt")
 }
@@ -282,9 +516,9 @@ func TestBuildContextGatheringPrompt_TemplateParseError(t *testing.T) {
 	err := os.WriteFile(customPromptPath, []byte("{{.Broken"), 0o600)
 	require.NoError(t, err)
 
-	m := New("", customPromptPath)
+	m := New("", customPromptPath, nil, nil)
 	m.SetConfigDir(tmpDir)
-	_, err = m.BuildContextGatheringPrompt("diff", []string{"file.go"}, nil, "")
+	_, err = m.BuildContextGatheringPrompt("diff", []string{"file.go"}, nil, "", "", nil, "", "")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to parse context gathering prompt template")
 }
@@ -297,9 +531,9 @@ func TestBuildContextGatheringPrompt_TemplateExecutionError(t *testing.T) {
 	err := os.WriteFile(customPromptPath, []byte("{{.Diff.Missing}}"), 0o600)
 	require.NoError(t, err)
 
-	m := New("", customPromptPath)
+	m := New("", customPromptPath, nil, nil)
 	m.SetConfigDir(tmpDir)
-	_, err = m.BuildContextGatheringPrompt("diff", []string{"file.go"}, nil, "")
+	_, err = m.BuildContextGatheringPrompt("diff", []string{"file.go"}, nil, "", "", nil, "", "")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to execute context gathering prompt template")
 }
@@ -308,7 +542,7 @@ func TestLoadPrompt_CustomContextGatheringPath_NotFound(t *testing.T) {
 	t.Parallel()
 	tmpDir := t.TempDir()
 	missing := filepath.Join(tmpDir, "missing.md")
-	m := New("", missing)
+	m := New("", missing, nil, nil)
 	m.SetConfigDir(tmpDir)
 	_, err := m.LoadPrompt(ContextGatheringPrompt)
 	require.Error(t, err)
@@ -322,9 +556,9 @@ func TestBuildReviewPrompt_TemplateExecutionError(t *testing.T) {
 	err := os.WriteFile(customPromptPath, []byte("{{.Diff.Missing}}"), 0o600)
 	require.NoError(t, err)
 
-	m := New(customPromptPath, "")
+	m := New(customPromptPath, "", nil, nil)
 	m.SetConfigDir(tmpDir)
-	_, err = m.BuildReviewPrompt("diff", []string{"file.go"}, nil, "", "")
+	_, err = m.BuildReviewPrompt("diff", []string{"file.go"}, nil, "", "", "", "", "")
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to execute review prompt template")
 }
@@ -335,7 +569,7 @@ func TestLoadPrompt_PathValidation(t *testing.T) {
 	t.Run("rejects parent-directory traversal", func(t *testing.T) {
 		t.Parallel()
 		tmpDir := t.TempDir()
-		m := New("../../../etc/passwd", "")
+		m := New("../../../etc/passwd", "", nil, nil)
 		m.SetConfigDir(tmpDir)
 		_, err := m.LoadPrompt(ReviewPrompt)
 		require.Error(t, err)
@@ -348,7 +582,7 @@ func TestLoadPrompt_PathValidation(t *testing.T) {
 		tmpDir := t.TempDir()
 		// /etc/passwd is well outside the per-test temp dir.
 		evil := filepath.Join(string(filepath.Separator), "etc", "passwd")
-		m := New(evil, "")
+		m := New(evil, "", nil, nil)
 		m.SetConfigDir(tmpDir)
 		_, err := m.LoadPrompt(ReviewPrompt)
 		require.Error(t, err)
@@ -361,7 +595,7 @@ func TestLoadPrompt_PathValidation(t *testing.T) {
 		tmpDir := t.TempDir()
 		promptPath := filepath.Join(tmpDir, "review.md")
 		require.NoError(t, os.WriteFile(promptPath, []byte("ok"), 0o600))
-		m := New(promptPath, "")
+		m := New(promptPath, "", nil, nil)
 		m.SetConfigDir(tmpDir)
 		got, err := m.LoadPrompt(ReviewPrompt)
 		require.NoError(t, err)
@@ -372,7 +606,7 @@ func TestLoadPrompt_PathValidation(t *testing.T) {
 		t.Parallel()
 		tmpDir := t.TempDir()
 		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "review.md"), []byte("relative-ok"), 0o600))
-		m := New("review.md", "")
+		m := New("review.md", "", nil, nil)
 		m.SetConfigDir(tmpDir)
 		got, err := m.LoadPrompt(ReviewPrompt)
 		require.NoError(t, err)
@@ -383,7 +617,7 @@ func TestLoadPrompt_PathValidation(t *testing.T) {
 		t.Parallel()
 		tmpDir := t.TempDir()
 		evil := filepath.Join(string(filepath.Separator), "etc", "shadow")
-		m := New("", evil)
+		m := New("", evil, nil, nil)
 		m.SetConfigDir(tmpDir)
 		_, err := m.LoadPrompt(ContextGatheringPrompt)
 		require.Error(t, err)
@@ -391,6 +625,45 @@ func TestLoadPrompt_PathValidation(t *testing.T) {
 	})
 }
 
+func TestManager_Validate(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	for name, content := range map[string]string{
+		"good.md":          "Review {{.Diff}} in {{.RepoName}}",
+		"good_context.md":  "Gather {{.RecentCommits}}",
+		"unclosed.md":      "Review {{.Diff",
+		"unknown_field.md": "Review {{.UnknownField}}",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o600))
+	}
+
+	tests := []struct {
+		name    string
+		manager *Manager
+		wantErr string
+	}{
+		{"defaults", New("", "", nil, nil), ""},
+		{"valid custom prompts", New("good.md", "good_context.md", map[string]string{"go": "good.md"}, nil), ""},
+		{"syntax error", New("unclosed.md", "", nil, nil), "invalid review prompt"},
+		{"unknown field", New("", "unknown_field.md", nil, nil), "invalid context gathering prompt"},
+		{"language prompt", New("", "", map[string]string{"py": "unknown_field.md"}, nil), "invalid review (py) prompt"},
+		{"missing file", New("missing.md", "", nil, nil), "failed to read prompt file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tt.manager.SetConfigDir(tmpDir)
+			err := tt.manager.Validate()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestEmbeddedPrompts(t *testing.T) {
 	t.Parallel()
 
@@ -422,8 +695,8 @@ func TestBuildPrompts_DeletedFileSections(t *testing.T) {
 
 	t.Run("review prompt with only existing files omits deleted section", func(t *testing.T) {
 		t.Parallel()
-		m := New("", "")
-		prompt, err := m.BuildReviewPrompt("diff", []string{"keep.go"}, nil, "", "")
+		m := New("", "", nil, nil)
+		prompt, err := m.BuildReviewPrompt("diff", []string{"keep.go"}, nil, "", "", "", "", "")
 		require.NoError(t, err)
 		assert.Contains(t, prompt, "Files changed in this diff")
 		assert.Contains(t, prompt, "keep.go")
@@ -432,8 +705,8 @@ func TestBuildPrompts_DeletedFileSections(t *testing.T) {
 
 	t.Run("review prompt with only deletions omits changed section", func(t *testing.T) {
 		t.Parallel()
-		m := New("", "")
-		prompt, err := m.BuildReviewPrompt("diff", []string{"gone.go"}, []string{"gone.go"}, "", "")
+		m := New("", "", nil, nil)
+		prompt, err := m.BuildReviewPrompt("diff", []string{"gone.go"}, []string{"gone.go"}, "", "", "", "", "")
 		require.NoError(t, err)
 		assert.NotContains(t, prompt, "Files changed in this diff")
 		assert.Contains(t, prompt, "Files deleted by this change")
@@ -442,9 +715,9 @@ func TestBuildPrompts_DeletedFileSections(t *testing.T) {
 
 	t.Run("review prompt with both kinds renders both sections", func(t *testing.T) {
 		t.Parallel()
-		m := New("", "")
+		m := New("", "", nil, nil)
 		prompt, err := m.BuildReviewPrompt(
-			"diff", []string{"keep.go", "gone.go"}, []string{"gone.go"}, "", "",
+			"diff", []string{"keep.go", "gone.go"}, []string{"gone.go"}, "", "", "", "", "",
 		)
 		require.NoError(t, err)
 		existingIdx := strings.Index(prompt, "Files changed in this diff")
@@ -460,8 +733,8 @@ func TestBuildPrompts_DeletedFileSections(t *testing.T) {
 
 	t.Run("context gathering prompt with only existing files omits deleted section", func(t *testing.T) {
 		t.Parallel()
-		m := New("", "")
-		prompt, err := m.BuildContextGatheringPrompt("diff", []string{"keep.go"}, nil, "")
+		m := New("", "", nil, nil)
+		prompt, err := m.BuildContextGatheringPrompt("diff", []string{"keep.go"}, nil, "", "", nil, "", "")
 		require.NoError(t, err)
 		assert.Contains(t, prompt, "Files changed in this diff")
 		assert.NotContains(t, prompt, "Files deleted by this change")
@@ -469,9 +742,9 @@ func TestBuildPrompts_DeletedFileSections(t *testing.T) {
 
 	t.Run("context gathering prompt with deletions includes warning not to fetch them", func(t *testing.T) {
 		t.Parallel()
-		m := New("", "")
+		m := New("", "", nil, nil)
 		prompt, err := m.BuildContextGatheringPrompt(
-			"diff", []string{"keep.go", "gone.go"}, []string{"gone.go"}, "",
+			"diff", []string{"keep.go", "gone.go"}, []string{"gone.go"}, "", "", nil, "", "",
 		)
 		require.NoError(t, err)
 		assert.Contains(t, prompt, "Files deleted by this change")
@@ -488,10 +761,10 @@ func TestBuildPrompts_DeletedFileSections(t *testing.T) {
 		err := os.WriteFile(customPromptPath, []byte("Files: {{.FilesList}}"), 0o600)
 		require.NoError(t, err)
 
-		m := New(customPromptPath, "")
+		m := New(customPromptPath, "", nil, nil)
 		m.SetConfigDir(tmpDir)
 		prompt, err := m.BuildReviewPrompt(
-			"diff", []string{"keep.go", "gone.go"}, []string{"gone.go"}, "", "",
+			"diff", []string{"keep.go", "gone.go"}, []string{"gone.go"}, "", "", "", "", "",
 		)
 		require.NoError(t, err)
 		assert.Contains(t, prompt, "keep.go")
@@ -0,0 +1,147 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package review
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"msrl.dev/lgtmcp/internal/config"
+)
+
+// Circuit breaker defaults, used when the retry config leaves the
+// corresponding field unset or unparseable.
+const (
+	defaultBreakerWindow   = 2 * time.Minute
+	defaultBreakerCooldown = time.Minute
+)
+
+// circuitBreaker stops calls to Gemini after a run of consecutive retryable
+// failures, so that during an outage each review fails fast instead of
+// spending its whole retry budget. It opens once threshold such failures
+// occur within window, and rejects calls until cooldown elapses. The first
+// call after that is a trial: one more retryable failure reopens it, any
+// other outcome closes it. A nil *circuitBreaker never opens.
+type circuitBreaker struct {
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu           sync.Mutex
+	failures     int
+	firstFailure time.Time
+	openUntil    time.Time
+}
+
+// newCircuitBreaker builds a breaker from the retry config. It returns nil,
+// disabling the breaker, when retryConfig is nil or its threshold is 0.
+func newCircuitBreaker(retryConfig *config.RetryConfig) *circuitBreaker {
+	if retryConfig == nil {
+		return nil
+	}
+	threshold := config.DefaultCircuitBreakerThreshold
+	if retryConfig.CircuitBreakerThreshold != nil {
+		threshold = *retryConfig.CircuitBreakerThreshold
+	}
+	if threshold <= 0 {
+		return nil
+	}
+
+	return &circuitBreaker{
+		threshold: threshold,
+		window:    parseDurationOr(retryConfig.CircuitBreakerWindow, defaultBreakerWindow),
+		cooldown:  parseDurationOr(retryConfig.CircuitBreakerCooldown, defaultBreakerCooldown),
+		now:       time.Now,
+	}
+}
+
+// parseDurationOr parses s, returning fallback when s is empty, unparseable,
+// or not positive.
+func parseDurationOr(s string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return fallback
+	}
+
+	return d
+}
+
+// allow returns an error wrapping ErrServiceUnavailable while the breaker is
+// open, and nil otherwise.
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return nil
+	}
+	now := b.now()
+	if remaining := b.openUntil.Sub(now); remaining > 0 {
+		return fmt.Errorf("%w: %d consecutive Gemini API failures; retry in %s",
+			ErrServiceUnavailable, b.failures, remaining.Round(time.Second))
+	}
+
+	// Cooldown elapsed: let a trial call through, leaving the count one
+	// short of the threshold so a single further failure reopens.
+	b.openUntil = time.Time{}
+	b.failures = b.threshold - 1
+	b.firstFailure = now
+
+	return nil
+}
+
+// recordFailure counts a retryable failure and reports whether it opened
+// the breaker.
+func (b *circuitBreaker) recordFailure() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if b.failures == 0 || now.Sub(b.firstFailure) > b.window {
+		// Start a new run; failures spread wider than the window do not
+		// indicate an outage.
+		b.failures = 0
+		b.firstFailure = now
+	}
+	b.failures++
+	if b.failures >= b.threshold && b.openUntil.IsZero() {
+		b.openUntil = now.Add(b.cooldown)
+
+		return true
+	}
+
+	return false
+}
+
+// recordSuccess resets the breaker after any call that was not a retryable
+// failure, since the service evidently answered.
+func (b *circuitBreaker) recordSuccess() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.openUntil = time.Time{}
+}
@@ -0,0 +1,168 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package review
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+	"msrl.dev/lgtmcp/internal/config"
+	"msrl.dev/lgtmcp/internal/prompts"
+	"msrl.dev/lgtmcp/internal/testutil"
+)
+
+// newTestBreaker returns a breaker driven by a fake clock, and a function to
+// advance that clock.
+func newTestBreaker(threshold int) (*circuitBreaker, func(time.Duration)) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := &circuitBreaker{
+		threshold: threshold,
+		window:    time.Minute,
+		cooldown:  30 * time.Second,
+		now:       func() time.Time { return now },
+	}
+
+	return b, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	t.Run("opens after threshold consecutive failures", func(t *testing.T) {
+		t.Parallel()
+		b, _ := newTestBreaker(3)
+		assert.False(t, b.recordFailure())
+		assert.False(t, b.recordFailure())
+		require.NoError(t, b.allow())
+		assert.True(t, b.recordFailure())
+		require.ErrorIs(t, b.allow(), ErrServiceUnavailable)
+	})
+
+	t.Run("success resets the count", func(t *testing.T) {
+		t.Parallel()
+		b, _ := newTestBreaker(2)
+		b.recordFailure()
+		b.recordSuccess()
+		assert.False(t, b.recordFailure())
+		require.NoError(t, b.allow())
+	})
+
+	t.Run("failures spread beyond the window do not open", func(t *testing.T) {
+		t.Parallel()
+		b, advance := newTestBreaker(2)
+		b.recordFailure()
+		advance(2 * time.Minute)
+		assert.False(t, b.recordFailure())
+		require.NoError(t, b.allow())
+	})
+
+	t.Run("cooldown allows a trial call", func(t *testing.T) {
+		t.Parallel()
+		b, advance := newTestBreaker(3)
+		for range 3 {
+			b.recordFailure()
+		}
+		advance(29 * time.Second)
+		require.ErrorIs(t, b.allow(), ErrServiceUnavailable)
+
+		advance(time.Second)
+		require.NoError(t, b.allow())
+		// A failed trial reopens immediately.
+		assert.True(t, b.recordFailure())
+		require.ErrorIs(t, b.allow(), ErrServiceUnavailable)
+
+		// A successful trial closes it.
+		advance(30 * time.Second)
+		require.NoError(t, b.allow())
+		b.recordSuccess()
+		assert.False(t, b.recordFailure())
+		require.NoError(t, b.allow())
+	})
+
+	t.Run("nil breaker never opens", func(t *testing.T) {
+		t.Parallel()
+		var b *circuitBreaker
+		assert.False(t, b.recordFailure())
+		require.NoError(t, b.allow())
+	})
+}
+
+func TestNewCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, newCircuitBreaker(nil))
+	assert.Nil(t, newCircuitBreaker(&config.RetryConfig{CircuitBreakerThreshold: new(0)}))
+
+	b := newCircuitBreaker(&config.RetryConfig{})
+	require.NotNil(t, b)
+	assert.Equal(t, config.DefaultCircuitBreakerThreshold, b.threshold)
+	assert.Equal(t, defaultBreakerWindow, b.window)
+	assert.Equal(t, defaultBreakerCooldown, b.cooldown)
+
+	b = newCircuitBreaker(&config.RetryConfig{
+		CircuitBreakerThreshold: new(4),
+		CircuitBreakerWindow:    "5m",
+		CircuitBreakerCooldown:  "bogus",
+	})
+	require.NotNil(t, b)
+	assert.Equal(t, 4, b.threshold)
+	assert.Equal(t, 5*time.Minute, b.window)
+	assert.Equal(t, defaultBreakerCooldown, b.cooldown)
+}
+
+// TestReviewDiff_CircuitBreaker verifies that once repeated server errors
+// open the breaker, ReviewDiff fails fast without calling Gemini.
+func TestReviewDiff_CircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	client := &StubGeminiClient{
+		CreateChatFunc: func(_ context.Context, _ string, _ *genai.GenerateContentConfig) (GeminiChat, error) {
+			return &StubGeminiChat{
+				SendMessageFunc: func(_ context.Context, _ ...genai.Part) (*genai.GenerateContentResponse, error) {
+					calls++
+
+					return nil, &genai.APIError{Code: http.StatusServiceUnavailable, Message: "unavailable"}
+				},
+			}, nil
+		},
+	}
+	breaker, _ := newTestBreaker(2)
+	r := &Reviewer{
+		client:        client,
+		modelName:     defaultModel,
+		temperature:   0.2,
+		retryConfig:   &config.RetryConfig{MaxRetries: new(5), InitialBackoff: "1ms", MaxBackoff: "1ms"},
+		breaker:       breaker,
+		promptManager: prompts.New("", "", nil, nil),
+		logger:        testutil.NewTestLogger(),
+	}
+
+	// The retry loop stops as soon as the breaker opens, well short of its
+	// six attempts.
+	_, err := r.ReviewDiff(t.Context(), "diff content", []string{"file.go"}, "/repo")
+	require.ErrorIs(t, err, ErrServiceUnavailable)
+	assert.Equal(t, 2, calls)
+
+	_, err = r.ReviewDiff(t.Context(), "diff content", []string{"file.go"}, "/repo")
+	require.ErrorIs(t, err, ErrServiceUnavailable)
+	assert.Contains(t, err.Error(), "temporarily unavailable")
+	assert.Equal(t, 2, calls, "open breaker should not call Gemini")
+}
@@ -16,10 +16,104 @@ package review
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
 
 	"google.golang.org/genai"
+	"msrl.dev/lgtmcp/internal/config"
 )
 
+// genai.APIError carries only the response body, not its headers, so the
+// Retry-After header is captured by retryAfterTransport into a recorder
+// carried on the request context and attached to the returned error.
+
+// retryAfterKey is the context key for a *retryAfterRecorder.
+type retryAfterKey struct{}
+
+// retryAfterRecorder holds the last Retry-After header seen for one call.
+type retryAfterRecorder struct {
+	mu    sync.Mutex
+	value string
+}
+
+// withRetryAfterRecorder returns a context whose requests record their
+// Retry-After header into the returned recorder.
+func withRetryAfterRecorder(ctx context.Context) (context.Context, *retryAfterRecorder) {
+	rec := &retryAfterRecorder{}
+
+	return context.WithValue(ctx, retryAfterKey{}, rec), rec
+}
+
+// wrap attaches the recorded Retry-After value, if any, to a non-nil err.
+func (r *retryAfterRecorder) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.value == "" {
+		return err
+	}
+
+	return &retryAfterError{err: err, retryAfter: r.value}
+}
+
+// retryAfterError is an API error together with the Retry-After header of
+// the response that produced it. It unwraps to the underlying error, so
+// errors.As still finds the genai.APIError.
+type retryAfterError struct {
+	err        error
+	retryAfter string
+}
+
+func (e *retryAfterError) Error() string { return e.err.Error() }
+
+func (e *retryAfterError) Unwrap() error { return e.err }
+
+// retryAfterTransport records the Retry-After header of each response into
+// the request context's retryAfterRecorder, if there is one.
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if rec, ok := req.Context().Value(retryAfterKey{}).(*retryAfterRecorder); ok {
+		if v := resp.Header.Get("Retry-After"); v != "" {
+			rec.mu.Lock()
+			rec.value = v
+			rec.mu.Unlock()
+		}
+	}
+
+	return resp, nil
+}
+
+// newBaseTransport returns the transport for Gemini API requests: one that
+// sends everything through proxyURL, or, when proxyURL is empty,
+// http.DefaultTransport, which honors the HTTPS_PROXY, HTTP_PROXY, and
+// NO_PROXY environment variables.
+func newBaseTransport(proxyURL string) (http.RoundTripper, error) {
+	if proxyURL == "" {
+		return http.DefaultTransport, nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		// Omit the URL itself, which may carry proxy credentials.
+		return nil, fmt.Errorf("invalid proxy URL: %w", config.ErrInvalidProxyURL)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert // Always *http.Transport
+	transport.Proxy = http.ProxyURL(u)
+
+	return transport, nil
+}
+
 // RealGeminiClient implements GeminiClient using the actual genai.Client.
 type RealGeminiClient struct {
 	client *genai.Client
@@ -41,7 +135,10 @@ func (c *RealGeminiClient) CreateChat(
 func (c *RealGeminiClient) GenerateContent(
 	ctx context.Context, modelName string, contents []*genai.Content, genConfig *genai.GenerateContentConfig,
 ) (*genai.GenerateContentResponse, error) {
-	return c.client.Models.GenerateContent(ctx, modelName, contents, genConfig)
+	ctx, rec := withRetryAfterRecorder(ctx)
+	resp, err := c.client.Models.GenerateContent(ctx, modelName, contents, genConfig)
+
+	return resp, rec.wrap(err)
 }
 
 // RealGeminiChat implements GeminiChat using the actual chat session.
@@ -53,5 +150,8 @@ type RealGeminiChat struct {
 func (c *RealGeminiChat) SendMessage(
 	ctx context.Context, parts ...genai.Part,
 ) (*genai.GenerateContentResponse, error) {
-	return c.chat.SendMessage(ctx, parts...)
+	ctx, rec := withRetryAfterRecorder(ctx)
+	resp, err := c.chat.SendMessage(ctx, parts...)
+
+	return resp, rec.wrap(err)
 }
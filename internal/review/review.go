@@ -23,16 +23,23 @@ import (
 	"io"
 	"math"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"google.golang.org/genai"
 	"msrl.dev/lgtmcp/internal/config"
 	"msrl.dev/lgtmcp/internal/git"
 	"msrl.dev/lgtmcp/internal/logging"
+	"msrl.dev/lgtmcp/internal/metrics"
 	"msrl.dev/lgtmcp/internal/prompts"
 )
 
@@ -47,11 +54,42 @@ var (
 	ErrNoAuthMethod = errors.New("no authentication method configured")
 	// ErrQuotaExhausted indicates the Gemini API quota has been exceeded.
 	ErrQuotaExhausted = errors.New("gemini API quota exhausted")
+	// ErrCostLimitExceeded indicates a review was refused because its
+	// estimated cost exceeds the configured gemini.max_estimated_cost.
+	ErrCostLimitExceeded = errors.New("estimated review cost exceeds configured maximum")
+	// ErrServiceUnavailable indicates a call was refused without contacting
+	// Gemini because the circuit breaker is open after repeated failures.
+	ErrServiceUnavailable = errors.New("gemini service temporarily unavailable")
+	// ErrMalformedReviewResponse indicates Gemini's review verdict was not
+	// valid JSON, even after it was asked once more for valid JSON only.
+	ErrMalformedReviewResponse = errors.New("review response is not valid JSON")
 )
 
+// AnalysisError is returned by ReviewDiff when context gathering succeeded
+// but the verdict phase then failed, for example on a transient 503 after
+// retries ran out. Analysis holds Gemini's analysis from context gathering,
+// which ReviewWithAnalysis accepts to retry only the verdict.
+type AnalysisError struct {
+	Analysis string
+	Err      error
+}
+
+func (e *AnalysisError) Error() string { return e.Err.Error() }
+
+func (e *AnalysisError) Unwrap() error { return e.Err }
+
 // quotaFailureType is the gRPC error detail type for quota exhaustion.
 const quotaFailureType = "type.googleapis.com/google.rpc.QuotaFailure"
 
+// maxMalformedSnippet bounds how many bytes of a malformed review response
+// are quoted in ErrMalformedReviewResponse errors.
+const maxMalformedSnippet = 200
+
+// malformedResponsePrompt follows a review response that failed to parse,
+// asking Gemini for the verdict again.
+const malformedResponsePrompt = "Your previous response was not valid JSON. " +
+	"Return valid JSON only, with the \"status\" and \"comments\" fields, and no other text."
+
 // maxRetrievedFileSize bounds how much of any single file handleFileRetrieval
 // will return to the model. It is well above any plausible source file but
 // low enough that even an attacker-supplied multi-gigabyte path cannot OOM
@@ -93,15 +131,116 @@ type TokenUsage struct {
 	ToolUseTokens    int32 `json:"tool_use_tokens,omitempty"`
 }
 
+// Status is the verdict of a review.
+type Status string
+
+// Review verdicts. Only [StatusApproved] allows a commit.
+const (
+	// StatusApproved means the change is ready to commit as is.
+	StatusApproved Status = "approved"
+	// StatusChangesRequested means the review found issues to fix first.
+	StatusChangesRequested Status = "changes_requested"
+	// StatusNeedsHuman means the change must not be approved automatically:
+	// the model could not decide, or a policy requires a person to review it.
+	StatusNeedsHuman Status = "needs_human"
+)
+
+// Category is the kind of issue a categorized comment raises.
+type Category string
+
+const (
+	// CategorySecurity covers vulnerabilities and unsafe handling of data.
+	CategorySecurity Category = "security"
+	// CategoryPerformance covers needless work, allocation, or latency.
+	CategoryPerformance Category = "performance"
+	// CategoryStyle covers naming, structure, and readability.
+	CategoryStyle Category = "style"
+	// CategoryCorrectness covers bugs and behavior that does not match intent.
+	CategoryCorrectness Category = "correctness"
+)
+
+// AllCategories lists every [Category], in the order comments are grouped
+// in output.
+var AllCategories = []Category{CategorySecurity, CategoryCorrectness, CategoryPerformance, CategoryStyle}
+
+// CategorizedComment is one issue from a review, filed under a category.
+type CategorizedComment struct {
+	Category Category `json:"category"`
+	Comment  string   `json:"comment"`
+}
+
 // Result represents the result of a code review.
 type Result struct {
-	Comments        string      `json:"comments"`
+	Comments string `json:"comments"`
+	// CategorizedComments repeats the issues in Comments one by one, each
+	// under a category, when gemini.categorize_comments is set. Categories
+	// lists the distinct categories among them, in [AllCategories] order.
+	CategorizedComments []CategorizedComment `json:"categorized_comments,omitempty"`
+	Categories          []string             `json:"categories,omitempty"`
+	// Status is the verdict. LGTM is kept for compatibility and is true
+	// exactly when Status is [StatusApproved].
+	Status          Status      `json:"status,omitempty"`
 	LGTM            bool        `json:"lgtm"`
 	TokenUsage      *TokenUsage `json:"token_usage,omitempty"`
 	DurationMS      int64       `json:"duration_ms,omitempty"`
 	CostUSD         float64     `json:"cost_usd,omitempty"`
 	CacheSavingsUSD float64     `json:"cache_savings_usd,omitempty"`
 	Model           string      `json:"model,omitempty"`
+	// ContextModel is the model that gathered context for the review, when
+	// it differs from Model, which gave the verdict.
+	ContextModel string `json:"context_model,omitempty"`
+	// FilesChanged, Additions, and Deletions summarize the size of the
+	// reviewed diff. They are set by the caller, not the model.
+	FilesChanged int `json:"files_changed,omitempty"`
+	Additions    int `json:"additions,omitempty"`
+	Deletions    int `json:"deletions,omitempty"`
+	// FetchedFiles lists, in first-fetch order, the repository files whose
+	// content was sent to Gemini through the file retrieval tool, across
+	// every model attempted. It is not part of the model's response.
+	FetchedFiles []string `json:"-"`
+}
+
+// SetStatus sets the verdict and keeps LGTM consistent with it.
+func (r *Result) SetStatus(status Status) {
+	r.Status = status
+	r.LGTM = status == StatusApproved
+}
+
+// normalizeStatus reconciles a verdict parsed from the model with LGTM. A
+// response without a status falls back to its lgtm field, and one with a
+// status outside the enum is treated as undecided rather than trusted.
+func (r *Result) normalizeStatus() {
+	switch r.Status {
+	case StatusApproved, StatusChangesRequested, StatusNeedsHuman:
+	case "":
+		if r.LGTM {
+			r.Status = StatusApproved
+		} else {
+			r.Status = StatusChangesRequested
+		}
+	default:
+		r.Status = StatusNeedsHuman
+	}
+	r.LGTM = r.Status == StatusApproved
+}
+
+// normalizeCategories drops categorized comments that are empty or filed
+// under a category outside the enum, and derives Categories from the rest.
+func (r *Result) normalizeCategories() {
+	r.CategorizedComments = slices.DeleteFunc(r.CategorizedComments, func(c CategorizedComment) bool {
+		return strings.TrimSpace(c.Comment) == "" || !slices.Contains(AllCategories, c.Category)
+	})
+	if len(r.CategorizedComments) == 0 {
+		r.CategorizedComments = nil
+	}
+	r.Categories = nil
+	for _, category := range AllCategories {
+		if slices.ContainsFunc(r.CategorizedComments, func(c CategorizedComment) bool {
+			return c.Category == category
+		}) {
+			r.Categories = append(r.Categories, string(category))
+		}
+	}
 }
 
 // FileFetchCallback is called when a file is fetched during review.
@@ -125,8 +264,26 @@ type GeminiChat interface {
 type Options struct {
 	FileFetchCallback FileFetchCallback
 	Instructions      string
+	// UserInstructions holds instructions the caller gave for this review
+	// only, rendered as {{.UserInstructions}} in the prompts.
+	UserInstructions string
 	// DeletedFiles is the subset of changed paths that the diff marks as deletions.
 	DeletedFiles []string
+	// PriorRejections holds the comments of earlier reviews that rejected
+	// this exact diff, oldest first.
+	PriorRejections []string
+	// RecentCommits holds the subjects of the repository's most recent
+	// commits, newest first, given as background during context gathering.
+	RecentCommits []string
+	// DiffStat is a "git diff --stat" summary of the diff, rendered as
+	// {{.DiffStat}} in the prompts.
+	DiffStat string
+	// TraceRedactor, when set, enables trace logging of the full prompts and
+	// the raw review response, each passed through it first.
+	TraceRedactor func(string) string
+	// analysis, when set, is the analysis of an earlier context-gathering
+	// phase, so the review resumes with the verdict.
+	analysis *string
 }
 
 // Option is a functional option for ReviewDiff.
@@ -146,6 +303,15 @@ func WithInstructions(instructions string) Option {
 	}
 }
 
+// WithUserInstructions sets instructions for this review only, such as what
+// to focus on. Unlike WithInstructions, they come from the caller rather than
+// from files in the repository. Empty means none.
+func WithUserInstructions(instructions string) Option {
+	return func(opts *Options) {
+		opts.UserInstructions = instructions
+	}
+}
+
 // WithDeletedFiles records which changed paths are deletions so the file
 // retrieval tool can respond with a clear deleted-file message.
 func WithDeletedFiles(deleted []string) Option {
@@ -154,6 +320,39 @@ func WithDeletedFiles(deleted []string) Option {
 	}
 }
 
+// WithDiffStat sets a summary of the diff to include in the prompts.
+func WithDiffStat(diffStat string) Option {
+	return func(opts *Options) {
+		opts.DiffStat = diffStat
+	}
+}
+
+// WithRecentCommits sets recent commit subjects, newest first, to include as
+// background in the context gathering prompt.
+func WithRecentCommits(subjects []string) Option {
+	return func(opts *Options) {
+		opts.RecentCommits = subjects
+	}
+}
+
+// WithTrace logs the full context gathering and review prompts and the raw
+// review response at trace level, after passing each through redact so that
+// secrets in them never reach the log.
+func WithTrace(redact func(string) string) Option {
+	return func(opts *Options) {
+		opts.TraceRedactor = redact
+	}
+}
+
+// WithPriorRejections supplies the comments of earlier reviews that rejected
+// the same diff. The prompts then tell the model the change was resubmitted
+// unchanged and ask for more specific, actionable feedback.
+func WithPriorRejections(comments []string) Option {
+	return func(opts *Options) {
+		opts.PriorRejections = comments
+	}
+}
+
 // Reviewer handles code review using Gemini.
 type Reviewer struct {
 	client        GeminiClient
@@ -161,6 +360,38 @@ type Reviewer struct {
 	modelName     string
 	fallbackModel string
 	temperature   float32
+	// contextModel and reviewModel, when set, replace modelName for the
+	// context-gathering phase and the structured verdict respectively.
+	contextModel string
+	reviewModel  string
+	// topP and topK are passed through to both phases when set; nil leaves
+	// the API default.
+	topP *float32
+	topK *float32
+	// seed is passed through to both phases when set, for best-effort
+	// reproducible output.
+	seed *int32
+	// maxEstimatedCost is the USD ceiling above which a review is refused
+	// before any API call; 0 disables the check.
+	maxEstimatedCost float64
+	// maxFileBytes truncates files fetched for context; 0 sends whole files
+	// up to maxRetrievedFileSize.
+	maxFileBytes int64
+	// maxToolCalls bounds the function calls of the Phase 1 tool-calling
+	// loop; 0 means config.DefaultMaxToolCalls.
+	maxToolCalls int
+	// denyReadGlobs lists files the model may never retrieve; nil means
+	// config.DefaultDenyReadGlobs.
+	denyReadGlobs []string
+	// lineNumbers numbers the lines of fetched files when the model does
+	// not set with_line_numbers.
+	lineNumbers bool
+	// categorize asks the model to also file each issue under a category.
+	categorize bool
+	// breaker fails calls fast during a Gemini outage; nil disables it.
+	breaker *circuitBreaker
+	// metrics counts retried API calls; nil disables counting.
+	metrics       *metrics.Metrics
 	promptManager *prompts.Manager
 	logger        logging.Logger
 }
@@ -168,13 +399,20 @@ type Reviewer struct {
 const (
 	defaultModel      = "gemini-3.6-flash"
 	errorKey          = "error"
+	errDeniedFileMsg  = "access denied: file matches gitleaks.deny_read_globs"
+	errNoRepoMsg      = "no repository is available for this review; only the diff can be reviewed"
 	errDeletedFileMsg = "file was deleted or renamed away in this change; the diff records the removal, " +
 		"and a renamed file's content lives at its new path"
 
-	// maxToolTurns bounds the Phase 1 tool-calling loop. Each turn can fetch
-	// several files (parallel function calls), so this is generous for a code
-	// review while still stopping a runaway model from burning tokens forever.
-	maxToolTurns = 32
+	// argWithLineNumbers is the get_file_content argument asking for
+	// numbered lines, and lineNumberSeparator follows each number.
+	argWithLineNumbers  = "with_line_numbers"
+	lineNumberSeparator = " | "
+
+	// charsPerToken is the heuristic used to estimate prompt tokens before a
+	// review is sent. English prose and source code average roughly four
+	// characters per token for Gemini's tokenizer.
+	charsPerToken = 4
 )
 
 // modelPricing contains per-million-token pricing for supported models.
@@ -215,6 +453,15 @@ func (t *tokenUsage) addFromResponse(resp *genai.GenerateContentResponse) {
 	t.ToolUseTokens += resp.UsageMetadata.ToolUsePromptTokenCount
 }
 
+// add accumulates the token counts of other.
+func (t *tokenUsage) add(other *tokenUsage) {
+	t.PromptTokens += other.PromptTokens
+	t.CandidatesTokens += other.CandidatesTokens
+	t.CachedTokens += other.CachedTokens
+	t.ThoughtsTokens += other.ThoughtsTokens
+	t.ToolUseTokens += other.ToolUseTokens
+}
+
 // total returns the total number of tokens used. It mirrors the genai
 // TotalTokenCount definition: prompt + candidates + tool-use prompt + thoughts.
 func (t *tokenUsage) total() int32 {
@@ -281,17 +528,59 @@ func (t *tokenUsage) cacheHitRate() float64 {
 	return float64(t.CachedTokens) / float64(t.PromptTokens)
 }
 
+// estimateInputCost estimates the USD input cost of a review whose Phase 1
+// prompt is prompt. The diff is sent again in the Phase 2 prompt, so the
+// prompt is counted twice; tool results and output are not known up front,
+// which makes this a lower bound. Returns -1 if the model is not in the
+// pricing table.
+func estimateInputCost(modelName, prompt string) float64 {
+	pricing, ok := pricingByModel[modelName]
+	if !ok {
+		return -1
+	}
+	tokens := 2 * (len(prompt) + charsPerToken - 1) / charsPerToken
+
+	return float64(tokens) / 1_000_000 * pricing.InputPrice
+}
+
 // New creates a new Reviewer with the Gemini API client.
 func New(cfg *config.Config, logger logging.Logger) (*Reviewer, error) {
 	ctx := context.Background()
 
+	transport, err := newBaseTransport(cfg.Google.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Google.ProxyURL != "" {
+		logger.Info("Using HTTP proxy for Gemini API requests")
+	}
+
 	// Create client configuration.
 	clientConfig := &genai.ClientConfig{
 		Backend: genai.BackendGeminiAPI,
+		// Capture Retry-After headers, which genai.APIError does not expose.
+		HTTPClient: &http.Client{Transport: &retryAfterTransport{base: transport}},
+	}
+	if cfg.Google.BaseURL != "" {
+		clientConfig.HTTPOptions.BaseURL = cfg.Google.BaseURL
+		logger.Info("Using custom Gemini API endpoint", "base_url", cfg.Google.BaseURL)
 	}
 
 	// Handle authentication based on configuration.
 	switch {
+	case cfg.Google.Backend == config.BackendVertex:
+		// Vertex AI authenticates with ADC only (config.Load rejects an API
+		// key there). genai attaches ADC itself only when it creates the HTTP
+		// client, so add it to ours explicitly.
+		clientConfig.Backend = genai.BackendVertexAI
+		clientConfig.Project = cfg.Google.Project
+		clientConfig.Location = cfg.Google.Location
+		if err := clientConfig.UseDefaultCredentials(); err != nil {
+			return nil, fmt.Errorf("failed to load Application Default Credentials for Vertex AI: %w", err)
+		}
+		logger.Info("Using Vertex AI with Application Default Credentials",
+			"project", cfg.Google.Project,
+			"location", cfg.Google.Location)
 	case cfg.Google.APIKey != "":
 		// Use API key if provided.
 		clientConfig.APIKey = cfg.Google.APIKey
@@ -320,21 +609,73 @@ func New(cfg *config.Config, logger logging.Logger) (*Reviewer, error) {
 		temperature = *cfg.Gemini.Temperature
 	}
 
+	// Check custom prompt templates now, so a typo fails at startup instead
+	// of in the middle of the first review.
+	promptManager := prompts.New(
+		cfg.Prompts.ReviewPromptPath,
+		cfg.Prompts.ContextGatheringPromptPath,
+		cfg.Prompts.ReviewPromptPaths,
+		cfg.Prompts.ExtraContextFiles,
+	)
+	if err := promptManager.Validate(); err != nil {
+		return nil, err
+	}
+
+	// The API takes top-k as a float.
+	var topK *float32
+	if cfg.Gemini.TopK != nil {
+		topK = new(float32(*cfg.Gemini.TopK))
+	}
+
+	var seed *int32
+	if cfg.Gemini.Seed != nil {
+		seed = new(int32(*cfg.Gemini.Seed)) //nolint:gosec // Range-checked in config.Load.
+	}
+
+	maxFileBytes := config.DefaultMaxFileBytes
+	if cfg.Gemini.MaxFileBytes != nil {
+		maxFileBytes = *cfg.Gemini.MaxFileBytes
+	}
+
+	// Reject malformed globs up front; path.Match would otherwise report
+	// ErrBadPattern on every file and the pattern would silently never match.
+	for _, pattern := range cfg.Gitleaks.DenyReadGlobs {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid gitleaks.deny_read_globs pattern %q: %w", pattern, err)
+		}
+	}
+
 	return &Reviewer{
-		client:        &RealGeminiClient{client: client},
-		modelName:     cfg.Gemini.Model,
-		fallbackModel: cfg.Gemini.FallbackModel,
-		temperature:   temperature,
-		retryConfig:   cfg.Gemini.Retry,
-		promptManager: prompts.New(
-			cfg.Prompts.ReviewPromptPath,
-			cfg.Prompts.ContextGatheringPromptPath,
-		),
-		logger: logger,
+		client:           &RealGeminiClient{client: client},
+		modelName:        cfg.Gemini.Model,
+		fallbackModel:    cfg.Gemini.FallbackModel,
+		contextModel:     cfg.Gemini.ContextModel,
+		reviewModel:      cfg.Gemini.ReviewModel,
+		temperature:      temperature,
+		topP:             cfg.Gemini.TopP,
+		topK:             topK,
+		seed:             seed,
+		maxEstimatedCost: cfg.Gemini.MaxEstimatedCost,
+		maxFileBytes:     maxFileBytes,
+		maxToolCalls:     cfg.Gemini.MaxToolCalls,
+		denyReadGlobs:    cfg.Gitleaks.DenyReadGlobs,
+		lineNumbers:      cfg.Gemini.FileLineNumbers,
+		categorize:       cfg.Gemini.CategorizeComments,
+		retryConfig:      cfg.Gemini.Retry,
+		breaker:          newCircuitBreaker(cfg.Gemini.Retry),
+		promptManager:    promptManager,
+		logger:           logger,
 	}, nil
 }
 
-// isRetryableError checks if the error is retryable (rate limit or server errors).
+// SetMetrics makes the reviewer count its retried API calls in m, which the
+// server shares with its own review counters.
+func (r *Reviewer) SetMetrics(m *metrics.Metrics) {
+	r.metrics = m
+}
+
+// isRetryableError checks if the error is retryable (rate limit, server or
+// transient network errors).
 func isRetryableError(err error) bool {
 	if err == nil {
 		return false
@@ -345,6 +686,16 @@ func isRetryableError(err error) bool {
 		return false
 	}
 
+	// The caller's context ending is final. This is checked before network
+	// errors because context.DeadlineExceeded is itself a net.Error timeout.
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if isTransientNetworkError(err) {
+		return true
+	}
+
 	// Check if it's a genai.APIError.
 	var apiErr *genai.APIError
 	if errors.As(err, &apiErr) {
@@ -399,7 +750,42 @@ func isRetryableError(err error) bool {
 	return false
 }
 
-// extractRetryDelay attempts to extract a retry delay from the error details.
+// transientNetworkErrors are substrings of network errors that usually clear
+// up on a new connection. They are matched as text because the HTTP client
+// does not always wrap the underlying error.
+var transientNetworkErrors = []string{
+	"connection reset by peer",
+	"broken pipe",
+	"TLS handshake timeout",
+	"unexpected EOF",
+	"i/o timeout",
+	"use of closed network connection",
+}
+
+// isTransientNetworkError reports whether err is a network failure worth
+// retrying: a dropped connection, a network timeout or an unexpected EOF.
+func isTransientNetworkError(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	if netErr, ok := errors.AsType[net.Error](err); ok && netErr.Timeout() {
+		return true
+	}
+
+	errStr := err.Error()
+	for _, s := range transientNetworkErrors {
+		if strings.Contains(errStr, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// extractRetryDelay attempts to extract a retry delay from the error details,
+// falling back to the response's Retry-After header when they have none.
 func extractRetryDelay(err error) time.Duration {
 	if err == nil {
 		return 0
@@ -422,6 +808,14 @@ func extractRetryDelay(err error) time.Duration {
 		}
 	}
 
+	// Without RetryInfo, honor a standard Retry-After header.
+	var raErr *retryAfterError
+	if errors.As(err, &raErr) {
+		if delay := parseRetryAfter(raErr.retryAfter, time.Now()); delay > 0 {
+			return delay
+		}
+	}
+
 	// Fallback to string parsing for non-APIError errors or when Details is not structured.
 	errStr := err.Error()
 
@@ -442,6 +836,32 @@ func extractRetryDelay(err error) time.Duration {
 	return 0
 }
 
+// parseRetryAfter parses a Retry-After header value, either delay-seconds or
+// an HTTP-date, into a delay from now. It returns 0 for an empty, malformed,
+// or past value.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		// Callers cap the delay at MaxBackoff; just avoid overflowing here.
+		if seconds > math.MaxInt64/int(time.Second) {
+			return math.MaxInt64
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+
+	return 0
+}
+
 // maxBackoffDuration returns the configured maximum backoff, falling back to
 // 60s when unset or unparseable.
 func maxBackoffDuration(retryConfig *config.RetryConfig) time.Duration {
@@ -453,8 +873,9 @@ func maxBackoffDuration(retryConfig *config.RetryConfig) time.Duration {
 	return maxBackoff
 }
 
-// calculateBackoff calculates the exponential backoff with jitter.
-func calculateBackoff(attempt int, retryConfig *config.RetryConfig) time.Duration {
+// calculateBackoff calculates the exponential backoff with jitter, and
+// reports whether it was capped at MaxBackoff.
+func calculateBackoff(attempt int, retryConfig *config.RetryConfig) (time.Duration, bool) {
 	// Parse initial and max backoff.
 	initialBackoff, err := time.ParseDuration(retryConfig.InitialBackoff)
 	if err != nil || initialBackoff == 0 {
@@ -466,16 +887,28 @@ func calculateBackoff(attempt int, retryConfig *config.RetryConfig) time.Duratio
 	// Calculate exponential backoff.
 	backoff := float64(initialBackoff) * math.Pow(retryConfig.BackoffMultiplier, float64(attempt))
 
-	// Add jitter (±20%).
-	jitter := (rand.Float64() * 0.4) - 0.2 //nolint:gosec // math/rand is sufficient for jitter
+	if retryConfig.JitterMode == config.JitterModeFull {
+		// Full jitter: uniform in [0, capped backoff].
+		capped := backoff > float64(maxBackoff)
+		backoff = min(backoff, float64(maxBackoff))
+
+		return time.Duration(rand.Float64() * backoff), capped //nolint:gosec // math/rand is sufficient for jitter
+	}
+
+	// Equal jitter: ±JitterFraction (default ±20%).
+	fraction := config.DefaultJitterFraction
+	if retryConfig.JitterFraction != nil {
+		fraction = *retryConfig.JitterFraction
+	}
+	jitter := (rand.Float64()*2 - 1) * fraction //nolint:gosec // math/rand is sufficient for jitter
 	backoff *= (1 + jitter)
 
 	// Cap at max backoff.
 	if backoff > float64(maxBackoff) {
-		backoff = float64(maxBackoff)
+		return maxBackoff, true
 	}
 
-	return time.Duration(backoff)
+	return time.Duration(backoff), false
 }
 
 // retryableOperation performs an operation with retry logic.
@@ -491,13 +924,17 @@ func (r *Reviewer) retryableOperation( //nolint:funcorder // Helper method used
 
 	if r.retryConfig == nil || maxRetries <= 0 {
 		// No retry configured, just run the operation once.
-		err := operation()
+		err := r.runAttempt(operation, operationName)
 		if err != nil && isQuotaExhaustedError(err) {
 			return errors.Join(ErrQuotaExhausted, err)
 		}
 		return err
 	}
 
+	// An empty, zero, or unparseable max_total_duration means no time limit.
+	maxTotal, _ := time.ParseDuration(r.retryConfig.MaxTotalDuration)
+	start := time.Now()
+
 	var lastErr error
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		// Check context cancellation.
@@ -508,7 +945,7 @@ func (r *Reviewer) retryableOperation( //nolint:funcorder // Helper method used
 		}
 
 		// Execute the operation.
-		err := operation()
+		err := r.runAttempt(operation, operationName)
 		if err == nil {
 			return nil // Success!
 		}
@@ -535,19 +972,48 @@ func (r *Reviewer) retryableOperation( //nolint:funcorder // Helper method used
 		// First, check if the API provided a retry delay. Cap it at MaxBackoff
 		// so a hostile or buggy server cannot pin us in a multi-minute (or
 		// multi-hour) sleep with an oversized retryDelay.
+		// A backoff often capped under sustained rate limiting suggests
+		// MaxBackoff is too low, so capping is logged.
 		if apiDelay := extractRetryDelay(err); apiDelay > 0 {
-			backoff = min(apiDelay, maxBackoffDuration(r.retryConfig))
+			maxBackoff := maxBackoffDuration(r.retryConfig)
+			backoff = min(apiDelay, maxBackoff)
 			r.logger.Debug("Using API-provided retry delay",
 				"operation", operationName,
 				"attempt", attempt+1,
 				"delay", backoff)
+			if apiDelay > maxBackoff {
+				r.logger.Debug("Retry delay capped at max_backoff",
+					"operation", operationName,
+					"attempt", attempt+1,
+					"requested_delay", apiDelay,
+					"max_backoff", maxBackoff)
+			}
 		} else {
 			// Use exponential backoff with jitter.
-			backoff = calculateBackoff(attempt, r.retryConfig)
+			var capped bool
+			backoff, capped = calculateBackoff(attempt, r.retryConfig)
 			r.logger.Debug("Using calculated backoff",
 				"operation", operationName,
 				"attempt", attempt+1,
 				"delay", backoff)
+			if capped {
+				r.logger.Debug("Calculated backoff capped at max_backoff",
+					"operation", operationName,
+					"attempt", attempt+1,
+					"max_backoff", maxBackoffDuration(r.retryConfig))
+			}
+		}
+
+		// Give up rather than sleep past the total time budget.
+		if elapsed := time.Since(start); maxTotal > 0 && elapsed+backoff > maxTotal {
+			r.logger.Warn("Retry time budget exhausted",
+				"operation", operationName,
+				"attempts", attempt+1,
+				"elapsed", elapsed,
+				"max_total_duration", maxTotal)
+
+			return fmt.Errorf("operation %s failed after %d attempts: retrying would exceed max_total_duration %s: %w",
+				operationName, attempt+1, maxTotal, lastErr)
 		}
 
 		// Wait before retrying.
@@ -561,15 +1027,45 @@ func (r *Reviewer) retryableOperation( //nolint:funcorder // Helper method used
 			// Continue to next attempt.
 		}
 
+		r.metrics.RecordRetry()
 		r.logger.Info("Retrying operation after rate limit",
 			"operation", operationName,
 			"attempt", attempt+2,
-			"max_attempts", maxRetries+1)
+			"max_attempts", maxRetries+1,
+			"slept", backoff)
 	}
 
 	return fmt.Errorf("operation %s failed after %d attempts: %w", operationName, maxRetries+1, lastErr)
 }
 
+// runAttempt runs a single attempt of operation through the circuit breaker:
+// it is refused while the breaker is open, and its outcome is recorded.
+// Errors from an open breaker are not retryable, so a retry loop in progress
+// stops as soon as the breaker opens.
+func (r *Reviewer) runAttempt( //nolint:funcorder // Helper method used by retryableOperation
+	operation func() error,
+	operationName string,
+) error {
+	if err := r.breaker.allow(); err != nil {
+		return err
+	}
+
+	err := operation()
+	if !isRetryableError(err) {
+		r.breaker.recordSuccess()
+
+		return err
+	}
+	if r.breaker.recordFailure() {
+		r.logger.Warn("Gemini circuit breaker opened; failing calls fast until cooldown elapses",
+			"operation", operationName,
+			"consecutive_failures", r.breaker.threshold,
+			"cooldown", r.breaker.cooldown)
+	}
+
+	return err
+}
+
 // modelSpend records the token usage attributed to a single model attempt.
 // ReviewDiff accumulates one per model it tries so cost can be computed with
 // each model's own pricing.
@@ -588,11 +1084,7 @@ func applyAggregateSpend(result *Result, spends []modelSpend) {
 	var cost, savings float64
 	var costKnown bool
 	for _, s := range spends {
-		combined.PromptTokens += s.usage.PromptTokens
-		combined.CandidatesTokens += s.usage.CandidatesTokens
-		combined.CachedTokens += s.usage.CachedTokens
-		combined.ThoughtsTokens += s.usage.ThoughtsTokens
-		combined.ToolUseTokens += s.usage.ToolUseTokens
+		combined.add(&s.usage)
 		if c := s.usage.cost(s.model); c >= 0 {
 			cost += c
 			savings += s.usage.savings(s.model)
@@ -624,6 +1116,11 @@ func (r *Reviewer) ReviewDiff(
 ) (*Result, error) {
 	startTime := time.Now()
 
+	// Fail fast, before building any prompt, while Gemini is known to be down.
+	if err := r.breaker.allow(); err != nil {
+		return nil, err
+	}
+
 	options := &Options{}
 	for _, opt := range opts {
 		opt(options)
@@ -636,18 +1133,38 @@ func (r *Reviewer) ReviewDiff(
 	record := func(model string, usage tokenUsage) {
 		spends = append(spends, modelSpend{model: model, usage: usage})
 	}
+	// Likewise collect every file sent to Gemini, including by an attempt
+	// that later failed, since its content left the machine either way.
+	var fetched []string
+	recordFetch := func(path string) {
+		if !slices.Contains(fetched, path) {
+			fetched = append(fetched, path)
+		}
+	}
 
-	result, err := r.reviewDiffWithModel(ctx, diff, changedFiles, repoPath, r.modelName, options, record)
-
-	// On quota exhaustion, try fallback model once. An empty fallback model
-	// (possible on a hand-constructed Reviewer; config.Load defaults it)
-	// means no fallback rather than a request with an empty model name.
+	result, err := r.reviewDiffWithModel(
+		ctx, diff, changedFiles, repoPath, r.phaseModels(), options, record, recordFetch,
+	)
+	// The fallback resumes from the primary model's analysis, if it got
+	// that far, rather than gathering context all over again.
+	if analysisErr, ok := errors.AsType[*AnalysisError](err); ok {
+		resumed := *options
+		resumed.analysis = &analysisErr.Analysis
+		options = &resumed
+	}
+
+	// On quota exhaustion, try fallback model once, for both phases. An
+	// empty fallback model (possible on a hand-constructed Reviewer;
+	// config.Load defaults it) means no fallback rather than a request with
+	// an empty model name.
 	if errors.Is(err, ErrQuotaExhausted) && r.fallbackModel != "" &&
 		r.fallbackModel != config.FallbackModelNone && r.fallbackModel != r.modelName {
 		r.logger.Warn("Primary model quota exhausted, falling back",
 			"primary_model", r.modelName,
 			"fallback_model", r.fallbackModel)
-		result, err = r.reviewDiffWithModel(ctx, diff, changedFiles, repoPath, r.fallbackModel, options, record)
+		result, err = r.reviewDiffWithModel(ctx, diff, changedFiles, repoPath,
+			phaseModels{context: r.fallbackModel, review: r.fallbackModel}, options, record, recordFetch,
+		)
 	}
 
 	// Fold the spend from every attempt onto the result and update duration to
@@ -655,104 +1172,171 @@ func (r *Reviewer) ReviewDiff(
 	if result != nil {
 		result.DurationMS = time.Since(startTime).Milliseconds()
 		applyAggregateSpend(result, spends)
+		result.FetchedFiles = fetched
 	}
 
 	return result, err
 }
 
-// reviewDiffWithModel performs a code review using the specified model.
-//
-//nolint:maintidx // Complex multi-phase review process; refactoring would hurt readability.
-func (r *Reviewer) reviewDiffWithModel(
-	ctx context.Context, diff string, changedFiles []string, repoPath string, modelName string,
-	opts *Options, recordSpend func(model string, usage tokenUsage),
+// ReviewWithAnalysis resumes a review whose verdict phase failed, given the
+// analysis from its context gathering (see [AnalysisError]): it asks Gemini
+// for the verdict on diff only, without gathering context, or retrieving
+// files, again. repoPath and opts should be those of the failed review;
+// repoPath only names the repository in the prompt. The estimated cost
+// check is not repeated.
+func (r *Reviewer) ReviewWithAnalysis(
+	ctx context.Context, diff string, changedFiles []string, repoPath, analysis string, opts ...Option,
 ) (*Result, error) {
-	startTime := time.Now()
-	// Validate inputs.
-	if diff == "" {
-		return nil, ErrEmptyDiff
+	opts = append(slices.Clip(opts), func(o *Options) { o.analysis = &analysis })
+
+	return r.ReviewDiff(ctx, diff, changedFiles, repoPath, opts...)
+}
+
+// phaseModels returns the models of the two review phases: contextModel
+// and reviewModel, each defaulting to modelName when unset.
+func (r *Reviewer) phaseModels() phaseModels {
+	models := phaseModels{context: r.contextModel, review: r.reviewModel}
+	if models.context == "" {
+		models.context = r.modelName
+	}
+	if models.review == "" {
+		models.review = r.modelName
 	}
 
-	// Track token usage across all API calls.
-	usage := &tokenUsage{}
+	return models
+}
 
-	// Log token usage when function exits (success or failure).
-	defer func() {
-		// Report this model's spend to the caller so ReviewDiff can aggregate
-		// across the primary attempt and any fallback. This runs on every exit
-		// path, so a model that consumed tokens before erroring (e.g. quota
-		// exhausted mid-review) still has its spend counted.
-		if recordSpend != nil {
-			recordSpend(modelName, *usage)
-		}
-		if usage.total() > 0 {
-			logArgs := []any{
-				"prompt_tokens", usage.PromptTokens,
-				"candidates_tokens", usage.CandidatesTokens,
-				"total_tokens", usage.total(),
-			}
-			if usage.CachedTokens > 0 {
-				logArgs = append(logArgs, "cached_tokens", usage.CachedTokens)
-			}
-			if usage.ThoughtsTokens > 0 {
-				logArgs = append(logArgs, "thoughts_tokens", usage.ThoughtsTokens)
-			}
-			if usage.ToolUseTokens > 0 {
-				logArgs = append(logArgs, "tool_use_tokens", usage.ToolUseTokens)
-			}
-			if cost := usage.cost(modelName); cost >= 0 {
-				logArgs = append(logArgs, "cost_usd", cost,
-					"cost_usd_uncached", usage.costWithoutCaching(modelName),
-					"cache_savings_usd", usage.savings(modelName),
-					"cache_hit_rate", usage.cacheHitRate(),
-					"cache_engaged", usage.CachedTokens > 0)
-			}
-			r.logger.Info("Token usage", logArgs...)
-
-			// Plain-language caching verdict so "did it work / are we saving
-			// money" is answerable with a single grep (msg="Context caching").
-			if usage.CachedTokens == 0 {
-				r.logger.Info("Context caching",
-					"engaged", false,
-					"reason", "no cached tokens (prompt below model minimum, prefix changed, or no implicit cache)",
-					"prompt_tokens", usage.PromptTokens,
-					"model", modelName)
-			} else {
-				r.logger.Info("Context caching",
-					"engaged", true,
-					"cached_tokens", usage.CachedTokens,
-					"prompt_tokens", usage.PromptTokens,
-					"hit_rate", usage.cacheHitRate(),
-					"saved_usd", usage.savings(modelName),
-					"model", modelName)
-			}
-		}
-	}()
+// checkEstimatedCost refuses a review whose estimated cost exceeds the
+// configured ceiling, before any tokens are spent. Models without known
+// pricing are allowed through, since their cost cannot be estimated.
+func (r *Reviewer) checkEstimatedCost(modelName, prompt string) error {
+	if r.maxEstimatedCost <= 0 {
+		return nil
+	}
+	estimate := estimateInputCost(modelName, prompt)
+	if estimate < 0 {
+		r.logger.Warn("Cannot estimate review cost for unknown model; skipping cost check",
+			"model", modelName)
+
+		return nil
+	}
+	if estimate > r.maxEstimatedCost {
+		return fmt.Errorf("%w: estimated at least $%.4f for %s, limit is $%.4f; "+
+			"review a smaller change or raise gemini.max_estimated_cost",
+			ErrCostLimitExceeded, estimate, modelName, r.maxEstimatedCost)
+	}
+
+	return nil
+}
+
+// trace logs text in full at trace level, redacted, when opts enables
+// tracing with [WithTrace].
+func (r *Reviewer) trace(opts *Options, msg, text string) {
+	if opts.TraceRedactor == nil {
+		return
+	}
+	r.logger.Trace(msg, "text", opts.TraceRedactor(text))
+}
+
+// logTokenUsage logs the tokens a model used in one review attempt, its
+// cost when the model's pricing is known, and whether caching engaged.
+func (r *Reviewer) logTokenUsage(modelName string, usage *tokenUsage) {
+	if usage.total() == 0 {
+		return
+	}
+	logArgs := []any{
+		"prompt_tokens", usage.PromptTokens,
+		"candidates_tokens", usage.CandidatesTokens,
+		"total_tokens", usage.total(),
+	}
+	if usage.CachedTokens > 0 {
+		logArgs = append(logArgs, "cached_tokens", usage.CachedTokens)
+	}
+	if usage.ThoughtsTokens > 0 {
+		logArgs = append(logArgs, "thoughts_tokens", usage.ThoughtsTokens)
+	}
+	if usage.ToolUseTokens > 0 {
+		logArgs = append(logArgs, "tool_use_tokens", usage.ToolUseTokens)
+	}
+	if cost := usage.cost(modelName); cost >= 0 {
+		logArgs = append(logArgs, "cost_usd", cost,
+			"cost_usd_uncached", usage.costWithoutCaching(modelName),
+			"cache_savings_usd", usage.savings(modelName),
+			"cache_hit_rate", usage.cacheHitRate(),
+			"cache_engaged", usage.CachedTokens > 0)
+	}
+	r.logger.Info("Token usage", logArgs...)
+
+	// Plain-language caching verdict so "did it work / are we saving
+	// money" is answerable with a single grep (msg="Context caching").
+	if usage.CachedTokens == 0 {
+		r.logger.Info("Context caching",
+			"engaged", false,
+			"reason", "no cached tokens (prompt below model minimum, prefix changed, or no implicit cache)",
+			"prompt_tokens", usage.PromptTokens,
+			"model", modelName)
+	} else {
+		r.logger.Info("Context caching",
+			"engaged", true,
+			"cached_tokens", usage.CachedTokens,
+			"prompt_tokens", usage.PromptTokens,
+			"hit_rate", usage.cacheHitRate(),
+			"saved_usd", usage.savings(modelName),
+			"model", modelName)
+	}
+}
 
+// phaseModels names the models of a review's two phases: context gathering
+// with file retrieval, and the structured verdict.
+type phaseModels struct {
+	context string
+	review  string
+}
+
+// gatherContext runs the first phase of a review: Gemini analyzes the diff,
+// retrieving files from repoPath as it needs them, up to the tool call
+// limit. It returns Gemini's analysis for the verdict phase.
+//
+//nolint:maintidx // Tool-calling loop; splitting it would hurt readability.
+func (r *Reviewer) gatherContext(
+	ctx context.Context, diff string, changedFiles []string, repoPath, repoName, instructions, modelName string,
+	opts *Options, usage *tokenUsage, recordFetch func(path string),
+) (string, error) {
 	deletedSet := make(map[string]bool, len(opts.DeletedFiles))
 	for _, p := range opts.DeletedFiles {
 		deletedSet[filepath.Clean(p)] = true
 	}
 
-	// Phase 1: Let Gemini analyze the code with tool support for file retrieval.
 	contextPrompt, err := r.promptManager.BuildContextGatheringPrompt(
-		diff, changedFiles, opts.DeletedFiles, opts.Instructions,
+		diff, changedFiles, opts.DeletedFiles, instructions, opts.UserInstructions, opts.RecentCommits, repoName,
+		opts.DiffStat,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build context gathering prompt: %w", err)
+		return "", fmt.Errorf("failed to build context gathering prompt: %w", err)
 	}
 
+	if err = r.checkEstimatedCost(modelName, contextPrompt); err != nil {
+		return "", err
+	}
+	r.trace(opts, "Context gathering prompt", contextPrompt)
+
 	// Configure the model with tools for context gathering.
+	systemInstruction := r.promptManager.LoadSystemInstruction()
 	toolConfig := &genai.GenerateContentConfig{
-		Temperature: &r.temperature,
+		SystemInstruction: genai.NewContentFromText(systemInstruction, genai.RoleUser),
+		Temperature:       &r.temperature,
+		TopP:              r.topP,
+		TopK:              r.topK,
+		Seed:              r.seed,
 	}
 
 	// Define the file retrieval tool.
 	fileRetrievalTool := &genai.Tool{
 		FunctionDeclarations: []*genai.FunctionDeclaration{
 			{
-				Name:        "get_file_content",
-				Description: "Retrieve the content of a file from the repository",
+				Name: "get_file_content",
+				Description: "Retrieve the content of a file from the repository. " +
+					"Large files are truncated; the response then has truncated set and the full size in bytes",
 				Parameters: &genai.Schema{
 					Type: genai.TypeObject,
 					Properties: map[string]*genai.Schema{
@@ -760,6 +1344,12 @@ func (r *Reviewer) reviewDiffWithModel(
 							Type:        genai.TypeString,
 							Description: "Path to the file relative to repository root",
 						},
+						argWithLineNumbers: {
+							Type: genai.TypeBoolean,
+							Description: fmt.Sprintf("Prefix each line with its 1-based line number and %q, "+
+								"which are not part of the file, for citing lines accurately (default: %t)",
+								lineNumberSeparator, r.lineNumbers),
+						},
 					},
 					Required: []string{"filepath"},
 				},
@@ -772,7 +1362,7 @@ func (r *Reviewer) reviewDiffWithModel(
 	// Start the chat session for context gathering.
 	chat, err := r.client.CreateChat(ctx, modelName, toolConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create chat session: %w", err)
+		return "", fmt.Errorf("failed to create chat session: %w", err)
 	}
 
 	// Send the initial prompt with retry logic.
@@ -787,19 +1377,28 @@ func (r *Reviewer) reviewDiffWithModel(
 		return sendErr
 	}, "initial_prompt")
 	if err != nil {
-		return nil, fmt.Errorf("failed to send message to Gemini: %w", err)
+		return "", fmt.Errorf("failed to send message to Gemini: %w", err)
 	}
 	usage.addFromResponse(response)
 
 	// Handle function calls. The loop is bounded: nothing upstream applies a
 	// deadline, so without a cap a model that keeps requesting files would
-	// fetch (and bill) forever. On hitting the cap we proceed to the
-	// structured review phase with the context gathered so far.
-	for turn := 0; response != nil && len(response.Candidates) > 0; turn++ {
-		if turn >= maxToolTurns {
-			r.logger.Warn("Tool-calling turn limit reached; proceeding to review",
-				"max_turns", maxToolTurns)
-			break
+	// fetch (and bill) forever. Once the calls made reach the cap we proceed
+	// to the structured review phase with the context gathered so far; the
+	// turn that reaches it is still answered in full.
+	maxToolCalls := r.maxToolCalls
+	if maxToolCalls <= 0 {
+		maxToolCalls = config.DefaultMaxToolCalls
+	}
+	toolCalls := 0
+	answered := make(map[string]*genai.Part)
+	for response != nil && len(response.Candidates) > 0 {
+		// Notice a client cancellation between turns rather than only at the
+		// next send, which may not check the context (e.g. with no retries).
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		default:
 		}
 
 		candidate := response.Candidates[0]
@@ -811,25 +1410,11 @@ func (r *Reviewer) reviewDiffWithModel(
 			break
 		}
 
-		// The model may make several function calls in one turn (parallel
-		// function calling). The API requires exactly one response part per
-		// call, so collect a response for each before replying.
-		var funcResponses []genai.Part
+		var funcCalls []*genai.FunctionCall
 		for _, part := range candidate.Content.Parts {
 			switch {
 			case part.FunctionCall != nil:
-				requestedFile, ok := part.FunctionCall.Args["filepath"].(string)
-				r.logger.Debug("Model requested file",
-					"function", part.FunctionCall.Name,
-					"filepath", requestedFile)
-
-				// Invoke file fetch callback if provided.
-				if opts.FileFetchCallback != nil && ok && requestedFile != "" {
-					opts.FileFetchCallback(requestedFile)
-				}
-
-				funcResponses = append(funcResponses,
-					*r.handleFileRetrieval(ctx, part.FunctionCall, repoPath, deletedSet))
+				funcCalls = append(funcCalls, part.FunctionCall)
 			case part.Text != "" && !part.Thought:
 				// Capture any analysis text from the model. Thought-summary
 				// parts also carry text but are reasoning, not analysis, so
@@ -841,9 +1426,53 @@ func (r *Reviewer) reviewDiffWithModel(
 		}
 
 		// If no tool calls, we have the analysis response.
-		if len(funcResponses) == 0 {
+		if len(funcCalls) == 0 {
 			break
 		}
+		if toolCalls >= maxToolCalls {
+			r.logger.Warn("Tool call limit reached; proceeding to review",
+				"max_tool_calls", maxToolCalls,
+				"unanswered_calls", len(funcCalls))
+			break
+		}
+		toolCalls += len(funcCalls)
+
+		// The model may make several function calls in one turn (parallel
+		// function calling). The API requires exactly one response part per
+		// call, so collect a response for each before replying.
+		funcResponses := make([]genai.Part, 0, len(funcCalls))
+		for _, funcCall := range funcCalls {
+			requestedFile, ok := funcCall.Args["filepath"].(string)
+			r.logger.Debug("Model requested file",
+				"function", funcCall.Name,
+				"filepath", requestedFile)
+
+			// Answer a repeated request for the same file, in this turn or
+			// an earlier one, from the first response instead of reading
+			// and fetching it again.
+			cacheKey := filepath.Clean(requestedFile)
+			if numbered, isBool := funcCall.Args[argWithLineNumbers].(bool); isBool {
+				cacheKey += "\x00" + strconv.FormatBool(numbered)
+			}
+			if cached, seen := answered[cacheKey]; ok && seen {
+				funcResponses = append(funcResponses, *cached)
+				continue
+			}
+
+			// Invoke file fetch callback if provided.
+			if opts.FileFetchCallback != nil && ok && requestedFile != "" {
+				opts.FileFetchCallback(requestedFile)
+			}
+
+			funcResponse := r.handleFileRetrieval(ctx, funcCall, repoPath, deletedSet)
+			if _, sent := funcResponse.FunctionResponse.Response["content"]; sent && recordFetch != nil {
+				recordFetch(filepath.Clean(requestedFile))
+			}
+			if ok {
+				answered[cacheKey] = funcResponse
+			}
+			funcResponses = append(funcResponses, *funcResponse)
+		}
 
 		// Send the function responses back with retry logic.
 		r.logger.Debug("Sending function responses", "count", len(funcResponses))
@@ -855,37 +1484,39 @@ func (r *Reviewer) reviewDiffWithModel(
 			return sendErr
 		}, "function_response")
 		if err != nil {
-			return nil, fmt.Errorf("failed to send function response: %w", err)
+			return "", fmt.Errorf("failed to send function response: %w", err)
 		}
 		usage.addFromResponse(response)
 	}
 
-	// Phase 2: Get structured review result without tools.
+	return analysisText, nil
+}
+
+// verdict runs the second phase of a review: given the analysis from
+// context gathering, Gemini returns its structured verdict, without tools.
+func (r *Reviewer) verdict(
+	ctx context.Context, diff string, changedFiles []string, repoName, instructions, analysisText, modelName string,
+	opts *Options, usage *tokenUsage,
+) (*Result, error) {
 	reviewPrompt, err := r.promptManager.BuildReviewPrompt(
-		diff, changedFiles, opts.DeletedFiles, analysisText, opts.Instructions,
+		diff, changedFiles, opts.DeletedFiles, analysisText, instructions, opts.UserInstructions, repoName,
+		opts.DiffStat,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build review prompt: %w", err)
 	}
+	r.trace(opts, "Review prompt", reviewPrompt)
 
 	// Configure for structured JSON output without tools.
+	systemInstruction := r.promptManager.LoadSystemInstruction()
 	jsonConfig := &genai.GenerateContentConfig{
-		Temperature:      &r.temperature,
-		ResponseMIMEType: "application/json",
-		ResponseSchema: &genai.Schema{
-			Type: genai.TypeObject,
-			Properties: map[string]*genai.Schema{
-				"lgtm": {
-					Type:        genai.TypeBoolean,
-					Description: "Whether the code is approved for production",
-				},
-				"comments": {
-					Type:        genai.TypeString,
-					Description: "Review comments or issues found",
-				},
-			},
-			Required: []string{"lgtm", "comments"},
-		},
+		SystemInstruction: genai.NewContentFromText(systemInstruction, genai.RoleUser),
+		Temperature:       &r.temperature,
+		TopP:              r.topP,
+		TopK:              r.topK,
+		Seed:              r.seed,
+		ResponseMIMEType:  "application/json",
+		ResponseSchema:    r.reviewSchema(),
 	}
 
 	// Use GenerateContent API directly for structured JSON output.
@@ -897,68 +1528,285 @@ func (r *Reviewer) reviewDiffWithModel(
 		},
 	}
 
-	var reviewResponse *genai.GenerateContentResponse
-	err = r.retryableOperation(ctx, func() error {
-		var sendErr error
-		reviewResponse, sendErr = r.client.GenerateContent(ctx, modelName, reviewContent, jsonConfig)
+	// A malformed verdict is usually a one-off, so Gemini is shown its
+	// response and asked once more for valid JSON before giving up.
+	var result *Result
+	for attempt := 0; ; attempt++ {
+		var reviewResponse *genai.GenerateContentResponse
+		err = r.retryableOperation(ctx, func() error {
+			var sendErr error
+			reviewResponse, sendErr = r.client.GenerateContent(ctx, modelName, reviewContent, jsonConfig)
 
-		return sendErr
-	}, "review_prompt")
+			return sendErr
+		}, "review_prompt")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get review response: %w", err)
+		}
+		usage.addFromResponse(reviewResponse)
+
+		var text string
+		text, err = reviewResponseText(reviewResponse)
+		if err != nil {
+			return nil, err
+		}
+		r.trace(opts, "Raw review response from Gemini", text)
+
+		result, err = parseReviewResult(text)
+		if err == nil {
+			break
+		}
+		if attempt > 0 {
+			return nil, fmt.Errorf("failed to parse review response: %w", err)
+		}
+		r.logger.Warn("Review response was not valid JSON; asking Gemini again", errorKey, err)
+		reviewContent = append(reviewContent,
+			&genai.Content{Parts: []*genai.Part{genai.NewPartFromText(text)}, Role: "model"},
+			&genai.Content{Parts: []*genai.Part{genai.NewPartFromText(malformedResponsePrompt)}, Role: "user"},
+		)
+	}
+
+	return result, nil
+}
+
+// reviewDiffWithModel performs a code review using the specified models.
+// When opts carries an analysis, from ReviewWithAnalysis or a previous
+// attempt, context gathering is skipped. Once context gathering has
+// succeeded, a failure of the verdict phase is returned as an
+// [*AnalysisError] carrying the analysis.
+func (r *Reviewer) reviewDiffWithModel(
+	ctx context.Context, diff string, changedFiles []string, repoPath string, models phaseModels,
+	opts *Options, recordSpend func(model string, usage tokenUsage), recordFetch func(path string),
+) (*Result, error) {
+	startTime := time.Now()
+	// Validate inputs.
+	if diff == "" {
+		return nil, ErrEmptyDiff
+	}
+
+	// Track token usage across all API calls, per phase since the phases
+	// may use different models.
+	contextUsage, reviewUsage := &tokenUsage{}, &tokenUsage{}
+	phaseSpends := func() []modelSpend {
+		if models.context == models.review {
+			combined := *contextUsage
+			combined.add(reviewUsage)
+
+			return []modelSpend{{model: models.context, usage: combined}}
+		}
+
+		return []modelSpend{
+			{model: models.context, usage: *contextUsage},
+			{model: models.review, usage: *reviewUsage},
+		}
+	}
+
+	// Log token usage when function exits (success or failure).
+	defer func() {
+		for _, spend := range phaseSpends() {
+			// Report this model's spend to the caller so ReviewDiff can
+			// aggregate across the primary attempt and any fallback. This
+			// runs on every exit path, so a model that consumed tokens before
+			// erroring (e.g. quota exhausted mid-review) still has its spend
+			// counted.
+			if recordSpend != nil {
+				recordSpend(spend.model, spend.usage)
+			}
+			r.logTokenUsage(spend.model, &spend.usage)
+		}
+	}()
+
+	instructions := opts.Instructions + formatPriorRejections(opts.PriorRejections)
+	var repoName string
+	if repoPath != "" {
+		repoName = filepath.Base(filepath.Clean(repoPath))
+	}
+
+	// Phase 1: Let Gemini analyze the code with tool support for file
+	// retrieval, unless resuming from an earlier analysis.
+	var analysisText string
+	if opts.analysis != nil {
+		analysisText = *opts.analysis
+	} else {
+		var err error
+		analysisText, err = r.gatherContext(
+			ctx, diff, changedFiles, repoPath, repoName, instructions, models.context, opts, contextUsage, recordFetch,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Don't start Phase 2 for a request that has already been canceled.
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	// Phase 2: Get structured review result without tools.
+	result, err := r.verdict(
+		ctx, diff, changedFiles, repoName, instructions, analysisText, models.review, opts, reviewUsage,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get review response: %w", err)
+		return nil, &AnalysisError{Analysis: analysisText, Err: err}
 	}
-	usage.addFromResponse(reviewResponse)
 
-	// Parse the structured response.
-	if reviewResponse == nil || len(reviewResponse.Candidates) == 0 {
-		return nil, ErrNoResponse
+	// Add usage statistics to result.
+	result.DurationMS = time.Since(startTime).Milliseconds()
+	result.Model = models.review
+	if models.context != models.review && opts.analysis == nil {
+		result.ContextModel = models.context
 	}
+	applyAggregateSpend(result, phaseSpends())
 
-	candidate := reviewResponse.Candidates[0]
+	return result, nil
+}
+
+// reviewResponseText returns the text of the structured review response: its
+// first text part that is not a thought summary. Thought summaries carry text
+// but are reasoning, not the JSON verdict.
+func reviewResponseText(resp *genai.GenerateContentResponse) (string, error) {
+	if resp == nil || len(resp.Candidates) == 0 {
+		return "", ErrNoResponse
+	}
+	candidate := resp.Candidates[0]
 	if candidate.Content == nil {
-		return nil, ErrEmptyResponse
+		return "", ErrEmptyResponse
 	}
 	for _, part := range candidate.Content.Parts {
-		// Skip thought-summary parts: they carry text but are reasoning, not
-		// the structured JSON verdict, and would fail to parse below.
 		if part.Text != "" && !part.Thought {
-			// Log the raw response for debugging.
-			r.logger.Debug("Raw review response from Gemini", "text", part.Text)
+			return part.Text, nil
+		}
+	}
 
-			// Parse the JSON response.
-			var result Result
-			if err := json.Unmarshal([]byte(part.Text), &result); err != nil {
-				return nil, fmt.Errorf("failed to parse review response: %w", err)
-			}
+	return "", ErrEmptyResponse
+}
+
+// reviewSchema returns the response schema of the review verdict. With
+// categorize set, it adds an optional categorized_comments array whose
+// categories are restricted to [AllCategories].
+func (r *Reviewer) reviewSchema() *genai.Schema {
+	schema := &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"status": {
+				Type: genai.TypeString,
+				Enum: []string{
+					string(StatusApproved), string(StatusChangesRequested), string(StatusNeedsHuman),
+				},
+				Description: "approved if the code is ready for production, changes_requested if " +
+					"issues must be fixed first, needs_human if a person must decide",
+			},
+			"comments": {
+				Type:        genai.TypeString,
+				Description: "Review comments or issues found",
+			},
+		},
+		Required: []string{"status", "comments"},
+	}
+	if !r.categorize {
+		return schema
+	}
+
+	categories := make([]string, len(AllCategories))
+	for i, category := range AllCategories {
+		categories[i] = string(category)
+	}
+	schema.Properties["categorized_comments"] = &genai.Schema{
+		Type:        genai.TypeArray,
+		Description: "Each issue from comments, one per entry, filed under the category it best fits",
+		Items: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"category": {Type: genai.TypeString, Enum: categories},
+				"comment":  {Type: genai.TypeString, Description: "The issue, self-contained"},
+			},
+			Required: []string{"category", "comment"},
+		},
+	}
+
+	return schema
+}
 
-			// Add usage statistics to result.
-			result.DurationMS = time.Since(startTime).Milliseconds()
-			result.Model = modelName
-			result.TokenUsage = &TokenUsage{
-				PromptTokens:     usage.PromptTokens,
-				CandidatesTokens: usage.CandidatesTokens,
-				TotalTokens:      usage.total(),
-				CachedTokens:     usage.CachedTokens,
-				ThoughtsTokens:   usage.ThoughtsTokens,
-				ToolUseTokens:    usage.ToolUseTokens,
+// parseReviewResult parses the JSON review verdict, tolerating the fences
+// and prose extractReviewJSON strips. On failure the error wraps
+// ErrMalformedReviewResponse and quotes the start of text, up to
+// maxMalformedSnippet bytes, for diagnosis.
+func parseReviewResult(text string) (*Result, error) {
+	var result Result
+	if err := json.Unmarshal([]byte(extractReviewJSON(text)), &result); err != nil {
+		snippet := text
+		if len(snippet) > maxMalformedSnippet {
+			cut := maxMalformedSnippet
+			for cut > 0 && !utf8.RuneStart(snippet[cut]) {
+				cut--
 			}
-			if cost := usage.cost(modelName); cost >= 0 {
-				result.CostUSD = cost
-				result.CacheSavingsUSD = usage.savings(modelName)
+			snippet = snippet[:cut] + "..."
+		}
+
+		return nil, fmt.Errorf("%w: %w (response: %q)", ErrMalformedReviewResponse, err, snippet)
+	}
+	result.normalizeStatus()
+	result.normalizeCategories()
+
+	return &result, nil
+}
+
+// extractReviewJSON returns the JSON object in a review response. Despite the
+// application/json response type, Gemini sometimes wraps the verdict in a
+// ```json fence or surrounds it with prose; the object is then taken from
+// inside the fence, or else from the first "{" to the last "}". Text that is
+// already valid JSON, or that holds no object, is returned unchanged.
+func extractReviewJSON(text string) string {
+	trimmed := strings.TrimSpace(text)
+	if json.Valid([]byte(trimmed)) {
+		return trimmed
+	}
+	if _, fenced, ok := strings.Cut(trimmed, "```"); ok {
+		// Drop the info string ("json") on the opening fence line.
+		if _, body, ok := strings.Cut(fenced, "\n"); ok {
+			body, _, _ = strings.Cut(body, "```")
+			if body = strings.TrimSpace(body); json.Valid([]byte(body)) {
+				return body
 			}
+		}
+	}
+	start := strings.Index(trimmed, "{")
+	end := strings.LastIndex(trimmed, "}")
+	if start < 0 || end < start {
+		return text
+	}
 
-			return &result, nil
+	return trimmed[start : end+1]
+}
+
+// denyRead reports whether the repo-relative file (in OS path syntax) matches
+// a deny_read_globs pattern, by basename or by its slash-separated path.
+func (r *Reviewer) denyRead(file string) bool {
+	patterns := r.denyReadGlobs
+	if patterns == nil {
+		patterns = config.DefaultDenyReadGlobs
+	}
+	file = filepath.ToSlash(file)
+	base := path.Base(file)
+	for _, pattern := range patterns {
+		// Patterns are validated in New, so Match cannot fail here.
+		if matched, _ := path.Match(pattern, base); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, file); matched {
+			return true
 		}
 	}
 
-	return nil, ErrEmptyResponse
+	return false
 }
 
 // handleFileRetrieval handles file retrieval tool calls from Gemini. The
 // deleted set contains paths the caller has identified as deletions in the
 // diff under review; requests for those paths return a clear deleted-file
 // response instead of attempting to open the (now-missing) file.
-func (*Reviewer) handleFileRetrieval(
+func (r *Reviewer) handleFileRetrieval(
 	ctx context.Context, funcCall *genai.FunctionCall, repoPath string, deleted map[string]bool,
 ) *genai.Part {
 	// Extract the filepath argument.
@@ -972,6 +1820,14 @@ func (*Reviewer) handleFileRetrieval(
 		)
 	}
 
+	// A diff reviewed on its own, without a repository, has no files to read.
+	if repoPath == "" {
+		return genai.NewPartFromFunctionResponse(
+			funcCall.Name,
+			map[string]any{errorKey: errNoRepoMsg},
+		)
+	}
+
 	// Short-circuit deletions so the model gets a deletion-specific message
 	// instead of a generic ENOENT it might think is transient.
 	if deleted[filepath.Clean(requestedPath)] {
@@ -990,7 +1846,16 @@ func (*Reviewer) handleFileRetrieval(
 			},
 		)
 	}
-
+	// An absolute path such as /proc/self/environ would otherwise be joined
+	// onto the repository root; refuse it outright rather than rely on that.
+	if filepath.IsAbs(requestedPath) || strings.HasPrefix(requestedPath, "/") {
+		return genai.NewPartFromFunctionResponse(
+			funcCall.Name,
+			map[string]any{
+				errorKey: "access denied: absolute paths are not allowed",
+			},
+		)
+	}
 	// Clean and join the path.
 	fullPath := filepath.Join(repoPath, requestedPath)
 
@@ -1046,6 +1911,14 @@ func (*Reviewer) handleFileRetrieval(
 			},
 		)
 	}
+	// Credential files stay off limits even when a repository tracks them
+	// or forgets to ignore them.
+	if r.denyRead(filepath.Clean(requestedPath)) {
+		return genai.NewPartFromFunctionResponse(
+			funcCall.Name,
+			map[string]any{errorKey: errDeniedFileMsg},
+		)
+	}
 
 	// git check-ignore matches the requested name only, while the rooted open
 	// below follows in-repo symlinks. Without this re-check, a symlink such as
@@ -1100,6 +1973,13 @@ func (*Reviewer) handleFileRetrieval(
 					},
 				)
 			}
+			// A link to a denied file is as sensitive as the file itself.
+			if r.denyRead(relResolved) {
+				return genai.NewPartFromFunctionResponse(
+					funcCall.Name,
+					map[string]any{errorKey: errDeniedFileMsg},
+				)
+			}
 		}
 	}
 
@@ -1155,11 +2035,32 @@ func (*Reviewer) handleFileRetrieval(
 		)
 	}
 
+	numbered := r.lineNumbers
+	if raw, present := funcCall.Args[argWithLineNumbers]; present && raw != nil {
+		var isBool bool
+		if numbered, isBool = raw.(bool); !isBool {
+			return genai.NewPartFromFunctionResponse(
+				funcCall.Name,
+				map[string]any{errorKey: argWithLineNumbers + " must be a boolean"},
+			)
+		}
+	}
+	render := func(b []byte) string {
+		if numbered {
+			return numberLines(string(b))
+		}
+		return string(b)
+	}
+
 	// Bound the read so an attacker (or a runaway request from the model)
 	// cannot OOM the review process by asking for a multi-gigabyte file.
-	// We read maxRetrievedFileSize+1 so we can distinguish "exactly fits"
+	// We read one byte past the limit so we can distinguish "exactly fits"
 	// from "overflows" after ReadAll returns.
-	limited := io.LimitReader(f, maxRetrievedFileSize+1)
+	readLimit := maxRetrievedFileSize
+	if r.maxFileBytes > 0 && r.maxFileBytes < readLimit {
+		readLimit = r.maxFileBytes
+	}
+	limited := io.LimitReader(f, readLimit+1)
 	content, err := io.ReadAll(limited)
 	if err != nil {
 		return genai.NewPartFromFunctionResponse(
@@ -1169,11 +2070,24 @@ func (*Reviewer) handleFileRetrieval(
 			},
 		)
 	}
-	if int64(len(content)) > maxRetrievedFileSize {
+	if int64(len(content)) > readLimit {
+		if readLimit == maxRetrievedFileSize {
+			return genai.NewPartFromFunctionResponse(
+				funcCall.Name,
+				map[string]any{
+					errorKey: fmt.Sprintf("file too large: exceeds %d bytes", maxRetrievedFileSize),
+				},
+			)
+		}
+
+		// Within the hard limit, send the head of the file rather than
+		// nothing, flagged so the model knows it is partial.
 		return genai.NewPartFromFunctionResponse(
 			funcCall.Name,
 			map[string]any{
-				errorKey: fmt.Sprintf("file too large: exceeds %d bytes", maxRetrievedFileSize),
+				"content":   render(content[:readLimit]),
+				"truncated": true,
+				"size":      openedInfo.Size(),
 			},
 		)
 	}
@@ -1181,7 +2095,47 @@ func (*Reviewer) handleFileRetrieval(
 	return genai.NewPartFromFunctionResponse(
 		funcCall.Name,
 		map[string]any{
-			"content": string(content),
+			"content": render(content),
 		},
 	)
 }
+
+// numberLines prefixes each line of content with its 1-based number,
+// right-aligned to a common width, and lineNumberSeparator.
+func numberLines(content string) string {
+	if content == "" {
+		return ""
+	}
+	width := len(strconv.Itoa(strings.Count(strings.TrimSuffix(content, "\n"), "\n") + 1))
+	var sb strings.Builder
+	n := 0
+	for line := range strings.Lines(content) {
+		n++
+		_, _ = fmt.Fprintf(&sb, "%*d%s%s", width, n, lineNumberSeparator, line)
+	}
+
+	return sb.String()
+}
+
+// formatPriorRejections renders earlier rejections of the same diff as a
+// prompt section asking the model to escalate: the author resubmitted without
+// changes, so vague feedback evidently did not help. Returns an empty string
+// when there are none.
+func formatPriorRejections(comments []string) string {
+	if len(comments) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "## Previous Rejections of This Diff\n\n"+
+		"This exact diff has already been rejected %d time(s) and was resubmitted without changes, "+
+		"so the earlier feedback was not acted on. Be more specific and actionable this time: for each "+
+		"issue, name the file and line, explain concretely why it is a problem, and state the exact "+
+		"change needed. If an earlier concern was mistaken, say so rather than repeating it.\n\n",
+		len(comments))
+	for i, c := range comments {
+		_, _ = fmt.Fprintf(&sb, "### Rejection %d\n\n%s\n\n", i+1, strings.TrimSpace(c))
+	}
+
+	return sb.String()
+}
@@ -18,10 +18,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
@@ -29,6 +34,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"google.golang.org/genai"
 	"msrl.dev/lgtmcp/internal/config"
+	"msrl.dev/lgtmcp/internal/logging"
+	"msrl.dev/lgtmcp/internal/metrics"
 	"msrl.dev/lgtmcp/internal/prompts"
 	"msrl.dev/lgtmcp/internal/testutil"
 )
@@ -360,6 +367,19 @@ func runFileRetrievalTests(t *testing.T, reviewer *Reviewer, repoDir string, tes
 	}
 }
 
+func TestHandleFileRetrieval_NoRepository(t *testing.T) {
+	t.Parallel()
+	// Run from a repository with a readable file, to show that an empty
+	// repository path does not fall back to the working directory.
+	reviewer := NewForTesting()
+	funcCall := &genai.FunctionCall{Name: "get_file_content", Args: map[string]any{"filepath": "review.go"}}
+
+	result := reviewer.handleFileRetrieval(t.Context(), funcCall, "", nil)
+	require.NotNil(t, result.FunctionResponse)
+	assert.Equal(t, errNoRepoMsg, result.FunctionResponse.Response[errorKey])
+	assert.NotContains(t, result.FunctionResponse.Response, "content")
+}
+
 func TestHandleFileRetrieval_GitCommandFailure(t *testing.T) {
 	t.Parallel()
 	// Create a temporary directory that is NOT a git repository
@@ -778,6 +798,75 @@ index 0000000..1111111 100644
 	})
 }
 
+func TestReviewDiff_Status(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		response string
+		status   Status
+	}{
+		{"approved", `{"status": "approved", "comments": "ok"}`, StatusApproved},
+		{"changes requested", `{"status": "changes_requested", "comments": "fix"}`, StatusChangesRequested},
+		{"needs human", `{"status": "needs_human", "comments": "unsure"}`, StatusNeedsHuman},
+		// Status wins over a contradictory lgtm field.
+		{"status overrides lgtm", `{"status": "changes_requested", "lgtm": true, "comments": "fix"}`,
+			StatusChangesRequested},
+		{"unknown status", `{"status": "approved_with_nits", "comments": "ok"}`, StatusNeedsHuman},
+		// A response without a status falls back to lgtm.
+		{"legacy approval", `{"lgtm": true, "comments": "ok"}`, StatusApproved},
+		{"legacy rejection", `{"lgtm": false, "comments": "fix"}`, StatusChangesRequested},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			reviewer := NewForTestingWithClient(newStubClient("Analysis", tc.response))
+			result, err := reviewer.ReviewDiff(t.Context(), "diff --git a/main.go b/main.go\n", nil, t.TempDir())
+			require.NoError(t, err)
+			assert.Equal(t, tc.status, result.Status)
+			assert.Equal(t, tc.status == StatusApproved, result.LGTM)
+		})
+	}
+}
+
+// traceLogger records Trace calls and discards everything else.
+type traceLogger struct {
+	logging.Logger
+
+	traces []string
+}
+
+func (l *traceLogger) Trace(msg string, args ...any) {
+	l.traces = append(l.traces, fmt.Sprint(append([]any{msg + ":"}, args...)...))
+}
+
+func TestReviewDiff_Trace(t *testing.T) {
+	t.Parallel()
+	diff := "diff --git a/main.go b/main.go\n+const token = \"SECRET\"\n"
+	redact := func(s string) string { return strings.ReplaceAll(s, "SECRET", "[redacted]") }
+
+	reviewer := WithStubResponse(true, "ok")
+	logger := &traceLogger{Logger: testutil.NewTestLogger()}
+	reviewer.logger = logger
+	_, err := reviewer.ReviewDiff(t.Context(), diff, []string{"main.go"}, t.TempDir(), WithTrace(redact))
+	require.NoError(t, err)
+
+	require.Len(t, logger.traces, 3)
+	assert.True(t, strings.HasPrefix(logger.traces[0], "Context gathering prompt:"))
+	assert.True(t, strings.HasPrefix(logger.traces[1], "Review prompt:"))
+	assert.True(t, strings.HasPrefix(logger.traces[2], "Raw review response from Gemini:"))
+	assert.Contains(t, logger.traces[2], `"lgtm":true`)
+	for _, trace := range logger.traces[:2] {
+		assert.Contains(t, trace, `const token = "[redacted]"`)
+		assert.NotContains(t, trace, "SECRET")
+	}
+
+	// Without WithTrace nothing is traced.
+	logger.traces = nil
+	_, err = reviewer.ReviewDiff(t.Context(), diff, []string{"main.go"}, t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, logger.traces)
+}
+
 func TestResult(t *testing.T) {
 	t.Parallel()
 	t.Run("struct fields", func(t *testing.T) {
@@ -825,7 +914,7 @@ func TestReviewDiff_ErrorCases(t *testing.T) {
 			},
 			modelName:     "gemini-3.1-pro-preview",
 			temperature:   0.2,
-			promptManager: prompts.New("", ""),
+			promptManager: prompts.New("", "", nil, nil),
 			logger:        testutil.NewTestLogger(),
 		}
 
@@ -1018,6 +1107,51 @@ func TestIsRetryableError(t *testing.T) {
 			err:      errors.New("Error 403: Permission Denied"), //nolint:err113 // test case
 			expected: false,
 		},
+		{
+			name:     "EOF",
+			err:      fmt.Errorf("reading response: %w", io.EOF),
+			expected: true,
+		},
+		{
+			name:     "unexpected EOF",
+			err:      io.ErrUnexpectedEOF,
+			expected: true,
+		},
+		{
+			name:     "connection reset (errno)",
+			err:      &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET},
+			expected: true,
+		},
+		{
+			name:     "network timeout",
+			err:      &net.DNSError{Err: "lookup timed out", Name: "example.com", IsTimeout: true},
+			expected: true,
+		},
+		{
+			name:     "network error without timeout",
+			err:      &net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true},
+			expected: false,
+		},
+		{
+			name:     "connection reset (string)",
+			err:      errors.New("read tcp 10.0.0.1:443: read: connection reset by peer"), //nolint:err113 // test case
+			expected: true,
+		},
+		{
+			name:     "TLS handshake timeout (string)",
+			err:      errors.New("net/http: TLS handshake timeout"), //nolint:err113 // test case
+			expected: true,
+		},
+		{
+			name:     "context canceled",
+			err:      fmt.Errorf("sending request: %w", context.Canceled),
+			expected: false,
+		},
+		{
+			name:     "context deadline exceeded",
+			err:      fmt.Errorf("sending request: %w", context.DeadlineExceeded),
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1117,6 +1251,39 @@ func TestExtractRetryDelay(t *testing.T) {
 			},
 			expected: 0,
 		},
+		// Retry-After header tests.
+		{
+			name: "Retry-After seconds without RetryInfo",
+			err: &retryAfterError{
+				err:        &genai.APIError{Code: http.StatusServiceUnavailable},
+				retryAfter: "30",
+			},
+			expected: 30 * time.Second,
+		},
+		{
+			name: "RetryInfo takes precedence over Retry-After",
+			err: &retryAfterError{
+				err: &genai.APIError{
+					Code: http.StatusTooManyRequests,
+					Details: []map[string]any{
+						{
+							"@type":      "type.googleapis.com/google.rpc.RetryInfo",
+							"retryDelay": "5s",
+						},
+					},
+				},
+				retryAfter: "30",
+			},
+			expected: 5 * time.Second,
+		},
+		{
+			name: "Retry-After date in the past",
+			err: &retryAfterError{
+				err:        &genai.APIError{Code: http.StatusTooManyRequests},
+				retryAfter: "Wed, 21 Oct 2015 07:28:00 GMT",
+			},
+			expected: 0,
+		},
 		// Fallback string parsing tests.
 		{
 			name:     "error with retryDelay 15s (string)",
@@ -1154,6 +1321,112 @@ func TestExtractRetryDelay(t *testing.T) {
 	}
 }
 
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{name: "integer seconds", value: "120", expected: 2 * time.Minute},
+		{name: "seconds with whitespace", value: " 7 ", expected: 7 * time.Second},
+		{name: "zero seconds", value: "0", expected: 0},
+		{name: "negative seconds", value: "-5", expected: 0},
+		{name: "HTTP-date", value: "Sun, 01 Mar 2026 12:01:30 GMT", expected: 90 * time.Second},
+		{name: "RFC 850 date", value: "Sunday, 01-Mar-26 12:00:10 GMT", expected: 10 * time.Second},
+		{name: "past HTTP-date", value: "Sun, 01 Mar 2026 11:59:00 GMT", expected: 0},
+		{name: "empty", value: "", expected: 0},
+		{name: "garbage", value: "soon", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, parseRetryAfter(tt.value, now))
+		})
+	}
+}
+
+// TestRetryAfterTransport verifies that a Retry-After header on a real HTTP
+// error response reaches extractRetryDelay through the genai client.
+func TestRetryAfterTransport(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "42")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error": {"code": 503, "message": "overloaded", "status": "UNAVAILABLE"}}`))
+	}))
+	defer server.Close()
+
+	client, err := genai.NewClient(t.Context(), &genai.ClientConfig{
+		Backend:     genai.BackendGeminiAPI,
+		APIKey:      "test-key",
+		HTTPClient:  &http.Client{Transport: &retryAfterTransport{base: http.DefaultTransport}},
+		HTTPOptions: genai.HTTPOptions{BaseURL: server.URL},
+	})
+	require.NoError(t, err)
+
+	geminiClient := &RealGeminiClient{client: client}
+	_, err = geminiClient.GenerateContent(t.Context(), defaultModel, genai.Text("hi"), nil)
+	require.Error(t, err)
+
+	// The genai client returns APIError by value.
+	var apiErr genai.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.Code)
+	assert.True(t, isRetryableError(err))
+	assert.Equal(t, 42*time.Second, extractRetryDelay(err))
+}
+
+func TestNew_BaseURL(t *testing.T) {
+	t.Parallel()
+
+	var gotPath atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath.Store(r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "ok"}]}}]}`))
+	}))
+	defer server.Close()
+
+	cfg := config.NewTestConfig()
+	cfg.Google.BaseURL = server.URL
+	reviewer, err := New(cfg, testutil.NewTestLogger())
+	require.NoError(t, err)
+
+	_, err = reviewer.client.GenerateContent(t.Context(), defaultModel, genai.Text("hi"), nil)
+	require.NoError(t, err)
+	assert.Contains(t, gotPath.Load(), defaultModel+":generateContent")
+}
+
+func TestNew_ProxyURL(t *testing.T) {
+	t.Parallel()
+
+	// A plain-http endpoint is requested from the proxy with an absolute URL,
+	// so the proxy sees the endpoint's host.
+	var gotHost atomic.Value
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost.Store(r.URL.Host)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "ok"}]}}]}`))
+	}))
+	defer proxy.Close()
+
+	cfg := config.NewTestConfig()
+	cfg.Google.BaseURL = "http://gemini.invalid"
+	cfg.Google.ProxyURL = proxy.URL
+	reviewer, err := New(cfg, testutil.NewTestLogger())
+	require.NoError(t, err)
+
+	_, err = reviewer.client.GenerateContent(t.Context(), defaultModel, genai.Text("hi"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "gemini.invalid", gotHost.Load())
+}
+
 func TestCalculateBackoff(t *testing.T) {
 	t.Parallel()
 
@@ -1200,7 +1473,8 @@ func TestCalculateBackoff(t *testing.T) {
 			t.Parallel()
 			// Test multiple times to account for randomness.
 			for range 10 {
-				result := calculateBackoff(tt.attempt, cfg)
+				result, capped := calculateBackoff(tt.attempt, cfg)
+				assert.False(t, capped)
 				assert.GreaterOrEqual(t, result, tt.minExpected,
 					"backoff should be at least %v", tt.minExpected)
 				assert.LessOrEqual(t, result, tt.maxExpected,
@@ -1210,6 +1484,68 @@ func TestCalculateBackoff(t *testing.T) {
 	}
 }
 
+func TestCalculateBackoff_Jitter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		jitterFraction *float64
+		jitterMode     string
+		attempt        int
+		minExpected    time.Duration
+		maxExpected    time.Duration
+		wantCapped     bool
+	}{
+		{
+			name:           "equal jitter with custom fraction",
+			jitterFraction: new(0.5),
+			jitterMode:     config.JitterModeEqual,
+			minExpected:    500 * time.Millisecond,  // 1s * 0.5.
+			maxExpected:    1500 * time.Millisecond, // 1s * 1.5.
+		},
+		{
+			name:           "equal jitter disabled",
+			jitterFraction: new(0.0),
+			minExpected:    time.Second,
+			maxExpected:    time.Second,
+		},
+		{
+			name:        "full jitter",
+			jitterMode:  config.JitterModeFull,
+			attempt:     1,
+			minExpected: 0,
+			maxExpected: 1400 * time.Millisecond, // 1.4s, fraction ignored.
+		},
+		{
+			name:        "full jitter capped at max backoff",
+			jitterMode:  config.JitterModeFull,
+			attempt:     20,
+			minExpected: 0,
+			maxExpected: 60 * time.Second,
+			wantCapped:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg := &config.RetryConfig{
+				InitialBackoff:    "1s",
+				MaxBackoff:        "60s",
+				BackoffMultiplier: 1.4,
+				JitterFraction:    tt.jitterFraction,
+				JitterMode:        tt.jitterMode,
+			}
+			for range 100 {
+				result, capped := calculateBackoff(tt.attempt, cfg)
+				assert.Equal(t, tt.wantCapped, capped)
+				assert.GreaterOrEqual(t, result, tt.minExpected)
+				assert.LessOrEqual(t, result, tt.maxExpected)
+			}
+		})
+	}
+}
+
 func TestRetryableOperation(t *testing.T) {
 	t.Parallel()
 
@@ -1247,6 +1583,8 @@ func TestRetryableOperation(t *testing.T) {
 		}
 
 		reviewer := &Reviewer{retryConfig: cfg, logger: testutil.NewTestLogger()}
+		m := metrics.New()
+		reviewer.SetMetrics(m)
 		callCount := 0
 
 		err := reviewer.retryableOperation(t.Context(), func() error {
@@ -1260,6 +1598,7 @@ func TestRetryableOperation(t *testing.T) {
 
 		require.NoError(t, err)
 		assert.Equal(t, 3, callCount)
+		assert.Equal(t, int64(2), m.Snapshot().Retries)
 	})
 
 	t.Run("non-retryable error", func(t *testing.T) {
@@ -1387,6 +1726,37 @@ func TestRetryableOperation(t *testing.T) {
 		// keeps the assertion meaningful without being CI-flaky.
 		assert.Less(t, elapsed, 10*time.Second)
 	})
+
+	t.Run("max total duration stops retries", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := &config.RetryConfig{
+			MaxRetries:        new(10),
+			InitialBackoff:    "10ms",
+			MaxBackoff:        "10s",
+			BackoffMultiplier: 2.0,
+			MaxTotalDuration:  "2s",
+		}
+
+		reviewer := &Reviewer{retryConfig: cfg, logger: testutil.NewTestLogger()}
+		callCount := 0
+
+		start := time.Now()
+		err := reviewer.retryableOperation(t.Context(), func() error {
+			callCount++
+
+			// Each API-provided delay is legal (under MaxBackoff), but the
+			// first one alone would overrun the 2s budget.
+			return errors.New("Error 429: rate limited retryDelay:5s") //nolint:err113 // test case
+		}, "test_operation")
+
+		elapsed := time.Since(start)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "max_total_duration")
+		assert.Contains(t, err.Error(), "rate limited")
+		assert.Equal(t, 1, callCount)
+		assert.Less(t, elapsed, 2*time.Second)
+	})
 }
 
 func TestTokenUsage(t *testing.T) {
@@ -1842,7 +2212,7 @@ func TestReviewDiff_FallbackOnQuota(t *testing.T) {
 		modelName:     "primary-model",
 		fallbackModel: "fallback-model",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -1858,11 +2228,13 @@ func TestReviewDiff_FallbackOnQuota(t *testing.T) {
 // priced at its own rate.
 func TestReviewDiff_FallbackAggregatesSpend(t *testing.T) {
 	t.Parallel()
-	genCalls := 0
+	genCalls, chats := 0, 0
 	client := &StubGeminiClient{
-		// Phase 1 (context gathering) runs for both the primary and the
-		// fallback attempt and accrues 100 prompt tokens each time.
+		// Phase 1 (context gathering) runs for the primary attempt only and
+		// accrues 100 prompt tokens; the fallback resumes from its analysis.
 		CreateChatFunc: func(_ context.Context, _ string, _ *genai.GenerateContentConfig) (GeminiChat, error) {
+			chats++
+
 			return &StubGeminiChat{
 				SendMessageFunc: func(_ context.Context, _ ...genai.Part) (*genai.GenerateContentResponse, error) {
 					return &genai.GenerateContentResponse{
@@ -1906,7 +2278,7 @@ func TestReviewDiff_FallbackAggregatesSpend(t *testing.T) {
 		modelName:     "gemini-3.1-pro-preview",
 		fallbackModel: "gemini-2.5-pro",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -1915,19 +2287,183 @@ func TestReviewDiff_FallbackAggregatesSpend(t *testing.T) {
 	require.NotNil(t, result.TokenUsage)
 
 	// Prompt tokens span both attempts: primary Phase 1 (100) plus fallback
-	// Phase 1 (100) and Phase 2 (200); candidates come from the fallback only.
-	assert.Equal(t, int32(400), result.TokenUsage.PromptTokens)
+	// Phase 2 (200); candidates come from the fallback only.
+	assert.Equal(t, 1, chats, "the fallback must not gather context again")
+	assert.Equal(t, int32(300), result.TokenUsage.PromptTokens)
 	assert.Equal(t, int32(80), result.TokenUsage.CandidatesTokens)
 	assert.Equal(t, "gemini-2.5-pro", result.Model)
 
 	// Cost folds the primary attempt's spend (priced at the primary model's
 	// rate) into the fallback's, so it exceeds the fallback-only cost.
 	primaryOnly := (&tokenUsage{PromptTokens: 100}).cost("gemini-3.1-pro-preview")
-	fallbackOnly := (&tokenUsage{PromptTokens: 300, CandidatesTokens: 80}).cost("gemini-2.5-pro")
+	fallbackOnly := (&tokenUsage{PromptTokens: 200, CandidatesTokens: 80}).cost("gemini-2.5-pro")
 	assert.InDelta(t, primaryOnly+fallbackOnly, result.CostUSD, 1e-9)
 	assert.Greater(t, result.CostUSD, fallbackOnly)
 }
 
+// TestReviewDiff_PhaseModels verifies that context gathering and the verdict
+// run on their own models when configured, each priced at its own rate, and
+// on the main model otherwise.
+func TestReviewDiff_PhaseModels(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		contextModel     string
+		reviewModel      string
+		wantChatModel    string
+		wantVerdictModel string
+		wantContextModel string
+	}{
+		{
+			name:             "main model for both phases",
+			wantChatModel:    "gemini-3.6-flash",
+			wantVerdictModel: "gemini-3.6-flash",
+		},
+		{
+			name:             "cheaper context model",
+			contextModel:     "gemini-2.5-flash-lite",
+			wantChatModel:    "gemini-2.5-flash-lite",
+			wantVerdictModel: "gemini-3.6-flash",
+			wantContextModel: "gemini-2.5-flash-lite",
+		},
+		{
+			name:             "both phases set",
+			contextModel:     "gemini-2.5-flash",
+			reviewModel:      "gemini-3.1-pro-preview",
+			wantChatModel:    "gemini-2.5-flash",
+			wantVerdictModel: "gemini-3.1-pro-preview",
+			wantContextModel: "gemini-2.5-flash",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var chatModel, verdictModel string
+			client := &StubGeminiClient{
+				CreateChatFunc: func(
+					_ context.Context, model string, _ *genai.GenerateContentConfig,
+				) (GeminiChat, error) {
+					chatModel = model
+
+					return &StubGeminiChat{
+						SendMessageFunc: func(_ context.Context, _ ...genai.Part) (*genai.GenerateContentResponse, error) {
+							return &genai.GenerateContentResponse{
+								Candidates: []*genai.Candidate{{Content: &genai.Content{
+									Parts: []*genai.Part{{Text: "Analysis"}},
+								}}},
+								UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+									PromptTokenCount: 1000,
+								},
+							}, nil
+						},
+					}, nil
+				},
+				GenerateContentFunc: func(
+					_ context.Context, model string, _ []*genai.Content, _ *genai.GenerateContentConfig,
+				) (*genai.GenerateContentResponse, error) {
+					verdictModel = model
+
+					return &genai.GenerateContentResponse{
+						Candidates: []*genai.Candidate{{Content: &genai.Content{
+							Parts: []*genai.Part{{Text: `{"lgtm": true, "comments": "OK"}`}},
+						}}},
+						UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+							PromptTokenCount:     2000,
+							CandidatesTokenCount: 100,
+						},
+					}, nil
+				},
+			}
+
+			r := &Reviewer{
+				client:        client,
+				modelName:     "gemini-3.6-flash",
+				contextModel:  tt.contextModel,
+				reviewModel:   tt.reviewModel,
+				temperature:   0.2,
+				promptManager: prompts.New("", "", nil, nil),
+				logger:        testutil.NewTestLogger(),
+			}
+
+			result, err := r.ReviewDiff(t.Context(), "diff content", []string{"file.go"}, "/repo")
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantChatModel, chatModel)
+			assert.Equal(t, tt.wantVerdictModel, verdictModel)
+			assert.Equal(t, tt.wantVerdictModel, result.Model)
+			assert.Equal(t, tt.wantContextModel, result.ContextModel)
+
+			require.NotNil(t, result.TokenUsage)
+			assert.Equal(t, int32(3000), result.TokenUsage.PromptTokens)
+			contextCost := (&tokenUsage{PromptTokens: 1000}).cost(tt.wantChatModel)
+			verdictCost := (&tokenUsage{PromptTokens: 2000, CandidatesTokens: 100}).cost(tt.wantVerdictModel)
+			assert.InDelta(t, contextCost+verdictCost, result.CostUSD, 1e-9)
+		})
+	}
+}
+
+func TestReviewDiff_VerdictFailureKeepsAnalysis(t *testing.T) {
+	t.Parallel()
+	errVerdict := errors.New("verdict failed")
+	client := newStubClientWithGenerateContent(func(
+		_ context.Context, _ string, _ []*genai.Content, _ *genai.GenerateContentConfig,
+	) (*genai.GenerateContentResponse, error) {
+		return nil, errVerdict
+	})
+	r := &Reviewer{
+		client:        client,
+		modelName:     "test-model",
+		temperature:   0.2,
+		promptManager: prompts.New("", "", nil, nil),
+		logger:        testutil.NewTestLogger(),
+	}
+
+	_, err := r.ReviewDiff(t.Context(), "diff content", []string{"file.go"}, "/repo")
+	require.ErrorIs(t, err, errVerdict)
+	analysisErr, ok := errors.AsType[*AnalysisError](err)
+	require.True(t, ok, "a verdict failure must carry the analysis: %v", err)
+	assert.Equal(t, "Analysis done", analysisErr.Analysis)
+}
+
+func TestReviewWithAnalysis(t *testing.T) {
+	t.Parallel()
+	var verdictPrompt string
+	client := &StubGeminiClient{
+		CreateChatFunc: func(_ context.Context, _ string, _ *genai.GenerateContentConfig) (GeminiChat, error) {
+			t.Error("context gathering must not run again")
+
+			return nil, errors.New("unexpected chat")
+		},
+		GenerateContentFunc: func(
+			_ context.Context, _ string, contents []*genai.Content, _ *genai.GenerateContentConfig,
+		) (*genai.GenerateContentResponse, error) {
+			verdictPrompt = contents[0].Parts[0].Text
+
+			return &genai.GenerateContentResponse{
+				Candidates: []*genai.Candidate{{Content: &genai.Content{
+					Parts: []*genai.Part{{Text: `{"lgtm": true, "comments": "OK"}`}},
+				}}},
+			}, nil
+		},
+	}
+	r := &Reviewer{
+		client:        client,
+		modelName:     "test-model",
+		temperature:   0.2,
+		promptManager: prompts.New("", "", nil, nil),
+		logger:        testutil.NewTestLogger(),
+	}
+
+	result, err := r.ReviewWithAnalysis(
+		t.Context(), "diff content", []string{"file.go"}, "/repo", "The earlier analysis",
+	)
+	require.NoError(t, err)
+	assert.True(t, result.LGTM)
+	assert.Contains(t, verdictPrompt, "The earlier analysis")
+	assert.Empty(t, result.FetchedFiles)
+}
+
 func TestReviewDiff_FallbackNone(t *testing.T) {
 	t.Parallel()
 	client := newStubClientWithGenerateContent(func(
@@ -1941,7 +2477,7 @@ func TestReviewDiff_FallbackNone(t *testing.T) {
 		modelName:     "primary-model",
 		fallbackModel: config.FallbackModelNone,
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -1963,7 +2499,7 @@ func TestReviewDiff_FallbackSameModel(t *testing.T) {
 		modelName:     "same-model",
 		fallbackModel: "same-model",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -1984,7 +2520,7 @@ func TestReviewDiffWithModel_NoResponse(t *testing.T) {
 		client:        client,
 		modelName:     "test-model",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -2010,7 +2546,7 @@ func TestReviewDiffWithModel_NilContentReviewPhase(t *testing.T) {
 		client:        client,
 		modelName:     "test-model",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -2048,7 +2584,7 @@ func TestReviewDiffWithModel_NilContentContextPhase(t *testing.T) {
 		client:        client,
 		modelName:     "test-model",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -2094,7 +2630,7 @@ func TestReviewDiffWithModel_EmptyResponse(t *testing.T) {
 		client:        client,
 		modelName:     "test-model",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -2104,10 +2640,12 @@ func TestReviewDiffWithModel_EmptyResponse(t *testing.T) {
 
 func TestReviewDiffWithModel_JSONParseError(t *testing.T) {
 	t.Parallel()
+	calls := 0
 	client := newStubClientWithGenerateContent(
 		func(
 			_ context.Context, _ string, _ []*genai.Content, _ *genai.GenerateContentConfig,
 		) (*genai.GenerateContentResponse, error) {
+			calls++
 			return &genai.GenerateContentResponse{
 				Candidates: []*genai.Candidate{{Content: &genai.Content{
 					Parts: []*genai.Part{{Text: "not valid json"}},
@@ -2120,36 +2658,187 @@ func TestReviewDiffWithModel_JSONParseError(t *testing.T) {
 		client:        client,
 		modelName:     "test-model",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
 	_, err := r.ReviewDiff(t.Context(), "diff content", []string{"file.go"}, "/repo")
-	require.Error(t, err)
+	require.ErrorIs(t, err, ErrMalformedReviewResponse)
 	assert.Contains(t, err.Error(), "failed to parse review response")
+	assert.Contains(t, err.Error(), `"not valid json"`, "the offending text is quoted for diagnosis")
+	assert.Equal(t, 2, calls, "Gemini is asked exactly once more for valid JSON")
 }
 
-func TestReviewDiffWithModel_ToolCallLoop(t *testing.T) {
+func TestReviewDiffWithModel_JSONParseErrorRecovers(t *testing.T) {
 	t.Parallel()
-	callCount := 0
-	client := &StubGeminiClient{
-		CreateChatFunc: func(_ context.Context, _ string, _ *genai.GenerateContentConfig) (GeminiChat, error) {
-			return &StubGeminiChat{
-				SendMessageFunc: func(_ context.Context, _ ...genai.Part) (*genai.GenerateContentResponse, error) {
-					callCount++
-					if callCount == 1 {
-						// First call: return a function call.
-						return &genai.GenerateContentResponse{
-							Candidates: []*genai.Candidate{{Content: &genai.Content{
-								Parts: []*genai.Part{{
-									FunctionCall: &genai.FunctionCall{
-										Name: "get_file_content",
-										Args: map[string]any{"filepath": "main.go"},
-									},
-								}},
-							}}},
-						}, nil
-					}
+	var retryContents []*genai.Content
+	client := newStubClientWithGenerateContent(
+		func(
+			_ context.Context, _ string, contents []*genai.Content, _ *genai.GenerateContentConfig,
+		) (*genai.GenerateContentResponse, error) {
+			text := "Sure! Here is my review: LGTM"
+			if len(contents) > 1 {
+				retryContents = contents
+				text = `{"status": "approved", "comments": "Looks good"}`
+			}
+			return &genai.GenerateContentResponse{
+				Candidates: []*genai.Candidate{{Content: &genai.Content{
+					Parts: []*genai.Part{{Text: text}},
+				}}},
+			}, nil
+		},
+	)
+
+	r := &Reviewer{
+		client:        client,
+		modelName:     "test-model",
+		temperature:   0.2,
+		promptManager: prompts.New("", "", nil, nil),
+		logger:        testutil.NewTestLogger(),
+	}
+
+	result, err := r.ReviewDiff(t.Context(), "diff content", []string{"file.go"}, "/repo")
+	require.NoError(t, err)
+	assert.True(t, result.LGTM)
+	assert.Equal(t, "Looks good", result.Comments)
+	require.Len(t, retryContents, 3)
+	assert.Equal(t, "model", retryContents[1].Role)
+	assert.Equal(t, "Sure! Here is my review: LGTM", retryContents[1].Parts[0].Text)
+	assert.Equal(t, "user", retryContents[2].Role)
+	assert.Contains(t, retryContents[2].Parts[0].Text, "Return valid JSON only")
+}
+
+func TestReviewDiffWithModel_WrappedJSON(t *testing.T) {
+	t.Parallel()
+	const verdict = `{"status": "changes_requested", "comments": "Handle the error"}`
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"json fence", "```json\n" + verdict + "\n```"},
+		{"bare fence", "```\n" + verdict + "\n```\n"},
+		{"prose around fence", "Here is my review:\n\n```json\n" + verdict + "\n```\n\nLet me know!"},
+		{"prose prefix", "Here is my review: " + verdict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			calls := 0
+			client := newStubClientWithGenerateContent(
+				func(
+					_ context.Context, _ string, _ []*genai.Content, _ *genai.GenerateContentConfig,
+				) (*genai.GenerateContentResponse, error) {
+					calls++
+					return &genai.GenerateContentResponse{
+						Candidates: []*genai.Candidate{{Content: &genai.Content{
+							Parts: []*genai.Part{{Text: tt.text}},
+						}}},
+					}, nil
+				},
+			)
+			r := &Reviewer{
+				client:        client,
+				modelName:     "test-model",
+				temperature:   0.2,
+				promptManager: prompts.New("", "", nil, nil),
+				logger:        testutil.NewTestLogger(),
+			}
+
+			result, err := r.ReviewDiff(t.Context(), "diff content", []string{"file.go"}, "/repo")
+			require.NoError(t, err)
+			assert.Equal(t, StatusChangesRequested, result.Status)
+			assert.Equal(t, "Handle the error", result.Comments)
+			assert.Equal(t, 1, calls, "an extractable verdict needs no second request")
+		})
+	}
+}
+
+func TestExtractReviewJSON(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", ` {"lgtm": true} `, `{"lgtm": true}`},
+		{"fence with braces in prose", "Use {x}:\n```json\n{\"a\": 1}\n```\nNot {y}.", `{"a": 1}`},
+		{"unterminated fence", "```json\n{\"a\": 1}", `{"a": 1}`},
+		{"prose suffix", `{"a": "}"} trailing`, `{"a": "}"}`},
+		{"no object", "LGTM", "LGTM"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, extractReviewJSON(tt.text), tt.name)
+	}
+}
+
+func TestParseReviewResult_TruncatesSnippet(t *testing.T) {
+	t.Parallel()
+	_, err := parseReviewResult(strings.Repeat("é", maxMalformedSnippet))
+	require.ErrorIs(t, err, ErrMalformedReviewResponse)
+	assert.Contains(t, err.Error(), strings.Repeat("é", maxMalformedSnippet/2)+`..."`)
+	assert.NotContains(t, err.Error(), strings.Repeat("é", maxMalformedSnippet/2+1))
+}
+
+func TestParseReviewResult_Categories(t *testing.T) {
+	t.Parallel()
+	result, err := parseReviewResult(`{"status": "changes_requested", "comments": "Three issues",
+		"categorized_comments": [
+			{"category": "style", "comment": "Rename x"},
+			{"category": "security", "comment": "Escape the query"},
+			{"category": "docs", "comment": "Not a category"},
+			{"category": "style", "comment": "  "},
+			{"category": "style", "comment": "Split the function"}
+		]}`)
+	require.NoError(t, err)
+	assert.Equal(t, "Three issues", result.Comments)
+	assert.Equal(t, []CategorizedComment{
+		{Category: CategoryStyle, Comment: "Rename x"},
+		{Category: CategorySecurity, Comment: "Escape the query"},
+		{Category: CategoryStyle, Comment: "Split the function"},
+	}, result.CategorizedComments)
+	assert.Equal(t, []string{"security", "style"}, result.Categories)
+
+	result, err = parseReviewResult(`{"status": "approved", "comments": "LGTM"}`)
+	require.NoError(t, err)
+	assert.Nil(t, result.CategorizedComments)
+	assert.Nil(t, result.Categories)
+}
+
+func TestReviewSchema_Categorize(t *testing.T) {
+	t.Parallel()
+	r := &Reviewer{}
+	assert.NotContains(t, r.reviewSchema().Properties, "categorized_comments")
+
+	r.categorize = true
+	schema := r.reviewSchema()
+	require.Contains(t, schema.Properties, "categorized_comments")
+	items := schema.Properties["categorized_comments"].Items
+	require.NotNil(t, items)
+	assert.Equal(t, []string{"security", "correctness", "performance", "style"}, items.Properties["category"].Enum)
+	assert.Equal(t, []string{"status", "comments"}, schema.Required, "categories stay optional")
+}
+
+func TestReviewDiffWithModel_ToolCallLoop(t *testing.T) {
+	t.Parallel()
+	callCount := 0
+	client := &StubGeminiClient{
+		CreateChatFunc: func(_ context.Context, _ string, _ *genai.GenerateContentConfig) (GeminiChat, error) {
+			return &StubGeminiChat{
+				SendMessageFunc: func(_ context.Context, _ ...genai.Part) (*genai.GenerateContentResponse, error) {
+					callCount++
+					if callCount == 1 {
+						// First call: return a function call.
+						return &genai.GenerateContentResponse{
+							Candidates: []*genai.Candidate{{Content: &genai.Content{
+								Parts: []*genai.Part{{
+									FunctionCall: &genai.FunctionCall{
+										Name: "get_file_content",
+										Args: map[string]any{"filepath": "main.go"},
+									},
+								}},
+							}}},
+						}, nil
+					}
 					// Second call (function response): return text analysis.
 					return &genai.GenerateContentResponse{
 						Candidates: []*genai.Candidate{{Content: &genai.Content{
@@ -2177,7 +2866,7 @@ func TestReviewDiffWithModel_ToolCallLoop(t *testing.T) {
 		client:        client,
 		modelName:     "test-model",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -2192,6 +2881,156 @@ func TestReviewDiffWithModel_ToolCallLoop(t *testing.T) {
 	assert.Equal(t, []string{"main.go"}, fetchedFiles)
 }
 
+func TestReviewDiff_FetchedFiles(t *testing.T) {
+	t.Parallel()
+	fileCall := func(paths ...string) *genai.GenerateContentResponse {
+		parts := make([]*genai.Part, len(paths))
+		for i, path := range paths {
+			parts[i] = &genai.Part{FunctionCall: &genai.FunctionCall{
+				Name: "get_file_content",
+				Args: map[string]any{"filepath": path},
+			}}
+		}
+
+		return &genai.GenerateContentResponse{
+			Candidates: []*genai.Candidate{{Content: &genai.Content{Parts: parts}}},
+		}
+	}
+	turns := []*genai.GenerateContentResponse{
+		fileCall("./util.go", "missing.go"),
+		fileCall("util.go"),
+		{Candidates: []*genai.Candidate{{Content: &genai.Content{
+			Parts: []*genai.Part{{Text: "Analysis"}},
+		}}}},
+	}
+	client := &StubGeminiClient{
+		CreateChatFunc: func(_ context.Context, _ string, _ *genai.GenerateContentConfig) (GeminiChat, error) {
+			turn := 0
+			return &StubGeminiChat{
+				SendMessageFunc: func(_ context.Context, _ ...genai.Part) (*genai.GenerateContentResponse, error) {
+					turn++
+					return turns[turn-1], nil
+				},
+			}, nil
+		},
+		GenerateContentFunc: func(
+			_ context.Context, _ string, _ []*genai.Content, _ *genai.GenerateContentConfig,
+		) (*genai.GenerateContentResponse, error) {
+			return &genai.GenerateContentResponse{
+				Candidates: []*genai.Candidate{{Content: &genai.Content{
+					Parts: []*genai.Part{{Text: `{"lgtm": true, "comments": "Good"}`}},
+				}}},
+			}, nil
+		},
+	}
+
+	tmpDir := testutil.CreateTempGitRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "util.go"), []byte("package main"), 0o600))
+
+	r := &Reviewer{
+		client:        client,
+		modelName:     "test-model",
+		temperature:   0.2,
+		promptManager: prompts.New("", "", nil, nil),
+		logger:        testutil.NewTestLogger(),
+	}
+
+	result, err := r.ReviewDiff(t.Context(), "diff content", []string{"main.go"}, tmpDir)
+	require.NoError(t, err)
+	// Files are recorded once, by clean path, and only if their content
+	// was sent: the missing file produced an error response instead.
+	assert.Equal(t, []string{"util.go"}, result.FetchedFiles)
+}
+
+// TestReviewDiffWithModel_CanceledMidLoop ensures a cancellation that lands
+// while a send is in flight is noticed before the next turn or Phase 2, even
+// though the send itself (with no retry configured) never checks the context.
+func TestReviewDiffWithModel_CanceledMidLoop(t *testing.T) {
+	t.Parallel()
+
+	funcCall := &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{Content: &genai.Content{
+			Parts: []*genai.Part{{
+				FunctionCall: &genai.FunctionCall{
+					Name: "get_file_content",
+					Args: map[string]any{"filepath": "main.go"},
+				},
+			}},
+		}}},
+	}
+	analysis := &genai.GenerateContentResponse{
+		Candidates: []*genai.Candidate{{Content: &genai.Content{
+			Parts: []*genai.Part{{Text: "Analysis"}},
+		}}},
+	}
+
+	tests := []struct {
+		name string
+		// cancelOnSend is the SendMessage call that cancels the context.
+		cancelOnSend int
+		// respond returns the response for the given (1-based) send.
+		respond func(send int) *genai.GenerateContentResponse
+	}{
+		{
+			name:         "between tool turns",
+			cancelOnSend: 2,
+			respond:      func(int) *genai.GenerateContentResponse { return funcCall },
+		},
+		{
+			name:         "before phase 2",
+			cancelOnSend: 1,
+			respond:      func(int) *genai.GenerateContentResponse { return analysis },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+
+			sends, generates := 0, 0
+			client := &StubGeminiClient{
+				CreateChatFunc: func(_ context.Context, _ string, _ *genai.GenerateContentConfig) (GeminiChat, error) {
+					return &StubGeminiChat{
+						SendMessageFunc: func(_ context.Context, _ ...genai.Part) (*genai.GenerateContentResponse, error) {
+							sends++
+							if sends == tt.cancelOnSend {
+								cancel()
+							}
+
+							return tt.respond(sends), nil
+						},
+					}, nil
+				},
+				GenerateContentFunc: func(
+					_ context.Context, _ string, _ []*genai.Content, _ *genai.GenerateContentConfig,
+				) (*genai.GenerateContentResponse, error) {
+					generates++
+
+					return nil, errTest
+				},
+			}
+
+			tmpDir := testutil.CreateTempGitRepo(t)
+			require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main"), 0o600))
+
+			r := &Reviewer{
+				client:        client,
+				modelName:     "test-model",
+				temperature:   0.2,
+				promptManager: prompts.New("", "", nil, nil),
+				logger:        testutil.NewTestLogger(),
+			}
+
+			_, err := r.ReviewDiff(ctx, "diff content", []string{"main.go"}, tmpDir)
+			require.ErrorIs(t, err, context.Canceled)
+			assert.Equal(t, tt.cancelOnSend, sends, "no message may be sent after cancellation")
+			assert.Zero(t, generates, "Phase 2 must not start after cancellation")
+		})
+	}
+}
+
 func TestReviewDiffWithModel_ChatCreationError(t *testing.T) {
 	t.Parallel()
 	client := &StubGeminiClient{
@@ -2204,7 +3043,7 @@ func TestReviewDiffWithModel_ChatCreationError(t *testing.T) {
 		client:        client,
 		modelName:     "test-model",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -2229,7 +3068,7 @@ func TestReviewDiffWithModel_InitialPromptError(t *testing.T) {
 		client:        client,
 		modelName:     "test-model",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -2250,7 +3089,7 @@ func TestReviewDiffWithModel_ReviewPromptError(t *testing.T) {
 		client:        client,
 		modelName:     "test-model",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -2318,7 +3157,7 @@ func TestCalculateBackoff_InvalidStrings(t *testing.T) {
 		MaxBackoff:        "invalid",
 		BackoffMultiplier: 1.5,
 	}
-	backoff := calculateBackoff(0, cfg)
+	backoff, _ := calculateBackoff(0, cfg)
 	// Should fall back to defaults: 1s initial, 60s max.
 	assert.Greater(t, backoff, 500*time.Millisecond)
 	assert.LessOrEqual(t, backoff, 2*time.Second)
@@ -2331,8 +3170,9 @@ func TestCalculateBackoff_CappedAtMax(t *testing.T) {
 		MaxBackoff:        "5s",
 		BackoffMultiplier: 10,
 	}
-	backoff := calculateBackoff(10, cfg)
-	assert.LessOrEqual(t, backoff, 6*time.Second) // 5s max + jitter.
+	backoff, capped := calculateBackoff(10, cfg)
+	assert.True(t, capped)
+	assert.Equal(t, 5*time.Second, backoff)
 }
 
 func TestHandleFileRetrieval_NonStringFilepath(t *testing.T) {
@@ -2386,7 +3226,7 @@ func TestReviewDiffWithModel_TokenUsage(t *testing.T) {
 		client:        client,
 		modelName:     "gemini-3.1-pro-preview",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -2488,6 +3328,7 @@ func TestHandleFileRetrieval_ParentSymlinkEscape(t *testing.T) {
 // TestHandleFileRetrieval_FileSizeLimit ensures that a file larger than the
 // maximum allowed size is rejected rather than read into memory, so the
 // review process cannot be OOM'd by an attacker-supplied or runaway request.
+// With max_file_bytes set to 0 (no truncation), the built-in limit applies.
 func TestHandleFileRetrieval_FileSizeLimit(t *testing.T) {
 	t.Parallel()
 	repoDir := testutil.CreateTempGitRepo(t)
@@ -2497,6 +3338,7 @@ func TestHandleFileRetrieval_FileSizeLimit(t *testing.T) {
 	require.NoError(t, os.WriteFile(bigFile, make([]byte, maxRetrievedFileSize+1), 0o600))
 
 	cfg := config.NewTestConfig()
+	cfg.Gemini.MaxFileBytes = new(int64(0))
 	reviewer, err := New(cfg, testutil.NewTestLogger())
 	require.NoError(t, err)
 
@@ -2513,6 +3355,40 @@ func TestHandleFileRetrieval_FileSizeLimit(t *testing.T) {
 	assert.False(t, leaked, "must not return content when size limit is exceeded")
 }
 
+// TestHandleFileRetrieval_MaxFileBytes verifies that files over
+// gemini.max_file_bytes are truncated and flagged with their full size,
+// while files within it are returned whole and unflagged.
+func TestHandleFileRetrieval_MaxFileBytes(t *testing.T) {
+	t.Parallel()
+	repoDir := testutil.CreateTempGitRepo(t)
+
+	testutil.CreateFile(t, repoDir, "long.txt", strings.Repeat("0123456789", 10))
+	testutil.CreateFile(t, repoDir, "short.txt", "0123456789")
+
+	cfg := config.NewTestConfig()
+	cfg.Gemini.MaxFileBytes = new(int64(25))
+	reviewer, err := New(cfg, testutil.NewTestLogger())
+	require.NoError(t, err)
+
+	resp := reviewer.handleFileRetrieval(t.Context(), &genai.FunctionCall{
+		Name: "get_file_content",
+		Args: map[string]any{"filepath": "long.txt"},
+	}, repoDir, nil)
+	require.NotNil(t, resp.FunctionResponse)
+	assert.Equal(t, map[string]any{
+		"content":   "0123456789012345678901234",
+		"truncated": true,
+		"size":      int64(100),
+	}, resp.FunctionResponse.Response)
+
+	resp = reviewer.handleFileRetrieval(t.Context(), &genai.FunctionCall{
+		Name: "get_file_content",
+		Args: map[string]any{"filepath": "short.txt"},
+	}, repoDir, nil)
+	require.NotNil(t, resp.FunctionResponse)
+	assert.Equal(t, map[string]any{"content": "0123456789"}, resp.FunctionResponse.Response)
+}
+
 // TestHandleFileRetrieval_SymlinkToIgnoredFile ensures an in-repo symlink
 // cannot launder gitignored content: check-ignore matches the requested name
 // only, so without re-checking the resolved target, "config-link -> .env"
@@ -2608,7 +3484,7 @@ func TestReviewDiffWithModel_ParallelFunctionCalls(t *testing.T) {
 		client:        client,
 		modelName:     "test-model",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -2624,56 +3500,80 @@ func TestReviewDiffWithModel_ParallelFunctionCalls(t *testing.T) {
 	assert.Equal(t, []int{2}, responsePartCounts)
 }
 
-// TestReviewDiffWithModel_ToolTurnLimit ensures the tool-calling loop is
-// bounded: a model that requests a file on every turn must not loop (and
-// bill) forever; the review proceeds to the structured phase at the cap.
-func TestReviewDiffWithModel_ToolTurnLimit(t *testing.T) {
+// TestReviewDiffWithModel_ToolCallLimit ensures the tool-calling loop is
+// bounded: a model that requests files on every turn must not loop (and
+// bill) forever; the review proceeds to the structured phase at the cap,
+// with the analysis text gathered so far.
+func TestReviewDiffWithModel_ToolCallLimit(t *testing.T) {
 	t.Parallel()
-	sendCount := 0
-	client := &StubGeminiClient{
-		CreateChatFunc: func(_ context.Context, _ string, _ *genai.GenerateContentConfig) (GeminiChat, error) {
-			return &StubGeminiChat{
-				SendMessageFunc: func(_ context.Context, _ ...genai.Part) (*genai.GenerateContentResponse, error) {
-					sendCount++
-					// Always request another file, forever.
+
+	tmpDir := testutil.CreateTempGitRepo(t)
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main"), 0o600))
+
+	for _, tc := range []struct {
+		name         string
+		maxToolCalls int
+		callsPerTurn int
+		wantSends    int
+	}{
+		// Initial prompt plus one function-response turn per call.
+		{"default", 0, 1, 1 + config.DefaultMaxToolCalls},
+		// Turns of two calls reach a cap of 3 on the second turn, which is
+		// still answered in full.
+		{"configured with parallel calls", 3, 2, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			sendCount := 0
+			var reviewPrompt string
+			client := &StubGeminiClient{
+				CreateChatFunc: func(_ context.Context, _ string, _ *genai.GenerateContentConfig) (GeminiChat, error) {
+					return &StubGeminiChat{
+						SendMessageFunc: func(_ context.Context, _ ...genai.Part) (*genai.GenerateContentResponse, error) {
+							sendCount++
+							// Always request more files, forever.
+							parts := []*genai.Part{{Text: fmt.Sprintf("Analysis after turn %d.", sendCount)}}
+							for range tc.callsPerTurn {
+								parts = append(parts, &genai.Part{FunctionCall: &genai.FunctionCall{
+									Name: "get_file_content",
+									Args: map[string]any{"filepath": "main.go"},
+								}})
+							}
+
+							return &genai.GenerateContentResponse{
+								Candidates: []*genai.Candidate{{Content: &genai.Content{Parts: parts}}},
+							}, nil
+						},
+					}, nil
+				},
+				GenerateContentFunc: func(
+					_ context.Context, _ string, contents []*genai.Content, _ *genai.GenerateContentConfig,
+				) (*genai.GenerateContentResponse, error) {
+					reviewPrompt = contents[0].Parts[0].Text
 					return &genai.GenerateContentResponse{
 						Candidates: []*genai.Candidate{{Content: &genai.Content{
-							Parts: []*genai.Part{{FunctionCall: &genai.FunctionCall{
-								Name: "get_file_content",
-								Args: map[string]any{"filepath": "main.go"},
-							}}},
+							Parts: []*genai.Part{{Text: `{"lgtm": true, "comments": "OK"}`}},
 						}}},
 					}, nil
 				},
-			}, nil
-		},
-		GenerateContentFunc: func(
-			_ context.Context, _ string, _ []*genai.Content, _ *genai.GenerateContentConfig,
-		) (*genai.GenerateContentResponse, error) {
-			return &genai.GenerateContentResponse{
-				Candidates: []*genai.Candidate{{Content: &genai.Content{
-					Parts: []*genai.Part{{Text: `{"lgtm": true, "comments": "OK"}`}},
-				}}},
-			}, nil
-		},
-	}
+			}
 
-	tmpDir := testutil.CreateTempGitRepo(t)
-	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main"), 0o600))
+			r := &Reviewer{
+				client:        client,
+				modelName:     "test-model",
+				temperature:   0.2,
+				maxToolCalls:  tc.maxToolCalls,
+				promptManager: prompts.New("", "", nil, nil),
+				logger:        testutil.NewTestLogger(),
+			}
 
-	r := &Reviewer{
-		client:        client,
-		modelName:     "test-model",
-		temperature:   0.2,
-		promptManager: prompts.New("", ""),
-		logger:        testutil.NewTestLogger(),
+			result, err := r.ReviewDiff(t.Context(), "diff content", []string{"main.go"}, tmpDir)
+			require.NoError(t, err)
+			assert.True(t, result.LGTM)
+			assert.Equal(t, tc.wantSends, sendCount)
+			assert.Contains(t, reviewPrompt, fmt.Sprintf("Analysis after turn %d.", tc.wantSends))
+		})
 	}
-
-	result, err := r.ReviewDiff(t.Context(), "diff content", []string{"main.go"}, tmpDir)
-	require.NoError(t, err)
-	assert.True(t, result.LGTM)
-	// Initial prompt plus at most maxToolTurns function-response turns.
-	assert.Equal(t, 1+maxToolTurns, sendCount)
 }
 
 // TestReviewDiff_NoFallbackWhenUnset ensures an empty fallback model (as on a
@@ -2694,7 +3594,7 @@ func TestReviewDiff_NoFallbackWhenUnset(t *testing.T) {
 		modelName:     "primary-model",
 		fallbackModel: "",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -2743,7 +3643,7 @@ func TestReviewDiffWithModel_ThoughtPartNotCapturedAsAnalysis(t *testing.T) {
 		client:        client,
 		modelName:     "test-model",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -2776,7 +3676,7 @@ func TestReviewDiffWithModel_ThoughtPartSkippedInReviewPhase(t *testing.T) {
 		client:        client,
 		modelName:     "test-model",
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        testutil.NewTestLogger(),
 	}
 
@@ -2785,3 +3685,300 @@ func TestReviewDiffWithModel_ThoughtPartSkippedInReviewPhase(t *testing.T) {
 	assert.True(t, result.LGTM)
 	assert.Equal(t, "OK", result.Comments)
 }
+
+func TestReviewDiff_MaxEstimatedCost(t *testing.T) {
+	t.Parallel()
+
+	smallDiff := "diff --git a/file.go b/file.go\n+package main\n"
+	// About 200K characters: ~100K estimated input tokens across both
+	// phases, or $0.03 at gemini-2.5-flash input pricing.
+	largeDiff := "diff --git a/file.go b/file.go\n" + strings.Repeat("+// padding line for cost estimate\n", 6000)
+
+	tests := []struct {
+		name     string
+		model    string
+		maxCost  float64
+		diff     string
+		wantErr  bool
+		wantChat bool
+	}{
+		{name: "oversized diff refused", model: "gemini-2.5-flash", maxCost: 0.01, diff: largeDiff, wantErr: true},
+		{name: "normal diff proceeds", model: "gemini-2.5-flash", maxCost: 0.01, diff: smallDiff, wantChat: true},
+		{name: "no limit", model: "gemini-2.5-flash", diff: largeDiff, wantChat: true},
+		{name: "unknown model not estimated", model: "unpriced-model", maxCost: 0.01, diff: largeDiff, wantChat: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newStubClient("Analysis complete.", stubReviewJSON)
+			createChat := client.CreateChatFunc
+			chats := 0
+			client.CreateChatFunc = func(
+				ctx context.Context, model string, cfg *genai.GenerateContentConfig,
+			) (GeminiChat, error) {
+				chats++
+				return createChat(ctx, model, cfg)
+			}
+
+			r := &Reviewer{
+				client:           client,
+				modelName:        tt.model,
+				temperature:      0.2,
+				maxEstimatedCost: tt.maxCost,
+				promptManager:    prompts.New("", "", nil, nil),
+				logger:           testutil.NewTestLogger(),
+			}
+
+			result, err := r.ReviewDiff(t.Context(), tt.diff, []string{"file.go"}, "/repo")
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrCostLimitExceeded)
+				assert.Contains(t, err.Error(), "gemini.max_estimated_cost")
+				assert.Zero(t, chats, "no API call should be made once the review is refused")
+
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, result)
+			assert.Equal(t, 1, chats)
+		})
+	}
+}
+
+func TestHandleFileRetrieval_DenyReadGlobs(t *testing.T) {
+	t.Parallel()
+	repoDir := testutil.CreateTempGitRepo(t)
+	for _, name := range []string{".env", "deploy/id_rsa", "certs/server.pem", "secrets/token.txt", "main.go"} {
+		testutil.CreateFile(t, repoDir, name, "content of "+name)
+	}
+	require.NoError(t, os.Symlink("deploy/id_rsa", filepath.Join(repoDir, "key-link.txt")))
+	testutil.RunGitCmd(t, repoDir, "add", ".")
+	testutil.RunGitCmd(t, repoDir, "commit", "-m", "initial")
+
+	reviewer, err := New(config.NewTestConfig(), testutil.NewTestLogger())
+	require.NoError(t, err)
+	runFileRetrievalTests(t, reviewer, repoDir, []fileRetrievalTest{
+		{name: "tracked .env", filepath: ".env", expectedError: errDeniedFileMsg},
+		{name: "basename in a subdirectory", filepath: "deploy/id_rsa", expectedError: errDeniedFileMsg},
+		{name: "glob", filepath: "certs/server.pem", expectedError: errDeniedFileMsg},
+		{name: "symlink to a denied file", filepath: "key-link.txt", expectedError: errDeniedFileMsg},
+		{name: "absolute path", filepath: filepath.Join(repoDir, "main.go"), expectedError: "absolute paths"},
+		{
+			name: "unlisted file", filepath: "secrets/token.txt",
+			shouldSucceed: true, expectContent: "content of secrets/token.txt",
+		},
+	})
+
+	cfg := config.NewTestConfig()
+	cfg.Gitleaks.DenyReadGlobs = []string{"secrets/*"}
+	custom, err := New(cfg, testutil.NewTestLogger())
+	require.NoError(t, err)
+	runFileRetrievalTests(t, custom, repoDir, []fileRetrievalTest{
+		{name: "configured path glob", filepath: "secrets/token.txt", expectedError: errDeniedFileMsg},
+		{name: "default replaced", filepath: ".env", shouldSucceed: true, expectContent: "content of .env"},
+	})
+
+	cfg = config.NewTestConfig()
+	cfg.Gitleaks.DenyReadGlobs = []string{"["}
+	_, err = New(cfg, testutil.NewTestLogger())
+	require.ErrorContains(t, err, "invalid gitleaks.deny_read_globs pattern")
+}
+
+func TestReviewDiffWithModel_DuplicateFileRequests(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "main.go", "package main")
+	testutil.CreateFile(t, tmpDir, "util.go", "package util")
+
+	fetchCall := func(file string) *genai.Part {
+		return &genai.Part{FunctionCall: &genai.FunctionCall{
+			Name: "get_file_content",
+			Args: map[string]any{"filepath": file},
+		}}
+	}
+	turns := [][]*genai.Part{
+		{fetchCall("main.go"), fetchCall("util.go"), fetchCall("./main.go")},
+		{fetchCall("util.go")},
+		{{Text: "Done."}},
+	}
+	var sent [][]genai.Part
+	client := &StubGeminiClient{
+		CreateChatFunc: func(_ context.Context, _ string, _ *genai.GenerateContentConfig) (GeminiChat, error) {
+			return &StubGeminiChat{
+				SendMessageFunc: func(_ context.Context, parts ...genai.Part) (*genai.GenerateContentResponse, error) {
+					sent = append(sent, parts)
+					return &genai.GenerateContentResponse{
+						Candidates: []*genai.Candidate{{Content: &genai.Content{Parts: turns[len(sent)-1]}}},
+					}, nil
+				},
+			}, nil
+		},
+	}
+	r := &Reviewer{
+		client:        client,
+		modelName:     "test-model",
+		temperature:   0.2,
+		promptManager: prompts.New("", "", nil, nil),
+		logger:        testutil.NewTestLogger(),
+	}
+
+	var fetched []string
+	_, err := r.ReviewDiff(t.Context(), "diff content", []string{"main.go"}, tmpDir,
+		WithFileFetchCallback(func(file string) { fetched = append(fetched, file) }))
+	require.NoError(t, err)
+
+	// Every call is answered, in call order, but each file is read once.
+	assert.Equal(t, []string{"main.go", "util.go"}, fetched)
+	require.Len(t, sent, 3)
+	contents := func(parts []genai.Part) []any {
+		var got []any
+		for _, part := range parts {
+			got = append(got, part.FunctionResponse.Response["content"])
+		}
+		return got
+	}
+	assert.Equal(t, []any{"package main", "package util", "package main"}, contents(sent[1]))
+	assert.Equal(t, []any{"package util"}, contents(sent[2]))
+}
+
+func TestNumberLines(t *testing.T) {
+	t.Parallel()
+	assert.Empty(t, numberLines(""))
+	assert.Equal(t, "1 | one\n", numberLines("one\n"))
+	assert.Equal(t, "1 | one\n2 | two", numberLines("one\ntwo"))
+	lines := strings.Repeat("x\n", 10)
+	got := numberLines(lines)
+	assert.True(t, strings.HasPrefix(got, " 1 | x\n 2 | x\n"), got)
+	assert.True(t, strings.HasSuffix(got, "10 | x\n"), got)
+}
+
+func TestHandleFileRetrieval_LineNumbers(t *testing.T) {
+	t.Parallel()
+	repoDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, repoDir, "main.go", "package main\n\nfunc main() {}\n")
+
+	retrieve := func(t *testing.T, reviewer *Reviewer, args map[string]any) map[string]any {
+		t.Helper()
+		args["filepath"] = "main.go"
+		result := reviewer.handleFileRetrieval(t.Context(), &genai.FunctionCall{
+			Name: "get_file_content",
+			Args: args,
+		}, repoDir, nil)
+		require.NotNil(t, result.FunctionResponse)
+
+		return result.FunctionResponse.Response
+	}
+	const raw = "package main\n\nfunc main() {}\n"
+	const numbered = "1 | package main\n2 | \n3 | func main() {}\n"
+
+	reviewer, err := New(config.NewTestConfig(), testutil.NewTestLogger())
+	require.NoError(t, err)
+	assert.Equal(t, raw, retrieve(t, reviewer, map[string]any{})["content"])
+	assert.Equal(t, numbered, retrieve(t, reviewer, map[string]any{"with_line_numbers": true})["content"])
+	assert.Equal(t, "with_line_numbers must be a boolean",
+		retrieve(t, reviewer, map[string]any{"with_line_numbers": "yes"})["error"])
+
+	cfg := config.NewTestConfig()
+	cfg.Gemini.FileLineNumbers = true
+	numbering, err := New(cfg, testutil.NewTestLogger())
+	require.NoError(t, err)
+	assert.Equal(t, numbered, retrieve(t, numbering, map[string]any{})["content"])
+	assert.Equal(t, raw, retrieve(t, numbering, map[string]any{"with_line_numbers": false})["content"])
+}
+
+// TestReviewDiff_SamplingParameters verifies top_p, top_k, and seed reach
+// both phases when set and are left to the API defaults otherwise.
+func TestReviewDiff_SamplingParameters(t *testing.T) {
+	t.Parallel()
+	for _, set := range []bool{false, true} {
+		t.Run(fmt.Sprintf("set=%t", set), func(t *testing.T) {
+			t.Parallel()
+			cfg := config.NewTestConfig()
+			if set {
+				cfg.Gemini.TopP = new(float32(0.9))
+				cfg.Gemini.TopK = new(int32(40))
+				cfg.Gemini.Seed = new(7)
+			}
+			r, err := New(cfg, testutil.NewTestLogger())
+			require.NoError(t, err)
+
+			client := newStubClient("Analysis complete.", stubReviewJSON)
+			var configs []*genai.GenerateContentConfig
+			createChat, generateContent := client.CreateChatFunc, client.GenerateContentFunc
+			client.CreateChatFunc = func(
+				ctx context.Context, model string, cfg *genai.GenerateContentConfig,
+			) (GeminiChat, error) {
+				configs = append(configs, cfg)
+				return createChat(ctx, model, cfg)
+			}
+			client.GenerateContentFunc = func(
+				ctx context.Context, model string, contents []*genai.Content, cfg *genai.GenerateContentConfig,
+			) (*genai.GenerateContentResponse, error) {
+				configs = append(configs, cfg)
+				return generateContent(ctx, model, contents, cfg)
+			}
+			r.client = client
+
+			_, err = r.ReviewDiff(t.Context(), "diff --git a/f.go b/f.go\n+package f\n", []string{"f.go"}, "/repo")
+			require.NoError(t, err)
+			require.Len(t, configs, 2, "context gathering and review")
+			for _, c := range configs {
+				if !set {
+					assert.Nil(t, c.TopP)
+					assert.Nil(t, c.TopK)
+					assert.Nil(t, c.Seed)
+					continue
+				}
+				require.NotNil(t, c.TopP)
+				require.NotNil(t, c.TopK)
+				require.NotNil(t, c.Seed)
+				assert.InDelta(t, 0.9, *c.TopP, 0.0001)
+				assert.InDelta(t, 40.0, *c.TopK, 0.0001)
+				assert.Equal(t, int32(7), *c.Seed)
+			}
+		})
+	}
+}
+
+func TestReviewDiff_SystemInstruction(t *testing.T) {
+	t.Parallel()
+	client := newStubClient("Analysis complete.", stubReviewJSON)
+	var configs []*genai.GenerateContentConfig
+	var reviewContents []*genai.Content
+	createChat, generateContent := client.CreateChatFunc, client.GenerateContentFunc
+	client.CreateChatFunc = func(
+		ctx context.Context, model string, cfg *genai.GenerateContentConfig,
+	) (GeminiChat, error) {
+		configs = append(configs, cfg)
+		return createChat(ctx, model, cfg)
+	}
+	client.GenerateContentFunc = func(
+		ctx context.Context, model string, contents []*genai.Content, cfg *genai.GenerateContentConfig,
+	) (*genai.GenerateContentResponse, error) {
+		configs = append(configs, cfg)
+		reviewContents = contents
+		return generateContent(ctx, model, contents, cfg)
+	}
+	r := &Reviewer{
+		client:        client,
+		modelName:     "test-model",
+		temperature:   0.2,
+		promptManager: prompts.New("", "", nil, nil),
+		logger:        testutil.NewTestLogger(),
+	}
+
+	_, err := r.ReviewDiff(t.Context(), "diff --git a/f.go b/f.go\n+package f\n", []string{"f.go"}, "/repo")
+	require.NoError(t, err)
+	require.Len(t, configs, 2, "context gathering and review")
+	for _, c := range configs {
+		require.NotNil(t, c.SystemInstruction)
+		require.Len(t, c.SystemInstruction.Parts, 1)
+		assert.Contains(t, c.SystemInstruction.Parts[0].Text, "strict code reviewer")
+	}
+	require.Len(t, reviewContents, 1)
+	assert.NotContains(t, reviewContents[0].Parts[0].Text, "strict code reviewer",
+		"the persona lives in the system instruction, not the user prompt")
+	assert.Contains(t, reviewContents[0].Parts[0].Text, "+package f")
+}
@@ -34,11 +34,20 @@ func NewForTesting() *Reviewer {
 		client:        newDefaultStubClient(),
 		modelName:     defaultModel,
 		temperature:   0.2,
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        logger,
 	}
 }
 
+// NewForTestingWithClient creates a Reviewer backed by the given client, so
+// tests outside this package can observe the prompts the reviewer sends.
+func NewForTestingWithClient(client GeminiClient) *Reviewer {
+	r := NewForTesting()
+	r.client = client
+
+	return r
+}
+
 // newDefaultStubClient creates a stub client with sensible defaults.
 func newDefaultStubClient() GeminiClient {
 	return newStubClient("Analysis complete. Code looks good.", stubReviewJSON)
@@ -94,7 +103,7 @@ func WithStubResponse(lgtm bool, comments string) *Reviewer {
 		modelName:     defaultModel,
 		temperature:   0.2,
 		retryConfig:   nil, // No retry for testing by default.
-		promptManager: prompts.New("", ""),
+		promptManager: prompts.New("", "", nil, nil),
 		logger:        logger,
 	}
 }
@@ -18,7 +18,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	stdpath "path"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -30,17 +32,51 @@ import (
 // ErrCustomConfigNotSupported indicates that custom gitleaks config is not supported.
 var ErrCustomConfigNotSupported = errors.New("custom config not supported in this version")
 
-// detectorMutex protects the creation of new detectors to avoid race conditions
-// in the gitleaks library which uses a global viper instance.
-var detectorMutex sync.Mutex
+// defaultDetector returns the detector for gitleaks' default config, which
+// every Scanner shares: the config is immutable, building it is expensive,
+// and DetectString keeps no state between scans. Building it once also
+// avoids a race in gitleaks, whose global viper instance is not safe for
+// concurrent detector creation.
+var defaultDetector = sync.OnceValues(func() (*detect.Detector, error) {
+	detector, err := detect.NewDetectorDefaultConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create detector with default config: %w", err)
+	}
+	detector.FollowSymlinks = false // Never follow symlinks for security.
+
+	return detector, nil
+})
 
 // Scanner provides secret detection capabilities using gitleaks.
 type Scanner struct {
-	detector *detect.Detector
+	detector       *detect.Detector
+	skipFiles      []string
+	scanAddedLines bool
+}
+
+// Option configures a Scanner.
+type Option func(*Scanner)
+
+// WithSkipFiles sets additional files that ScanDiff never scans. Each pattern
+// is matched (path.Match syntax) against both the file's basename and its
+// repo-relative path, so "yarn.lock" skips every yarn.lock while
+// "testdata/*.json" skips only JSON directly under testdata/.
+func WithSkipFiles(patterns []string) Option {
+	return func(s *Scanner) {
+		s.skipFiles = patterns
+	}
+}
+
+// WithScanAddedLines makes ScanDiff scan only the lines the diff adds rather
+// than each changed file's full current content. See [Scanner.ScanDiff].
+func WithScanAddedLines(enabled bool) Option {
+	return func(s *Scanner) {
+		s.scanAddedLines = enabled
+	}
 }
 
 // New creates a new Scanner with optional custom configuration.
-func New(configPath string) (*Scanner, error) {
+func New(configPath string, opts ...Option) (*Scanner, error) {
 	if configPath != "" {
 		// For custom config, we need to load it ourselves.
 		// The v8 API doesn't have a LoadConfig function exposed.
@@ -48,30 +84,64 @@ func New(configPath string) (*Scanner, error) {
 		return nil, ErrCustomConfigNotSupported
 	}
 
-	// Synchronize detector creation to avoid race conditions in gitleaks.
-	// The gitleaks library uses a global viper instance which causes races
-	// when multiple detectors are created concurrently.
-	detectorMutex.Lock()
-	detector, err := detect.NewDetectorDefaultConfig()
-	detectorMutex.Unlock()
-
+	detector, err := defaultDetector()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create detector with default config: %w", err)
+		return nil, err
 	}
 
-	detector.FollowSymlinks = false // Never follow symlinks for security.
-
-	return &Scanner{
+	s := &Scanner{
 		detector: detector,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// Reject malformed globs up front; path.Match would otherwise report
+	// ErrBadPattern on every file and the pattern would silently never match.
+	for _, pattern := range s.skipFiles {
+		if _, err := stdpath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid skip_files pattern %q: %w", pattern, err)
+		}
+	}
+
+	return s, nil
+}
+
+// shouldSkip reports whether file is exempt from scanning. go.sum and
+// go.work.sum are always skipped; their checksums trigger false positives for
+// API key detection. Use path (not filepath) since git diffs always use
+// forward slashes.
+func (s *Scanner) shouldSkip(file string) bool {
+	base := stdpath.Base(file)
+	if base == "go.sum" || base == "go.work.sum" {
+		return true
+	}
+	for _, pattern := range s.skipFiles {
+		if matched, _ := stdpath.Match(pattern, base); matched {
+			return true
+		}
+		if matched, _ := stdpath.Match(pattern, file); matched {
+			return true
+		}
+	}
+
+	return false
 }
 
 // ScanDiff scans a git diff for secrets by extracting changed files.
 // Note: This method extracts file paths from the diff and scans the actual files
 // rather than scanning the diff directly, as gitleaks v8 doesn't reliably
 // detect secrets in diff format when used as a library.
+//
+// With [WithScanAddedLines], it instead scans just the "+" lines of each
+// file's hunks, stripped of the diff markup, and getFileContent is unused.
+// Findings then carry the 1-based line number in the new file, and secrets
+// in unchanged or removed lines are not reported.
+//
+// The scan stops with ctx's error once ctx is done, checked between files
+// and within each file's scan.
 func (s *Scanner) ScanDiff(
-	_ context.Context,
+	ctx context.Context,
 	diff string,
 	getFileContent func(path string) (string, error),
 ) ([]report.Finding, error) {
@@ -79,16 +149,29 @@ func (s *Scanner) ScanDiff(
 		return nil, nil
 	}
 
-	// Parse the diff to extract changed files.
-	changedFiles := ExtractChangedFiles(diff)
+	if s.scanAddedLines {
+		return s.scanDiffAddedLines(ctx, diff)
+	}
+
+	return s.ScanFiles(ctx, ExtractChangedFiles(diff), getFileContent)
+}
 
+// ScanFiles scans the full content of each file, as returned by
+// getFileContent, for secrets. Files matching skip_files and files that
+// cannot be read are skipped. Unlike [Scanner.ScanDiff], it is unaffected by
+// [WithScanAddedLines], since the files need not have changed. As with
+// ScanDiff, it stops with ctx's error once ctx is done.
+func (s *Scanner) ScanFiles(
+	ctx context.Context, files []string, getFileContent func(path string) (string, error),
+) ([]report.Finding, error) {
 	var allFindings []report.Finding
-	for _, file := range changedFiles {
-		// Skip go.sum and go.work.sum files as they may contain
-		// checksums/hashes that trigger false positives for API key
-		// detection. Use path.Base (not filepath.Base) since git diffs
-		// always use forward slashes.
-		if base := stdpath.Base(file); base == "go.sum" || base == "go.work.sum" {
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Skip lockfiles and other generated files whose checksums/hashes
+		// trigger false positives for API key detection.
+		if s.shouldSkip(file) {
 			continue
 		}
 
@@ -98,12 +181,226 @@ func (s *Scanner) ScanDiff(
 			continue
 		}
 
-		allFindings = append(allFindings, s.scanContent(content, file)...)
+		findings, err := s.scanContent(ctx, content, file)
+		if err != nil {
+			return nil, err
+		}
+		allFindings = append(allFindings, findings...)
+	}
+
+	return allFindings, nil
+}
+
+// ScanAddedLines scans just the lines diff adds, as [Scanner.ScanDiff] does
+// with [WithScanAddedLines], whether or not that option is set. It is for a
+// diff that did not come from a working tree, whose files cannot be read.
+func (s *Scanner) ScanAddedLines(ctx context.Context, diff string) ([]report.Finding, error) {
+	return s.scanDiffAddedLines(ctx, diff)
+}
+
+// scanDiffAddedLines scans the lines diff adds to each file, remapping each
+// finding's line numbers from the joined added content back to the new file.
+func (s *Scanner) scanDiffAddedLines(ctx context.Context, diff string) ([]report.Finding, error) {
+	var allFindings []report.Finding
+	for _, added := range extractAddedLines(diff) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(added.lines) == 0 || s.shouldSkip(added.file) {
+			continue
+		}
+
+		findings, err := s.scanContent(ctx, strings.Join(added.lines, "\n"), added.file)
+		if err != nil {
+			return nil, err
+		}
+		for i := range findings {
+			// DetectString numbers lines from 0 within the scanned content.
+			if n := findings[i].StartLine; n >= 0 && n < len(added.lineNos) {
+				findings[i].StartLine = added.lineNos[n]
+			}
+			if n := findings[i].EndLine; n >= 0 && n < len(added.lineNos) {
+				findings[i].EndLine = added.lineNos[n]
+			}
+		}
+		allFindings = append(allFindings, findings...)
 	}
 
 	return allFindings, nil
 }
 
+// addedLines holds the lines a diff adds to one file. lineNos[i] is the
+// 1-based line number of lines[i] in the new file.
+type addedLines struct {
+	file    string
+	lines   []string
+	lineNos []int
+}
+
+// extractAddedLines parses a git diff and returns the added lines of each
+// file block in diff order. The destination path is resolved as in
+// [ExtractChangedFilesDetailed]; header lines such as "+++ b/file" are
+// ignored because only lines inside a hunk are considered.
+func extractAddedLines(diff string) []addedLines {
+	var result []addedLines
+	inHunk := false
+	newLine := 0
+
+	for rawLine := range strings.SplitSeq(diff, "\n") {
+		line := strings.TrimSuffix(rawLine, "\r")
+		if strings.HasPrefix(line, "diff --git ") {
+			result = append(result, addedLines{file: parseGitDiffHeader(line)})
+			inHunk = false
+
+			continue
+		}
+		if len(result) == 0 {
+			continue
+		}
+		cur := &result[len(result)-1]
+
+		if strings.HasPrefix(line, "@@ ") {
+			newLine, inHunk = parseHunkNewStart(line)
+
+			continue
+		}
+		if !inHunk {
+			if path, ok := strings.CutPrefix(line, "rename to "); ok {
+				cur.file = unquoteIfQuoted(path)
+			} else if path, ok := strings.CutPrefix(line, "copy to "); ok {
+				cur.file = unquoteIfQuoted(path)
+			}
+
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+"):
+			cur.lines = append(cur.lines, line[1:])
+			cur.lineNos = append(cur.lineNos, newLine)
+			newLine++
+		case strings.HasPrefix(line, " "):
+			newLine++
+		case strings.HasPrefix(line, "-"), strings.HasPrefix(line, `\`):
+			// Removed lines and "\ No newline at end of file" markers do not
+			// advance the new file's line count.
+		default:
+			inHunk = false
+		}
+	}
+
+	return result
+}
+
+// parseHunkNewStart returns the new-file start line from a hunk header of
+// the form "@@ -a[,b] +c[,d] @@".
+func parseHunkNewStart(line string) (int, bool) {
+	rest, ok := strings.CutPrefix(line, "@@ -")
+	if !ok {
+		return 0, false
+	}
+	_, rest, ok = strings.Cut(rest, " +")
+	if !ok {
+		return 0, false
+	}
+	end := strings.IndexAny(rest, ", ")
+	if end == -1 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(rest[:end])
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// RemovedRegion is the span of old-file lines, 1-based and inclusive, that a
+// diff hunk removes or replaces.
+type RemovedRegion struct {
+	File       string
+	Start, End int
+}
+
+// ExtractRemovedRegions parses a git diff and returns, for each hunk that
+// removes lines, the span from its first to its last removed line in the old
+// version of the file, in diff order. The path is the rename or copy source
+// when there is one, since that is where the old lines live. Hunks that only
+// add lines have no old lines and are skipped.
+func ExtractRemovedRegions(diff string) []RemovedRegion {
+	var result []RemovedRegion
+	var file string
+	inHunk := false
+	oldLine := 0
+	// cur is the region of the current hunk, or nil before its first
+	// removed line.
+	var cur *RemovedRegion
+
+	for rawLine := range strings.SplitSeq(diff, "\n") {
+		line := strings.TrimSuffix(rawLine, "\r")
+		if strings.HasPrefix(line, "diff --git ") {
+			file = parseGitDiffHeader(line)
+			inHunk = false
+			cur = nil
+
+			continue
+		}
+		if strings.HasPrefix(line, "@@ ") {
+			oldLine, inHunk = parseHunkOldStart(line)
+			cur = nil
+
+			continue
+		}
+		if !inHunk {
+			if path, ok := strings.CutPrefix(line, "rename from "); ok {
+				file = unquoteIfQuoted(path)
+			} else if path, ok := strings.CutPrefix(line, "copy from "); ok {
+				file = unquoteIfQuoted(path)
+			}
+
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "-"):
+			if cur == nil {
+				result = append(result, RemovedRegion{File: file, Start: oldLine})
+				cur = &result[len(result)-1]
+			}
+			cur.End = oldLine
+			oldLine++
+		case strings.HasPrefix(line, " "):
+			oldLine++
+		case strings.HasPrefix(line, "+"), strings.HasPrefix(line, `\`):
+			// Added lines and "\ No newline at end of file" markers do not
+			// advance the old file's line count.
+		default:
+			inHunk = false
+		}
+	}
+
+	return result
+}
+
+// parseHunkOldStart returns the old-file start line from a hunk header of
+// the form "@@ -a[,b] +c[,d] @@".
+func parseHunkOldStart(line string) (int, bool) {
+	rest, ok := strings.CutPrefix(line, "@@ -")
+	if !ok {
+		return 0, false
+	}
+	end := strings.IndexAny(rest, ", ")
+	if end == -1 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(rest[:end])
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
+
 // ChangedFiles is the structured result of parsing a diff.
 type ChangedFiles struct {
 	// All is every changed path in diff order, with duplicates removed.
@@ -205,6 +502,110 @@ func ExtractChangedFilesDetailed(diff string) ChangedFiles {
 	return ChangedFiles{All: all, Deleted: deleted}
 }
 
+// DiffStats summarizes the size of a diff.
+type DiffStats struct {
+	// FilesChanged counts file blocks; a rename is one file.
+	FilesChanged int
+	// Additions and Deletions count the added and removed lines of the
+	// hunks, not the "+++" and "---" file headers.
+	Additions int
+	Deletions int
+}
+
+// CountChanges returns the DiffStats of diff. Only lines within hunks are
+// counted, so a removed line that itself begins with "--" is not mistaken
+// for a header. Binary files count as changed, with no lines.
+func CountChanges(diff string) DiffStats {
+	var stats DiffStats
+	inHunk := false
+	for rawLine := range strings.SplitSeq(diff, "\n") {
+		switch {
+		case strings.HasPrefix(rawLine, "diff --git "):
+			stats.FilesChanged++
+			inHunk = false
+		case strings.HasPrefix(rawLine, "@@ "):
+			inHunk = true
+		case !inHunk:
+		case strings.HasPrefix(rawLine, "+"):
+			stats.Additions++
+		case strings.HasPrefix(rawLine, "-"):
+			stats.Deletions++
+		}
+	}
+
+	return stats
+}
+
+// FilterDiff returns diff with the file blocks removed whose path keep
+// rejects, preserving the remaining blocks byte for byte. A block's path is
+// resolved as in [ExtractChangedFilesDetailed]: the destination of a rename or
+// copy, otherwise the header path. A rename block is kept only if keep
+// accepts both its source and its destination, so a filter can never hide
+// half of a rename. Any preamble before the first block is kept.
+func FilterDiff(diff string, keep func(path string) bool) string {
+	return filterDiff(diff, func(dest, src string) bool {
+		return keep(dest) && (src == "" || keep(src))
+	})
+}
+
+// OmitFromDiff returns diff without the file blocks whose paths all match
+// omit, preserving the remaining blocks byte for byte. Unlike
+// [FilterDiff] it errs toward keeping: a rename block is removed only if
+// omit matches both its source and its destination, so a file moved out of
+// an omitted path still shows.
+func OmitFromDiff(diff string, omit func(path string) bool) string {
+	return filterDiff(diff, func(dest, src string) bool {
+		return !omit(dest) || src != "" && !omit(src)
+	})
+}
+
+// filterDiff implements FilterDiff and OmitFromDiff, keeping each file block
+// for which keep, given its destination path and its rename source (or ""),
+// returns true.
+func filterDiff(diff string, keep func(dest, src string) bool) string {
+	var out strings.Builder
+	var block strings.Builder
+	var pending, renameSource string
+	inBlock := false
+
+	flush := func() {
+		if !inBlock {
+			return
+		}
+		if keep(pending, renameSource) {
+			_, _ = out.WriteString(block.String())
+		}
+		block.Reset()
+	}
+
+	for rawLine := range strings.SplitAfterSeq(diff, "\n") {
+		line := strings.TrimSuffix(strings.TrimSuffix(rawLine, "\n"), "\r")
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			inBlock = true
+			pending = parseGitDiffHeader(line)
+			renameSource = ""
+		} else if inBlock {
+			if path, ok := strings.CutPrefix(line, "rename from "); ok {
+				renameSource = unquoteIfQuoted(path)
+			} else if path, ok := strings.CutPrefix(line, "rename to "); ok {
+				pending = unquoteIfQuoted(path)
+			} else if path, ok := strings.CutPrefix(line, "copy to "); ok {
+				pending = unquoteIfQuoted(path)
+			}
+		}
+
+		if inBlock {
+			_, _ = block.WriteString(rawLine)
+		} else {
+			_, _ = out.WriteString(rawLine)
+		}
+	}
+	flush()
+
+	return out.String()
+}
+
 // parseGitDiffHeader extracts the destination file path from a "diff --git"
 // header. It handles three forms - C-quoted, standard prefixed
 // ("a/<p> b/<p>"), and noprefix ("<p> <p>", used when diff.noprefix is set) -
@@ -348,24 +749,69 @@ func splitDiffPathsFallback(rest, sep string) string {
 // scanContent scans arbitrary content for secrets, stamping the given filename
 // onto each finding. DetectString leaves Finding.File empty, so this attributes
 // the secret to the right file for downstream reporting. A blank filename
-// leaves the field as-is.
-func (s *Scanner) scanContent(content, filename string) []report.Finding {
+// leaves the field as-is. The detector gives up partway once ctx is done, so
+// its partial findings are discarded for ctx's error.
+func (s *Scanner) scanContent(ctx context.Context, content, filename string) ([]report.Finding, error) {
 	if content == "" {
-		return nil
+		return nil, nil
 	}
 
-	findings := s.detector.DetectString(content)
+	findings := s.detector.DetectContext(ctx, detect.Fragment{Raw: content})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	if filename != "" {
 		for i := range findings {
 			findings[i].File = filename
 		}
 	}
 
-	return findings
+	return findings, nil
+}
+
+// Redact returns text with every secret the scanner detects in it redacted
+// as in FormatFindings, revealing revealChars characters at each end. It is
+// for text that is logged rather than reviewed, such as full prompts.
+func (s *Scanner) Redact(text string, revealChars int) string {
+	// A background context is never done, so there is no error to handle.
+	findings, _ := s.scanContent(context.Background(), text, "")
+	var secrets []string
+	for _, finding := range findings {
+		if finding.Secret != "" {
+			secrets = append(secrets, finding.Secret)
+		}
+	}
+	// Rules can match overlapping spans of one secret; redacting the longest
+	// first leaves no unredacted tail behind.
+	slices.SortFunc(secrets, func(a, b string) int { return len(b) - len(a) })
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, redactSecret(secret, revealChars))
+	}
+
+	return text
+}
+
+// DefaultRevealChars is the default number of characters FormatFindings
+// reveals at each end of a redacted secret.
+const DefaultRevealChars = 3
+
+// FormatFindings formats findings into a human-readable string. Each secret
+// is redacted to revealChars characters at each end (see redactSecret); 0
+// masks secrets entirely.
+func FormatFindings(findings []report.Finding, revealChars int) string {
+	return formatFindings(findings, revealChars, false)
 }
 
-// FormatFindings formats findings into a human-readable string.
-func FormatFindings(findings []report.Finding) string {
+// FormatFindingsVerbose is like [FormatFindings], but also reports the
+// column where each match starts and the secret's Shannon entropy, when
+// gitleaks provides them. A low entropy often marks a generic-api-key hit
+// as a false positive.
+func FormatFindingsVerbose(findings []report.Finding, revealChars int) string {
+	return formatFindings(findings, revealChars, true)
+}
+
+// formatFindings implements [FormatFindings] and [FormatFindingsVerbose].
+func formatFindings(findings []report.Finding, revealChars int, verbose bool) string {
 	if len(findings) == 0 {
 		return ""
 	}
@@ -379,10 +825,16 @@ func FormatFindings(findings []report.Finding) string {
 		if finding.StartLine > 0 {
 			_, _ = fmt.Fprintf(&sb, "   Line: %d\n", finding.StartLine)
 		}
+		if verbose && finding.StartColumn > 0 {
+			_, _ = fmt.Fprintf(&sb, "   Column: %d\n", finding.StartColumn)
+		}
 		_, _ = fmt.Fprintf(&sb, "   Rule: %s\n", finding.RuleID)
+		if verbose && finding.Entropy > 0 {
+			_, _ = fmt.Fprintf(&sb, "   Entropy: %.2f\n", finding.Entropy)
+		}
 		if finding.Secret != "" {
 			// Redact most of the secret for safety.
-			redacted := redactSecret(finding.Secret)
+			redacted := redactSecret(finding.Secret, revealChars)
 			_, _ = fmt.Fprintf(&sb, "   Secret: %s\n", redacted)
 		}
 		if finding.Commit != "" {
@@ -394,14 +846,83 @@ func FormatFindings(findings []report.Finding) string {
 	return sb.String()
 }
 
-// redactSecret redacts most of a secret, showing only first and last few characters.
-func redactSecret(secret string) string {
-	if len(secret) <= 8 {
+// redactSecret redacts most of a secret, showing only the first and last
+// reveal characters. The secret is masked entirely ("***") when reveal is 0
+// or less, when it is 8 characters or shorter, or when revealing would expose
+// more than half of it, so a short or low-entropy secret is never mostly
+// disclosed.
+func redactSecret(secret string, reveal int) string {
+	if reveal <= 0 || len(secret) <= 8 || len(secret) < 4*reveal {
 		return "***"
 	}
 
-	// Show first 3 and last 3 characters.
-	return fmt.Sprintf("%s...%s", secret[:3], secret[len(secret)-3:])
+	return fmt.Sprintf("%s...%s", secret[:reveal], secret[len(secret)-reveal:])
+}
+
+// FindingSummary is a finding reduced to the fields a client needs, with the
+// secret redacted as in FormatFindings. It is safe to serialize.
+type FindingSummary struct {
+	Description string `json:"description"`
+	File        string `json:"file"`
+	Line        int    `json:"line,omitempty"`
+	RuleID      string `json:"rule_id"`
+	Secret      string `json:"secret,omitempty"`
+}
+
+// SummarizeFindings converts findings into redacted summaries, revealing
+// revealChars characters at each end of each secret like FormatFindings.
+func SummarizeFindings(findings []report.Finding, revealChars int) []FindingSummary {
+	summaries := make([]FindingSummary, len(findings))
+	for i, finding := range findings {
+		summaries[i] = FindingSummary{
+			Description: finding.Description,
+			File:        finding.File,
+			Line:        finding.StartLine,
+			RuleID:      finding.RuleID,
+		}
+		if finding.Secret != "" {
+			summaries[i].Secret = redactSecret(finding.Secret, revealChars)
+		}
+	}
+
+	return summaries
+}
+
+// CountFindingsByRule returns how many findings each gitleaks rule
+// produced, keyed by rule ID, for spotting noisy rules to allowlist. It
+// returns nil when there are no findings.
+func CountFindingsByRule(findings []report.Finding) map[string]int {
+	if len(findings) == 0 {
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, finding := range findings {
+		counts[finding.RuleID]++
+	}
+
+	return counts
+}
+
+// FormatRuleCounts formats counts from [CountFindingsByRule] as a single
+// line, most frequent rule first and ties by rule ID, or "" when empty.
+func FormatRuleCounts(counts map[string]int) string {
+	if len(counts) == 0 {
+		return ""
+	}
+	rules := slices.Collect(maps.Keys(counts))
+	slices.SortFunc(rules, func(a, b string) int {
+		if c := counts[b] - counts[a]; c != 0 {
+			return c
+		}
+
+		return strings.Compare(a, b)
+	})
+	parts := make([]string, len(rules))
+	for i, rule := range rules {
+		parts[i] = fmt.Sprintf("%s (%d)", rule, counts[rule])
+	}
+
+	return "Rules triggered: " + strings.Join(parts, ", ") + "\n"
 }
 
 // HasFindings returns true if there are any findings.
@@ -72,6 +72,8 @@ func run() int {
 		Level:     cfg.Logging.Level,
 		Output:    cfg.Logging.Output,
 		Directory: cfg.Logging.Directory,
+
+		MCPMaxMessageSize: cfg.Logging.MCPMaxMessageSize,
 	}
 
 	// The "mcp" output delivers logs to the client as notifications/message via
@@ -0,0 +1,220 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import "github.com/mark3labs/mcp-go/mcp"
+
+// JSON Schema keywords and types used to describe tool arguments.
+const (
+	schemaType    = "type"
+	schemaDescKey = "description"
+	schemaString  = "string"
+	schemaArray   = "array"
+	schemaBoolean = "boolean"
+	schemaNumber  = "number"
+)
+
+// toolArg describes one tool argument. Each tool's advertised InputSchema is
+// built from its argument list, so adding an argument here is all it takes
+// for clients to see it.
+type toolArg struct {
+	name        string
+	typ         string // JSON Schema type
+	altTyp      string // Second JSON Schema type, for an argument taking either form
+	items       string // Element type when typ or altTyp is "array"
+	description string
+	required    bool
+	enum        []string // Allowed values, if restricted
+}
+
+var (
+	directoryArg = toolArg{
+		name:        argDirectory,
+		typ:         schemaString,
+		description: "Path to the git repository directory to review",
+		required:    true,
+	}
+	filesDescription = "Optional repo-relative paths to review instead of all changes. " +
+		"Only changes to these files (or files under these directories) are reviewed"
+	instructionsArg = toolArg{
+		name: argInstructions,
+		typ:  schemaString,
+		description: "Optional instructions for this review only, such as what to focus on " +
+			"(e.g. \"check error handling\")",
+	}
+	timeoutArg = toolArg{
+		name: argTimeout,
+		typ:  schemaNumber,
+		description: "Optional limit, in seconds, on how long the review may take; " +
+			"past it, the review is abandoned with a timeout error",
+	}
+
+	// reviewOnlyArgs are the arguments of the review_only tool.
+	reviewOnlyArgs = []toolArg{
+		directoryArg,
+		{name: argFiles, typ: schemaArray, items: schemaString, description: filesDescription + "."},
+		instructionsArg,
+		timeoutArg,
+		{
+			name: argIncludeDiff,
+			typ:  schemaBoolean,
+			description: "If true, return the reviewed diff alongside the verdict, truncated if " +
+				"very large (default false)",
+		},
+		{
+			name: argBaseBranch,
+			typ:  schemaString,
+			description: "Optional branch (e.g. origin/main) to review the current branch against: " +
+				"the commits on HEAD since it diverged from it are reviewed, as in a pull request, " +
+				"instead of the workspace changes. Cannot be combined with files",
+		},
+	}
+
+	// reviewAndCommitArgs are the arguments of the review_and_commit tool.
+	reviewAndCommitArgs = []toolArg{
+		directoryArg,
+		{
+			name: argCommitMessage,
+			typ:  schemaString,
+			description: "Commit message to use if changes are approved. \"@<path>\" reads it from a " +
+				"repo-relative file, and \"COMMIT_EDITMSG\" reads the message git prepared. " +
+				"Required unless the server sets git.default_commit_message",
+		},
+		{
+			name:        argFiles,
+			typ:         schemaArray,
+			items:       schemaString,
+			description: filesDescription + ", and only they are committed.",
+		},
+		{
+			name: argAmend,
+			typ:  schemaBoolean,
+			description: "If true, fold the approved changes into the previous commit " +
+				"(git commit --amend), replacing its message, instead of creating a new commit",
+		},
+		{
+			name:   argStage,
+			typ:    schemaString,
+			altTyp: schemaArray,
+			items:  schemaString,
+			description: "What to commit once approved: \"all\" reviewed changes (the default), " +
+				"only changes to \"tracked\" files, or an array of repo-relative paths (files or " +
+				"directories) among the reviewed changes. Everything reviewed is reviewed either way",
+		},
+		instructionsArg,
+		timeoutArg,
+	}
+
+	// scanSecretsArgs are the arguments of the scan_secrets and scan_repo
+	// tools.
+	scanSecretsArgs = []toolArg{
+		{
+			name:        argDirectory,
+			typ:         schemaString,
+			description: "Path to the git repository directory to scan",
+			required:    true,
+		},
+	}
+
+	// reviewHeadArgs are the arguments of the review_head tool.
+	reviewHeadArgs = []toolArg{directoryArg}
+
+	// reviewCommitsArgs are the arguments of the review_commits tool.
+	reviewCommitsArgs = []toolArg{
+		directoryArg,
+		{
+			name:        argFrom,
+			typ:         schemaString,
+			description: "Base commit (branch, tag, or hash); its changes are not reviewed",
+			required:    true,
+		},
+		{
+			name:        argTo,
+			typ:         schemaString,
+			description: "Commit whose changes since from are reviewed, e.g. HEAD",
+			required:    true,
+		},
+	}
+
+	// reviewPatchArgs are the arguments of the review_patch tool.
+	reviewPatchArgs = []toolArg{
+		{
+			name:        argDiff,
+			typ:         schemaString,
+			description: "The diff to review, in git format (as from git diff or a pull request's .diff URL)",
+			required:    true,
+		},
+		{
+			name: argDirectory,
+			typ:  schemaString,
+			description: "Optional path to a checkout of the repository, from which Gemini may read " +
+				"files for context. Without it, only the diff is reviewed",
+		},
+		instructionsArg,
+		timeoutArg,
+	}
+
+	// reviewMultiArgs are the arguments of the review_multi tool.
+	reviewMultiArgs = []toolArg{
+		{
+			name:        argDirectories,
+			typ:         schemaArray,
+			items:       schemaString,
+			description: "Paths to the git repository directories to review",
+			required:    true,
+		},
+		instructionsArg,
+		timeoutArg,
+	}
+
+	// confirmCommitArgs are the arguments of the confirm_commit tool.
+	confirmCommitArgs = []toolArg{
+		{
+			name:        argToken,
+			typ:         schemaString,
+			description: "Confirmation token returned by review_and_commit for an approved review",
+			required:    true,
+		},
+	}
+)
+
+// inputSchema builds a tool's InputSchema from its arguments.
+func inputSchema(args []toolArg) mcp.ToolInputSchema {
+	schema := mcp.ToolInputSchema{
+		Type:       "object",
+		Properties: make(map[string]any, len(args)),
+	}
+	for _, arg := range args {
+		prop := map[string]any{
+			schemaType:    arg.typ,
+			schemaDescKey: arg.description,
+		}
+		if arg.altTyp != "" {
+			prop[schemaType] = []string{arg.typ, arg.altTyp}
+		}
+		if arg.items != "" {
+			prop["items"] = map[string]any{schemaType: arg.items}
+		}
+		if len(arg.enum) > 0 {
+			prop["enum"] = arg.enum
+		}
+		schema.Properties[arg.name] = prop
+		if arg.required {
+			schema.Required = append(schema.Required, arg.name)
+		}
+	}
+
+	return schema
+}
@@ -0,0 +1,156 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"msrl.dev/lgtmcp/internal/config"
+	"msrl.dev/lgtmcp/internal/review"
+	"msrl.dev/lgtmcp/internal/security"
+	"msrl.dev/lgtmcp/internal/testutil"
+)
+
+func TestInputSchema(t *testing.T) {
+	t.Parallel()
+	schema := inputSchema([]toolArg{
+		directoryArg,
+		{
+			name:        "persona",
+			typ:         schemaString,
+			description: "Reviewer persona",
+			enum:        []string{"strict", "lenient"},
+		},
+		{name: "paths", typ: schemaArray, items: schemaString, description: "Paths"},
+		{name: "scope", typ: schemaString, altTyp: schemaArray, items: schemaString, description: "Scope"},
+	})
+
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, []string{argDirectory}, schema.Required)
+	assert.Equal(t, map[string]any{
+		schemaType:    schemaString,
+		schemaDescKey: "Reviewer persona",
+		"enum":        []string{"strict", "lenient"},
+	}, schema.Properties["persona"])
+	assert.Equal(t, map[string]any{
+		schemaType:    schemaArray,
+		schemaDescKey: "Paths",
+		"items":       map[string]any{schemaType: schemaString},
+	}, schema.Properties["paths"])
+	assert.Equal(t, map[string]any{
+		schemaType:    []string{schemaString, schemaArray},
+		schemaDescKey: "Scope",
+		"items":       map[string]any{schemaType: schemaString},
+	}, schema.Properties["scope"])
+
+	empty := inputSchema(nil)
+	assert.Equal(t, "object", empty.Type)
+	assert.NotNil(t, empty.Properties, "an argument-less tool still advertises an object schema")
+	assert.Empty(t, empty.Required)
+}
+
+// TestRegisterTools_AdvertisesArgRegistry checks that every argument in a
+// tool's registry appears in the schema the server advertises for it.
+func TestRegisterTools_AdvertisesArgRegistry(t *testing.T) {
+	t.Parallel()
+	scanner, err := security.New("")
+	require.NoError(t, err)
+	s := newForTesting(config.NewTestConfig(), testutil.NewTestLogger(), review.NewForTesting(), scanner)
+
+	for tool, args := range map[string][]toolArg{
+		"review_only":       reviewOnlyArgs,
+		"review_and_commit": reviewAndCommitArgs,
+		"review_commits":    reviewCommitsArgs,
+		"review_head":       reviewHeadArgs,
+		"scan_secrets":      scanSecretsArgs,
+		"scan_repo":         scanSecretsArgs,
+		"ping":              nil,
+		"config_info":       nil,
+		"metrics":           nil,
+		"confirm_commit":    confirmCommitArgs,
+		"review_multi":      reviewMultiArgs,
+		"review_patch":      reviewPatchArgs,
+	} {
+		registered := s.mcpServer.GetTool(tool)
+		require.NotNil(t, registered, tool)
+		schema := registered.Tool.InputSchema
+		for _, arg := range args {
+			prop, ok := schema.Properties[arg.name].(map[string]any)
+			require.True(t, ok, "%s: argument %q not advertised", tool, arg.name)
+			wantType := any(arg.typ)
+			if arg.altTyp != "" {
+				wantType = []string{arg.typ, arg.altTyp}
+			}
+			assert.Equal(t, wantType, prop[schemaType], "%s.%s", tool, arg.name)
+			assert.Equal(t, arg.description, prop[schemaDescKey], "%s.%s", tool, arg.name)
+			assert.Equal(t, arg.required, slices.Contains(schema.Required, arg.name), "%s.%s", tool, arg.name)
+		}
+	}
+}
+
+// TestRegisterTools_SchemasAreValid checks that every advertised InputSchema
+// is a JSON Schema object whose properties have a type and description, whose
+// arrays say what they hold, and whose required arguments are all defined.
+func TestRegisterTools_SchemasAreValid(t *testing.T) {
+	t.Parallel()
+	scanner, err := security.New("")
+	require.NoError(t, err)
+	s := newForTesting(config.NewTestConfig(), testutil.NewTestLogger(), review.NewForTesting(), scanner)
+
+	validTypes := []string{schemaString, schemaArray, schemaBoolean, schemaNumber, "integer", "object"}
+	tools := s.mcpServer.ListTools()
+	require.NotEmpty(t, tools)
+	for name, registered := range tools {
+		data, err := json.Marshal(registered.Tool.InputSchema)
+		require.NoError(t, err, name)
+		var schema struct {
+			Type       string                    `json:"type"`
+			Properties map[string]map[string]any `json:"properties"`
+			Required   []string                  `json:"required"`
+		}
+		require.NoError(t, json.Unmarshal(data, &schema), name)
+
+		assert.Equal(t, "object", schema.Type, name)
+		for _, req := range schema.Required {
+			assert.Contains(t, schema.Properties, req, "%s: required argument is not defined", name)
+		}
+		for arg, prop := range schema.Properties {
+			var types []string
+			switch typ := prop[schemaType].(type) {
+			case string:
+				types = []string{typ}
+			case []any:
+				for _, v := range typ {
+					str, ok := v.(string)
+					require.True(t, ok, "%s.%s: type must be a string or array of strings", name, arg)
+					types = append(types, str)
+				}
+			default:
+				t.Errorf("%s.%s: missing or malformed type %v", name, arg, typ)
+			}
+			for _, typ := range types {
+				assert.Contains(t, validTypes, typ, "%s.%s", name, arg)
+			}
+			if slices.Contains(types, schemaArray) {
+				assert.Contains(t, prop, "items", "%s.%s: arrays must say what they hold", name, arg)
+			}
+			assert.NotEmpty(t, prop[schemaDescKey], "%s.%s: missing description", name, arg)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"msrl.dev/lgtmcp/internal/review"
+	"msrl.dev/lgtmcp/internal/security"
+)
+
+const (
+	argToken = "token"
+
+	// confirmationTTL is how long an approved review waits for
+	// confirm_commit before its token expires.
+	confirmationTTL = 10 * time.Minute
+
+	// maxPendingConfirmations bounds how many approved commits may await
+	// confirmation; the oldest is dropped first.
+	maxPendingConfirmations = 64
+)
+
+// ErrTokenNotString indicates the token argument is not a non-empty string.
+var ErrTokenNotString = errors.New("token must be a non-empty string")
+
+// approvedCommit holds what review_and_commit needs to commit an approved
+// review, so that the commit can run immediately or after confirmation.
+type approvedCommit struct {
+	reviewCtx *reviewContext
+	// files is the files argument the review was limited to, if any.
+	files   []string
+	result  *review.Result
+	message string
+	amend   bool
+	// narrow reports that only filesToStage may be committed, leaving other
+	// changes already in the index alone.
+	narrow       bool
+	filesToStage []string
+}
+
+// pendingConfirmation is an approved commit awaiting confirm_commit.
+type pendingConfirmation struct {
+	commit  *approvedCommit
+	expires time.Time
+}
+
+// confirmationStore holds the approved commits awaiting confirm_commit,
+// keyed by their one-time token, for the lifetime of the server.
+type confirmationStore struct {
+	mu      sync.Mutex
+	pending map[string]pendingConfirmation
+	order   []string // Tokens, oldest first
+	now     func() time.Time
+}
+
+func newConfirmationStore() *confirmationStore {
+	return &confirmationStore{pending: make(map[string]pendingConfirmation), now: time.Now}
+}
+
+// add stores commit under a new random token and returns the token.
+func (c *confirmationStore) add(commit *approvedCommit) (string, error) {
+	token, err := generateConfirmationToken()
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending[token] = pendingConfirmation{commit: commit, expires: c.now().Add(confirmationTTL)}
+	c.order = append(c.order, token)
+	for len(c.order) > maxPendingConfirmations {
+		delete(c.pending, c.order[0])
+		c.order = c.order[1:]
+	}
+
+	return token, nil
+}
+
+// take removes and returns the commit stored under token. It reports false
+// for a token that is unknown, already used, or expired.
+func (c *confirmationStore) take(token string) (*approvedCommit, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending, ok := c.pending[token]
+	if !ok {
+		return nil, false
+	}
+	delete(c.pending, token)
+	if i := slices.Index(c.order, token); i != -1 {
+		c.order = slices.Delete(c.order, i, i+1)
+	}
+
+	return pending.commit, c.now().Before(pending.expires)
+}
+
+// generateConfirmationToken creates a random token. Unlike a request ID it
+// authorizes a commit, so it is long enough not to be guessed.
+func generateConfirmationToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// awaitConfirmation holds an approved commit for confirm_commit and returns
+// the review result with the token that confirms it.
+//
+//nolint:funcorder // Helper method
+func (s *Server) awaitConfirmation(requestID string, start time.Time, approved *approvedCommit) *mcp.CallToolResult {
+	token, err := s.confirmations.add(approved)
+	if err != nil {
+		return toolErrorResult(err, CodeInternal)
+	}
+	s.logger.Info("Review approved; commit awaits confirmation",
+		"request_id", requestID,
+		"total_duration_ms", time.Since(start).Milliseconds())
+
+	result := newReviewToolResult(approved.result, "", false)
+	if output, ok := result.StructuredContent.(ReviewOutput); ok {
+		output.ConfirmationToken = token
+		result.StructuredContent = output
+	}
+	if text, ok := result.Content[0].(mcp.TextContent); ok {
+		text.Text += fmt.Sprintf("\n\nNot committed yet: review.commit_requires_confirmation is set. "+
+			"Call confirm_commit with token %s within %s to commit these changes.", token, confirmationTTL)
+		result.Content[0] = text
+	}
+
+	return result
+}
+
+// HandleConfirmCommit commits the changes of a review that review_and_commit
+// approved while review.commit_requires_confirmation is set. Its token is
+// single-use and expires after confirmationTTL. The commit is refused if the
+// reviewed changes have been modified since the review.
+func (s *Server) HandleConfirmCommit(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	requestID, err := generateRequestID()
+	if err != nil {
+		s.logger.Error("Failed to generate request ID", "error", err)
+		return nil, err
+	}
+	start := time.Now()
+
+	s.logger.Info("Commit confirmation started",
+		"request_id", requestID,
+		"tool", "confirm_commit")
+
+	args, ok := request.Params.Arguments.(map[string]any)
+	if !ok {
+		return nil, ErrInvalidArguments
+	}
+	token, ok := args[argToken].(string)
+	if !ok || token == "" {
+		return nil, ErrTokenNotString
+	}
+
+	approved, ok := s.confirmations.take(token)
+	if !ok {
+		return newToolError(CodeCommitRefused, "invalid confirmation token: it is unknown, already used, or expired; "+
+			"run review_and_commit again"), nil
+	}
+
+	// Commit only what was reviewed: any edit since, even one that leaves
+	// the file list unchanged, needs a fresh review.
+	diff, err := approved.reviewCtx.gitClient.GetDiff(ctx, approved.files...)
+	if err != nil {
+		return newToolErrorf(CodeGitFailed, "failed to get diff: %v", err), nil
+	}
+	if reportPath := s.reportPath(); reportPath != "" {
+		diff = security.FilterDiff(diff, func(p string) bool { return p != reportPath })
+	}
+	if diff != approved.reviewCtx.diff {
+		s.logger.Warn("Changes modified since review; commit refused",
+			"request_id", requestID,
+			"repo", filepath.Base(approved.reviewCtx.absPath))
+		return newToolError(CodeCommitRefused, "the changes were modified after the review; run review_and_commit again"), nil
+	}
+
+	// Progress continues the six steps of review_and_commit.
+	const totalSteps = 6.0
+
+	return s.commitApproved(ctx, requestID, start, s.createProgressReporter(request), totalSteps, approved), nil
+}
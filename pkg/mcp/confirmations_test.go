@@ -0,0 +1,150 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"msrl.dev/lgtmcp/internal/testutil"
+)
+
+func TestConfirmationStore(t *testing.T) {
+	t.Parallel()
+
+	t.Run("tokens are single-use", func(t *testing.T) {
+		t.Parallel()
+		c := newConfirmationStore()
+		commit := &approvedCommit{message: "msg"}
+		token, err := c.add(commit)
+		require.NoError(t, err)
+		assert.Len(t, token, 32)
+
+		got, ok := c.take(token)
+		assert.True(t, ok)
+		assert.Same(t, commit, got)
+		_, ok = c.take(token)
+		assert.False(t, ok)
+		_, ok = c.take("unknown")
+		assert.False(t, ok)
+	})
+
+	t.Run("tokens expire", func(t *testing.T) {
+		t.Parallel()
+		c := newConfirmationStore()
+		now := time.Now()
+		c.now = func() time.Time { return now }
+		token, err := c.add(&approvedCommit{})
+		require.NoError(t, err)
+
+		now = now.Add(confirmationTTL)
+		_, ok := c.take(token)
+		assert.False(t, ok)
+	})
+
+	t.Run("evicts the oldest pending commit", func(t *testing.T) {
+		t.Parallel()
+		c := newConfirmationStore()
+		first, err := c.add(&approvedCommit{})
+		require.NoError(t, err)
+		for range maxPendingConfirmations {
+			_, err := c.add(&approvedCommit{})
+			require.NoError(t, err)
+		}
+		assert.Len(t, c.pending, maxPendingConfirmations)
+		_, ok := c.take(first)
+		assert.False(t, ok)
+	})
+}
+
+func TestHandleConfirmCommit(t *testing.T) {
+	t.Parallel()
+
+	// setup returns a server requiring confirmation and a repository with
+	// an approved, uncommitted change, along with its confirmation token.
+	setup := func(t *testing.T) (*Server, string, string) {
+		t.Helper()
+		s, tmpDir := createTestServer(t)
+		s.config.Review.CommitRequiresConfirmation = true
+		testutil.CreateFile(t, tmpDir, "base.go", "package main\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+		testutil.CreateFile(t, tmpDir, "base.go", "package main\n\nfunc main() {}\n")
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"directory": tmpDir, "commit_message": "Add main"}
+		result, err := s.HandleReviewAndCommit(t.Context(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		assert.Contains(t, textContent.Text, "APPROVED")
+		assert.Contains(t, textContent.Text, "Call confirm_commit")
+		output, ok := result.StructuredContent.(ReviewOutput)
+		require.True(t, ok)
+		assert.False(t, output.Committed)
+		require.NotEmpty(t, output.ConfirmationToken)
+		assert.Contains(t, textContent.Text, output.ConfirmationToken)
+		assert.Equal(t, "initial", testutil.RunGitCmd(t, tmpDir, "log", "-1", "--format=%s"),
+			"nothing is committed before confirmation")
+
+		return s, tmpDir, output.ConfirmationToken
+	}
+	confirm := func(t *testing.T, s *Server, token any) *mcp.CallToolResult {
+		t.Helper()
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"token": token}
+		result, err := s.HandleConfirmCommit(t.Context(), request)
+		require.NoError(t, err)
+
+		return result
+	}
+
+	t.Run("commits once with the token", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir, token := setup(t)
+
+		result := confirm(t, s, token)
+		require.False(t, result.IsError)
+		output, ok := result.StructuredContent.(ReviewOutput)
+		require.True(t, ok)
+		assert.True(t, output.Committed)
+		assert.Equal(t, "Add main", testutil.RunGitCmd(t, tmpDir, "log", "-1", "--format=%s"))
+
+		assertInBandToolError(t, confirm(t, s, token), nil, "invalid confirmation token")
+	})
+
+	t.Run("refuses changes modified since the review", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir, token := setup(t)
+		testutil.CreateFile(t, tmpDir, "base.go", "package main\n\nfunc main() { panic(0) }\n")
+
+		assertInBandToolError(t, confirm(t, s, token), nil, "modified after the review")
+		assert.Equal(t, "initial", testutil.RunGitCmd(t, tmpDir, "log", "-1", "--format=%s"))
+	})
+
+	t.Run("rejects a missing token", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"token": 42}
+		result, err := s.HandleConfirmCommit(t.Context(), request)
+		require.ErrorIs(t, err, ErrTokenNotString)
+		assert.Nil(t, result)
+	})
+}
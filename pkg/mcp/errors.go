@@ -0,0 +1,139 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"msrl.dev/lgtmcp/internal/git"
+	"msrl.dev/lgtmcp/internal/review"
+)
+
+// ErrorCode is a machine-readable classification of a tool failure, so a
+// client can react to it without parsing the message.
+type ErrorCode string
+
+// Error codes of tool failures.
+const (
+	// CodeInvalidDirectory is a directory argument that cannot be resolved.
+	CodeInvalidDirectory ErrorCode = "INVALID_DIRECTORY"
+	// CodeNotARepo is a directory that is not a git repository.
+	CodeNotARepo ErrorCode = "NOT_A_REPO"
+	// CodeInvalidRef is a from, to, or HEAD that does not name a commit, or a
+	// base_branch that shares no history with HEAD.
+	CodeInvalidRef ErrorCode = "INVALID_REF"
+	// CodeGitFailed is a git operation that failed, such as getting the diff.
+	CodeGitFailed ErrorCode = "GIT_FAILED"
+	// CodeScanFailed is a secret scan that could not run.
+	CodeScanFailed ErrorCode = "SCAN_FAILED"
+	// CodeNoAuth is a server with no Gemini authentication configured.
+	CodeNoAuth ErrorCode = "NO_AUTH"
+	// CodeQuotaExhausted is a Gemini quota that is exhausted.
+	CodeQuotaExhausted ErrorCode = "QUOTA_EXHAUSTED"
+	// CodeServiceUnavailable is a Gemini service that is temporarily
+	// unavailable; the review may succeed if retried later.
+	CodeServiceUnavailable ErrorCode = "SERVICE_UNAVAILABLE"
+	// CodeCostLimitExceeded is a review whose estimated cost exceeds
+	// gemini.max_estimated_cost.
+	CodeCostLimitExceeded ErrorCode = "COST_LIMIT_EXCEEDED"
+	// CodeReviewFailed is any other failure of the review itself.
+	CodeReviewFailed ErrorCode = "REVIEW_FAILED"
+	// CodeTimeout is a review that did not finish within timeout_seconds.
+	CodeTimeout ErrorCode = "TIMEOUT"
+	// CodeCanceled is a request canceled before it finished.
+	CodeCanceled ErrorCode = "CANCELED"
+	// CodeCommitRefused is an approved change that is not committed because
+	// the request cannot be honored, such as an amend with no commit to
+	// amend or a confirmation token that is unknown.
+	CodeCommitRefused ErrorCode = "COMMIT_REFUSED"
+	// CodeCommitFailed is a failure staging or committing approved changes.
+	CodeCommitFailed ErrorCode = "COMMIT_FAILED"
+	// CodeInternal is a failure of the server itself.
+	CodeInternal ErrorCode = "INTERNAL"
+)
+
+// ErrorOutput is the structured content of a tool failure (an IsError
+// result), alongside its message as text.
+type ErrorOutput struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// codedError attaches the ErrorCode to report an error with, when no
+// sentinel it wraps determines one.
+type codedError struct {
+	code ErrorCode
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+
+func (e *codedError) Unwrap() error { return e.err }
+
+// withCode returns err annotated with code.
+func withCode(code ErrorCode, err error) error {
+	return &codedError{code: code, err: err}
+}
+
+// errorCode classifies err: by the sentinel errors it wraps, then by any
+// code attached with withCode, and otherwise as fallback.
+func errorCode(err error, fallback ErrorCode) ErrorCode {
+	switch {
+	case errors.Is(err, git.ErrNotGitRepo):
+		return CodeNotARepo
+	case errors.Is(err, git.ErrInvalidRef), errors.Is(err, git.ErrNoMergeBase):
+		return CodeInvalidRef
+	case errors.Is(err, review.ErrNoAuthMethod):
+		return CodeNoAuth
+	case errors.Is(err, review.ErrQuotaExhausted):
+		return CodeQuotaExhausted
+	case errors.Is(err, review.ErrServiceUnavailable):
+		return CodeServiceUnavailable
+	case errors.Is(err, review.ErrCostLimitExceeded):
+		return CodeCostLimitExceeded
+	case errors.Is(err, ErrReviewTimedOut):
+		return CodeTimeout
+	case errors.Is(err, context.Canceled):
+		return CodeCanceled
+	}
+	if coded, ok := errors.AsType[*codedError](err); ok {
+		return coded.code
+	}
+
+	return fallback
+}
+
+// newToolError returns an IsError result with message as its text and an
+// ErrorOutput as its structured content.
+func newToolError(code ErrorCode, message string) *mcp.CallToolResult {
+	result := mcp.NewToolResultStructured(ErrorOutput{Code: code, Message: message}, message)
+	result.IsError = true
+
+	return result
+}
+
+// newToolErrorf is newToolError with a formatted message.
+func newToolErrorf(code ErrorCode, format string, args ...any) *mcp.CallToolResult {
+	return newToolError(code, fmt.Sprintf(format, args...))
+}
+
+// toolErrorResult reports err as a tool failure, with the code errorCode
+// gives it.
+func toolErrorResult(err error, fallback ErrorCode) *mcp.CallToolResult {
+	return newToolError(errorCode(err, fallback), err.Error())
+}
@@ -0,0 +1,116 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+	"msrl.dev/lgtmcp/internal/git"
+	"msrl.dev/lgtmcp/internal/review"
+	"msrl.dev/lgtmcp/internal/testutil"
+)
+
+// assertToolErrorCode checks that result is a tool failure carrying code
+// and its message as structured content.
+func assertToolErrorCode(t *testing.T, result *mcp.CallToolResult, code ErrorCode) {
+	t.Helper()
+	require.NotNil(t, result)
+	assert.True(t, result.IsError)
+	output, ok := result.StructuredContent.(ErrorOutput)
+	require.True(t, ok, "expected ErrorOutput, got %T", result.StructuredContent)
+	assert.Equal(t, code, output.Code)
+	textContent, ok := result.Content[0].(mcp.TextContent)
+	require.True(t, ok)
+	assert.Equal(t, textContent.Text, output.Message)
+}
+
+func TestErrorCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want ErrorCode
+	}{
+		{"not a repository", fmt.Errorf("invalid git repository: %w", git.ErrNotGitRepo), CodeNotARepo},
+		{"invalid ref", fmt.Errorf("%w: %q", git.ErrInvalidRef, "nope"), CodeInvalidRef},
+		{"no auth", review.ErrNoAuthMethod, CodeNoAuth},
+		{"quota", errors.Join(review.ErrQuotaExhausted, errors.New("429")), CodeQuotaExhausted},
+		{"unavailable", fmt.Errorf("review failed: %w", review.ErrServiceUnavailable), CodeServiceUnavailable},
+		{"cost", review.ErrCostLimitExceeded, CodeCostLimitExceeded},
+		{"timeout", ErrReviewTimedOut, CodeTimeout},
+		{"canceled", fmt.Errorf("gave up: %w", context.Canceled), CodeCanceled},
+		{"attached code", withCode(CodeScanFailed, errors.New("scanner broke")), CodeScanFailed},
+		{"sentinel beats attached code", withCode(CodeNotARepo, review.ErrNoAuthMethod), CodeNoAuth},
+		{"fallback", errors.New("something else"), CodeReviewFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, errorCode(tt.err, CodeReviewFailed))
+		})
+	}
+}
+
+func TestToolErrorResult_KeepsMessage(t *testing.T) {
+	t.Parallel()
+	err := fmt.Errorf("review failed: %w", review.ErrServiceUnavailable)
+	result := toolErrorResult(err, CodeReviewFailed)
+	assertToolErrorCode(t, result, CodeServiceUnavailable)
+	textContent, ok := result.Content[0].(mcp.TextContent)
+	require.True(t, ok)
+	assert.Equal(t, err.Error(), textContent.Text)
+}
+
+func TestHandleReviewOnly_ErrorCodes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not a repository", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"directory": t.TempDir()}
+		result, err := s.HandleReviewOnly(t.Context(), request)
+		assertInBandToolError(t, result, err, "invalid git repository")
+		assertToolErrorCode(t, result, CodeNotARepo)
+	})
+
+	t.Run("quota exhausted", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir := createTestServer(t)
+		s.reviewer = review.NewForTestingWithClient(&review.StubGeminiClient{
+			CreateChatFunc: func(_ context.Context, _ string, _ *genai.GenerateContentConfig) (review.GeminiChat, error) {
+				return &review.StubGeminiChat{
+					SendMessageFunc: func(_ context.Context, _ ...genai.Part) (*genai.GenerateContentResponse, error) {
+						return nil, errors.New("Error 429: type.googleapis.com/google.rpc.QuotaFailure")
+					},
+				}, nil
+			},
+		})
+		testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"directory": tmpDir}
+		result, err := s.HandleReviewOnly(t.Context(), request)
+		assertInBandToolError(t, result, err, "review failed")
+		assertToolErrorCode(t, result, CodeQuotaExhausted)
+	})
+}
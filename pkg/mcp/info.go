@@ -0,0 +1,129 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"msrl.dev/lgtmcp/internal/appinfo"
+	"msrl.dev/lgtmcp/internal/config"
+	"msrl.dev/lgtmcp/internal/git"
+	"msrl.dev/lgtmcp/internal/logging"
+	"sigs.k8s.io/yaml"
+)
+
+// HandlePing reports that the server is alive along with the settings a
+// client most often needs to debug an integration: version, model, auth
+// method, and whether git is on PATH. It never calls the Gemini API and never
+// reveals credentials.
+func (s *Server) HandlePing(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var sb strings.Builder
+	_, _ = sb.WriteString("lgtmcp is running\n\n")
+	_, _ = fmt.Fprintf(&sb, "Version: %s\n", appinfo.Version)
+
+	if s.config != nil {
+		_, _ = fmt.Fprintf(&sb, "Model: %s\n", s.config.Gemini.Model)
+		if s.config.Gemini.ContextModel != "" {
+			_, _ = fmt.Fprintf(&sb, "Context model: %s\n", s.config.Gemini.ContextModel)
+		}
+		if s.config.Gemini.ReviewModel != "" {
+			_, _ = fmt.Fprintf(&sb, "Review model: %s\n", s.config.Gemini.ReviewModel)
+		}
+		if s.config.Gemini.FallbackModel != "" {
+			_, _ = fmt.Fprintf(&sb, "Fallback model: %s\n", s.config.Gemini.FallbackModel)
+		}
+		_, _ = fmt.Fprintf(&sb, "Auth: %s\n", authMethod(&s.config.Google))
+	}
+
+	if gitPath, err := s.lookPath("git"); err != nil {
+		_, _ = sb.WriteString("Git: not found on PATH\n")
+	} else {
+		_, _ = fmt.Fprintf(&sb, "Git: available (%s)\n", gitPath)
+	}
+
+	return mcp.NewToolResultText(sb.String()), nil
+}
+
+// authMethod names the credential source the reviewer uses, mirroring the
+// precedence in review.New: an API key wins over Application Default
+// Credentials.
+func authMethod(cfg *config.GoogleConfig) string {
+	switch {
+	case cfg.APIKey != "":
+		return "api_key"
+	case cfg.UseADC:
+		return "adc"
+	default:
+		return "none"
+	}
+}
+
+// HandleConfigInfo reports the effective configuration: the file it was
+// loaded from and every setting after defaults were applied, rendered as
+// YAML in the config file's own schema. The API key is masked.
+func (s *Server) HandleConfigInfo(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if s.config == nil {
+		return newToolError(CodeInternal, "no configuration loaded"), nil
+	}
+
+	view := s.config.Redacted()
+	// Some defaults are applied by the consuming package rather than by
+	// config.Load; fill them in so the view shows what is actually in effect.
+	if view.Git.DiffContextLines == nil {
+		view.Git.DiffContextLines = new(git.DefaultDiffContextLines)
+	}
+	if view.Git.CommandTimeout == "" {
+		view.Git.CommandTimeout = git.DefaultCommandTimeout.String()
+	}
+	if view.Logging.Output == "mcp" && view.Logging.MCPMaxMessageSize == nil {
+		view.Logging.MCPMaxMessageSize = new(logging.DefaultMCPMaxMessageSize)
+	}
+
+	out, err := yaml.Marshal(view)
+	if err != nil {
+		return newToolErrorf(CodeInternal, "failed to render configuration: %v", err), nil
+	}
+
+	path := s.config.Path
+	if path == "" {
+		path = "(none; configuration was not loaded from a file)"
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf(
+		"Config file: %s\n\nEffective configuration (defaults applied, API key masked):\n\n```yaml\n%s```\n",
+		path, out)), nil
+}
+
+// HandleMetrics reports the review counters accumulated since the server
+// started, as text and as structured content for monitoring scripts.
+func (s *Server) HandleMetrics(_ context.Context, _ mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	snap := s.metrics.Snapshot()
+
+	var sb strings.Builder
+	_, _ = sb.WriteString("Review metrics since server start\n\n")
+	_, _ = fmt.Fprintf(&sb, "Reviews: %d\n", snap.Reviews)
+	_, _ = fmt.Fprintf(&sb, "Approvals: %d\n", snap.Approvals)
+	_, _ = fmt.Fprintf(&sb, "Rejections: %d\n", snap.Rejections)
+	_, _ = fmt.Fprintf(&sb, "Failures: %d\n", snap.Failures)
+	_, _ = fmt.Fprintf(&sb, "Security blocks: %d\n", snap.SecurityBlocks)
+	_, _ = fmt.Fprintf(&sb, "Retries: %d\n", snap.Retries)
+	_, _ = fmt.Fprintf(&sb, "Average review duration: %s\n", snap.AverageReviewDuration.Round(time.Millisecond))
+
+	return mcp.NewToolResultStructured(snap, sb.String()), nil
+}
@@ -0,0 +1,176 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"msrl.dev/lgtmcp/internal/appinfo"
+	"msrl.dev/lgtmcp/internal/config"
+	"msrl.dev/lgtmcp/internal/metrics"
+	"msrl.dev/lgtmcp/internal/security"
+	"msrl.dev/lgtmcp/internal/testutil"
+)
+
+func pingText(t *testing.T, s *Server) string {
+	t.Helper()
+	result, err := s.HandlePing(t.Context(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.False(t, result.IsError)
+	require.Len(t, result.Content, 1)
+	textContent, ok := result.Content[0].(mcp.TextContent)
+	require.True(t, ok)
+
+	return textContent.Text
+}
+
+func TestHandlePing(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reports version, model, and auth without the key", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		s.config.Gemini.Model = "gemini-test-model"
+		s.lookPath = func(string) (string, error) { return "/usr/bin/git", nil }
+
+		text := pingText(t, s)
+		assert.Contains(t, text, "lgtmcp is running")
+		assert.Contains(t, text, "Version: "+appinfo.Version)
+		assert.Contains(t, text, "Model: gemini-test-model")
+		assert.Contains(t, text, "Auth: api_key")
+		assert.Contains(t, text, "Git: available (/usr/bin/git)")
+		assert.NotContains(t, text, s.config.Google.APIKey)
+	})
+
+	t.Run("reports missing git", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		s.lookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+
+		assert.Contains(t, pingText(t, s), "Git: not found on PATH")
+	})
+
+	t.Run("is registered", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		assert.NotNil(t, s.mcpServer.GetTool("ping"))
+	})
+}
+
+func TestAuthMethod(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "api_key", authMethod(&config.GoogleConfig{APIKey: "k", UseADC: true}))
+	assert.Equal(t, "adc", authMethod(&config.GoogleConfig{UseADC: true}))
+	assert.Equal(t, "none", authMethod(&config.GoogleConfig{}))
+}
+
+func configInfoText(t *testing.T, s *Server) string {
+	t.Helper()
+	result, err := s.HandleConfigInfo(t.Context(), mcp.CallToolRequest{})
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.False(t, result.IsError)
+	textContent, ok := result.Content[0].(mcp.TextContent)
+	require.True(t, ok)
+
+	return textContent.Text
+}
+
+func TestHandleConfigInfo(t *testing.T) {
+	t.Parallel()
+
+	t.Run("masks the API key and shows effective settings", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		s.config.Google.APIKey = "AIzaSuperSecretKeyValue"
+		s.config.Path = "/home/user/.config/lgtmcp/config.yaml"
+
+		text := configInfoText(t, s)
+		assert.NotContains(t, text, "AIzaSuperSecretKeyValue")
+		assert.Contains(t, text, "api_key: '"+config.RedactedPlaceholder+"'")
+		assert.Contains(t, text, "Config file: /home/user/.config/lgtmcp/config.yaml")
+		assert.Contains(t, text, "model: "+s.config.Gemini.Model)
+		assert.Contains(t, text, "temperature: 0.2")
+		assert.Contains(t, text, "max_retries: 5")
+		assert.Contains(t, text, "diff_context_lines: 20")
+		assert.Contains(t, text, "level: info")
+		// The server's own config is left untouched.
+		assert.Equal(t, "AIzaSuperSecretKeyValue", s.config.Google.APIKey)
+		assert.Nil(t, s.config.Git.DiffContextLines)
+	})
+
+	t.Run("reports configs not loaded from a file", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		assert.Contains(t, configInfoText(t, s), "Config file: (none;")
+	})
+
+	t.Run("is registered", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		assert.NotNil(t, s.mcpServer.GetTool("config_info"))
+	})
+}
+
+func TestHandleMetrics(t *testing.T) {
+	t.Parallel()
+	reviewer, _ := newPromptCapturingReviewer(t, true, "LGTM")
+	scanner, err := security.New("")
+	require.NoError(t, err)
+	s := newForTesting(config.NewTestConfig(), testutil.NewTestLogger(), reviewer, scanner)
+
+	metricsResult := func() (string, metrics.Snapshot) {
+		t.Helper()
+		result, err := s.HandleMetrics(t.Context(), mcp.CallToolRequest{})
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		assert.False(t, result.IsError)
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		snap, ok := result.StructuredContent.(metrics.Snapshot)
+		require.True(t, ok)
+
+		return textContent.Text, snap
+	}
+
+	text, snap := metricsResult()
+	assert.Equal(t, metrics.Snapshot{}, snap)
+	assert.Contains(t, text, "Reviews: 0\n")
+
+	clean := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, clean, "main.go", "package main\n")
+	leaky := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, leaky, "config.go", "const token = \""+fakeSecrets.GitHubPAT()+"\"\n")
+	for _, dir := range []string{clean, clean, leaky} {
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"directory": dir}
+		_, err := s.HandleReviewOnly(t.Context(), request)
+		require.NoError(t, err)
+	}
+
+	text, snap = metricsResult()
+	assert.Equal(t, int64(2), snap.Reviews)
+	assert.Equal(t, int64(2), snap.Approvals)
+	assert.Equal(t, int64(0), snap.Rejections)
+	assert.Equal(t, int64(1), snap.SecurityBlocks)
+	assert.Contains(t, text, "Reviews: 2\n")
+	assert.Contains(t, text, "Security blocks: 1\n")
+	assert.NotNil(t, s.mcpServer.GetTool("metrics"))
+}
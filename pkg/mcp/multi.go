@@ -0,0 +1,193 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"msrl.dev/lgtmcp/internal/review"
+)
+
+const argDirectories = "directories"
+
+// ErrDirectoriesNotStringArray indicates the directories argument is not a
+// non-empty array of strings.
+var ErrDirectoriesNotStringArray = errors.New("directories must be a non-empty array of strings")
+
+// MultiReviewOutput is the structured content of a review_multi result.
+type MultiReviewOutput struct {
+	// LGTM is true when no review failed, none requested changes or a
+	// human, and at least one repository had changes to approve.
+	LGTM bool `json:"lgtm"`
+	// Repos maps each repository's absolute path to its outcome.
+	Repos map[string]RepoReviewOutput `json:"repos"`
+}
+
+// RepoReviewOutput is one repository's outcome in a review_multi result:
+// either its review, as review_only would return it, or the error that
+// stopped it, with its code.
+type RepoReviewOutput struct {
+	Review    *ReviewOutput `json:"review,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	ErrorCode ErrorCode     `json:"error_code,omitempty"`
+}
+
+// HandleReviewMulti reviews the workspace changes of several repositories in
+// one request, as review_only would each in turn, and never commits. A
+// failure in one repository is reported with its outcome and does not stop
+// the others.
+func (s *Server) HandleReviewMulti(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	requestID, err := generateRequestID()
+	if err != nil {
+		s.logger.Error("Failed to generate request ID", "error", err)
+		return nil, err
+	}
+	start := time.Now()
+
+	s.logger.Info("Review request started",
+		"request_id", requestID,
+		"tool", "review_multi")
+
+	// Create progress reporter based on whether client requested progress.
+	reporter := s.createProgressReporter(request)
+
+	args, ok := request.Params.Arguments.(map[string]any)
+	if !ok {
+		s.logger.Error("Invalid arguments format",
+			"request_id", requestID,
+			"tool", "review_multi")
+		return nil, ErrInvalidArguments
+	}
+	directories, err := parseDirectories(args)
+	if err != nil {
+		return nil, err
+	}
+	instructions, err := parseInstructions(args)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := parseTimeout(args)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := withReviewTimeout(ctx, timeout)
+	defer cancel()
+
+	// Repositories are reviewed one at a time, so a large batch does not
+	// take every concurrent review slot at once.
+	output := MultiReviewOutput{Repos: make(map[string]RepoReviewOutput, len(directories))}
+	var text strings.Builder
+	approved, rejected, failed := 0, 0, 0
+	for _, dir := range directories {
+		directory, err := filepath.Abs(dir)
+		if err != nil {
+			directory = dir
+		}
+		if _, seen := output.Repos[directory]; seen {
+			continue
+		}
+		s.logger.Info("Processing repository",
+			"request_id", requestID,
+			"repo", filepath.Base(directory))
+
+		var result *mcp.CallToolResult
+		if err != nil {
+			result = newToolErrorf(CodeInvalidDirectory, "failed to resolve directory path: %v", err)
+		} else {
+			result = timeoutResult(ctx, timeout, s.reviewWithoutCommit(
+				ctx, requestID, start, reporter, directory, reviewTarget{}, instructions, false,
+			))
+		}
+
+		repo := RepoReviewOutput{}
+		if reviewOutput, isReview := result.StructuredContent.(ReviewOutput); isReview && !result.IsError {
+			repo.Review = &reviewOutput
+			switch reviewOutput.Status {
+			case review.StatusApproved:
+				approved++
+			case "":
+				// Nothing to review.
+			default:
+				rejected++
+			}
+		} else {
+			repo.Error = resultText(result)
+			if errorOutput, isError := result.StructuredContent.(ErrorOutput); isError {
+				repo.ErrorCode = errorOutput.Code
+			}
+			failed++
+		}
+		output.Repos[directory] = repo
+		_, _ = fmt.Fprintf(&text, "\n\n## %s\n\n%s", directory, resultText(result))
+	}
+	output.LGTM = approved > 0 && rejected == 0 && failed == 0
+
+	verdict := "NOT APPROVED"
+	if output.LGTM {
+		verdict = "APPROVED (LGTM)"
+	}
+	summary := fmt.Sprintf("Combined Review Result: %s (%d of %d repositories approved",
+		verdict, approved, len(output.Repos))
+	if failed > 0 {
+		summary += fmt.Sprintf(", %d failed", failed)
+	}
+	summary += ")"
+
+	s.logger.Info("Multi-repository review completed",
+		"request_id", requestID,
+		"repos", len(output.Repos),
+		"approved", approved,
+		"failed", failed,
+		"total_duration_ms", time.Since(start).Milliseconds())
+
+	return mcp.NewToolResultStructured(output, summary+text.String()), nil
+}
+
+// parseDirectories extracts the required directories argument of
+// review_multi. Each entry is resolved later, as the directory argument of
+// the single-repository tools is.
+func parseDirectories(args map[string]any) ([]string, error) {
+	list, ok := args[argDirectories].([]any)
+	if !ok || len(list) == 0 {
+		return nil, ErrDirectoriesNotStringArray
+	}
+	directories := make([]string, len(list))
+	for i, v := range list {
+		if directories[i], ok = v.(string); !ok {
+			return nil, ErrDirectoriesNotStringArray
+		}
+	}
+
+	return directories, nil
+}
+
+// resultText returns the text of a tool result's first content block.
+func resultText(result *mcp.CallToolResult) string {
+	if len(result.Content) == 0 {
+		return ""
+	}
+	if text, ok := result.Content[0].(mcp.TextContent); ok {
+		return text.Text
+	}
+
+	return ""
+}
@@ -0,0 +1,101 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"msrl.dev/lgtmcp/internal/review"
+	"msrl.dev/lgtmcp/internal/testutil"
+)
+
+func reviewMulti(t *testing.T, s *Server, directories any) (*mcp.CallToolResult, error) {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"directories": directories}
+
+	return s.HandleReviewMulti(t.Context(), request)
+}
+
+func TestHandleReviewMulti(t *testing.T) {
+	t.Parallel()
+
+	t.Run("approves when every repository is approved", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		first := testutil.CreateTempGitRepo(t)
+		second := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, first, "a.go", "package a\n")
+		testutil.CreateFile(t, second, "b.go", "package b\n")
+
+		result, err := reviewMulti(t, s, []any{first, second, first})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		output, ok := result.StructuredContent.(MultiReviewOutput)
+		require.True(t, ok)
+		assert.True(t, output.LGTM)
+		require.Len(t, output.Repos, 2, "a repeated directory is reviewed once")
+		for _, dir := range []string{first, second} {
+			require.NotNil(t, output.Repos[dir].Review, dir)
+			assert.Equal(t, review.StatusApproved, output.Repos[dir].Review.Status)
+		}
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		assert.Contains(t, textContent.Text, "Combined Review Result: APPROVED (LGTM) (2 of 2 repositories approved)")
+		assert.Contains(t, textContent.Text, "## "+first)
+	})
+
+	t.Run("reports failures per repository", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		changed := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, changed, "a.go", "package a\n")
+		unchanged := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, unchanged, "b.go", "package b\n")
+		testutil.RunGitCmd(t, unchanged, "add", ".")
+		testutil.RunGitCmd(t, unchanged, "commit", "-m", "initial")
+		notRepo := t.TempDir()
+
+		result, err := reviewMulti(t, s, []any{notRepo, changed, unchanged})
+		require.NoError(t, err)
+		assert.False(t, result.IsError, "one repository's failure is not a tool failure")
+		output, ok := result.StructuredContent.(MultiReviewOutput)
+		require.True(t, ok)
+		assert.False(t, output.LGTM)
+		assert.Nil(t, output.Repos[notRepo].Review)
+		assert.Contains(t, output.Repos[notRepo].Error, "invalid git repository")
+		assert.Equal(t, CodeNotARepo, output.Repos[notRepo].ErrorCode)
+		require.NotNil(t, output.Repos[changed].Review)
+		assert.True(t, output.Repos[changed].Review.LGTM)
+		require.NotNil(t, output.Repos[unchanged].Review)
+		assert.Empty(t, output.Repos[unchanged].Review.Status, "nothing to review")
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		assert.Contains(t, textContent.Text, "NOT APPROVED (1 of 3 repositories approved, 1 failed)")
+	})
+
+	t.Run("rejects malformed directories", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		for _, directories := range []any{nil, "repo", []any{}, []any{"repo", 42}} {
+			result, err := reviewMulti(t, s, directories)
+			require.ErrorIs(t, err, ErrDirectoriesNotStringArray)
+			assert.Nil(t, result)
+		}
+	})
+}
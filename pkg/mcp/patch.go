@@ -0,0 +1,181 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"msrl.dev/lgtmcp/internal/audit"
+	"msrl.dev/lgtmcp/internal/config"
+	"msrl.dev/lgtmcp/internal/git"
+	"msrl.dev/lgtmcp/internal/progress"
+	"msrl.dev/lgtmcp/internal/prompts"
+	"msrl.dev/lgtmcp/internal/review"
+	"msrl.dev/lgtmcp/internal/security"
+)
+
+const argDiff = "diff"
+
+// ErrDiffInvalid indicates the diff argument of review_patch is not a
+// string holding a git diff.
+var ErrDiffInvalid = errors.New(`diff must be a non-empty git diff ("diff --git" format)`)
+
+// HandleReviewPatch reviews a diff passed as an argument, for clients that
+// already have one, such as from a pull request webhook, and never commits.
+// The secret scan covers the lines the diff adds. The optional directory is
+// a checkout Gemini may read files from for context; without it, Gemini sees
+// only the diff.
+func (s *Server) HandleReviewPatch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	requestID, err := generateRequestID()
+	if err != nil {
+		s.logger.Error("Failed to generate request ID", "error", err)
+		return nil, err
+	}
+	start := time.Now()
+
+	s.logger.Info("Review request started",
+		"request_id", requestID,
+		"tool", "review_patch")
+
+	// Create progress reporter based on whether client requested progress.
+	reporter := s.createProgressReporter(request)
+
+	// Parse arguments.
+	args, ok := request.Params.Arguments.(map[string]any)
+	if !ok {
+		s.logger.Error("Invalid arguments format",
+			"request_id", requestID,
+			"tool", "review_patch")
+		return nil, ErrInvalidArguments
+	}
+
+	diff, ok := args[argDiff].(string)
+	if !ok {
+		return nil, ErrDiffInvalid
+	}
+	cf := security.ExtractChangedFilesDetailed(diff)
+	if len(cf.All) == 0 {
+		return nil, ErrDiffInvalid
+	}
+	instructions, err := parseInstructions(args)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := parseTimeout(args)
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &reviewContext{
+		diff:             diff,
+		changedFiles:     cf.All,
+		reviewDiff:       diff,
+		reviewFiles:      cf.All,
+		reviewStats:      security.CountChanges(diff),
+		deletedFiles:     cf.Deleted,
+		userInstructions: instructions,
+		languages:        prompts.DetectLanguages(cf.All),
+	}
+	if raw, present := args[argDirectory]; present && raw != nil {
+		var directory string
+		if directory, err = s.parseDirectory(args); err != nil {
+			if errors.Is(err, ErrDirectoryNotString) {
+				return nil, err
+			}
+			return newToolErrorf(CodeInvalidDirectory, "failed to process directory: %v", err), nil
+		}
+		var gitConfig *config.GitConfig
+		if s.config != nil {
+			gitConfig = &s.config.Git
+		}
+		if rc.gitClient, err = git.New(directory, gitConfig); err != nil {
+			return toolErrorResult(fmt.Errorf("invalid git repository: %w", err), CodeNotARepo), nil
+		}
+		rc.absPath = directory
+	}
+
+	s.logger.Info("Processing patch",
+		"request_id", requestID,
+		"repo", rc.repoName(),
+		"changed_files", len(rc.changedFiles))
+
+	ctx, cancel := withReviewTimeout(ctx, timeout)
+	defer cancel()
+
+	return timeoutResult(ctx, timeout, s.reviewPatch(ctx, requestID, start, reporter, rc)), nil
+}
+
+// reviewPatch scans and reviews the diff in rc, which HandleReviewPatch
+// built from its arguments. Every failure is reported in-band.
+//
+//nolint:funcorder // Helper method
+func (s *Server) reviewPatch(
+	ctx context.Context, requestID string, start time.Time, reporter progress.Reporter, rc *reviewContext,
+) *mcp.CallToolResult {
+	// As for reviewWithoutCommit, there is no staging or committing.
+	const totalSteps = 4.0
+	release, err := s.acquireReviewSlot(ctx, requestID)
+	if err != nil {
+		return toolErrorResult(fmt.Errorf("review not started: %w", err), CodeCanceled)
+	}
+	defer release()
+
+	// The diff's files need not exist anywhere, so only its added lines are
+	// scanned.
+	reporter.Report(ctx, 1, totalSteps, "Scanning for secrets...")
+	findings, err := s.scanner.ScanAddedLines(ctx, rc.diff)
+	if err != nil {
+		return toolErrorResult(fmt.Errorf("security scan failed: %w", err), CodeScanFailed)
+	}
+	s.logger.Info("Security scan completed",
+		"request_id", requestID,
+		"findings", len(findings))
+	if security.HasFindings(findings) {
+		if !s.secretsOnlyWarn() {
+			s.metrics.RecordSecurityBlock()
+			s.recordAudit(audit.Record{
+				Repo:         rc.repoName(),
+				ChangedFiles: len(rc.changedFiles),
+				Status:       string(review.StatusChangesRequested),
+			})
+
+			return s.secretsBlockedResult(findings)
+		}
+		s.logger.Warn("Security scan detected secrets; reviewing anyway because gitleaks.mode is warn",
+			"request_id", requestID,
+			"findings", len(findings))
+		rc.secrets = findings
+	}
+
+	reviewResult, err := s.performReview(ctx, rc, reporter, totalSteps)
+	if err != nil {
+		s.logger.Error("Review failed",
+			"request_id", requestID,
+			"total_duration_ms", time.Since(start).Milliseconds(),
+			"error", err)
+		return toolErrorResult(fmt.Errorf("review failed: %w", err), CodeReviewFailed)
+	}
+
+	s.logger.Info("Review completed",
+		"request_id", requestID,
+		"approved", reviewResult.LGTM,
+		"total_duration_ms", time.Since(start).Milliseconds())
+
+	return newReviewToolResult(reviewResult, "", false)
+}
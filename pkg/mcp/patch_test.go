@@ -0,0 +1,139 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"msrl.dev/lgtmcp/internal/audit"
+	"msrl.dev/lgtmcp/internal/config"
+	"msrl.dev/lgtmcp/internal/review"
+	"msrl.dev/lgtmcp/internal/security"
+	"msrl.dev/lgtmcp/internal/testutil"
+)
+
+const testPatch = `diff --git a/pkg/a.go b/pkg/a.go
+index 1111111..2222222 100644
+--- a/pkg/a.go
++++ b/pkg/a.go
+@@ -1,3 +1,4 @@
+ package pkg
+ 
++func A() {}
+ // end
+`
+
+func reviewPatch(t *testing.T, s *Server, args map[string]any) (*mcp.CallToolResult, error) {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+
+	return s.HandleReviewPatch(t.Context(), request)
+}
+
+func TestHandleReviewPatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reviews the diff without a repository", func(t *testing.T) {
+		t.Parallel()
+		reviewer, lastPrompt := newPromptCapturingReviewer(t, true, "Looks good")
+		scanner, err := security.New("")
+		require.NoError(t, err)
+		s := newForTesting(config.NewTestConfig(), testutil.NewTestLogger(), reviewer, scanner)
+
+		result, err := reviewPatch(t, s, map[string]any{"diff": testPatch, "instructions": "check naming"})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		output, ok := result.StructuredContent.(ReviewOutput)
+		require.True(t, ok)
+		assert.Equal(t, review.StatusApproved, output.Status)
+		assert.False(t, output.Committed)
+		assert.Equal(t, 1, output.FilesChanged)
+		assert.Equal(t, 1, output.Additions)
+		assert.Contains(t, lastPrompt(), "+func A() {}")
+		assert.Contains(t, lastPrompt(), "check naming")
+	})
+
+	t.Run("reviews with a repository for context", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir := createTestServer(t)
+
+		result, err := reviewPatch(t, s, map[string]any{"diff": testPatch, "directory": tmpDir})
+		require.NoError(t, err)
+		assert.False(t, result.IsError)
+		output, ok := result.StructuredContent.(ReviewOutput)
+		require.True(t, ok)
+		assert.True(t, output.LGTM)
+	})
+
+	t.Run("blocks secrets in added lines", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		leaky := "diff --git a/config.go b/config.go\nnew file mode 100644\n--- /dev/null\n+++ b/config.go\n" +
+			"@@ -0,0 +1 @@\n+const token = \"" + fakeSecrets.GitHubPAT() + "\"\n"
+
+		result, err := reviewPatch(t, s, map[string]any{"diff": leaky})
+		require.NoError(t, err)
+		assert.False(t, result.IsError, "detected secrets are a non-approval, not a tool failure")
+		output, ok := result.StructuredContent.(ReviewOutput)
+		require.True(t, ok)
+		assert.Equal(t, review.StatusChangesRequested, output.Status)
+		require.NotEmpty(t, output.Findings)
+		assert.Equal(t, "config.go", output.Findings[0].File)
+		assert.Equal(t, 1, output.Findings[0].Line)
+	})
+
+	t.Run("audits no repository without a directory", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		auditDir := t.TempDir()
+		var err error
+		s.audit, err = audit.New(auditDir)
+		require.NoError(t, err)
+		leaky := "diff --git a/config.go b/config.go\nnew file mode 100644\n--- /dev/null\n+++ b/config.go\n" +
+			"@@ -0,0 +1 @@\n+const token = \"" + fakeSecrets.GitHubPAT() + "\"\n"
+
+		for _, diff := range []string{testPatch, leaky} {
+			_, err = reviewPatch(t, s, map[string]any{"diff": diff})
+			require.NoError(t, err)
+		}
+		records := readAudit(t, auditDir)
+		require.Len(t, records, 2)
+		for _, record := range records {
+			assert.Empty(t, record.Repo, "not the \".\" of filepath.Base(\"\")")
+		}
+	})
+
+	t.Run("rejects an invalid diff", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		for _, diff := range []any{nil, 42, "", "just some text\n"} {
+			_, err := reviewPatch(t, s, map[string]any{"diff": diff})
+			require.ErrorIs(t, err, ErrDiffInvalid, "%v", diff)
+		}
+	})
+
+	t.Run("reports a directory that is not a repository", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+
+		result, err := reviewPatch(t, s, map[string]any{"diff": testPatch, "directory": t.TempDir()})
+		assertInBandToolError(t, result, err, "invalid git repository")
+		assertToolErrorCode(t, result, CodeNotARepo)
+	})
+}
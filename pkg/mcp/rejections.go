@@ -0,0 +1,101 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"sync"
+)
+
+const (
+	// maxTrackedDiffs bounds how many distinct diffs the tracker remembers;
+	// the least recently recorded diff is evicted first.
+	maxTrackedDiffs = 64
+
+	// maxPriorRejections bounds how many rejections are kept per diff, so a
+	// client stuck in a retry loop cannot grow the prompt without limit.
+	maxPriorRejections = 3
+)
+
+// rejectionTracker remembers, for the lifetime of the server, the review
+// comments of diffs that were rejected, keyed by a hash of the diff text. A
+// resubmission of an identical diff can then show the model its earlier
+// verdicts.
+type rejectionTracker struct {
+	mu     sync.Mutex
+	byDiff map[string][]string
+	order  []string // Keys, least recently recorded first
+}
+
+func newRejectionTracker() *rejectionTracker {
+	return &rejectionTracker{byDiff: make(map[string][]string)}
+}
+
+// diffKey returns the tracker key for diff.
+func diffKey(diff string) string {
+	sum := sha256.Sum256([]byte(diff))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// prior returns a copy of the rejection comments recorded for diff, oldest
+// first.
+func (t *rejectionTracker) prior(diff string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return slices.Clone(t.byDiff[diffKey(diff)])
+}
+
+// record appends a rejection for diff, keeping only the most recent
+// maxPriorRejections and evicting the stalest diff when full.
+func (t *rejectionTracker) record(diff, comments string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := diffKey(diff)
+	t.removeFromOrder(key)
+	t.order = append(t.order, key)
+
+	rejections := append(t.byDiff[key], comments)
+	if len(rejections) > maxPriorRejections {
+		rejections = rejections[len(rejections)-maxPriorRejections:]
+	}
+	t.byDiff[key] = rejections
+
+	for len(t.order) > maxTrackedDiffs {
+		delete(t.byDiff, t.order[0])
+		t.order = t.order[1:]
+	}
+}
+
+// forget drops any rejections recorded for diff, e.g. once it is approved.
+func (t *rejectionTracker) forget(diff string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := diffKey(diff)
+	delete(t.byDiff, key)
+	t.removeFromOrder(key)
+}
+
+// removeFromOrder deletes key from the eviction order. Callers hold t.mu.
+func (t *rejectionTracker) removeFromOrder(key string) {
+	if i := slices.Index(t.order, key); i != -1 {
+		t.order = slices.Delete(t.order, i, i+1)
+	}
+}
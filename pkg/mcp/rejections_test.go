@@ -0,0 +1,78 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRejectionTracker(t *testing.T) {
+	t.Parallel()
+
+	t.Run("records and forgets per diff", func(t *testing.T) {
+		t.Parallel()
+		tr := newRejectionTracker()
+		assert.Empty(t, tr.prior("diff a"))
+
+		tr.record("diff a", "first")
+		tr.record("diff a", "second")
+		tr.record("diff b", "other")
+		assert.Equal(t, []string{"first", "second"}, tr.prior("diff a"))
+		assert.Equal(t, []string{"other"}, tr.prior("diff b"))
+
+		tr.forget("diff a")
+		assert.Empty(t, tr.prior("diff a"))
+		assert.Equal(t, []string{"other"}, tr.prior("diff b"))
+	})
+
+	t.Run("keeps only the most recent rejections per diff", func(t *testing.T) {
+		t.Parallel()
+		tr := newRejectionTracker()
+		for i := range maxPriorRejections + 2 {
+			tr.record("diff", strconv.Itoa(i))
+		}
+		prior := tr.prior("diff")
+		assert.Len(t, prior, maxPriorRejections)
+		assert.Equal(t, strconv.Itoa(maxPriorRejections+1), prior[len(prior)-1])
+	})
+
+	t.Run("evicts the least recently recorded diff", func(t *testing.T) {
+		t.Parallel()
+		tr := newRejectionTracker()
+		for i := range maxTrackedDiffs {
+			tr.record("diff "+strconv.Itoa(i), "rejected")
+		}
+		// Touch diff 0 so diff 1 becomes the stalest.
+		tr.record("diff 0", "again")
+		tr.record("one too many", "rejected")
+
+		assert.NotEmpty(t, tr.prior("diff 0"))
+		assert.Empty(t, tr.prior("diff 1"))
+		assert.NotEmpty(t, tr.prior("one too many"))
+		assert.Len(t, tr.byDiff, maxTrackedDiffs)
+	})
+
+	t.Run("prior returns a copy", func(t *testing.T) {
+		t.Parallel()
+		tr := newRejectionTracker()
+		tr.record("diff", "original")
+		prior := tr.prior("diff")
+		prior[0] = "mutated"
+		assert.Equal(t, []string{"original"}, tr.prior("diff"))
+	})
+}
@@ -0,0 +1,154 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/zricethezav/gitleaks/v8/report"
+	"msrl.dev/lgtmcp/internal/config"
+	"msrl.dev/lgtmcp/internal/git"
+	"msrl.dev/lgtmcp/internal/security"
+)
+
+// noSecretsText is the scan result when the scan finds nothing.
+const noSecretsText = "No secrets detected"
+
+// ScanOutput is the structured content of a scan_secrets or scan_repo result.
+type ScanOutput struct {
+	Findings []security.FindingSummary `json:"findings"`
+	// RuleCounts maps each gitleaks rule ID among Findings to how many
+	// findings it produced, omitted when there are none.
+	RuleCounts map[string]int `json:"rule_counts,omitempty"`
+}
+
+// HandleScanSecrets runs the secret scan a review starts with over the
+// workspace changes, and nothing else: no Gemini call is made. Findings are
+// reported as an error result so that a client can use the tool as a
+// pre-commit gate.
+func (s *Server) HandleScanSecrets(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	directory, gitClient, earlyReturn, err := s.openScanRepo(request)
+	if earlyReturn != nil || err != nil {
+		return earlyReturn, err
+	}
+
+	diff, err := gitClient.GetDiff(ctx)
+	if errors.Is(err, git.ErrNoChanges) {
+		return mcp.NewToolResultStructured(
+			ScanOutput{Findings: []security.FindingSummary{}}, noSecretsText+" (no changes to scan)",
+		), nil
+	}
+	if err != nil {
+		return newToolErrorf(CodeGitFailed, "failed to get diff: %v", err), nil
+	}
+	// Scan what a review would: the review report is excluded there too.
+	if reportPath := s.reportPath(); reportPath != "" {
+		diff = security.FilterDiff(diff, func(p string) bool { return p != reportPath })
+	}
+
+	findings, err := s.scanner.ScanDiff(ctx, diff, s.scanReader(func(path string) (string, error) {
+		return gitClient.GetFileContent(ctx, path)
+	}, gitClient.FileSize))
+	if err != nil {
+		return newToolErrorf(CodeScanFailed, "security scan failed: %v", err), nil
+	}
+	s.logger.Info("Secret scan completed",
+		"repo", filepath.Base(directory),
+		"findings", len(findings))
+
+	return s.scanResult(findings), nil
+}
+
+// HandleScanRepo scans every tracked file in the repository for secrets, as
+// a one-time audit when adopting lgtmcp in an existing repository. Files are
+// read from the working tree; symlinks are not followed, and files matching
+// gitleaks.skip_files are skipped, as in the per-change scan. No Gemini call
+// is made, and findings are reported as an error result.
+func (s *Server) HandleScanRepo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	directory, gitClient, earlyReturn, err := s.openScanRepo(request)
+	if earlyReturn != nil || err != nil {
+		return earlyReturn, err
+	}
+
+	files, err := gitClient.TrackedFiles(ctx)
+	if err != nil {
+		return toolErrorResult(err, CodeGitFailed), nil
+	}
+	findings, err := s.scanner.ScanFiles(ctx, files, s.scanReader(func(path string) (string, error) {
+		return gitClient.GetFileContent(ctx, path)
+	}, gitClient.FileSize))
+	if err != nil {
+		return toolErrorResult(fmt.Errorf("security scan failed: %w", err), CodeScanFailed), nil
+	}
+	s.logger.Info("Repository secret scan completed",
+		"repo", filepath.Base(directory),
+		"files", len(files),
+		"findings", len(findings))
+
+	return s.scanResult(findings), nil
+}
+
+// openScanRepo parses the directory argument of a scan tool and opens the
+// repository, returning its absolute path and git client. As for the review
+// tools, a wrong-typed argument is returned as an error and any other failure
+// as an in-band error result.
+//
+//nolint:funcorder // Helper method
+func (s *Server) openScanRepo(request mcp.CallToolRequest) (string, *git.Git, *mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]any)
+	if !ok {
+		return "", nil, nil, ErrInvalidArguments
+	}
+	directory, err := s.parseDirectory(args)
+	if err != nil {
+		if errors.Is(err, ErrDirectoryNotString) {
+			return "", nil, nil, err
+		}
+		return "", nil, newToolErrorf(CodeInvalidDirectory, "failed to process directory: %v", err), nil
+	}
+
+	var gitConfig *config.GitConfig
+	if s.config != nil {
+		gitConfig = &s.config.Git
+	}
+	gitClient, err := git.New(directory, gitConfig)
+	if err != nil {
+		return "", nil, toolErrorResult(fmt.Errorf("invalid git repository: %w", err), CodeNotARepo), nil
+	}
+
+	return directory, gitClient, nil, nil
+}
+
+// scanResult reports findings as the result of a scan tool: an error
+// result listing them, or noSecretsText when there are none.
+//
+//nolint:funcorder // Helper method
+func (s *Server) scanResult(findings []report.Finding) *mcp.CallToolResult {
+	output := ScanOutput{
+		Findings:   security.SummarizeFindings(findings, s.revealChars()),
+		RuleCounts: security.CountFindingsByRule(findings),
+	}
+	if !security.HasFindings(findings) {
+		return mcp.NewToolResultStructured(output, noSecretsText)
+	}
+	result := mcp.NewToolResultStructured(output, s.formatFindings(findings))
+	result.IsError = true
+
+	return result
+}
@@ -0,0 +1,209 @@
+// Copyright © 2026 Michael Shields
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"msrl.dev/lgtmcp/internal/config"
+	"msrl.dev/lgtmcp/internal/review"
+	"msrl.dev/lgtmcp/internal/security"
+	"msrl.dev/lgtmcp/internal/testutil"
+)
+
+func scanSecrets(t *testing.T, s *Server, directory any) *mcp.CallToolResult {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"directory": directory}
+	result, err := s.HandleScanSecrets(t.Context(), request)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+
+	return result
+}
+
+func scanRepo(t *testing.T, s *Server, directory string) *mcp.CallToolResult {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"directory": directory}
+	result, err := s.HandleScanRepo(t.Context(), request)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+
+	return result
+}
+
+func TestHandleScanSecrets(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reports clean changes", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		tmpDir := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+
+		result := scanSecrets(t, s, tmpDir)
+		assert.False(t, result.IsError)
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		assert.Equal(t, "No secrets detected", textContent.Text)
+		output, ok := result.StructuredContent.(ScanOutput)
+		require.True(t, ok)
+		assert.NotNil(t, output.Findings, "findings is an empty list, not null")
+		assert.Empty(t, output.Findings)
+	})
+
+	t.Run("reports no changes", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		tmpDir := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+
+		result := scanSecrets(t, s, tmpDir)
+		assert.False(t, result.IsError)
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		assert.Equal(t, "No secrets detected (no changes to scan)", textContent.Text)
+	})
+
+	t.Run("fails on findings", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		tmpDir := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, tmpDir, "config.go", "const token = \""+fakeSecrets.GitHubPAT()+"\"\n")
+
+		result := scanSecrets(t, s, tmpDir)
+		assert.True(t, result.IsError)
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		assert.Contains(t, textContent.Text, "potential secret(s)")
+		assert.Contains(t, textContent.Text, "File: config.go")
+		assert.NotContains(t, textContent.Text, fakeSecrets.GitHubPAT())
+		output, ok := result.StructuredContent.(ScanOutput)
+		require.True(t, ok)
+		require.NotEmpty(t, output.Findings)
+		assert.Equal(t, "config.go", output.Findings[0].File)
+		assert.Positive(t, output.RuleCounts["github-pat"])
+		total := 0
+		for _, n := range output.RuleCounts {
+			total += n
+		}
+		assert.Equal(t, len(output.Findings), total)
+		assert.Contains(t, textContent.Text, "Rules triggered: ")
+	})
+
+	t.Run("skips files over max_scan_bytes", func(t *testing.T) {
+		t.Parallel()
+		cfg := config.NewTestConfig()
+		secret := "const token = \"" + fakeSecrets.GitHubPAT() + "\"\n"
+		cfg.Gitleaks.MaxScanBytes = new(int64(len(secret) - 1))
+		scanner, err := security.New("")
+		require.NoError(t, err)
+		s := newForTesting(cfg, testutil.NewTestLogger(), review.NewForTesting(), scanner)
+		tmpDir := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, tmpDir, "config.go", secret)
+
+		result := scanSecrets(t, s, tmpDir)
+		assert.False(t, result.IsError)
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		assert.Equal(t, "No secrets detected", textContent.Text)
+
+		cfg.Gitleaks.MaxScanBytes = new(int64(len(secret)))
+		assert.True(t, scanSecrets(t, s, tmpDir).IsError, "a file at the limit is scanned")
+	})
+
+	t.Run("rejects a non-string directory", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"directory": 42}
+		result, err := s.HandleScanSecrets(t.Context(), request)
+		require.ErrorIs(t, err, ErrDirectoryNotString)
+		assert.Nil(t, result)
+	})
+
+	t.Run("reports a directory that is not a repository", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		result := scanSecrets(t, s, t.TempDir())
+		assert.True(t, result.IsError)
+	})
+
+	t.Run("is registered", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		assert.NotNil(t, s.mcpServer.GetTool("scan_secrets"))
+	})
+}
+
+func TestHandleScanRepo(t *testing.T) {
+	t.Parallel()
+
+	t.Run("scans every tracked file once", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		scanner, err := security.New("", security.WithSkipFiles([]string{"*.lock"}))
+		require.NoError(t, err)
+		s.scanner = scanner
+
+		tmpDir := testutil.CreateTempGitRepo(t)
+		secret := "const token = \"" + fakeSecrets.GitHubPAT() + "\"\n"
+		testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+		testutil.CreateFile(t, tmpDir, "old/config.go", secret)
+		testutil.CreateFile(t, tmpDir, "deps.lock", secret)
+		require.NoError(t, os.Symlink("old/config.go", filepath.Join(tmpDir, "config-link.go")))
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+		testutil.CreateFile(t, tmpDir, "untracked.go", secret)
+
+		result := scanRepo(t, s, tmpDir)
+		assert.True(t, result.IsError)
+		output, ok := result.StructuredContent.(ScanOutput)
+		require.True(t, ok)
+		require.NotEmpty(t, output.Findings)
+		for _, finding := range output.Findings {
+			assert.Equal(t, "old/config.go", finding.File)
+		}
+	})
+
+	t.Run("reports a clean repository", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		tmpDir := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+
+		result := scanRepo(t, s, tmpDir)
+		assert.False(t, result.IsError)
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		assert.Equal(t, "No secrets detected", textContent.Text)
+	})
+
+	t.Run("is registered", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		assert.NotNil(t, s.mcpServer.GetTool("scan_repo"))
+	})
+}
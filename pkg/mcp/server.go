@@ -21,18 +21,26 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
+	"os/exec"
+	"path"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/zricethezav/gitleaks/v8/report"
 	"msrl.dev/lgtmcp/internal/appinfo"
+	"msrl.dev/lgtmcp/internal/audit"
 	"msrl.dev/lgtmcp/internal/config"
 	"msrl.dev/lgtmcp/internal/git"
 	"msrl.dev/lgtmcp/internal/logging"
+	"msrl.dev/lgtmcp/internal/metrics"
 	"msrl.dev/lgtmcp/internal/progress"
+	"msrl.dev/lgtmcp/internal/prompts"
 	"msrl.dev/lgtmcp/internal/review"
 	"msrl.dev/lgtmcp/internal/security"
 )
@@ -44,16 +52,68 @@ var (
 	ErrInvalidArguments = errors.New("invalid arguments format")
 	// ErrCommitMessageNotString indicates commit_message argument is not a string.
 	ErrCommitMessageNotString = errors.New("commit_message must be a string")
+	// ErrCommitMessageRequired indicates a missing commit_message argument
+	// with no git.default_commit_message to fall back to.
+	ErrCommitMessageRequired = errors.New("commit_message is required unless git.default_commit_message is set")
+	// ErrFilesNotStringArray indicates the files argument is not a non-empty
+	// array of strings.
+	ErrFilesNotStringArray = errors.New("files must be a non-empty array of strings")
+	// ErrAmendNotBool indicates the amend argument is not a boolean.
+	ErrAmendNotBool = errors.New("amend must be a boolean")
+	// ErrStageInvalid indicates the stage argument is neither "all",
+	// "tracked", nor a non-empty array of repo-relative paths.
+	ErrStageInvalid = errors.New(`stage must be "all", "tracked", or a non-empty array of repo-relative paths`)
+	// ErrStageSelectsNothing indicates the stage argument selects none of the
+	// reviewed changes, leaving nothing to commit.
+	ErrStageSelectsNothing = errors.New("stage selects none of the reviewed changes")
+	// ErrRefNotString indicates the from or to argument is not a non-empty
+	// string.
+	ErrRefNotString = errors.New("from and to must be non-empty strings")
+	// ErrInstructionsNotString indicates the instructions argument is not a
+	// string.
+	ErrInstructionsNotString = errors.New("instructions must be a string")
+	// ErrTimeoutNotPositive indicates the timeout_seconds argument is not a
+	// positive number.
+	ErrTimeoutNotPositive = errors.New("timeout_seconds must be a positive number")
+	// ErrReviewTimedOut indicates a review exceeded its timeout_seconds
+	// deadline.
+	ErrReviewTimedOut = errors.New("review timed out")
+	// ErrIncludeDiffNotBool indicates the include_diff argument is not a
+	// boolean.
+	ErrIncludeDiffNotBool = errors.New("include_diff must be a boolean")
+	// ErrBaseBranchNotString indicates the base_branch argument is not a
+	// non-empty string.
+	ErrBaseBranchNotString = errors.New("base_branch must be a non-empty string")
+	// ErrBaseBranchWithFiles indicates a request giving both base_branch and
+	// files, which select different changes to review.
+	ErrBaseBranchWithFiles = errors.New("base_branch cannot be combined with files")
 )
 
 const (
-	argDirectory  = "directory"
-	schemaType    = "type"
-	schemaString  = "string"
-	schemaDescKey = "description"
+	argDirectory     = "directory"
+	argFiles         = "files"
+	argCommitMessage = "commit_message"
+	argAmend         = "amend"
+	argFrom          = "from"
+	argTo            = "to"
+	argInstructions  = "instructions"
+	argTimeout       = "timeout_seconds"
+	argStage         = "stage"
+	argIncludeDiff   = "include_diff"
+	argBaseBranch    = "base_branch"
+
+	// stageAll and stageTracked are the keyword values of the stage
+	// argument: every reviewed change, or only changes to files git already
+	// tracks.
+	stageAll     = "all"
+	stageTracked = "tracked"
 
 	// footerSeparator joins the usage statistics within a footer line.
 	footerSeparator = " · "
+
+	// maxResultDiffBytes bounds the diff include_diff attaches to a review
+	// result (64KB), so a huge change cannot bloat the client's context.
+	maxResultDiffBytes = 64 * 1024
 )
 
 // Server implements the MCP server for LGTMCP.
@@ -64,6 +124,18 @@ type Server struct {
 	logger    logging.Logger
 	config    *config.Config
 	serveFunc func(*server.MCPServer, ...server.StdioOption) error
+	lookPath  func(file string) (string, error)
+
+	rejections *rejectionTracker
+	// audit records each review decision; nil when audit.directory is unset.
+	audit *audit.Writer
+	// metrics counts review outcomes for the metrics tool.
+	metrics *metrics.Metrics
+	// confirmations holds approved commits awaiting confirm_commit.
+	confirmations *confirmationStore
+	// reviewSlots is a counting semaphore bounding concurrent reviews; nil
+	// means unlimited.
+	reviewSlots chan struct{}
 }
 
 // New creates a new MCP server instance.
@@ -92,11 +164,53 @@ func New(cfg *config.Config, logger logging.Logger) (*Server, error) {
 		return nil, fmt.Errorf("failed to create reviewer: %w", err)
 	}
 
-	scanner, err := security.New(cfg.Gitleaks.Config)
+	scanner, err := security.New(cfg.Gitleaks.Config,
+		security.WithSkipFiles(cfg.Gitleaks.SkipFiles),
+		security.WithScanAddedLines(cfg.Gitleaks.ScanAddedLines))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create security scanner: %w", err)
 	}
 
+	for _, pattern := range cfg.Review.HumanReviewFiles {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid review.human_review_files pattern %q: %w", pattern, err)
+		}
+	}
+
+	if t := cfg.Git.CommitMessageTemplate; t != "" {
+		if _, err := git.ParseCommitMessageTemplate(t); err != nil {
+			return nil, fmt.Errorf("invalid git.commit_message_template: %w", err)
+		}
+	}
+	if t := cfg.Git.DefaultCommitMessage; t != "" {
+		if _, err := git.ParseDefaultCommitMessage(t); err != nil {
+			return nil, fmt.Errorf("invalid git.default_commit_message: %w", err)
+		}
+	}
+
+	if p := cfg.Review.ReportPath; p != "" {
+		clean := path.Clean(p)
+		first, _, _ := strings.Cut(clean, "/")
+		if clean == "." || !filepath.IsLocal(filepath.FromSlash(clean)) || first == ".git" {
+			return nil, fmt.Errorf("invalid review.report_path %q: must be a relative path inside the repository "+
+				"and outside .git", p)
+		}
+	}
+
+	var auditWriter *audit.Writer
+	if dir := cfg.Audit.Directory; dir != "" {
+		safeDir, err := config.ValidatePath(dir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid audit.directory: %w", err)
+		}
+		if auditWriter, err = audit.New(safeDir); err != nil {
+			return nil, fmt.Errorf("failed to create audit log: %w", err)
+		}
+	}
+
+	m := metrics.New()
+	reviewer.SetMetrics(m)
+
 	s := &Server{
 		mcpServer: mcpServer,
 		reviewer:  reviewer,
@@ -104,9 +218,16 @@ func New(cfg *config.Config, logger logging.Logger) (*Server, error) {
 		logger:    logger,
 		config:    cfg,
 		serveFunc: server.ServeStdio,
+		lookPath:  exec.LookPath,
+
+		rejections:    newRejectionTracker(),
+		audit:         auditWriter,
+		metrics:       m,
+		confirmations: newConfirmationStore(),
+		reviewSlots:   newReviewSlots(cfg),
 	}
 
-	// Register the review_only and review_and_commit tools.
+	// Register the review and diagnostic tools.
 	s.registerTools()
 
 	return s, nil
@@ -124,11 +245,62 @@ func newForTesting(cfg *config.Config, logger logging.Logger, reviewer *review.R
 		logger:    logger,
 		config:    cfg,
 		serveFunc: server.ServeStdio,
+		lookPath:  exec.LookPath,
+
+		rejections:    newRejectionTracker(),
+		metrics:       metrics.New(),
+		confirmations: newConfirmationStore(),
+		reviewSlots:   newReviewSlots(cfg),
+	}
+	if reviewer != nil {
+		reviewer.SetMetrics(s.metrics)
 	}
 	s.registerTools()
 	return s
 }
 
+// newReviewSlots creates the semaphore bounding concurrent reviews, sized by
+// server.max_concurrent_reviews. It returns nil (no limit) when the setting
+// is 0 or negative.
+func newReviewSlots(cfg *config.Config) chan struct{} {
+	limit := config.DefaultMaxConcurrentReviews
+	if cfg != nil && cfg.Server.MaxConcurrentReviews != nil {
+		limit = *cfg.Server.MaxConcurrentReviews
+	}
+	if limit <= 0 {
+		return nil
+	}
+
+	return make(chan struct{}, limit)
+}
+
+// acquireReviewSlot waits until fewer than server.max_concurrent_reviews
+// reviews are running, or ctx is done. On success the caller must call the
+// returned release function when its review finishes.
+//
+//nolint:funcorder // Helper method
+func (s *Server) acquireReviewSlot(ctx context.Context, requestID string) (func(), error) {
+	if s.reviewSlots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case s.reviewSlots <- struct{}{}:
+	default:
+		s.logger.Info("Review queued; concurrent review limit reached",
+			"request_id", requestID,
+			"limit", cap(s.reviewSlots))
+		select {
+		case s.reviewSlots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gave up waiting for one of %d concurrent review slots: %w",
+				cap(s.reviewSlots), ctx.Err())
+		}
+	}
+
+	return func() { <-s.reviewSlots }, nil
+}
+
 // BindLogSender binds ls to this server's MCP transport so the "mcp" logging
 // output can deliver records to the connected client as notifications/message.
 // The logger (and its sender) are created before the server, so startup calls
@@ -144,18 +316,10 @@ func (s *Server) registerTools() { //nolint:funcorder // Helper method
 		Name: "review_only",
 		Description: "Review code changes using Gemini and return feedback without committing. " +
 			"Reviews all workspace changes (staged, unstaged, and untracked), not just staged " +
-			"files; stash anything you want to exclude first. Returns review comments and " +
+			"files, unless files limits the review to some paths or base_branch reviews the " +
+			"current branch's commits against a base branch instead. Returns review comments and " +
 			"approval status.",
-		InputSchema: mcp.ToolInputSchema{
-			Type: "object",
-			Properties: map[string]any{
-				argDirectory: map[string]any{
-					schemaType:    schemaString,
-					schemaDescKey: "Path to the git repository directory to review",
-				},
-			},
-			Required: []string{argDirectory},
-		},
+		InputSchema: inputSchema(reviewOnlyArgs),
 	}, s.HandleReviewOnly)
 
 	// Register review_and_commit tool.
@@ -163,23 +327,107 @@ func (s *Server) registerTools() { //nolint:funcorder // Helper method
 		Name: "review_and_commit",
 		Description: "Review code changes using Gemini and commit if approved (LGTM). " +
 			"Reviews and commits all workspace changes (staged, unstaged, and untracked), not " +
-			"just staged files; stash anything you want to exclude first for a partial commit. " +
+			"just staged files, unless files limits both to some paths; stage narrows only what " +
+			"is committed, and amend folds the changes into the previous commit. " +
 			"Returns review comments if not approved or success message with commit hash if approved and committed.",
-		InputSchema: mcp.ToolInputSchema{
-			Type: "object",
-			Properties: map[string]any{
-				argDirectory: map[string]any{
-					schemaType:    schemaString,
-					schemaDescKey: "Path to the git repository directory to review",
-				},
-				"commit_message": map[string]any{
-					schemaType:    schemaString,
-					schemaDescKey: "Commit message to use if changes are approved",
-				},
-			},
-			Required: []string{argDirectory, "commit_message"},
-		},
+		InputSchema: inputSchema(reviewAndCommitArgs),
 	}, s.HandleReviewAndCommit)
+
+	// Register review_commits tool.
+	s.mcpServer.AddTool(mcp.Tool{
+		Name: "review_commits",
+		Description: "Review changes that are already committed, for after-the-fact audits: the diff " +
+			"between two commits (git diff from..to) goes through the same secret scan and Gemini " +
+			"review as workspace changes. Never commits. Files Gemini fetches for context are " +
+			"read from the working tree, which may differ from the \"to\" commit.",
+		InputSchema: inputSchema(reviewCommitsArgs),
+	}, s.HandleReviewCommits)
+
+	// Register review_head tool.
+	s.mcpServer.AddTool(mcp.Tool{
+		Name: "review_head",
+		Description: "Review the most recent commit (git diff HEAD~1 HEAD, or everything in HEAD if it " +
+			"is the first commit) for a second opinion after committing. The diff goes through the " +
+			"same secret scan and Gemini review as workspace changes. Never commits.",
+		InputSchema: inputSchema(reviewHeadArgs),
+	}, s.HandleReviewHead)
+
+	// Register review_patch tool.
+	s.mcpServer.AddTool(mcp.Tool{
+		Name: "review_patch",
+		Description: "Review a diff passed as an argument, such as one from a pull request, without " +
+			"needing it in a working tree. The lines it adds go through the same secret scan as " +
+			"workspace changes before the Gemini review. Given a directory, Gemini may read files " +
+			"there for context. Never commits.",
+		InputSchema: inputSchema(reviewPatchArgs),
+	}, s.HandleReviewPatch)
+
+	// Register review_multi tool.
+	s.mcpServer.AddTool(mcp.Tool{
+		Name: "review_multi",
+		Description: "Review the workspace changes of several git repositories in one request, as " +
+			"review_only would each in turn, without committing. Returns each repository's result, " +
+			"keyed by its path, and a combined verdict that is approved only if no review failed or " +
+			"found issues. A failure in one repository does not stop the others.",
+		InputSchema: inputSchema(reviewMultiArgs),
+	}, s.HandleReviewMulti)
+
+	// Register confirm_commit tool.
+	s.mcpServer.AddTool(mcp.Tool{
+		Name: "confirm_commit",
+		Description: "Commit the changes of a review that review_and_commit approved while " +
+			"review.commit_requires_confirmation is set, using the confirmation token it returned. " +
+			"Ask the user before calling this: the token is a human approval gate. Tokens are " +
+			"single-use and expire after 10 minutes, and the commit is refused if the reviewed " +
+			"changes were modified since the review.",
+		InputSchema: inputSchema(confirmCommitArgs),
+	}, s.HandleConfirmCommit)
+
+	// Register scan_secrets tool.
+	s.mcpServer.AddTool(mcp.Tool{
+		Name: "scan_secrets",
+		Description: "Scan workspace changes (staged, unstaged, and untracked) for secrets with " +
+			"Gitleaks, the same scan a review starts with, without a Gemini review. Fast and free, " +
+			"for use as a pre-commit gate. Returns an error result listing the findings if any " +
+			"secrets are detected, and \"No secrets detected\" otherwise.",
+		InputSchema: inputSchema(scanSecretsArgs),
+	}, s.HandleScanSecrets)
+
+	// Register scan_repo tool.
+	s.mcpServer.AddTool(mcp.Tool{
+		Name: "scan_repo",
+		Description: "Scan every tracked file in the repository for secrets with Gitleaks, not just " +
+			"the changes, as a one-time audit of an existing repository. Makes no Gemini API call. " +
+			"Returns an error result listing the findings if any secrets are detected, and " +
+			"\"No secrets detected\" otherwise.",
+		InputSchema: inputSchema(scanSecretsArgs),
+	}, s.HandleScanRepo)
+
+	// Register ping tool.
+	s.mcpServer.AddTool(mcp.Tool{
+		Name: "ping",
+		Description: "Check that lgtmcp is running and configured. Returns the server version, " +
+			"configured model, authentication method, and whether git is available. " +
+			"Makes no Gemini API call.",
+		InputSchema: inputSchema(nil),
+	}, s.HandlePing)
+
+	// Register config_info tool.
+	s.mcpServer.AddTool(mcp.Tool{
+		Name: "config_info",
+		Description: "Show the effective lgtmcp configuration: the config file that was loaded and " +
+			"every setting after defaults were applied. The API key is masked.",
+		InputSchema: inputSchema(nil),
+	}, s.HandleConfigInfo)
+
+	// Register metrics tool.
+	s.mcpServer.AddTool(mcp.Tool{
+		Name: "metrics",
+		Description: "Show review counters since the server started: reviews completed, approvals and " +
+			"rejections, failed reviews, reviews blocked by the secret scan, retried Gemini API calls, " +
+			"and average review duration. Makes no Gemini API call.",
+		InputSchema: inputSchema(nil),
+	}, s.HandleMetrics)
 }
 
 // parseDirectory extracts and validates the directory argument from the request.
@@ -198,6 +446,186 @@ func (*Server) parseDirectory(args map[string]any) (string, error) { //nolint:fu
 	return absPath, nil
 }
 
+// parseFiles extracts the optional files argument from the request. It
+// returns nil when the argument is absent. Paths are validated against the
+// repository later, by git.Git.GetDiff.
+func parseFiles(args map[string]any) ([]string, error) {
+	raw, present := args[argFiles]
+	if !present || raw == nil {
+		return nil, nil
+	}
+	list, ok := raw.([]any)
+	if !ok || len(list) == 0 {
+		return nil, ErrFilesNotStringArray
+	}
+	files := make([]string, len(list))
+	for i, v := range list {
+		if files[i], ok = v.(string); !ok {
+			return nil, ErrFilesNotStringArray
+		}
+	}
+
+	return files, nil
+}
+
+// parseStage extracts the optional stage argument of review_and_commit. It
+// returns stageAll or stageTracked, or, for a list, "" and the cleaned
+// slash-separated paths, which must stay inside the repository. It returns
+// stageAll when the argument is absent.
+func parseStage(args map[string]any) (string, []string, error) {
+	raw, present := args[argStage]
+	if !present || raw == nil {
+		return stageAll, nil, nil
+	}
+	switch v := raw.(type) {
+	case string:
+		if v != stageAll && v != stageTracked {
+			return "", nil, ErrStageInvalid
+		}
+
+		return v, nil, nil
+	case []any:
+		if len(v) == 0 {
+			return "", nil, ErrStageInvalid
+		}
+		paths := make([]string, len(v))
+		for i, entry := range v {
+			p, ok := entry.(string)
+			if !ok || p == "" || !filepath.IsLocal(filepath.FromSlash(p)) {
+				return "", nil, fmt.Errorf("%w: %v", ErrStageInvalid, entry)
+			}
+			paths[i] = path.Clean(p)
+		}
+
+		return "", paths, nil
+	default:
+		return "", nil, ErrStageInvalid
+	}
+}
+
+// selectStaged returns the reviewed files that the stage argument (as
+// returned by parseStage) selects for commit. Only reviewed files are ever
+// selected, so a path list cannot bring unscanned content into the commit;
+// a path matches a reviewed file it names or that lies under it.
+func selectStaged(ctx context.Context, rc *reviewContext, keyword string, paths []string) ([]string, error) {
+	var selected []string
+	switch keyword {
+	case stageAll:
+		selected = rc.changedFiles
+	case stageTracked:
+		untracked, err := rc.gitClient.UntrackedFiles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range rc.changedFiles {
+			if !slices.Contains(untracked, file) {
+				selected = append(selected, file)
+			}
+		}
+	default:
+		for _, file := range rc.changedFiles {
+			if slices.ContainsFunc(paths, func(p string) bool {
+				return p == "." || file == p || strings.HasPrefix(file, p+"/")
+			}) {
+				selected = append(selected, file)
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return nil, ErrStageSelectsNothing
+	}
+
+	return selected, nil
+}
+
+// parseRef extracts a required, non-empty ref argument such as from or to.
+// Refs are resolved and validated against the repository later, by
+// git.Git.ResolveCommit.
+func parseRef(args map[string]any, name string) (string, error) {
+	ref, ok := args[name].(string)
+	if !ok || ref == "" {
+		return "", ErrRefNotString
+	}
+
+	return ref, nil
+}
+
+// parseBaseBranch extracts the optional base_branch argument. It returns ""
+// when the argument is absent.
+func parseBaseBranch(args map[string]any) (string, error) {
+	raw, present := args[argBaseBranch]
+	if !present || raw == nil {
+		return "", nil
+	}
+	branch, ok := raw.(string)
+	if !ok || branch == "" {
+		return "", ErrBaseBranchNotString
+	}
+
+	return branch, nil
+}
+
+// parseInstructions extracts the optional instructions argument, which
+// applies to this review only. It returns "" when the argument is absent.
+func parseInstructions(args map[string]any) (string, error) {
+	raw, present := args[argInstructions]
+	if !present || raw == nil {
+		return "", nil
+	}
+	instructions, ok := raw.(string)
+	if !ok {
+		return "", ErrInstructionsNotString
+	}
+
+	return instructions, nil
+}
+
+// parseTimeout extracts the optional timeout_seconds argument. It returns 0,
+// meaning no deadline, when the argument is absent.
+func parseTimeout(args map[string]any) (time.Duration, error) {
+	raw, present := args[argTimeout]
+	if !present || raw == nil {
+		return 0, nil
+	}
+	var seconds float64
+	switch v := raw.(type) {
+	case float64:
+		seconds = v
+	case int:
+		seconds = float64(v)
+	default:
+		return 0, ErrTimeoutNotPositive
+	}
+	// Also reject values that would overflow a time.Duration.
+	if math.IsNaN(seconds) || seconds <= 0 || seconds > math.MaxInt64/float64(time.Second) {
+		return 0, ErrTimeoutNotPositive
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// withReviewTimeout bounds ctx by timeout, the parsed timeout_seconds
+// argument, with [ErrReviewTimedOut] as the cause. A zero timeout leaves ctx
+// unbounded.
+func withReviewTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeoutCause(ctx, timeout, ErrReviewTimedOut)
+}
+
+// timeoutResult replaces result, when it reports a failure that ctx's
+// timeout_seconds deadline caused, with a clear timeout error; whatever
+// step happened to be running when the deadline passed is beside the point.
+func timeoutResult(ctx context.Context, timeout time.Duration, result *mcp.CallToolResult) *mcp.CallToolResult {
+	if result == nil || !result.IsError || !errors.Is(context.Cause(ctx), ErrReviewTimedOut) {
+		return result
+	}
+
+	return newToolErrorf(CodeTimeout, "%v: no result within timeout_seconds (%s)", ErrReviewTimedOut, timeout)
+}
+
 // generateRequestID creates a short unique ID for request tracing.
 func generateRequestID() (string, error) {
 	b := make([]byte, 4)
@@ -207,14 +635,64 @@ func generateRequestID() (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
+// reviewTarget selects the changes prepareReview reviews: workspace changes,
+// limited to files when any are given, or the changes between two commits.
+type reviewTarget struct {
+	files []string
+	// from and to, when set, are the refs of a committed range to review
+	// instead of the workspace.
+	from, to string
+	// head, when set, reviews the most recent commit against its parent, or
+	// against the empty tree for a root commit, instead of the workspace.
+	head bool
+	// baseBranch, when set, reviews the commits on HEAD since it diverged
+	// from this branch instead of the workspace.
+	baseBranch string
+}
+
 // reviewContext holds the context needed for performing a review.
 type reviewContext struct {
 	gitClient    *git.Git
 	diff         string
 	absPath      string
 	changedFiles []string
+	// reviewDiff and reviewFiles are diff and changedFiles less the paths
+	// excluded by .lgtmcpignore and, with git.ignore_whitespace, changes
+	// only to whitespace: what Gemini is shown. The full diff is still what
+	// is scanned, staged, and committed.
+	reviewDiff  string
+	reviewFiles []string
+	// reviewStats summarizes reviewDiff for the result.
+	reviewStats  security.DiffStats
 	deletedFiles []string
 	instructions string
+	// userInstructions holds the instructions argument of the request, for
+	// this review only.
+	userInstructions string
+	// recentCommits holds recent commit subjects, newest first, when
+	// gemini.include_recent_commits is set.
+	recentCommits []string
+	// diffStat summarizes diff when gemini.include_diffstat is set.
+	diffStat string
+	// subset reports that the review was limited to the paths given in the
+	// files argument, so only those paths may be committed.
+	subset bool
+	// languages lists the programming languages of the changed files, from
+	// their extensions, sorted by name.
+	languages []string
+	// secrets holds what the secret scan found when gitleaks.mode is
+	// "warn" and so did not stop the review.
+	secrets []report.Finding
+}
+
+// repoName returns the base name of the repository under review, or "" for
+// a patch reviewed without a directory.
+func (rc *reviewContext) repoName() string {
+	if rc.absPath == "" {
+		return ""
+	}
+
+	return filepath.Base(rc.absPath)
 }
 
 // createProgressReporter creates a progress reporter based on whether the request includes a progress token.
@@ -227,23 +705,188 @@ func (s *Server) createProgressReporter(request mcp.CallToolRequest) progress.Re
 	return progress.NewNoOpReporter()
 }
 
+// ReviewOutput is the structured content attached to every review outcome of
+// review_only and review_and_commit, alongside the human-readable text, so
+// clients can branch on the result without parsing prose. Tool failures
+// (IsError results) carry an [ErrorOutput] instead.
+type ReviewOutput struct {
+	// Status is the verdict, omitted when nothing was reviewed. LGTM is
+	// true exactly when it is "approved".
+	Status     review.Status             `json:"status,omitempty"`
+	LGTM       bool                      `json:"lgtm"`
+	Comments   string                    `json:"comments"`
+	Findings   []security.FindingSummary `json:"findings,omitempty"`
+	Committed  bool                      `json:"committed"`
+	Amended    bool                      `json:"amended,omitempty"`
+	CommitHash string                    `json:"commit_hash,omitempty"`
+	// FilesChanged, Additions, and Deletions summarize the reviewed diff,
+	// omitted when nothing was reviewed.
+	FilesChanged int `json:"files_changed,omitempty"`
+	Additions    int `json:"additions,omitempty"`
+	Deletions    int `json:"deletions,omitempty"`
+	// RuleCounts maps each gitleaks rule ID among Findings to how many
+	// findings it produced.
+	RuleCounts map[string]int `json:"rule_counts,omitempty"`
+	// Categories and CommentsByCategory are set when
+	// gemini.categorize_comments is: the categories the review raised
+	// issues in, and the issues grouped under each.
+	Categories         []string            `json:"categories,omitempty"`
+	CommentsByCategory map[string][]string `json:"comments_by_category,omitempty"`
+	// ConfirmationToken is set when an approved review awaits confirm_commit
+	// instead of committing.
+	ConfirmationToken string `json:"confirmation_token,omitempty"`
+	// Diff is the reviewed diff, when review_only was asked to include it,
+	// and DiffTruncated reports that it was cut at maxResultDiffBytes.
+	Diff          string `json:"diff,omitempty"`
+	DiffTruncated bool   `json:"diff_truncated,omitempty"`
+}
+
+// newReviewToolResult builds a review result carrying both the text and
+// the structured output for result. commitHash is empty if nothing was
+// committed; amended reports that the commit amended the previous one.
+func newReviewToolResult(result *review.Result, commitHash string, amended bool) *mcp.CallToolResult {
+	return mcp.NewToolResultStructured(ReviewOutput{
+		Status:             reviewStatus(result),
+		LGTM:               result.LGTM,
+		Comments:           result.Comments,
+		Categories:         result.Categories,
+		CommentsByCategory: commentsByCategory(result.CategorizedComments),
+		Committed:          commitHash != "",
+		Amended:            amended,
+		CommitHash:         commitHash,
+		FilesChanged:       result.FilesChanged,
+		Additions:          result.Additions,
+		Deletions:          result.Deletions,
+	}, formatReviewResponse(result, commitHash, amended))
+}
+
+// commentsByCategory groups categorized comments by category, in review
+// order within each, or returns nil when there are none.
+func commentsByCategory(comments []review.CategorizedComment) map[string][]string {
+	if len(comments) == 0 {
+		return nil
+	}
+	grouped := make(map[string][]string)
+	for _, c := range comments {
+		grouped[string(c.Category)] = append(grouped[string(c.Category)], c.Comment)
+	}
+
+	return grouped
+}
+
+// formatCategorizedComments renders categorized comments as a section per
+// category, in [review.AllCategories] order, or returns "" when there are
+// none.
+func formatCategorizedComments(comments []review.CategorizedComment) string {
+	grouped := commentsByCategory(comments)
+	if grouped == nil {
+		return ""
+	}
+	var sb strings.Builder
+	_, _ = sb.WriteString("Comments by category:")
+	for _, category := range review.AllCategories {
+		items := grouped[string(category)]
+		if len(items) == 0 {
+			continue
+		}
+		name := string(category)
+		_, _ = fmt.Fprintf(&sb, "\n\n### %s%s (%d)\n", strings.ToUpper(name[:1]), name[1:], len(items))
+		for _, item := range items {
+			_, _ = fmt.Fprintf(&sb, "\n- %s", item)
+		}
+	}
+
+	return sb.String()
+}
+
+// attachDiff adds the reviewed diff to a review result, both as Diff in its
+// structured content and as a second text block, truncated at a line
+// boundary to maxResultDiffBytes with a note saying so. When the diff is
+// truncated, diffStat, if known, is shown ahead of it so the client still
+// sees the whole change's scope.
+func attachDiff(result *mcp.CallToolResult, diff, diffStat string) {
+	output, ok := result.StructuredContent.(ReviewOutput)
+	if !ok {
+		return
+	}
+	var text strings.Builder
+	if len(diff) > maxResultDiffBytes {
+		cut := strings.LastIndexByte(diff[:maxResultDiffBytes], '\n') + 1
+		if cut == 0 {
+			// A single enormous line, as in minified code.
+			cut = maxResultDiffBytes
+		}
+		_, _ = fmt.Fprintf(&text, "Reviewed diff (truncated: showing the first %d of %d bytes):\n\n",
+			cut, len(diff))
+		if diffStat != "" {
+			_, _ = fmt.Fprintf(&text, "```\n%s```\n\n", diffStat)
+		}
+		diff = diff[:cut]
+		output.DiffTruncated = true
+	} else {
+		_, _ = text.WriteString("Reviewed diff:\n\n")
+	}
+	output.Diff = diff
+	if !strings.HasSuffix(diff, "\n") {
+		diff += "\n"
+	}
+	_, _ = fmt.Fprintf(&text, "```diff\n%s```", diff)
+	result.StructuredContent = output
+	result.Content = append(result.Content, mcp.NewTextContent(text.String()))
+}
+
+// noChangesResult is the result when there is nothing to review.
+func noChangesResult() *mcp.CallToolResult {
+	return noChangesResultf("No changes to review")
+}
+
+// noChangesResultf is noChangesResult with a custom explanation.
+func noChangesResultf(text string) *mcp.CallToolResult {
+	return mcp.NewToolResultStructured(ReviewOutput{Comments: text}, text)
+}
+
+// reviewStatus returns result's verdict, deriving it from LGTM for a result
+// built without one.
+func reviewStatus(result *review.Result) review.Status {
+	switch {
+	case result.Status != "":
+		return result.Status
+	case result.LGTM:
+		return review.StatusApproved
+	default:
+		return review.StatusChangesRequested
+	}
+}
+
 // formatReviewResponse formats the review result with usage statistics.
-// If commitHash is provided, it adds a commit success message before the stats footer.
-func formatReviewResponse(result *review.Result, commitHash string) string {
+// If commitHash is provided, it adds a commit success message before the stats
+// footer, noting whether the previous commit was amended.
+func formatReviewResponse(result *review.Result, commitHash string, amended bool) string {
 	var status string
-	if result.LGTM {
+	switch reviewStatus(result) {
+	case review.StatusApproved:
 		status = "Review Result: APPROVED (LGTM)"
-	} else {
-		status = "Review Result: NOT APPROVED"
+	case review.StatusNeedsHuman:
+		status = "Review Result: NOT APPROVED (needs human review)"
+	default:
+		status = "Review Result: NOT APPROVED (changes requested)"
 	}
 
 	var sb strings.Builder
 	_, _ = sb.WriteString(status)
 	_, _ = sb.WriteString("\n\n")
 	_, _ = sb.WriteString(result.Comments)
+	if categorized := formatCategorizedComments(result.CategorizedComments); categorized != "" {
+		_, _ = sb.WriteString("\n\n")
+		_, _ = sb.WriteString(categorized)
+	}
 
 	// Add commit success message if provided.
-	if commitHash != "" {
+	switch {
+	case commitHash != "" && amended:
+		_, _ = sb.WriteString("\n\nChanges amended into the previous commit successfully!\nCommit: ")
+		_, _ = sb.WriteString(commitHash)
+	case commitHash != "":
 		_, _ = sb.WriteString("\n\nChanges committed successfully!\nCommit: ")
 		_, _ = sb.WriteString(commitHash)
 	}
@@ -257,6 +900,30 @@ func formatReviewResponse(result *review.Result, commitHash string) string {
 	return sb.String()
 }
 
+// reportPath returns the cleaned review.report_path, or "" when no report
+// is configured.
+//
+//nolint:funcorder // Helper method
+func (s *Server) reportPath() string {
+	if s.config == nil || s.config.Review.ReportPath == "" {
+		return ""
+	}
+
+	return path.Clean(s.config.Review.ReportPath)
+}
+
+// formatReviewReport renders the report file written to review.report_path:
+// the commit message the review approved, followed by the review itself.
+func formatReviewReport(result *review.Result, commitMessage string) string {
+	var sb strings.Builder
+	_, _ = sb.WriteString("# lgtmcp Review\n\n")
+	_, _ = fmt.Fprintf(&sb, "Commit message:\n\n```\n%s\n```\n\n", strings.TrimSpace(commitMessage))
+	_, _ = sb.WriteString(formatReviewResponse(result, "", false))
+	_, _ = sb.WriteString("\n")
+
+	return sb.String()
+}
+
 // formatUsageFooter renders the usage statistics as two lines: what the review
 // cost to run (model, wall time, dollars), then how it spent its tokens.
 // Returns "" when the result carries no statistics at all, so the caller can
@@ -264,11 +931,23 @@ func formatReviewResponse(result *review.Result, commitHash string) string {
 func formatUsageFooter(result *review.Result) string {
 	var summary []string
 
+	if result.FilesChanged > 0 {
+		files := "files"
+		if result.FilesChanged == 1 {
+			files = "file"
+		}
+		summary = append(summary, fmt.Sprintf("Reviewed: +%d/-%d across %d %s",
+			result.Additions, result.Deletions, result.FilesChanged, files))
+	}
+
 	// Model that produced the verdict; after a quota fallback this is the
 	// model that answered, not necessarily the configured primary.
 	if result.Model != "" {
 		summary = append(summary, "Model: "+result.Model)
 	}
+	if result.ContextModel != "" {
+		summary = append(summary, "Context model: "+result.ContextModel)
+	}
 
 	if result.DurationMS > 0 {
 		seconds := float64(result.DurationMS) / 1000.0
@@ -339,11 +1018,71 @@ func formatCount(n int32) string {
 	return sign + sb.String()
 }
 
+// revealChars returns how many characters of each detected secret findings
+// may show, falling back to the scanner default when unconfigured.
+//
+//nolint:funcorder // Helper method
+func (s *Server) revealChars() int {
+	if s.config != nil && s.config.Gitleaks.RevealChars != nil {
+		return *s.config.Gitleaks.RevealChars
+	}
+
+	return security.DefaultRevealChars
+}
+
+// errTooLargeToScan is returned by a scanReader for a file over
+// gitleaks.max_scan_bytes, so that the secret scan skips it.
+var errTooLargeToScan = errors.New("file exceeds gitleaks.max_scan_bytes")
+
+// scanReader wraps read, a file reader for the secret scan, so that a file
+// size reports as over gitleaks.max_scan_bytes is skipped with a warning
+// instead of read into memory. A file whose size is unknown is read.
+//
+//nolint:funcorder // Helper method
+func (s *Server) scanReader(
+	read func(path string) (string, error), size func(path string) (int64, error),
+) func(path string) (string, error) {
+	limit := config.DefaultMaxScanBytes
+	if s.config != nil && s.config.Gitleaks.MaxScanBytes != nil {
+		limit = *s.config.Gitleaks.MaxScanBytes
+	}
+	if limit <= 0 {
+		return read
+	}
+
+	return func(path string) (string, error) {
+		if n, err := size(path); err == nil && n > limit {
+			s.logger.Warn("Skipping file too large to scan for secrets",
+				"file", path, "size", n, "max_scan_bytes", limit)
+
+			return "", fmt.Errorf("%w: %s is %d bytes", errTooLargeToScan, path, n)
+		}
+
+		return read(path)
+	}
+}
+
+// formatFindings formats secret scan findings for a tool result, verbosely
+// when gitleaks.verbose_findings is set, followed by how many findings each
+// rule produced.
+//
+//nolint:funcorder // Helper method
+func (s *Server) formatFindings(findings []report.Finding) string {
+	var text string
+	if s.config != nil && s.config.Gitleaks.VerboseFindings {
+		text = security.FormatFindingsVerbose(findings, s.revealChars())
+	} else {
+		text = security.FormatFindings(findings, s.revealChars())
+	}
+
+	return text + security.FormatRuleCounts(security.CountFindingsByRule(findings))
+}
+
 // prepareReview handles common review preparation logic: getting diff, security scan, etc.
 //
 //nolint:funcorder // Helper method
 func (s *Server) prepareReview(
-	ctx context.Context, directory string, reporter progress.Reporter, totalSteps float64,
+	ctx context.Context, directory string, target reviewTarget, reporter progress.Reporter, totalSteps float64,
 ) (*reviewContext, *mcp.CallToolResult, error) {
 	// Create a git client for this repository.
 	var gitConfig *config.GitConfig
@@ -352,15 +1091,52 @@ func (s *Server) prepareReview(
 	}
 	gitClient, err := git.New(directory, gitConfig)
 	if err != nil {
-		return nil, nil, fmt.Errorf("invalid git repository: %w", err)
+		return nil, nil, withCode(CodeNotARepo, fmt.Errorf("invalid git repository: %w", err))
+	}
+
+	// Scan file contents as of the reviewed commit for a range, and from the
+	// working tree otherwise.
+	getFileContent := s.scanReader(func(path string) (string, error) {
+		return gitClient.GetFileContent(ctx, path)
+	}, gitClient.FileSize)
+	var from, to string
+	switch {
+	case target.head:
+		if to, err = gitClient.ResolveCommit(ctx, "HEAD"); err == nil {
+			from, err = gitClient.ParentOf(ctx, to)
+		}
+	case target.from != "":
+		if from, err = gitClient.ResolveCommit(ctx, target.from); err == nil {
+			to, err = gitClient.ResolveCommit(ctx, target.to)
+		}
+	case target.baseBranch != "":
+		if to, err = gitClient.ResolveCommit(ctx, "HEAD"); err == nil {
+			from, err = gitClient.MergeBase(ctx, target.baseBranch)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if to != "" {
+		getFileContent = s.scanReader(func(path string) (string, error) {
+			return gitClient.FileContentAt(ctx, to, path)
+		}, func(path string) (int64, error) {
+			return gitClient.FileSizeAt(ctx, to, path)
+		})
 	}
 
 	// Report progress: getting git diff.
 	reporter.Report(ctx, 1, totalSteps, "Getting git diff...")
 
-	// Get the diff of staged and unstaged changes.
+	// Get the diff of the commit range, or else of staged and unstaged
+	// changes, limited to files if given.
 	start := time.Now()
-	diff, err := gitClient.GetDiff(ctx)
+	var diff string
+	if to != "" {
+		diff, err = gitClient.DiffRange(ctx, from, to)
+	} else {
+		diff, err = gitClient.GetDiff(ctx, target.files...)
+	}
 	diffDuration := time.Since(start)
 	if err != nil {
 		s.logger.Error("Git diff failed",
@@ -376,19 +1152,41 @@ func (s *Server) prepareReview(
 	if err != nil {
 		// Check if it's the "no changes" error.
 		if errors.Is(err, git.ErrNoChanges) {
-			return nil, mcp.NewToolResultText("No changes to review"), nil
+			return nil, noChangesResult(), nil
 		}
 
 		return nil, nil, fmt.Errorf("failed to get diff: %w", err)
 	}
 
+	// The review report is rewritten on every approved commit; reviewing it
+	// would feed the previous verdict back to the model as if it were code.
+	if reportPath := s.reportPath(); reportPath != "" {
+		diff = security.FilterDiff(diff, func(p string) bool { return p != reportPath })
+		if diff == "" {
+			return nil, noChangesResult(), nil
+		}
+	}
+
+	// With git.ignore_whitespace, Gemini is shown the workspace changes with
+	// whitespace ignored, limited to files in the full diff so that none
+	// escapes the scan. The full diff is still what is scanned and committed.
+	var wsDiff string
+	if to == "" && s.config != nil && s.config.Git.IgnoreWhitespace {
+		wsDiff, err = gitClient.GetDiffIgnoringWhitespace(ctx, target.files...)
+		if err != nil && !errors.Is(err, git.ErrNoChanges) {
+			return nil, nil, fmt.Errorf("failed to get diff: %w", err)
+		}
+		inDiff := make(map[string]bool)
+		for _, file := range security.ExtractChangedFiles(diff) {
+			inDiff[file] = true
+		}
+		wsDiff = security.OmitFromDiff(wsDiff, func(p string) bool { return !inDiff[p] })
+	}
+
 	// Report progress: security scan.
 	reporter.Report(ctx, 2, totalSteps, "Running security scan...")
 
 	// Security scan on the changed files using secure git client.
-	getFileContent := func(path string) (string, error) {
-		return gitClient.GetFileContent(ctx, path)
-	}
 	scanStart := time.Now()
 	findings, err := s.scanner.ScanDiff(ctx, diff, getFileContent)
 	scanDuration := time.Since(scanStart)
@@ -402,34 +1200,82 @@ func (s *Server) prepareReview(
 			"findings", len(findings))
 	}
 	if err != nil {
-		return nil, nil, fmt.Errorf("security scan failed: %w", err)
-	}
-
-	if security.HasFindings(findings) {
-		// Detected secrets are a non-approval, not a tool failure: the scan ran
-		// successfully and is reporting a finding (like a NOT APPROVED review),
-		// so this is a normal in-band result with IsError unset.
-		return nil, mcp.NewToolResultText(
-			"Review Result: NOT APPROVED\n\nSecurity scan detected secrets in the changes:\n" +
-				security.FormatFindings(findings),
-		), nil
+		return nil, nil, withCode(CodeScanFailed, fmt.Errorf("security scan failed: %w", err))
 	}
 
 	// Extract list of changed files from the diff for Gemini's file retrieval.
 	cf := security.ExtractChangedFilesDetailed(diff)
 	changedFiles := cf.All
 
+	var secretFindings []report.Finding
+	if security.HasFindings(findings) {
+		if !s.secretsOnlyWarn() {
+			s.metrics.RecordSecurityBlock()
+			s.recordAudit(audit.Record{
+				Repo:         filepath.Base(directory),
+				ChangedFiles: len(changedFiles),
+				Status:       string(review.StatusChangesRequested),
+			})
+			return nil, s.secretsBlockedResult(findings), nil
+		}
+		s.logger.Warn("Security scan detected secrets; reviewing anyway because gitleaks.mode is warn",
+			"findings", len(findings))
+		secretFindings = findings
+	}
+
+	// Paths in .lgtmcpignore are scanned and committed like any other, but
+	// are not shown to Gemini. The rules are read from the commit the change
+	// starts from, and a change that edits them is reviewed in full, so a
+	// change cannot hide its own files from review.
+	ignore := &git.ReviewIgnore{}
+	if slices.Contains(changedFiles, git.ReviewIgnoreFile) {
+		s.logger.Info("Reviewing every changed file because the change edits " + git.ReviewIgnoreFile)
+	} else {
+		base := from
+		if base == "" {
+			base = "HEAD"
+		}
+		ignore, err = gitClient.LoadReviewIgnore(ctx, base)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", git.ReviewIgnoreFile, err)
+		}
+	}
+	reviewDiff := diff
+	if !ignore.Empty() {
+		reviewDiff = security.OmitFromDiff(diff, ignore.Match)
+		if reviewDiff == "" {
+			return nil, noChangesResultf("No changes to review (all changed files are excluded by " +
+				git.ReviewIgnoreFile + ")"), nil
+		}
+		cf = security.ExtractChangedFilesDetailed(reviewDiff)
+		s.logger.Info("Excluded files from review",
+			"file", git.ReviewIgnoreFile,
+			"excluded", len(changedFiles)-len(cf.All))
+	}
+	// A change that is only whitespace is reviewed in full.
+	if wsDiff != "" {
+		if wsReviewDiff := security.OmitFromDiff(wsDiff, ignore.Match); wsReviewDiff != "" {
+			reviewDiff = wsReviewDiff
+			cf = security.ExtractChangedFilesDetailed(reviewDiff)
+		}
+	}
+	reviewFiles := cf.All
+
 	// Discover AGENTS.md and REVIEW.md files relevant to the changed files.
 	var instructionsBuf strings.Builder
 	for _, discovery := range []struct {
 		label  string
-		find   func([]string) ([]git.InstructionFile, error)
+		find   func([]string) ([]git.InstructionFile, []git.SkippedFile, error)
 		format func([]git.InstructionFile) string
 	}{
 		{"AGENTS.md", gitClient.FindAgentFiles, git.FormatAgentInstructions},
 		{"REVIEW.md", gitClient.FindReviewFiles, git.FormatReviewInstructions},
 	} {
-		files, err := discovery.find(changedFiles)
+		files, skipped, err := discovery.find(reviewFiles)
+		for _, f := range skipped {
+			s.logger.Warn("Skipped instruction file",
+				"type", discovery.label, "file", f.Path, "reason", f.Reason)
+		}
 		if err != nil {
 			s.logger.Warn("Failed to discover instruction files", "type", discovery.label, "error", err)
 		} else if len(files) > 0 {
@@ -442,16 +1288,147 @@ func (s *Server) prepareReview(
 		}
 	}
 
+	if s.config != nil && s.config.Review.IncludeEnvironment {
+		if env := gitClient.DetectEnvironment(ctx); env != nil {
+			_, _ = instructionsBuf.WriteString(git.FormatEnvironment(env))
+			s.logger.Info("Detected repository environment", "manifests", env.ManifestsFound)
+		}
+	}
+
+	if s.config != nil && s.config.Review.RecentChanges > 0 {
+		recent, err := gitClient.RecentChanges(ctx, reviewFiles, s.config.Review.RecentChanges)
+		if err != nil {
+			s.logger.Warn("Failed to get recent related changes", "error", err)
+		} else if recent != "" {
+			_, _ = instructionsBuf.WriteString(git.FormatRecentChanges(recent))
+			s.logger.Info("Included recent related changes", "size", len(recent))
+		}
+	}
+
+	if s.config != nil && s.config.Gemini.IncludeBlame > 0 {
+		// A range is blamed as of its base; the workspace as of HEAD.
+		rev := from
+		if rev == "" {
+			rev = "HEAD"
+		}
+		if blame := git.FormatBlame(s.blameChangedLines(ctx, gitClient, rev, reviewDiff)); blame != "" {
+			_, _ = instructionsBuf.WriteString(blame)
+			s.logger.Info("Included blame of changed lines", "size", len(blame))
+		}
+	}
+
+	if s.config != nil && s.config.Gemini.RequireTests {
+		existing := slices.DeleteFunc(slices.Clone(reviewFiles), func(f string) bool {
+			return slices.Contains(cf.Deleted, f)
+		})
+		if untested := prompts.UntestedFiles(existing); len(untested) > 0 {
+			_, _ = instructionsBuf.WriteString(prompts.FormatMissingTests(untested))
+			s.logger.Info("Changed source files lack changed tests", "files", len(untested))
+		}
+	}
+
+	var recentCommits []string
+	if s.config != nil && s.config.Gemini.IncludeRecentCommits > 0 {
+		recentCommits, err = gitClient.RecentCommitSubjects(ctx, s.config.Gemini.IncludeRecentCommits)
+		if err != nil {
+			s.logger.Warn("Failed to get recent commits", "error", err)
+		}
+	}
+
+	var diffStat string
+	if s.config != nil && s.config.Gemini.IncludeDiffStat {
+		diffStat, err = gitClient.GetDiffStat(ctx, reviewDiff)
+		if err != nil {
+			s.logger.Warn("Failed to summarize diff", "error", err)
+		}
+	}
+
 	return &reviewContext{
-		gitClient:    gitClient,
-		diff:         diff,
-		changedFiles: changedFiles,
-		deletedFiles: cf.Deleted,
-		absPath:      directory,
-		instructions: instructionsBuf.String(),
+		gitClient:     gitClient,
+		diff:          diff,
+		changedFiles:  changedFiles,
+		reviewDiff:    reviewDiff,
+		reviewFiles:   reviewFiles,
+		reviewStats:   security.CountChanges(reviewDiff),
+		deletedFiles:  cf.Deleted,
+		absPath:       directory,
+		instructions:  instructionsBuf.String(),
+		recentCommits: recentCommits,
+		diffStat:      diffStat,
+		subset:        len(target.files) > 0,
+		languages:     prompts.DetectLanguages(reviewFiles),
+		secrets:       secretFindings,
 	}, nil, nil
 }
 
+// blameChangedLines blames, as of rev, the lines that the first
+// gemini.include_blame hunks of diff modify or remove. Blame is only
+// background, so a hunk that cannot be blamed is logged and skipped.
+//
+//nolint:funcorder // Helper method
+func (s *Server) blameChangedLines(ctx context.Context, gitClient *git.Git, rev, diff string) []git.FileBlame {
+	regions := security.ExtractRemovedRegions(diff)
+	if len(regions) > s.config.Gemini.IncludeBlame {
+		regions = regions[:s.config.Gemini.IncludeBlame]
+	}
+	blames := make([]git.FileBlame, 0, len(regions))
+	for _, r := range regions {
+		lines, err := gitClient.BlameLines(ctx, rev, r.File, r.Start, r.End)
+		if err != nil {
+			s.logger.Warn("Failed to blame changed lines", "file", r.File, "error", err)
+
+			continue
+		}
+		blames = append(blames, git.FileBlame{File: r.File, Regions: lines})
+	}
+
+	return blames
+}
+
+// secretsOnlyWarn reports whether gitleaks.mode is "warn", so that secret
+// scan findings are reported with the review instead of stopping it.
+//
+//nolint:funcorder // Helper method
+func (s *Server) secretsOnlyWarn() bool {
+	return s.config != nil && s.config.Gitleaks.Mode == config.GitleaksModeWarn
+}
+
+// warnOfSecrets puts the findings of a secret scan that did not stop the
+// review, under gitleaks.mode "warn", at the top of result's comments.
+//
+//nolint:funcorder // Helper method
+func (s *Server) warnOfSecrets(result *review.Result, findings []report.Finding) {
+	var sb strings.Builder
+	_, _ = sb.WriteString("**⚠️ WARNING: the security scan detected secrets in the changes.** " +
+		"gitleaks.mode is \"warn\", so they did not block this review and were sent to Gemini. " +
+		"Remove and rotate any real secret.\n\n")
+	_, _ = sb.WriteString(s.formatFindings(findings))
+	_, _ = sb.WriteString("\n")
+	_, _ = sb.WriteString(result.Comments)
+
+	result.Comments = sb.String()
+}
+
+// secretsBlockedResult is the result of a review that the secret scan
+// stopped before Gemini saw the changes. Detected secrets are a
+// non-approval, not a tool failure: the scan ran successfully and is
+// reporting a finding (like a NOT APPROVED review), so this is a normal
+// in-band result with IsError unset.
+//
+//nolint:funcorder // Helper method
+func (s *Server) secretsBlockedResult(findings []report.Finding) *mcp.CallToolResult {
+	return mcp.NewToolResultStructured(
+		ReviewOutput{
+			Status:     review.StatusChangesRequested,
+			Comments:   "Security scan detected secrets in the changes.",
+			Findings:   security.SummarizeFindings(findings, s.revealChars()),
+			RuleCounts: security.CountFindingsByRule(findings),
+		},
+		"Review Result: NOT APPROVED (changes requested)\n\nSecurity scan detected secrets in the changes:\n"+
+			s.formatFindings(findings),
+	)
+}
+
 // performReview executes the review with Gemini.
 //
 //nolint:funcorder // Helper method
@@ -460,9 +1437,10 @@ func (s *Server) performReview(
 ) (*review.Result, error) {
 	start := time.Now()
 	s.logger.Info("Starting Gemini review",
-		"repo", filepath.Base(rc.absPath),
-		"changed_files", len(rc.changedFiles),
-		"diff_size", len(rc.diff))
+		"repo", rc.repoName(),
+		"changed_files", len(rc.reviewFiles),
+		"languages", rc.languages,
+		"diff_size", len(rc.reviewDiff))
 
 	// Report progress: analyzing code context and fetching files.
 	reporter.Report(ctx, 3, totalSteps, "Analyzing code context...")
@@ -472,27 +1450,193 @@ func (s *Server) performReview(
 		reporter.Report(ctx, 3, totalSteps, "Fetching file: "+path)
 	}
 
-	reviewResult, err := s.reviewer.ReviewDiff(ctx, rc.diff, rc.changedFiles, rc.absPath,
+	opts := []review.Option{
 		review.WithFileFetchCallback(fileFetchCallback),
 		review.WithInstructions(rc.instructions),
-		review.WithDeletedFiles(rc.deletedFiles))
+		review.WithUserInstructions(rc.userInstructions),
+		review.WithDeletedFiles(rc.deletedFiles),
+		review.WithRecentCommits(rc.recentCommits),
+		review.WithDiffStat(rc.diffStat),
+	}
+	// Prompts quote instruction files and model output that the secret scan
+	// never saw, so traced text is redacted by the same scanner.
+	if s.config != nil && s.config.Logging.Level == "trace" {
+		opts = append(opts, review.WithTrace(func(text string) string {
+			return s.scanner.Redact(text, s.revealChars())
+		}))
+	}
+	escalate := s.config != nil && s.config.Review.EscalateRejections && s.rejections != nil
+	if escalate {
+		if prior := s.rejections.prior(rc.diff); len(prior) > 0 {
+			s.logger.Info("Diff was previously rejected; escalating review", "prior_rejections", len(prior))
+			opts = append(opts, review.WithPriorRejections(prior))
+		}
+	}
+
+	reviewResult, err := s.reviewer.ReviewDiff(ctx, rc.reviewDiff, rc.reviewFiles, rc.absPath, opts...)
+	// A verdict that failed after context gathering is retried once from
+	// the gathered analysis, without fetching files all over again. Quota
+	// exhaustion has already been through the fallback model.
+	if analysisErr, ok := errors.AsType[*review.AnalysisError](err); ok &&
+		ctx.Err() == nil && !errors.Is(err, review.ErrQuotaExhausted) {
+		s.logger.Warn("Gemini verdict failed; retrying it from the gathered analysis", "error", analysisErr.Err)
+		reviewResult, err = s.reviewer.ReviewWithAnalysis(
+			ctx, rc.reviewDiff, rc.reviewFiles, rc.absPath, analysisErr.Analysis, opts...,
+		)
+	}
 
 	duration := time.Since(start)
 	if err != nil {
+		s.metrics.RecordFailure()
 		s.logger.Error("Gemini review failed",
 			"duration_ms", duration.Milliseconds(),
 			"error", err)
 	} else {
 		// Report progress: review generation complete.
 		reporter.Report(ctx, 4, totalSteps, "Review complete")
+		reviewResult.FilesChanged = rc.reviewStats.FilesChanged
+		reviewResult.Additions = rc.reviewStats.Additions
+		reviewResult.Deletions = rc.reviewStats.Deletions
 		s.logger.Info("Gemini review completed",
 			"duration_ms", duration.Milliseconds(),
 			"approved", reviewResult.LGTM)
+		// Track the model's own verdict, before any human-review override:
+		// escalation is about feedback the model gave and the client ignored.
+		if escalate {
+			if reviewResult.LGTM {
+				s.rejections.forget(rc.diff)
+			} else {
+				s.rejections.record(rc.diff, reviewResult.Comments)
+			}
+		}
+		s.applyHumanReviewPolicy(reviewResult, rc.changedFiles)
+		s.scanFetchedFiles(ctx, reviewResult, rc)
+		if len(rc.secrets) > 0 {
+			s.warnOfSecrets(reviewResult, rc.secrets)
+		}
+		s.metrics.RecordReview(reviewResult.LGTM, duration)
+		s.recordAudit(audit.Record{
+			Repo:          rc.repoName(),
+			ChangedFiles:  len(rc.changedFiles),
+			Status:        string(reviewResult.Status),
+			LGTM:          reviewResult.LGTM,
+			CommentLength: len(reviewResult.Comments),
+		})
 	}
 
 	return reviewResult, err
 }
 
+// scanFetchedFiles runs the secret scan over the files Gemini retrieved for
+// context that were not already scanned as part of the change, and forces
+// result to NOT APPROVED if any contain secrets. They are read from the
+// working tree, as the file retrieval tool read them.
+//
+//nolint:funcorder // Helper method
+func (s *Server) scanFetchedFiles(ctx context.Context, result *review.Result, rc *reviewContext) {
+	var unscanned []string
+	for _, file := range result.FetchedFiles {
+		if !slices.Contains(rc.changedFiles, file) {
+			unscanned = append(unscanned, file)
+		}
+	}
+	if len(unscanned) == 0 {
+		return
+	}
+
+	findings, err := s.scanner.ScanFiles(ctx, unscanned, s.scanReader(func(path string) (string, error) {
+		return rc.gitClient.GetFileContent(ctx, path)
+	}, rc.gitClient.FileSize))
+	if err != nil {
+		// Files that could not be scanned cannot be cleared either.
+		s.logger.Warn("Failed to scan files retrieved during review", "error", err)
+		result.SetStatus(review.StatusNeedsHuman)
+		result.Comments = "**Retrieved files not scanned:** the security scan of files Gemini retrieved " +
+			"for context did not complete (" + err.Error() + "), so the review cannot be approved " +
+			"automatically.\n" + result.Comments
+
+		return
+	}
+	s.logger.Info("Scanned files retrieved during review",
+		"files", len(unscanned),
+		"findings", len(findings))
+	if !security.HasFindings(findings) {
+		return
+	}
+
+	var sb strings.Builder
+	_, _ = sb.WriteString("**Secrets in retrieved files:** the security scan detected secrets in files " +
+		"Gemini retrieved for context, which cannot be approved automatically:\n")
+	_, _ = sb.WriteString(s.formatFindings(findings))
+	_, _ = sb.WriteString(result.Comments)
+
+	result.SetStatus(review.StatusNeedsHuman)
+	result.Comments = sb.String()
+}
+
+// recordAudit appends rec to the audit log, if audit.directory is set. A
+// failed write is logged but does not fail the review.
+//
+//nolint:funcorder // Helper method
+func (s *Server) recordAudit(rec audit.Record) {
+	if s.audit == nil {
+		return
+	}
+	if err := s.audit.Write(rec); err != nil {
+		s.logger.Warn("Failed to write audit record", "error", err)
+	}
+}
+
+// applyHumanReviewPolicy forces result to NOT APPROVED when any changed file
+// matches review.human_review_files, prepending a note that lists the matched
+// files. Changes to such files (CI workflows, security policy, and the like)
+// must never be committed on the model's say-so alone.
+//
+//nolint:funcorder // Helper method
+func (s *Server) applyHumanReviewPolicy(result *review.Result, changedFiles []string) {
+	matched := matchHumanReviewFiles(changedFiles, s.config.Review.HumanReviewFiles)
+	if len(matched) == 0 {
+		return
+	}
+
+	s.logger.Info("Changes touch files that require human review",
+		"files", matched,
+		"model_approved", result.LGTM)
+
+	var sb strings.Builder
+	_, _ = sb.WriteString("**Requires human review:** this change touches files listed in " +
+		"review.human_review_files and cannot be approved automatically:\n")
+	for _, file := range matched {
+		_, _ = sb.WriteString("- " + file + "\n")
+	}
+	if result.Comments != "" {
+		_, _ = sb.WriteString("\n" + result.Comments)
+	}
+
+	result.SetStatus(review.StatusNeedsHuman)
+	result.Comments = sb.String()
+}
+
+// matchHumanReviewFiles returns the files that match any of patterns. A
+// pattern matches a file's basename or its full repo-relative path, so
+// "Makefile" applies anywhere in the tree while ".github/workflows/*" is
+// anchored at the repository root.
+func matchHumanReviewFiles(files, patterns []string) []string {
+	var matched []string
+	for _, file := range files {
+		for _, pattern := range patterns {
+			// Patterns are validated in New, so Match cannot fail here.
+			baseMatch, _ := path.Match(pattern, path.Base(file))
+			fullMatch, _ := path.Match(pattern, file)
+			if baseMatch || fullMatch {
+				matched = append(matched, file)
+				break
+			}
+		}
+	}
+	return matched
+}
+
 // HandleReviewOnly reviews code changes without committing.
 func (s *Server) HandleReviewOnly(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	requestID, err := generateRequestID()
@@ -532,19 +1676,173 @@ func (s *Server) HandleReviewOnly(ctx context.Context, request mcp.CallToolReque
 		if errors.Is(err, ErrDirectoryNotString) {
 			return nil, err
 		}
-		return mcp.NewToolResultErrorf("failed to process directory: %v", err), nil
+		return newToolErrorf(CodeInvalidDirectory, "failed to process directory: %v", err), nil
 	}
 
 	s.logger.Info("Processing repository",
 		"request_id", requestID,
 		"repo", filepath.Base(directory))
 
-	// review_only has 4 total steps (no staging/committing).
+	files, err := parseFiles(args)
+	if err != nil {
+		return nil, err
+	}
+	instructions, err := parseInstructions(args)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := parseTimeout(args)
+	if err != nil {
+		return nil, err
+	}
+	includeDiff := false
+	if raw, present := args[argIncludeDiff]; present && raw != nil {
+		if includeDiff, ok = raw.(bool); !ok {
+			return nil, ErrIncludeDiffNotBool
+		}
+	}
+	baseBranch, err := parseBaseBranch(args)
+	if err != nil {
+		return nil, err
+	}
+	if baseBranch != "" && len(files) > 0 {
+		return nil, ErrBaseBranchWithFiles
+	}
+
+	ctx, cancel := withReviewTimeout(ctx, timeout)
+	defer cancel()
+	target := reviewTarget{files: files, baseBranch: baseBranch}
+	result := s.reviewWithoutCommit(ctx, requestID, start, reporter, directory, target, instructions, includeDiff)
+
+	return timeoutResult(ctx, timeout, result), nil
+}
+
+// HandleReviewCommits reviews the changes between two existing commits, for
+// after-the-fact audits. It never commits.
+func (s *Server) HandleReviewCommits(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	requestID, err := generateRequestID()
+	if err != nil {
+		s.logger.Error("Failed to generate request ID", "error", err)
+		return nil, err
+	}
+	start := time.Now()
+
+	s.logger.Info("Review request started",
+		"request_id", requestID,
+		"tool", "review_commits")
+
+	// Create progress reporter based on whether client requested progress.
+	reporter := s.createProgressReporter(request)
+
+	// Parse arguments.
+	args, ok := request.Params.Arguments.(map[string]any)
+	if !ok {
+		s.logger.Error("Invalid arguments format",
+			"request_id", requestID,
+			"tool", "review_commits")
+		return nil, ErrInvalidArguments
+	}
+
+	// Parse and validate directory.
+	directory, err := s.parseDirectory(args)
+	if err != nil {
+		s.logger.Error("Failed to parse directory",
+			"request_id", requestID,
+			"total_duration_ms", time.Since(start).Milliseconds(),
+			"error", err)
+		if errors.Is(err, ErrDirectoryNotString) {
+			return nil, err
+		}
+		return newToolErrorf(CodeInvalidDirectory, "failed to process directory: %v", err), nil
+	}
+
+	from, err := parseRef(args, argFrom)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseRef(args, argTo)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Processing repository",
+		"request_id", requestID,
+		"repo", filepath.Base(directory),
+		"from", from,
+		"to", to)
+
+	return s.reviewWithoutCommit(
+		ctx, requestID, start, reporter, directory, reviewTarget{from: from, to: to}, "", false,
+	), nil
+}
+
+// HandleReviewHead reviews the most recent commit, for a second opinion on
+// changes that are already committed. It never commits.
+func (s *Server) HandleReviewHead(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	requestID, err := generateRequestID()
+	if err != nil {
+		s.logger.Error("Failed to generate request ID", "error", err)
+		return nil, err
+	}
+	start := time.Now()
+
+	s.logger.Info("Review request started",
+		"request_id", requestID,
+		"tool", "review_head")
+
+	// Create progress reporter based on whether client requested progress.
+	reporter := s.createProgressReporter(request)
+
+	// Parse arguments.
+	args, ok := request.Params.Arguments.(map[string]any)
+	if !ok {
+		s.logger.Error("Invalid arguments format",
+			"request_id", requestID,
+			"tool", "review_head")
+		return nil, ErrInvalidArguments
+	}
+
+	// Parse and validate directory.
+	directory, err := s.parseDirectory(args)
+	if err != nil {
+		s.logger.Error("Failed to parse directory",
+			"request_id", requestID,
+			"total_duration_ms", time.Since(start).Milliseconds(),
+			"error", err)
+		if errors.Is(err, ErrDirectoryNotString) {
+			return nil, err
+		}
+		return newToolErrorf(CodeInvalidDirectory, "failed to process directory: %v", err), nil
+	}
+
+	s.logger.Info("Processing repository",
+		"request_id", requestID,
+		"repo", filepath.Base(directory))
+
+	return s.reviewWithoutCommit(ctx, requestID, start, reporter, directory, reviewTarget{head: true}, "", false), nil
+}
+
+// reviewWithoutCommit runs a review of target, with the caller's
+// userInstructions if any, and returns its result, for the tools that never
+// commit. Every failure is reported in-band.
+//
+//nolint:funcorder // Helper method
+func (s *Server) reviewWithoutCommit(
+	ctx context.Context, requestID string, start time.Time, reporter progress.Reporter,
+	directory string, target reviewTarget, userInstructions string, includeDiff bool,
+) *mcp.CallToolResult {
+	// Reviews without a commit have 4 total steps (no staging/committing).
 	const totalSteps = 4.0
+	// Bound concurrent reviews: each runs git subprocesses and Gemini calls.
+	release, err := s.acquireReviewSlot(ctx, requestID)
+	if err != nil {
+		return toolErrorResult(fmt.Errorf("review not started: %w", err), CodeCanceled)
+	}
+	defer release()
 
 	// Prepare for review (get diff, security scan, etc.)
 	prepStart := time.Now()
-	reviewCtx, earlyReturn, err := s.prepareReview(ctx, directory, reporter, totalSteps)
+	reviewCtx, earlyReturn, err := s.prepareReview(ctx, directory, target, reporter, totalSteps)
 	prepDuration := time.Since(prepStart)
 
 	s.logger.Info("Review preparation completed",
@@ -554,7 +1852,7 @@ func (s *Server) HandleReviewOnly(ctx context.Context, request mcp.CallToolReque
 		"error", err)
 
 	if earlyReturn != nil {
-		return earlyReturn, nil
+		return earlyReturn
 	}
 	if err != nil {
 		elapsed := time.Since(start)
@@ -562,8 +1860,9 @@ func (s *Server) HandleReviewOnly(ctx context.Context, request mcp.CallToolReque
 			"request_id", requestID,
 			"total_duration_ms", elapsed.Milliseconds(),
 			"error", err)
-		return mcp.NewToolResultError(err.Error()), nil
+		return toolErrorResult(err, CodeGitFailed)
 	}
+	reviewCtx.userInstructions = userInstructions
 
 	// Perform the review.
 	s.logger.Info("Starting review analysis",
@@ -576,7 +1875,7 @@ func (s *Server) HandleReviewOnly(ctx context.Context, request mcp.CallToolReque
 			"request_id", requestID,
 			"total_duration_ms", elapsed.Milliseconds(),
 			"error", err)
-		return mcp.NewToolResultErrorf("review failed: %v", err), nil
+		return toolErrorResult(fmt.Errorf("review failed: %w", err), CodeReviewFailed)
 	}
 
 	// Return review result (approved or not).
@@ -587,9 +1886,19 @@ func (s *Server) HandleReviewOnly(ctx context.Context, request mcp.CallToolReque
 		"total_duration_ms", elapsed.Milliseconds())
 
 	// Format the response with usage statistics.
-	responseText := formatReviewResponse(reviewResult, "")
+	result := newReviewToolResult(reviewResult, "", false)
+	if includeDiff {
+		// A truncated diff is preceded by a diffstat of the whole change.
+		diffStat := reviewCtx.diffStat
+		if diffStat == "" && len(reviewCtx.reviewDiff) > maxResultDiffBytes {
+			if diffStat, err = reviewCtx.gitClient.GetDiffStat(ctx, reviewCtx.reviewDiff); err != nil {
+				s.logger.Warn("Failed to summarize diff", "request_id", requestID, "error", err)
+			}
+		}
+		attachDiff(result, reviewCtx.reviewDiff, diffStat)
+	}
 
-	return mcp.NewToolResultText(responseText), nil
+	return result
 }
 
 // HandleReviewAndCommit handles the review_and_commit tool invocation.
@@ -629,25 +1938,75 @@ func (s *Server) HandleReviewAndCommit(ctx context.Context, request mcp.CallTool
 		if errors.Is(err, ErrDirectoryNotString) {
 			return nil, err
 		}
-		return mcp.NewToolResultErrorf("failed to process directory: %v", err), nil
+		return newToolErrorf(CodeInvalidDirectory, "failed to process directory: %v", err), nil
 	}
 
 	s.logger.Info("Processing repository",
 		"request_id", requestID,
 		"repo", filepath.Base(directory))
 
-	// Parse commit message.
-	commitMessage, ok := args["commit_message"].(string)
-	if !ok {
-		return nil, ErrCommitMessageNotString
+	files, err := parseFiles(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the commit message, which may be omitted when
+	// git.default_commit_message is set.
+	var commitMessage string
+	defaultMessage := false
+	if raw, present := args[argCommitMessage]; present && raw != nil {
+		if commitMessage, ok = raw.(string); !ok {
+			return nil, ErrCommitMessageNotString
+		}
+	} else {
+		if s.config == nil || s.config.Git.DefaultCommitMessage == "" {
+			return nil, ErrCommitMessageRequired
+		}
+		defaultMessage = true
+	}
+
+	// Parse the optional amend flag.
+	amend := false
+	if raw, present := args[argAmend]; present && raw != nil {
+		if amend, ok = raw.(bool); !ok {
+			return nil, ErrAmendNotBool
+		}
+	}
+
+	stage, stagePaths, err := parseStage(args)
+	if err != nil {
+		return nil, err
+	}
+
+	instructions, err := parseInstructions(args)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := parseTimeout(args)
+	if err != nil {
+		return nil, err
 	}
 
 	// review_and_commit has 6 total steps (includes staging/committing).
 	const totalSteps = 6.0
 
+	// The deadline bounds the review but not the commit, so an approved
+	// change is never left half-committed.
+	deadlineCtx, cancel := withReviewTimeout(ctx, timeout)
+	defer cancel()
+
+	// Bound concurrent reviews: each runs git subprocesses and Gemini calls.
+	release, err := s.acquireReviewSlot(deadlineCtx, requestID)
+	if err != nil {
+		result := toolErrorResult(fmt.Errorf("review not started: %w", err), CodeCanceled)
+		return timeoutResult(deadlineCtx, timeout, result), nil
+	}
+	defer release()
+
 	// Prepare for review (get diff, security scan, etc.)
 	prepStart := time.Now()
-	reviewCtx, earlyReturn, err := s.prepareReview(ctx, directory, reporter, totalSteps)
+	reviewCtx, earlyReturn, err := s.prepareReview(
+		deadlineCtx, directory, reviewTarget{files: files}, reporter, totalSteps)
 	prepDuration := time.Since(prepStart)
 
 	s.logger.Info("Review preparation completed",
@@ -665,21 +2024,52 @@ func (s *Server) HandleReviewAndCommit(ctx context.Context, request mcp.CallTool
 			"request_id", requestID,
 			"total_duration_ms", elapsed.Milliseconds(),
 			"error", err)
-		return mcp.NewToolResultError(err.Error()), nil
+		return timeoutResult(deadlineCtx, timeout, toolErrorResult(err, CodeGitFailed)), nil
+	}
+	reviewCtx.userInstructions = instructions
+
+	// Refuse an amend with nothing to amend before paying for a review.
+	if amend {
+		hasHead, headErr := reviewCtx.gitClient.HasCommits(ctx)
+		if headErr != nil {
+			return newToolErrorf(CodeGitFailed, "failed to check for a previous commit: %v", headErr), nil
+		}
+		if !hasHead {
+			return newToolErrorf(CodeCommitRefused, "cannot amend: %v", git.ErrNoCommitToAmend), nil
+		}
+	}
+
+	// Likewise refuse a stage argument that would leave nothing to commit.
+	// The whole change is reviewed either way; stage only narrows what is
+	// committed.
+	filesToStage, err := selectStaged(ctx, reviewCtx, stage, stagePaths)
+	if err != nil {
+		return newToolErrorf(CodeCommitRefused, "cannot commit: %v", err), nil
+	}
+	// And a commit message given by reference that cannot be read, or a
+	// default one that cannot be rendered.
+	if defaultMessage {
+		commitMessage, err = reviewCtx.gitClient.DefaultCommitMessage(len(filesToStage))
+	} else {
+		commitMessage, err = reviewCtx.gitClient.ResolveCommitMessage(ctx, commitMessage)
+	}
+	if err != nil {
+		return newToolErrorf(CodeCommitRefused, "cannot commit: %v", err), nil
 	}
 
 	// Perform the review.
 	s.logger.Info("Starting review analysis",
 		"request_id", requestID)
 
-	reviewResult, err := s.performReview(ctx, reviewCtx, reporter, totalSteps)
+	reviewResult, err := s.performReview(deadlineCtx, reviewCtx, reporter, totalSteps)
 	if err != nil {
 		elapsed := time.Since(start)
 		s.logger.Error("Review failed",
 			"request_id", requestID,
 			"total_duration_ms", elapsed.Milliseconds(),
 			"error", err)
-		return mcp.NewToolResultErrorf("review failed: %v", err), nil
+		result := toolErrorResult(fmt.Errorf("review failed: %w", err), CodeReviewFailed)
+		return timeoutResult(deadlineCtx, timeout, result), nil
 	}
 
 	// If not approved, return review comments with usage stats.
@@ -689,11 +2079,39 @@ func (s *Server) HandleReviewAndCommit(ctx context.Context, request mcp.CallTool
 			"request_id", requestID,
 			"total_duration_ms", elapsed.Milliseconds())
 
-		responseText := formatReviewResponse(reviewResult, "")
-		return mcp.NewToolResultText(responseText), nil
+		return newReviewToolResult(reviewResult, "", false), nil
+	}
+
+	// Changes are approved: commit them, or hold the commit until it is
+	// confirmed when review.commit_requires_confirmation is set.
+	approved := &approvedCommit{
+		reviewCtx:    reviewCtx,
+		files:        files,
+		result:       reviewResult,
+		message:      commitMessage,
+		amend:        amend,
+		narrow:       reviewCtx.subset || stage != stageAll,
+		filesToStage: filesToStage,
+	}
+	if s.config != nil && s.config.Review.CommitRequiresConfirmation {
+		return s.awaitConfirmation(requestID, start, approved), nil
 	}
 
-	// Changes are approved - proceed to commit.
+	return s.commitApproved(ctx, requestID, start, reporter, totalSteps, approved), nil
+}
+
+// commitApproved stages and commits the changes of an approved review,
+// along with the review report when review.report_path is set, and returns
+// the tool result. Every failure is reported in-band.
+//
+//nolint:funcorder // Helper method
+func (s *Server) commitApproved(
+	ctx context.Context, requestID string, start time.Time, reporter progress.Reporter, totalSteps float64,
+	approved *approvedCommit,
+) *mcp.CallToolResult {
+	gitClient := approved.reviewCtx.gitClient
+	filesToStage := approved.filesToStage
+
 	// Report progress: staging changes.
 	reporter.Report(ctx, 5, totalSteps, "Staging changes...")
 
@@ -712,14 +2130,27 @@ func (s *Server) HandleReviewAndCommit(ctx context.Context, request mcp.CallTool
 	// entries outside the reviewed list), a larger refactor tracked
 	// separately. This change still removes the most exploitable vector
 	// (creating an entirely new unscanned file during the review window).
+	if reportPath := s.reportPath(); reportPath != "" {
+		report := formatReviewReport(approved.result, approved.message)
+		if writeErr := gitClient.WriteFile(reportPath, []byte(report)); writeErr != nil {
+			elapsed := time.Since(start)
+			s.logger.Error("Failed to write review report",
+				"request_id", requestID,
+				"total_duration_ms", elapsed.Milliseconds(),
+				"error", writeErr)
+			return newToolErrorf(CodeCommitFailed, "failed to write review report: %v", writeErr)
+		}
+		filesToStage = append(slices.Clone(filesToStage), reportPath)
+	}
+
 	stageStart := time.Now()
-	if stageErr := reviewCtx.gitClient.StageFiles(ctx, reviewCtx.changedFiles); stageErr != nil {
+	if stageErr := gitClient.StageFiles(ctx, filesToStage); stageErr != nil {
 		elapsed := time.Since(start)
 		s.logger.Error("Failed to stage changes",
 			"request_id", requestID,
 			"total_duration_ms", elapsed.Milliseconds(),
 			"error", stageErr)
-		return mcp.NewToolResultErrorf("failed to stage changes: %v", stageErr), nil
+		return newToolErrorf(CodeCommitFailed, "failed to stage changes: %v", stageErr)
 	}
 	stageDuration := time.Since(stageStart)
 	s.logger.Info("Changes staged",
@@ -731,14 +2162,25 @@ func (s *Server) HandleReviewAndCommit(ctx context.Context, request mcp.CallTool
 
 	// Commit the changes.
 	commitStart := time.Now()
-	commitHash, err := reviewCtx.gitClient.Commit(ctx, commitMessage)
+	// When only a subset of files was reviewed or is to be committed, commit
+	// just those paths so other changes already staged in the index are not
+	// swept in.
+	var commitPaths []string
+	if approved.narrow {
+		commitPaths = filesToStage
+	}
+	commit := gitClient.Commit
+	if approved.amend {
+		commit = gitClient.CommitAmend
+	}
+	commitHash, err := commit(ctx, approved.message, commitPaths...)
 	if err != nil {
 		elapsed := time.Since(start)
 		s.logger.Error("Failed to commit",
 			"request_id", requestID,
 			"total_duration_ms", elapsed.Milliseconds(),
 			"error", err)
-		return mcp.NewToolResultErrorf("failed to commit: %v", err), nil
+		return newToolErrorf(CodeCommitFailed, "failed to commit: %v", err)
 	}
 	commitDuration := time.Since(commitStart)
 
@@ -750,9 +2192,7 @@ func (s *Server) HandleReviewAndCommit(ctx context.Context, request mcp.CallTool
 		"total_duration_ms", elapsed.Milliseconds())
 
 	// Format response with usage stats and commit message.
-	responseText := formatReviewResponse(reviewResult, commitHash)
-
-	return mcp.NewToolResultText(responseText), nil
+	return newReviewToolResult(approved.result, commitHash, approved.amend)
 }
 
 // Run starts the MCP server.
@@ -16,6 +16,9 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"math"
 	"os"
 	"path/filepath"
@@ -26,7 +29,10 @@ import (
 	mcpsrv "github.com/mark3labs/mcp-go/server"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genai"
+	"msrl.dev/lgtmcp/internal/audit"
 	"msrl.dev/lgtmcp/internal/config"
+	"msrl.dev/lgtmcp/internal/git"
 	"msrl.dev/lgtmcp/internal/progress"
 	"msrl.dev/lgtmcp/internal/review"
 	"msrl.dev/lgtmcp/internal/security"
@@ -146,9 +152,8 @@ func TestHandleReviewAndCommit(t *testing.T) {
 		}
 
 		result, err := server.HandleReviewAndCommit(ctx, request)
-		require.Error(t, err)
+		require.ErrorIs(t, err, ErrCommitMessageRequired)
 		assert.Nil(t, result)
-		assert.Contains(t, err.Error(), "commit_message must be a string")
 	})
 
 	t.Run("invalid directory path", func(t *testing.T) {
@@ -615,6 +620,91 @@ func TestHandleReviewOnlyWithSecrets(t *testing.T) {
 	})
 }
 
+func TestHandleReviewOnlyWithSecrets_RevealChars(t *testing.T) {
+	t.Parallel()
+	s, tmpDir := createTestServer(t)
+	s.config.Gitleaks.RevealChars = new(0)
+
+	testutil.CreateFile(t, tmpDir, "config.txt", "initial content")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial commit")
+	testutil.CreateFile(t, tmpDir, "config.txt", "token: "+fakeSecrets.GitHubPAT()+"\n")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"directory": tmpDir}
+
+	result, err := s.HandleReviewOnly(t.Context(), request)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	textContent, ok := result.Content[0].(mcp.TextContent)
+	require.True(t, ok)
+	assert.Contains(t, textContent.Text, "Security scan detected secrets")
+	assert.Contains(t, textContent.Text, "Secret: ***")
+	assert.NotContains(t, textContent.Text, "ghp")
+}
+
+func TestHandleReviewOnlyWithSecrets_VerboseFindings(t *testing.T) {
+	t.Parallel()
+
+	for _, verbose := range []bool{false, true} {
+		t.Run(fmt.Sprintf("verbose=%t", verbose), func(t *testing.T) {
+			t.Parallel()
+			s, tmpDir := createTestServer(t)
+			s.config.Gitleaks.VerboseFindings = verbose
+
+			testutil.CreateFile(t, tmpDir, "config.txt", "token: "+fakeSecrets.GitHubPAT()+"\n")
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = map[string]any{"directory": tmpDir}
+
+			result, err := s.HandleReviewOnly(t.Context(), request)
+			require.NoError(t, err)
+			require.NotNil(t, result)
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+			assert.Contains(t, textContent.Text, "Security scan detected secrets")
+			if verbose {
+				assert.Contains(t, textContent.Text, "Column: ")
+				assert.Contains(t, textContent.Text, "Entropy: ")
+			} else {
+				assert.NotContains(t, textContent.Text, "Column: ")
+				assert.NotContains(t, textContent.Text, "Entropy: ")
+			}
+		})
+	}
+}
+
+func TestHandleReviewAndCommitWithSecrets_WarnMode(t *testing.T) {
+	t.Parallel()
+	reviewer, _ := newPromptCapturingReviewer(t, true, "Looks good.")
+	scanner, err := security.New("")
+	require.NoError(t, err)
+	cfg := config.NewTestConfig()
+	cfg.Gitleaks.Mode = config.GitleaksModeWarn
+	s := newForTesting(cfg, testutil.NewTestLogger(), reviewer, scanner)
+
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "config.txt", "token: "+fakeSecrets.GitHubPAT()+"\n")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{
+		"directory":      tmpDir,
+		"commit_message": "Add config",
+	}
+	result, err := s.HandleReviewAndCommit(t.Context(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	// The findings do not block the review or the commit, but lead the
+	// comments.
+	out, ok := result.StructuredContent.(ReviewOutput)
+	require.True(t, ok)
+	assert.True(t, out.LGTM)
+	assert.True(t, strings.HasPrefix(out.Comments, "**⚠️ WARNING: the security scan detected secrets"), out.Comments)
+	assert.Contains(t, out.Comments, "Rule: github-pat")
+	assert.Contains(t, out.Comments, "Looks good.")
+	assert.Equal(t, "Add config", testutil.RunGitCmd(t, tmpDir, "log", "-1", "--format=%s"))
+}
 func TestHandleReviewAndCommitWithDiffError(t *testing.T) {
 	t.Parallel()
 	// Test with a directory that exists but isn't a git repo.
@@ -682,7 +772,7 @@ func TestFormatReviewResponse(t *testing.T) {
 			Comments: "Code looks good!",
 		}
 
-		response := formatReviewResponse(result, "")
+		response := formatReviewResponse(result, "", false)
 		assert.Contains(t, response, "Review Result: APPROVED (LGTM)")
 		assert.Contains(t, response, "Code looks good!")
 		assert.NotContains(t, response, "---")
@@ -695,12 +785,22 @@ func TestFormatReviewResponse(t *testing.T) {
 			Comments: "Found issues",
 		}
 
-		response := formatReviewResponse(result, "")
-		assert.Contains(t, response, "Review Result: NOT APPROVED")
+		response := formatReviewResponse(result, "", false)
+		assert.Contains(t, response, "Review Result: NOT APPROVED (changes requested)")
 		assert.Contains(t, response, "Found issues")
 		assert.NotContains(t, response, "---")
 	})
 
+	t.Run("needs human review", func(t *testing.T) {
+		t.Parallel()
+		result := &review.Result{Comments: "Cannot tell if the migration is safe"}
+		result.SetStatus(review.StatusNeedsHuman)
+
+		response := formatReviewResponse(result, "", false)
+		assert.Contains(t, response, "Review Result: NOT APPROVED (needs human review)")
+		assert.Contains(t, response, "Cannot tell if the migration is safe")
+	})
+
 	t.Run("with duration only", func(t *testing.T) {
 		t.Parallel()
 		result := &review.Result{
@@ -709,12 +809,55 @@ func TestFormatReviewResponse(t *testing.T) {
 			DurationMS: 12345,
 		}
 
-		response := formatReviewResponse(result, "")
+		response := formatReviewResponse(result, "", false)
 		assert.Contains(t, response, "Review Result: APPROVED (LGTM)")
 		assert.Contains(t, response, "---")
 		assert.Contains(t, response, "Duration: 12.3 s")
 	})
 
+	t.Run("with diff stats", func(t *testing.T) {
+		t.Parallel()
+		result := &review.Result{
+			LGTM:         true,
+			Comments:     "LGTM",
+			Model:        "gemini-3.6-flash",
+			FilesChanged: 5,
+			Additions:    120,
+			Deletions:    30,
+		}
+
+		response := formatReviewResponse(result, "", false)
+		assert.Contains(t, response, "---\nReviewed: +120/-30 across 5 files · Model: gemini-3.6-flash")
+
+		result.FilesChanged = 1
+		assert.Contains(t, formatReviewResponse(result, "", false), "Reviewed: +120/-30 across 1 file ·")
+	})
+
+	t.Run("with categorized comments", func(t *testing.T) {
+		t.Parallel()
+		result := &review.Result{
+			Comments: "Two issues",
+			CategorizedComments: []review.CategorizedComment{
+				{Category: review.CategoryStyle, Comment: "Rename x"},
+				{Category: review.CategorySecurity, Comment: "Escape the query"},
+				{Category: review.CategoryStyle, Comment: "Split the function"},
+			},
+			Categories: []string{"security", "style"},
+		}
+
+		response := formatReviewResponse(result, "", false)
+		assert.Contains(t, response, "Two issues\n\nComments by category:\n\n### Security (1)\n\n- Escape the query"+
+			"\n\n### Style (2)\n\n- Rename x\n- Split the function")
+
+		output, ok := newReviewToolResult(result, "", false).StructuredContent.(ReviewOutput)
+		require.True(t, ok)
+		assert.Equal(t, []string{"security", "style"}, output.Categories)
+		assert.Equal(t, map[string][]string{
+			"security": {"Escape the query"},
+			"style":    {"Rename x", "Split the function"},
+		}, output.CommentsByCategory)
+	})
+
 	t.Run("with model only", func(t *testing.T) {
 		t.Parallel()
 		result := &review.Result{
@@ -723,7 +866,7 @@ func TestFormatReviewResponse(t *testing.T) {
 			Model:    "gemini-3.6-flash",
 		}
 
-		response := formatReviewResponse(result, "")
+		response := formatReviewResponse(result, "", false)
 		assert.Contains(t, response, "---")
 		assert.Contains(t, response, "Model: gemini-3.6-flash")
 		// With no token usage the second footer line is dropped entirely.
@@ -743,7 +886,7 @@ func TestFormatReviewResponse(t *testing.T) {
 			},
 		}
 
-		response := formatReviewResponse(result, "")
+		response := formatReviewResponse(result, "", false)
 		assert.Contains(t, response, "Tokens: 12,000 (in: 10,000, out: 2,000)")
 	})
 
@@ -755,7 +898,7 @@ func TestFormatReviewResponse(t *testing.T) {
 			CostUSD:  0.042,
 		}
 
-		response := formatReviewResponse(result, "")
+		response := formatReviewResponse(result, "", false)
 		assert.Contains(t, response, "Cost: $0.04")
 	})
 
@@ -767,7 +910,7 @@ func TestFormatReviewResponse(t *testing.T) {
 			CostUSD:  0.0023,
 		}
 
-		response := formatReviewResponse(result, "")
+		response := formatReviewResponse(result, "", false)
 		assert.Contains(t, response, "Cost: $0.0023")
 	})
 
@@ -786,7 +929,7 @@ func TestFormatReviewResponse(t *testing.T) {
 			Model:   "gemini-3.6-flash",
 		}
 
-		response := formatReviewResponse(result, "")
+		response := formatReviewResponse(result, "", false)
 		assert.Contains(t, response, "Review Result: APPROVED (LGTM)")
 		// The footer is two lines: run summary, then token breakdown.
 		_, footer, ok := strings.Cut(response, "\n\n---\n")
@@ -812,7 +955,7 @@ func TestFormatReviewResponse(t *testing.T) {
 			CacheSavingsUSD: 0.0332,
 		}
 
-		response := formatReviewResponse(result, "")
+		response := formatReviewResponse(result, "", false)
 		assert.Contains(t, response, "Cached: 4,700 (47% hit, saved $0.0332)")
 	})
 
@@ -829,7 +972,7 @@ func TestFormatReviewResponse(t *testing.T) {
 			},
 		}
 
-		response := formatReviewResponse(result, "")
+		response := formatReviewResponse(result, "", false)
 		assert.Contains(t, response, "Cached: 0 (no hit)")
 		assert.NotContains(t, response, "saved $")
 	})
@@ -842,7 +985,7 @@ func TestFormatReviewResponse(t *testing.T) {
 			DurationMS: 5000,
 		}
 
-		response := formatReviewResponse(result, "abc123def")
+		response := formatReviewResponse(result, "abc123def", false)
 		assert.Contains(t, response, "Review Result: APPROVED (LGTM)")
 		assert.Contains(t, response, "Changes committed successfully!")
 		assert.Contains(t, response, "Commit: abc123def")
@@ -866,7 +1009,7 @@ func TestFormatReviewResponse(t *testing.T) {
 
 		// With no model, duration, or cost the summary line is dropped and
 		// the token breakdown becomes the only footer line.
-		_, footer, ok := strings.Cut(formatReviewResponse(result, ""), "\n\n---\n")
+		_, footer, ok := strings.Cut(formatReviewResponse(result, "", false), "\n\n---\n")
 		require.True(t, ok, "response should carry a usage footer")
 		assert.Equal(t, "Tokens: 1,000 (in: 900, out: 100) · Cached: 0 (no hit)", footer)
 	})
@@ -956,6 +1099,29 @@ func TestHandleReviewAndCommit_Approved(t *testing.T) {
 	assert.Contains(t, textContent.Text, "committed successfully")
 }
 
+func TestHandleReviewAndCommit_DefaultCommitMessage(t *testing.T) {
+	t.Parallel()
+	s, tmpDir := createTestServer(t)
+	s.config.Git.DefaultCommitMessage = "lgtmcp: {{.Files}} files reviewed and approved"
+	testutil.CreateFile(t, tmpDir, "a.go", "package a\n")
+	testutil.CreateFile(t, tmpDir, "b.go", "package b\n")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"directory": tmpDir}
+	result, err := s.HandleReviewAndCommit(t.Context(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.Equal(t, "lgtmcp: 2 files reviewed and approved", testutil.RunGitCmd(t, tmpDir, "log", "-1", "--format=%s"))
+
+	// An explicit message still wins.
+	testutil.CreateFile(t, tmpDir, "c.go", "package c\n")
+	request.Params.Arguments = map[string]any{"directory": tmpDir, "commit_message": "Add c"}
+	result, err = s.HandleReviewAndCommit(t.Context(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.Equal(t, "Add c", testutil.RunGitCmd(t, tmpDir, "log", "-1", "--format=%s"))
+}
+
 func TestHandleReviewAndCommit_Rejected(t *testing.T) {
 	t.Parallel()
 	cfg := config.NewTestConfig()
@@ -1052,7 +1218,7 @@ func TestPrepareReview_SplitsDeletionsFromChangedFiles(t *testing.T) {
 	require.NoError(t, os.Remove(filepath.Join(tmpDir, "gone.go")))
 
 	reporter := progress.NewNoOpReporter()
-	rc, earlyReturn, err := s.prepareReview(t.Context(), tmpDir, reporter, 4)
+	rc, earlyReturn, err := s.prepareReview(t.Context(), tmpDir, reviewTarget{}, reporter, 4)
 	require.NoError(t, err)
 	require.Nil(t, earlyReturn, "expected real diff, not an early-return result")
 	require.NotNil(t, rc)
@@ -1061,6 +1227,7 @@ func TestPrepareReview_SplitsDeletionsFromChangedFiles(t *testing.T) {
 		"changedFiles must include both modified and deleted paths so StageFiles can stage both")
 	assert.Equal(t, []string{"gone.go"}, rc.deletedFiles,
 		"deletedFiles must contain exactly the deleted path so Gemini gets the right signal")
+	assert.Equal(t, []string{"Go"}, rc.languages)
 }
 
 func TestPrepareReview_NoDeletionsLeavesDeletedFilesEmpty(t *testing.T) {
@@ -1073,7 +1240,7 @@ func TestPrepareReview_NoDeletionsLeavesDeletedFilesEmpty(t *testing.T) {
 	testutil.CreateFile(t, tmpDir, "kept.go", "package main\n\nfunc main() {}\n")
 
 	reporter := progress.NewNoOpReporter()
-	rc, earlyReturn, err := s.prepareReview(t.Context(), tmpDir, reporter, 4)
+	rc, earlyReturn, err := s.prepareReview(t.Context(), tmpDir, reviewTarget{}, reporter, 4)
 	require.NoError(t, err)
 	require.Nil(t, earlyReturn)
 	require.NotNil(t, rc)
@@ -1146,3 +1313,1643 @@ func TestNew_ScannerFailure(t *testing.T) {
 	assert.Nil(t, s)
 	assert.Contains(t, err.Error(), "failed to create security scanner")
 }
+
+func TestNew_InvalidHumanReviewFilesPattern(t *testing.T) {
+	t.Parallel()
+	cfg := config.NewTestConfig()
+	cfg.Review.HumanReviewFiles = []string{"[unterminated"}
+
+	s, err := New(cfg, testutil.NewTestLogger())
+	require.Error(t, err)
+	assert.Nil(t, s)
+	assert.Contains(t, err.Error(), "human_review_files")
+}
+
+func TestHandleReviewAndCommit_HumanReviewFiles(t *testing.T) {
+	t.Parallel()
+	s, tmpDir := createTestServer(t)
+	s.config.Review.HumanReviewFiles = []string{".github/workflows/*", "SECURITY.md"}
+
+	testutil.CreateFile(t, tmpDir, "file.go", "package main\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+	testutil.CreateFile(t, tmpDir, "file.go", "package main\n\nfunc main() {}\n")
+	testutil.CreateFile(t, tmpDir, ".github/workflows/ci.yml", "on: push\n")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{
+		"directory":      tmpDir,
+		"commit_message": "test commit",
+	}
+
+	result, err := s.HandleReviewAndCommit(t.Context(), request)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	textContent, ok := result.Content[0].(mcp.TextContent)
+	require.True(t, ok)
+	assert.Contains(t, textContent.Text, "NOT APPROVED (needs human review)")
+	assert.Contains(t, textContent.Text, "Requires human review")
+	output, ok := result.StructuredContent.(ReviewOutput)
+	require.True(t, ok)
+	assert.Equal(t, review.StatusNeedsHuman, output.Status)
+	assert.False(t, output.LGTM)
+	assert.Contains(t, textContent.Text, "- .github/workflows/ci.yml")
+	assert.NotContains(t, textContent.Text, "- file.go")
+	// The model's own comments are preserved below the note.
+	assert.Contains(t, textContent.Text, "Test approved")
+	assert.NotContains(t, textContent.Text, "committed successfully")
+}
+
+func TestMatchHumanReviewFiles(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		files    []string
+		patterns []string
+		want     []string
+	}{
+		{
+			name:     "no patterns",
+			files:    []string{"main.go"},
+			patterns: nil,
+			want:     nil,
+		},
+		{
+			name:     "basename matches anywhere",
+			files:    []string{"SECURITY.md", "docs/SECURITY.md", "main.go"},
+			patterns: []string{"SECURITY.md"},
+			want:     []string{"SECURITY.md", "docs/SECURITY.md"},
+		},
+		{
+			name:     "path glob is anchored at the root",
+			files:    []string{".github/workflows/ci.yml", "sub/.github/workflows/ci.yml"},
+			patterns: []string{".github/workflows/*"},
+			want:     []string{".github/workflows/ci.yml"},
+		},
+		{
+			name:     "file matching several patterns is listed once",
+			files:    []string{"Makefile"},
+			patterns: []string{"Makefile", "Make*"},
+			want:     []string{"Makefile"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, matchHumanReviewFiles(tt.files, tt.patterns))
+		})
+	}
+}
+
+// newPromptCapturingReviewer returns a stub reviewer answering with the given
+// verdict, together with a function reporting the text of the last phase-2
+// review prompt it received.
+func newPromptCapturingReviewer(t *testing.T, lgtm bool, comments string) (*review.Reviewer, func() string) {
+	t.Helper()
+	responseJSON, err := json.Marshal(review.Result{LGTM: lgtm, Comments: comments})
+	require.NoError(t, err)
+
+	var prompt string
+	textResp := func(text string) *genai.GenerateContentResponse {
+		return &genai.GenerateContentResponse{
+			Candidates: []*genai.Candidate{{Content: &genai.Content{Parts: []*genai.Part{{Text: text}}}}},
+		}
+	}
+	client := &review.StubGeminiClient{
+		CreateChatFunc: func(_ context.Context, _ string, _ *genai.GenerateContentConfig) (review.GeminiChat, error) {
+			return &review.StubGeminiChat{
+				SendMessageFunc: func(_ context.Context, _ ...genai.Part) (*genai.GenerateContentResponse, error) {
+					return textResp("Analysis complete."), nil
+				},
+			}, nil
+		},
+		GenerateContentFunc: func(_ context.Context, _ string, contents []*genai.Content,
+			_ *genai.GenerateContentConfig,
+		) (*genai.GenerateContentResponse, error) {
+			var sb strings.Builder
+			for _, c := range contents {
+				for _, p := range c.Parts {
+					_, _ = sb.WriteString(p.Text)
+				}
+			}
+			prompt = sb.String()
+
+			return textResp(string(responseJSON)), nil
+		},
+	}
+
+	return review.NewForTestingWithClient(client), func() string { return prompt }
+}
+
+func TestPrepareReview_IncludeEnvironment(t *testing.T) {
+	t.Parallel()
+
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			t.Parallel()
+			cfg := config.NewTestConfig()
+			cfg.Review.IncludeEnvironment = enabled
+			reviewer, lastPrompt := newPromptCapturingReviewer(t, true, "ok")
+			scanner, err := security.New("")
+			require.NoError(t, err)
+			s := newForTesting(cfg, testutil.NewTestLogger(), reviewer, scanner)
+
+			tmpDir := testutil.CreateTempGitRepo(t)
+			testutil.CreateFile(t, tmpDir, "go.mod", "module example.com/stackprobe\n\ngo 1.26\n")
+			testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+			testutil.RunGitCmd(t, tmpDir, "add", ".")
+			testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+			testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nfunc main() {}\n")
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = map[string]any{"directory": tmpDir}
+			result, err := s.HandleReviewOnly(t.Context(), request)
+			require.NoError(t, err)
+			require.NotNil(t, result)
+			assert.False(t, result.IsError)
+
+			if enabled {
+				assert.Contains(t, lastPrompt(), "## Repository Environment")
+				assert.Contains(t, lastPrompt(), "- Go module: example.com/stackprobe")
+			} else {
+				assert.NotContains(t, lastPrompt(), "example.com/stackprobe")
+			}
+		})
+	}
+}
+
+func TestPrepareReview_RecentChanges(t *testing.T) {
+	t.Parallel()
+
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			t.Parallel()
+			cfg := config.NewTestConfig()
+			if enabled {
+				cfg.Review.RecentChanges = 2
+			}
+			reviewer, lastPrompt := newPromptCapturingReviewer(t, true, "ok")
+			scanner, err := security.New("")
+			require.NoError(t, err)
+			s := newForTesting(cfg, testutil.NewTestLogger(), reviewer, scanner)
+
+			tmpDir := testutil.CreateTempGitRepo(t)
+			testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+			testutil.RunGitCmd(t, tmpDir, "add", ".")
+			testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+			testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nconst limit = 10 // was off by one\n")
+			testutil.RunGitCmd(t, tmpDir, "commit", "-am", "Fix off-by-one in limit")
+			testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nconst limit = 11\n")
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = map[string]any{"directory": tmpDir}
+			result, err := s.HandleReviewOnly(t.Context(), request)
+			require.NoError(t, err)
+			require.NotNil(t, result)
+			assert.False(t, result.IsError)
+
+			if enabled {
+				assert.Contains(t, lastPrompt(), "## Recent Related Changes")
+				assert.Contains(t, lastPrompt(), "Fix off-by-one in limit")
+				assert.Contains(t, lastPrompt(), "+const limit = 10 // was off by one")
+			} else {
+				assert.NotContains(t, lastPrompt(), "Fix off-by-one in limit")
+			}
+		})
+	}
+}
+
+func TestPrepareReview_RequireTests(t *testing.T) {
+	t.Parallel()
+
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			t.Parallel()
+			cfg := config.NewTestConfig()
+			cfg.Gemini.RequireTests = enabled
+			reviewer, lastPrompt := newPromptCapturingReviewer(t, true, "ok")
+			scanner, err := security.New("")
+			require.NoError(t, err)
+			s := newForTesting(cfg, testutil.NewTestLogger(), reviewer, scanner)
+
+			tmpDir := testutil.CreateTempGitRepo(t)
+			testutil.CreateFile(t, tmpDir, "old.go", "package main\n")
+			testutil.RunGitCmd(t, tmpDir, "add", ".")
+			testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+			testutil.RunGitCmd(t, tmpDir, "rm", "-q", "old.go")
+			testutil.CreateFile(t, tmpDir, "limit.go", "package main\n\nconst limit = 11\n")
+			testutil.CreateFile(t, tmpDir, "parse.go", "package main\n\nfunc parse() {}\n")
+			testutil.CreateFile(t, tmpDir, "parse_test.go", "package main\n")
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = map[string]any{"directory": tmpDir}
+			result, err := s.HandleReviewOnly(t.Context(), request)
+			require.NoError(t, err)
+			require.NotNil(t, result)
+			assert.False(t, result.IsError)
+
+			if enabled {
+				_, section, found := strings.Cut(lastPrompt(), "## Test Coverage")
+				require.True(t, found)
+				section, _, _ = strings.Cut(section, "CRITICAL:")
+				assert.Contains(t, section, "- limit.go\n")
+				assert.NotContains(t, section, "parse.go")
+				assert.NotContains(t, section, "old.go")
+			} else {
+				assert.NotContains(t, lastPrompt(), "## Test Coverage")
+			}
+		})
+	}
+}
+
+func TestPerformReview_EscalatesRepeatedRejections(t *testing.T) {
+	t.Parallel()
+	cfg := config.NewTestConfig()
+	cfg.Review.EscalateRejections = true
+	reviewer, lastPrompt := newPromptCapturingReviewer(t, false, "Missing error check in main.go:3")
+	scanner, err := security.New("")
+	require.NoError(t, err)
+	s := newForTesting(cfg, testutil.NewTestLogger(), reviewer, scanner)
+
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nfunc main() {}\n")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"directory": tmpDir}
+
+	_, err = s.HandleReviewOnly(t.Context(), request)
+	require.NoError(t, err)
+	assert.NotContains(t, lastPrompt(), "Previous Rejections of This Diff")
+
+	// Resubmitting the identical diff carries the earlier verdict.
+	_, err = s.HandleReviewOnly(t.Context(), request)
+	require.NoError(t, err)
+	assert.Contains(t, lastPrompt(), "Previous Rejections of This Diff")
+	assert.Contains(t, lastPrompt(), "rejected 1 time(s)")
+	assert.Contains(t, lastPrompt(), "Missing error check in main.go:3")
+
+	// A changed diff starts fresh.
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nfunc main() { _ = 1 }\n")
+	_, err = s.HandleReviewOnly(t.Context(), request)
+	require.NoError(t, err)
+	assert.NotContains(t, lastPrompt(), "Previous Rejections of This Diff")
+}
+
+func TestPerformReview_NoEscalationWhenDisabled(t *testing.T) {
+	t.Parallel()
+	cfg := config.NewTestConfig()
+	reviewer, lastPrompt := newPromptCapturingReviewer(t, false, "Missing error check")
+	scanner, err := security.New("")
+	require.NoError(t, err)
+	s := newForTesting(cfg, testutil.NewTestLogger(), reviewer, scanner)
+
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nfunc main() {}\n")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"directory": tmpDir}
+	for range 2 {
+		_, err = s.HandleReviewOnly(t.Context(), request)
+		require.NoError(t, err)
+	}
+	assert.NotContains(t, lastPrompt(), "Previous Rejections of This Diff")
+}
+
+func TestHandleReviewAndCommit_ReportPath(t *testing.T) {
+	t.Parallel()
+	s, tmpDir := createTestServer(t)
+	s.config.Review.ReportPath = ".lgtmcp/last-review.md"
+
+	testutil.CreateFile(t, tmpDir, "file.go", "package main\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+	testutil.CreateFile(t, tmpDir, "file.go", "package main\n\nfunc main() {}\n")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{
+		"directory":      tmpDir,
+		"commit_message": "Add main",
+	}
+	result, err := s.HandleReviewAndCommit(t.Context(), request)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	textContent, ok := result.Content[0].(mcp.TextContent)
+	require.True(t, ok)
+	require.Contains(t, textContent.Text, "committed successfully")
+
+	// The report is committed alongside the reviewed change.
+	committed := testutil.RunGitCmd(t, tmpDir, "show", "--name-only", "--format=", "HEAD")
+	assert.ElementsMatch(t, []string{"file.go", ".lgtmcp/last-review.md"}, strings.Fields(committed))
+	report := testutil.RunGitCmd(t, tmpDir, "show", "HEAD:.lgtmcp/last-review.md")
+	assert.Contains(t, report, "# lgtmcp Review")
+	assert.Contains(t, report, "Add main")
+	assert.Contains(t, report, "Review Result: APPROVED (LGTM)")
+	assert.Contains(t, report, "Test approved")
+	assert.Empty(t, testutil.RunGitCmd(t, tmpDir, "status", "--porcelain"))
+
+	// A change to only the report is never reviewed.
+	testutil.CreateFile(t, tmpDir, ".lgtmcp/last-review.md", "edited by hand\n")
+	onlyRequest := mcp.CallToolRequest{}
+	onlyRequest.Params.Arguments = map[string]any{"directory": tmpDir}
+	result, err = s.HandleReviewOnly(t.Context(), onlyRequest)
+	require.NoError(t, err)
+	textContent, ok = result.Content[0].(mcp.TextContent)
+	require.True(t, ok)
+	assert.Equal(t, "No changes to review", textContent.Text)
+}
+
+func TestNew_InvalidReportPath(t *testing.T) {
+	t.Parallel()
+	for _, p := range []string{"../outside.md", "/abs/report.md", ".git/review.md", "."} {
+		cfg := config.NewTestConfig()
+		cfg.Review.ReportPath = p
+
+		s, err := New(cfg, testutil.NewTestLogger())
+		require.Error(t, err, p)
+		assert.Nil(t, s)
+		assert.Contains(t, err.Error(), "invalid review.report_path")
+	}
+}
+
+func TestNew_InvalidCommitMessageTemplate(t *testing.T) {
+	t.Parallel()
+	for _, tmpl := range []string{"{{.Message", "{{.Issue}} {{.Message}}", "[{{.Ticket}}]"} {
+		cfg := config.NewTestConfig()
+		cfg.Git.CommitMessageTemplate = tmpl
+
+		s, err := New(cfg, testutil.NewTestLogger())
+		require.Error(t, err, tmpl)
+		assert.Nil(t, s)
+		assert.Contains(t, err.Error(), "invalid git.commit_message_template")
+	}
+}
+
+func TestNew_InvalidDefaultCommitMessage(t *testing.T) {
+	t.Parallel()
+	cfg := config.NewTestConfig()
+	cfg.Git.DefaultCommitMessage = "{{.Message}}"
+
+	_, err := New(cfg, testutil.NewTestLogger())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid git.default_commit_message")
+}
+
+func TestHandleReviewAndCommit_Files(t *testing.T) {
+	t.Parallel()
+	s, tmpDir := createTestServer(t)
+
+	testutil.CreateFile(t, tmpDir, "base.go", "package main\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+	testutil.CreateFile(t, tmpDir, "base.go", "package main\n\nfunc main() {}\n")
+	testutil.CreateFile(t, tmpDir, "wip.go", "package main\n\nvar wip = 1\n")
+	testutil.CreateFile(t, tmpDir, "staged.go", "package main\n\nvar staged = 1\n")
+	testutil.RunGitCmd(t, tmpDir, "add", "staged.go")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{
+		"directory":      tmpDir,
+		"commit_message": "Add main",
+		"files":          []any{"base.go"},
+	}
+	result, err := s.HandleReviewAndCommit(t.Context(), request)
+	require.NoError(t, err)
+	textContent, ok := result.Content[0].(mcp.TextContent)
+	require.True(t, ok)
+	require.Contains(t, textContent.Text, "committed successfully")
+
+	// Only the reviewed file is committed; the untracked file stays untracked
+	// and the previously staged one stays staged.
+	committed := testutil.RunGitCmd(t, tmpDir, "show", "--name-only", "--format=", "HEAD")
+	assert.Equal(t, []string{"base.go"}, strings.Fields(committed))
+	status := testutil.RunGitCmd(t, tmpDir, "status", "--porcelain")
+	assert.Contains(t, status, "A  staged.go")
+	assert.Contains(t, status, "?? wip.go")
+}
+
+func TestHandleReviewAndCommit_Stage(t *testing.T) {
+	t.Parallel()
+
+	// setup creates a repository with a modified tracked file, a modified
+	// tracked file in a subdirectory, and a new untracked file.
+	setup := func(t *testing.T) (*Server, string) {
+		t.Helper()
+		s, tmpDir := createTestServer(t)
+		testutil.CreateFile(t, tmpDir, "base.go", "package main\n")
+		testutil.CreateFile(t, tmpDir, "pkg/util.go", "package pkg\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+		testutil.CreateFile(t, tmpDir, "base.go", "package main\n\nfunc main() {}\n")
+		testutil.CreateFile(t, tmpDir, "pkg/util.go", "package pkg\n\nvar v = 1\n")
+		testutil.CreateFile(t, tmpDir, "wip.go", "package main\n\nvar wip = 1\n")
+
+		return s, tmpDir
+	}
+	call := func(t *testing.T, s *Server, tmpDir string, stage any) (*mcp.CallToolResult, error) {
+		t.Helper()
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{
+			"directory":      tmpDir,
+			"commit_message": "Update",
+			"stage":          stage,
+		}
+
+		return s.HandleReviewAndCommit(t.Context(), request)
+	}
+
+	for _, tc := range []struct {
+		name          string
+		stage         any
+		wantCommitted []string
+		wantStatus    string
+	}{
+		{"all", "all", []string{"base.go", "pkg/util.go", "wip.go"}, ""},
+		{"tracked", "tracked", []string{"base.go", "pkg/util.go"}, "?? wip.go"},
+		{"paths", []any{"pkg", "wip.go"}, []string{"pkg/util.go", "wip.go"}, "M base.go"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s, tmpDir := setup(t)
+
+			result, err := call(t, s, tmpDir, tc.stage)
+			require.NoError(t, err)
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+			require.Contains(t, textContent.Text, "committed successfully")
+
+			committed := testutil.RunGitCmd(t, tmpDir, "show", "--name-only", "--format=", "HEAD")
+			assert.ElementsMatch(t, tc.wantCommitted, strings.Fields(committed))
+			// RunGitCmd trims the output, and with it the first status column.
+			assert.Equal(t, tc.wantStatus, testutil.RunGitCmd(t, tmpDir, "status", "--porcelain"))
+		})
+	}
+
+	t.Run("selects nothing", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir := setup(t)
+		testutil.RunGitCmd(t, tmpDir, "stash", "--", "base.go", "pkg/util.go")
+
+		result, err := call(t, s, tmpDir, "tracked")
+		assertInBandToolError(t, result, err, ErrStageSelectsNothing.Error())
+		assert.Equal(t, "1", testutil.RunGitCmd(t, tmpDir, "rev-list", "--count", "HEAD"))
+	})
+
+	for _, tc := range []struct {
+		name  string
+		stage any
+	}{
+		{"unknown keyword", "staged"},
+		{"empty array", []any{}},
+		{"non-string entry", []any{"base.go", 1}},
+		{"escaping path", []any{"../base.go"}},
+		{"absolute path", []any{"/etc/passwd"}},
+		{"wrong type", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s, tmpDir := setup(t)
+			result, err := call(t, s, tmpDir, tc.stage)
+			require.ErrorIs(t, err, ErrStageInvalid)
+			assert.Nil(t, result)
+		})
+	}
+}
+
+func TestHandleReviewOnly_Files(t *testing.T) {
+	t.Parallel()
+	s, tmpDir := createTestServer(t)
+
+	testutil.CreateFile(t, tmpDir, "base.go", "package main\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+	testutil.CreateFile(t, tmpDir, "other.go", "package main\n")
+
+	tests := []struct {
+		name     string
+		files    any
+		wantErr  error
+		wantText string
+		isError  bool
+	}{
+		{name: "not an array", files: "base.go", wantErr: ErrFilesNotStringArray},
+		{name: "empty array", files: []any{}, wantErr: ErrFilesNotStringArray},
+		{name: "non-string entry", files: []any{"base.go", 1}, wantErr: ErrFilesNotStringArray},
+		{name: "outside repository", files: []any{"../outside.go"}, wantText: "path is outside repository", isError: true},
+		{name: "unchanged file", files: []any{"base.go"}, wantText: "No changes to review"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = map[string]any{"directory": tmpDir, "files": tt.files}
+
+			result, err := s.HandleReviewOnly(t.Context(), request)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.isError, result.IsError)
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+			assert.Contains(t, textContent.Text, tt.wantText)
+		})
+	}
+}
+
+func TestHandleReview_UserInstructions(t *testing.T) {
+	t.Parallel()
+	const focus = "We are migrating to generics; flag any new interface{} parameters."
+
+	for _, tool := range []string{"review_only", "review_and_commit"} {
+		t.Run(tool, func(t *testing.T) {
+			t.Parallel()
+			reviewer, lastPrompt := newPromptCapturingReviewer(t, false, "needs work")
+			scanner, err := security.New("")
+			require.NoError(t, err)
+			s := newForTesting(config.NewTestConfig(), testutil.NewTestLogger(), reviewer, scanner)
+			handle := s.HandleReviewOnly
+			if tool == "review_and_commit" {
+				handle = s.HandleReviewAndCommit
+			}
+
+			tmpDir := testutil.CreateTempGitRepo(t)
+			testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+			call := func(instructions any) (*mcp.CallToolResult, error) {
+				request := mcp.CallToolRequest{}
+				request.Params.Arguments = map[string]any{
+					"directory":      tmpDir,
+					"commit_message": "Add main",
+					"instructions":   instructions,
+				}
+
+				return handle(t.Context(), request)
+			}
+
+			result, err := call(focus)
+			require.NoError(t, err)
+			require.False(t, result.IsError)
+			assert.Contains(t, lastPrompt(), focus)
+
+			result, err = call("")
+			require.NoError(t, err)
+			require.False(t, result.IsError)
+			assert.NotContains(t, lastPrompt(), focus, "instructions apply to one request only")
+			assert.NotContains(t, lastPrompt(), "Instructions for This Review")
+
+			result, err = call(42)
+			require.ErrorIs(t, err, ErrInstructionsNotString)
+			assert.Nil(t, result)
+		})
+	}
+}
+
+func TestHandleReview_TimeoutSeconds(t *testing.T) {
+	t.Parallel()
+
+	// A model that never answers: only the deadline ends the review.
+	hanging := &review.StubGeminiClient{
+		CreateChatFunc: func(_ context.Context, _ string, _ *genai.GenerateContentConfig) (review.GeminiChat, error) {
+			return &review.StubGeminiChat{
+				SendMessageFunc: func(ctx context.Context, _ ...genai.Part) (*genai.GenerateContentResponse, error) {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				},
+			}, nil
+		},
+	}
+
+	for _, tool := range []string{"review_only", "review_and_commit"} {
+		t.Run(tool, func(t *testing.T) {
+			t.Parallel()
+			scanner, err := security.New("")
+			require.NoError(t, err)
+			s := newForTesting(config.NewTestConfig(), testutil.NewTestLogger(),
+				review.NewForTestingWithClient(hanging), scanner)
+			handle := s.HandleReviewOnly
+			if tool == "review_and_commit" {
+				handle = s.HandleReviewAndCommit
+			}
+
+			tmpDir := testutil.CreateTempGitRepo(t)
+			testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+			call := func(timeout any) (*mcp.CallToolResult, error) {
+				request := mcp.CallToolRequest{}
+				request.Params.Arguments = map[string]any{
+					"directory":       tmpDir,
+					"commit_message":  "Add main",
+					"timeout_seconds": timeout,
+				}
+
+				return handle(t.Context(), request)
+			}
+
+			result, err := call(0.2)
+			require.NoError(t, err)
+			require.True(t, result.IsError)
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+			assert.Equal(t, "review timed out: no result within timeout_seconds (200ms)", textContent.Text)
+			assertToolErrorCode(t, result, CodeTimeout)
+
+			for _, bad := range []any{0, -5.0, "30", math.NaN(), 1e300} {
+				result, err = call(bad)
+				require.ErrorIs(t, err, ErrTimeoutNotPositive, "%v", bad)
+				assert.Nil(t, result)
+			}
+		})
+	}
+}
+
+func TestHandleReviewAndCommit_ScansFetchedFiles(t *testing.T) {
+	t.Parallel()
+
+	// The model approves main.go after fetching config.go for context.
+	textResp := func(text string) *genai.GenerateContentResponse {
+		return &genai.GenerateContentResponse{
+			Candidates: []*genai.Candidate{{Content: &genai.Content{Parts: []*genai.Part{{Text: text}}}}},
+		}
+	}
+	fetchingClient := func() *review.StubGeminiClient {
+		return &review.StubGeminiClient{
+			CreateChatFunc: func(_ context.Context, _ string, _ *genai.GenerateContentConfig) (review.GeminiChat, error) {
+				fetched := false
+				return &review.StubGeminiChat{
+					SendMessageFunc: func(_ context.Context, _ ...genai.Part) (*genai.GenerateContentResponse, error) {
+						if fetched {
+							return textResp("Analysis complete."), nil
+						}
+						fetched = true
+						return &genai.GenerateContentResponse{
+							Candidates: []*genai.Candidate{{Content: &genai.Content{Parts: []*genai.Part{{
+								FunctionCall: &genai.FunctionCall{
+									Name: "get_file_content",
+									Args: map[string]any{"filepath": "config.go"},
+								},
+							}}}}},
+						}, nil
+					},
+				}, nil
+			},
+			GenerateContentFunc: func(_ context.Context, _ string, _ []*genai.Content,
+				_ *genai.GenerateContentConfig,
+			) (*genai.GenerateContentResponse, error) {
+				return textResp(`{"lgtm": true, "comments": "Looks good."}`), nil
+			},
+		}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		config  string
+		approve bool
+	}{
+		{"clean", "package main\n", true},
+		{"secret", "package main\n\nconst token = \"" + fakeSecrets.GitHubPAT() + "\"\n", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			scanner, err := security.New("")
+			require.NoError(t, err)
+			s := newForTesting(config.NewTestConfig(), testutil.NewTestLogger(),
+				review.NewForTestingWithClient(fetchingClient()), scanner)
+
+			tmpDir := testutil.CreateTempGitRepo(t)
+			testutil.CreateFile(t, tmpDir, "config.go", tc.config)
+			testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+			testutil.RunGitCmd(t, tmpDir, "add", ".")
+			testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+			testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nfunc main() {}\n")
+
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = map[string]any{"directory": tmpDir, "commit_message": "Add main"}
+			result, err := s.HandleReviewAndCommit(t.Context(), request)
+			require.NoError(t, err)
+			require.False(t, result.IsError)
+			textContent, ok := result.Content[0].(mcp.TextContent)
+			require.True(t, ok)
+
+			if tc.approve {
+				assert.Contains(t, textContent.Text, "Changes committed successfully")
+				return
+			}
+			assert.Contains(t, textContent.Text, "Review Result: NOT APPROVED")
+			assert.Contains(t, textContent.Text, "Secrets in retrieved files")
+			assert.Contains(t, textContent.Text, "File: config.go")
+			assert.Contains(t, textContent.Text, "Looks good.")
+			assert.Equal(t, "initial", testutil.RunGitCmd(t, tmpDir, "log", "-1", "--format=%s"))
+		})
+	}
+}
+
+func TestHandleReviewOnly_RetriesVerdictFromAnalysis(t *testing.T) {
+	t.Parallel()
+	var chats, verdicts int
+	client := &review.StubGeminiClient{
+		CreateChatFunc: func(_ context.Context, _ string, _ *genai.GenerateContentConfig) (review.GeminiChat, error) {
+			chats++
+
+			return &review.StubGeminiChat{
+				SendMessageFunc: func(_ context.Context, _ ...genai.Part) (*genai.GenerateContentResponse, error) {
+					return &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+						Content: &genai.Content{Parts: []*genai.Part{{Text: "Analysis complete."}}},
+					}}}, nil
+				},
+			}, nil
+		},
+		GenerateContentFunc: func(_ context.Context, _ string, contents []*genai.Content,
+			_ *genai.GenerateContentConfig,
+		) (*genai.GenerateContentResponse, error) {
+			verdicts++
+			if verdicts == 1 {
+				return nil, errors.New("service unavailable")
+			}
+			assert.Contains(t, contents[0].Parts[0].Text, "Analysis complete.")
+
+			return &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+				Content: &genai.Content{Parts: []*genai.Part{{Text: `{"lgtm": true, "comments": "ok"}`}}},
+			}}}, nil
+		},
+	}
+	scanner, err := security.New("")
+	require.NoError(t, err)
+	s := newForTesting(config.NewTestConfig(), testutil.NewTestLogger(), review.NewForTestingWithClient(client), scanner)
+
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"directory": tmpDir}
+	result, err := s.HandleReviewOnly(t.Context(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError, "a failed verdict is retried: %v", result.Content)
+
+	assert.Equal(t, 1, chats, "context gathering is not repeated")
+	assert.Equal(t, 2, verdicts)
+}
+
+func TestPrepareReview_IncludeRecentCommits(t *testing.T) {
+	t.Parallel()
+	cfg := config.NewTestConfig()
+	cfg.Gemini.IncludeRecentCommits = 1
+
+	// Recent commits go in the phase-1 context gathering prompt, which is
+	// sent as a chat message.
+	var contextPrompt string
+	client := &review.StubGeminiClient{
+		CreateChatFunc: func(_ context.Context, _ string, _ *genai.GenerateContentConfig) (review.GeminiChat, error) {
+			return &review.StubGeminiChat{
+				SendMessageFunc: func(_ context.Context, parts ...genai.Part) (*genai.GenerateContentResponse, error) {
+					if contextPrompt == "" && len(parts) > 0 {
+						contextPrompt = parts[0].Text
+					}
+
+					return &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+						Content: &genai.Content{Parts: []*genai.Part{{Text: "Analysis complete."}}},
+					}}}, nil
+				},
+			}, nil
+		},
+		GenerateContentFunc: func(_ context.Context, _ string, _ []*genai.Content,
+			_ *genai.GenerateContentConfig,
+		) (*genai.GenerateContentResponse, error) {
+			return &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{
+				Content: &genai.Content{Parts: []*genai.Part{{Text: `{"lgtm": true, "comments": "ok"}`}}},
+			}}}, nil
+		},
+	}
+	reviewer := review.NewForTestingWithClient(client)
+	scanner, err := security.New("")
+	require.NoError(t, err)
+	s := newForTesting(cfg, testutil.NewTestLogger(), reviewer, scanner)
+
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "Older work")
+	testutil.RunGitCmd(t, tmpDir, "commit", "--allow-empty", "-m", "Migrate storage to SQLite")
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nconst dsn = \"file:db\"\n")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"directory": tmpDir}
+	result, err := s.HandleReviewOnly(t.Context(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+
+	assert.Contains(t, contextPrompt, "- Migrate storage to SQLite")
+	assert.NotContains(t, contextPrompt, "Older work")
+}
+
+func TestPrepareReview_IncludeDiffStat(t *testing.T) {
+	t.Parallel()
+	reviewer, lastPrompt := newPromptCapturingReviewer(t, true, "ok")
+	scanner, err := security.New("")
+	require.NoError(t, err)
+	cfg := config.NewTestConfig()
+	cfg.Gemini.IncludeDiffStat = true
+	s := newForTesting(cfg, testutil.NewTestLogger(), reviewer, scanner)
+
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"directory": tmpDir}
+	result, err := s.HandleReviewOnly(t.Context(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	assert.Contains(t, lastPrompt(), "1 file changed, 1 insertion(+)")
+}
+
+func TestPrepareReview_IncludeBlame(t *testing.T) {
+	t.Parallel()
+	reviewer, lastPrompt := newPromptCapturingReviewer(t, true, "ok")
+	scanner, err := security.New("")
+	require.NoError(t, err)
+	cfg := config.NewTestConfig()
+	cfg.Gemini.IncludeBlame = 1
+	cfg.Git.DiffContextLines = new(0)
+	s := newForTesting(cfg, testutil.NewTestLogger(), reviewer, scanner)
+
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nconst a = 1\n\nconst b = 2\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "Add constants")
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nconst a = 10\n\nconst b = 20\n")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"directory": tmpDir}
+	result, err := s.HandleReviewOnly(t.Context(), request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	prompt := lastPrompt()
+	assert.Contains(t, prompt, "## Blame of Changed Lines")
+	assert.Regexp(t, `main\.go:3 [0-9a-f]{7} \d{4}-\d{2}-\d{2} Test User: Add constants`, prompt)
+	assert.NotContains(t, prompt, "main.go:5 ", "only the first gemini.include_blame hunks are blamed")
+}
+
+func TestHandleReviewCommits(t *testing.T) {
+	t.Parallel()
+	reviewer, lastPrompt := newPromptCapturingReviewer(t, true, "ok")
+	scanner, err := security.New("")
+	require.NoError(t, err)
+	s := newForTesting(config.NewTestConfig(), testutil.NewTestLogger(), reviewer, scanner)
+
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+	testutil.RunGitCmd(t, tmpDir, "tag", "base")
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nconst landed = true\n")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-am", "Land a change")
+	// Uncommitted work must not leak into a commit-range review.
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nconst uncommitted = true\n")
+	head := testutil.RunGitCmd(t, tmpDir, "rev-parse", "HEAD")
+
+	call := func(t *testing.T, args map[string]any) (*mcp.CallToolResult, error) {
+		t.Helper()
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+
+		return s.HandleReviewCommits(t.Context(), request)
+	}
+
+	t.Run("reviews the range", func(t *testing.T) {
+		result, err := call(t, map[string]any{"directory": tmpDir, "from": "base", "to": "HEAD"})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		assert.Contains(t, lastPrompt(), "+const landed = true")
+		assert.NotContains(t, lastPrompt(), "uncommitted")
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		assert.Contains(t, textContent.Text, "LGTM")
+		assert.Equal(t, head, testutil.RunGitCmd(t, tmpDir, "rev-parse", "HEAD"), "must never commit")
+	})
+
+	t.Run("identical commits", func(t *testing.T) {
+		result, err := call(t, map[string]any{"directory": tmpDir, "from": "HEAD", "to": head})
+		require.NoError(t, err)
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		assert.Contains(t, textContent.Text, "No changes to review")
+	})
+
+	t.Run("unknown ref", func(t *testing.T) {
+		result, err := call(t, map[string]any{"directory": tmpDir, "from": "no-such-ref", "to": "HEAD"})
+		assertInBandToolError(t, result, err, "does not name a commit")
+	})
+
+	t.Run("missing ref", func(t *testing.T) {
+		_, err := call(t, map[string]any{"directory": tmpDir, "from": "base"})
+		require.ErrorIs(t, err, ErrRefNotString)
+	})
+}
+
+func TestHandleReviewOnly_BaseBranch(t *testing.T) {
+	t.Parallel()
+	reviewer, lastPrompt := newPromptCapturingReviewer(t, true, "ok")
+	scanner, err := security.New("")
+	require.NoError(t, err)
+	s := newForTesting(config.NewTestConfig(), testutil.NewTestLogger(), reviewer, scanner)
+
+	tmpDir := testutil.CreateTempGitRepo(t)
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+	testutil.RunGitCmd(t, tmpDir, "branch", "upstream")
+	testutil.RunGitCmd(t, tmpDir, "checkout", "-q", "-b", "feature")
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nconst feature = true\n")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-am", "Add feature")
+	testutil.RunGitCmd(t, tmpDir, "checkout", "-q", "upstream")
+	testutil.CreateFile(t, tmpDir, "other.go", "package main\n\nconst upstream = true\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "Move upstream on")
+	testutil.RunGitCmd(t, tmpDir, "checkout", "-q", "feature")
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nconst uncommitted = true\n")
+
+	call := func(t *testing.T, args map[string]any) (*mcp.CallToolResult, error) {
+		t.Helper()
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+
+		return s.HandleReviewOnly(t.Context(), request)
+	}
+
+	t.Run("reviews the branch since it diverged", func(t *testing.T) {
+		result, err := call(t, map[string]any{"directory": tmpDir, "base_branch": "upstream"})
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		assert.Contains(t, lastPrompt(), "+const feature = true")
+		assert.NotContains(t, lastPrompt(), "const upstream", "the base branch's own changes are not reviewed")
+		assert.NotContains(t, lastPrompt(), "uncommitted")
+	})
+
+	t.Run("unknown branch", func(t *testing.T) {
+		result, err := call(t, map[string]any{"directory": tmpDir, "base_branch": "no-such-branch"})
+		assertInBandToolError(t, result, err, "does not name a commit")
+		assertToolErrorCode(t, result, CodeInvalidRef)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := call(t, map[string]any{"directory": tmpDir, "base_branch": 1})
+		require.ErrorIs(t, err, ErrBaseBranchNotString)
+		_, err = call(t, map[string]any{"directory": tmpDir, "base_branch": "upstream", "files": []any{"main.go"}})
+		require.ErrorIs(t, err, ErrBaseBranchWithFiles)
+	})
+}
+
+func TestHandleReviewHead(t *testing.T) {
+	t.Parallel()
+
+	reviewHead := func(t *testing.T, s *Server, directory string) *mcp.CallToolResult {
+		t.Helper()
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"directory": directory}
+		result, err := s.HandleReviewHead(t.Context(), request)
+		require.NoError(t, err)
+
+		return result
+	}
+
+	t.Run("reviews the most recent commit", func(t *testing.T) {
+		t.Parallel()
+		reviewer, lastPrompt := newPromptCapturingReviewer(t, true, "ok")
+		scanner, err := security.New("")
+		require.NoError(t, err)
+		s := newForTesting(config.NewTestConfig(), testutil.NewTestLogger(), reviewer, scanner)
+
+		tmpDir := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nconst earlier = true\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+		testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nconst landed = true\n")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-am", "Land a change")
+		testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nconst uncommitted = true\n")
+		head := testutil.RunGitCmd(t, tmpDir, "rev-parse", "HEAD")
+
+		result := reviewHead(t, s, tmpDir)
+		require.False(t, result.IsError)
+		assert.Contains(t, lastPrompt(), "+const landed = true")
+		assert.Contains(t, lastPrompt(), "-const earlier = true")
+		assert.NotContains(t, lastPrompt(), "uncommitted")
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		assert.Contains(t, textContent.Text, "LGTM")
+		assert.Equal(t, head, testutil.RunGitCmd(t, tmpDir, "rev-parse", "HEAD"), "must never commit")
+	})
+
+	t.Run("reviews a root commit in full", func(t *testing.T) {
+		t.Parallel()
+		reviewer, lastPrompt := newPromptCapturingReviewer(t, true, "ok")
+		scanner, err := security.New("")
+		require.NoError(t, err)
+		s := newForTesting(config.NewTestConfig(), testutil.NewTestLogger(), reviewer, scanner)
+
+		tmpDir := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nconst first = true\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+
+		result := reviewHead(t, s, tmpDir)
+		require.False(t, result.IsError)
+		assert.Contains(t, lastPrompt(), "+const first = true")
+	})
+
+	t.Run("blocks a committed secret", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir := createTestServer(t)
+		testutil.CreateFile(t, tmpDir, "config.go", "const token = \""+fakeSecrets.GitHubPAT()+"\"\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "Add config")
+
+		result := reviewHead(t, s, tmpDir)
+		output, ok := result.StructuredContent.(ReviewOutput)
+		require.True(t, ok)
+		assert.Equal(t, review.StatusChangesRequested, output.Status)
+		assert.NotEmpty(t, output.Findings)
+	})
+
+	t.Run("repository without commits", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir := createTestServer(t)
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"directory": tmpDir}
+		result, err := s.HandleReviewHead(t.Context(), request)
+		assertInBandToolError(t, result, err, "does not name a commit")
+	})
+}
+
+func TestReviewToolResults_StructuredContent(t *testing.T) {
+	t.Parallel()
+
+	newRepo := func(t *testing.T, changed string) string {
+		t.Helper()
+		tmpDir := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, tmpDir, "file.go", "package main\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+		if changed != "" {
+			testutil.CreateFile(t, tmpDir, "file.go", changed)
+		}
+
+		return tmpDir
+	}
+	newServer := func(t *testing.T, lgtm bool, comments string) *Server {
+		t.Helper()
+		scanner, err := security.New("")
+		require.NoError(t, err)
+
+		return newForTesting(config.NewTestConfig(), testutil.NewTestLogger(),
+			review.WithStubResponse(lgtm, comments), scanner)
+	}
+	structured := func(t *testing.T, result *mcp.CallToolResult) ReviewOutput {
+		t.Helper()
+		require.NotNil(t, result)
+		assert.False(t, result.IsError)
+		out, ok := result.StructuredContent.(ReviewOutput)
+		require.True(t, ok, "expected ReviewOutput, got %T", result.StructuredContent)
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		assert.NotEmpty(t, textContent.Text, "text content must be kept for humans")
+
+		return out
+	}
+
+	t.Run("approved review", func(t *testing.T) {
+		t.Parallel()
+		s := newServer(t, true, "Looks good")
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"directory": newRepo(t, "package main\n\nfunc main() {}\n")}
+
+		result, err := s.HandleReviewOnly(t.Context(), request)
+		require.NoError(t, err)
+		assert.Equal(t, ReviewOutput{
+			Status: review.StatusApproved, LGTM: true, Comments: "Looks good", FilesChanged: 1, Additions: 2,
+		}, structured(t, result))
+	})
+
+	t.Run("rejected commit", func(t *testing.T) {
+		t.Parallel()
+		s := newServer(t, false, "Issues found")
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{
+			"directory":      newRepo(t, "package main\n\nfunc main() {}\n"),
+			"commit_message": "Add main",
+		}
+
+		result, err := s.HandleReviewAndCommit(t.Context(), request)
+		require.NoError(t, err)
+		assert.Equal(t, ReviewOutput{
+			Status: review.StatusChangesRequested, Comments: "Issues found", FilesChanged: 1, Additions: 2,
+		}, structured(t, result))
+	})
+
+	t.Run("approved commit", func(t *testing.T) {
+		t.Parallel()
+		s := newServer(t, true, "Looks good")
+		tmpDir := newRepo(t, "package main\n\nfunc main() {}\n")
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"directory": tmpDir, "commit_message": "Add main"}
+
+		result, err := s.HandleReviewAndCommit(t.Context(), request)
+		require.NoError(t, err)
+		out := structured(t, result)
+		assert.True(t, out.LGTM)
+		assert.True(t, out.Committed)
+		head := strings.TrimSpace(testutil.RunGitCmd(t, tmpDir, "rev-parse", "HEAD"))
+		assert.Equal(t, head, out.CommitHash)
+	})
+
+	t.Run("secret findings", func(t *testing.T) {
+		t.Parallel()
+		s := newServer(t, true, "Looks good")
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{
+			"directory": newRepo(t, "package main\n\nconst token = \""+fakeSecrets.GitHubPAT()+"\"\n"),
+		}
+
+		result, err := s.HandleReviewOnly(t.Context(), request)
+		require.NoError(t, err)
+		out := structured(t, result)
+		assert.False(t, out.LGTM)
+		assert.False(t, out.Committed)
+		require.NotEmpty(t, out.Findings)
+		for _, f := range out.Findings {
+			assert.Equal(t, "file.go", f.File)
+			assert.NotEmpty(t, f.RuleID)
+			assert.NotContains(t, f.Secret, fakeSecrets.GitHubPAT())
+		}
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		t.Parallel()
+		s := newServer(t, true, "Looks good")
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"directory": newRepo(t, "")}
+
+		result, err := s.HandleReviewOnly(t.Context(), request)
+		require.NoError(t, err)
+		assert.Equal(t, ReviewOutput{Comments: "No changes to review"}, structured(t, result))
+	})
+}
+
+func TestHandleReviewOnly_MaxConcurrentReviews(t *testing.T) {
+	t.Parallel()
+	s, tmpDir := createTestServer(t)
+	s.reviewSlots = newReviewSlots(&config.Config{Server: config.ServerConfig{MaxConcurrentReviews: new(1)}})
+
+	testutil.CreateFile(t, tmpDir, "file.go", "package main\n")
+	testutil.RunGitCmd(t, tmpDir, "add", ".")
+	testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+	testutil.CreateFile(t, tmpDir, "file.go", "package main\n\nfunc main() {}\n")
+
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"directory": tmpDir}
+
+	// Occupy the only slot, as a review in progress would.
+	release, err := s.acquireReviewSlot(t.Context(), "in-progress")
+	require.NoError(t, err)
+
+	// A request whose client gives up while queued is reported in-band.
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	result, err := s.HandleReviewOnly(ctx, request)
+	assertInBandToolError(t, result, err, "concurrent review slots")
+
+	// A queued request proceeds once the slot is released.
+	done := make(chan *mcp.CallToolResult)
+	go func() {
+		result, _ := s.HandleReviewOnly(t.Context(), request)
+		done <- result
+	}()
+	release()
+	result = <-done
+	require.NotNil(t, result)
+	textContent, ok := result.Content[0].(mcp.TextContent)
+	require.True(t, ok)
+	assert.Contains(t, textContent.Text, "APPROVED")
+}
+
+func TestNewReviewSlots(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, config.DefaultMaxConcurrentReviews, cap(newReviewSlots(nil)))
+	assert.Equal(t, config.DefaultMaxConcurrentReviews, cap(newReviewSlots(config.NewTestConfig())))
+	assert.Equal(t, 2, cap(newReviewSlots(&config.Config{Server: config.ServerConfig{MaxConcurrentReviews: new(2)}})))
+	assert.Nil(t, newReviewSlots(&config.Config{Server: config.ServerConfig{MaxConcurrentReviews: new(0)}}))
+}
+
+func TestHandleReviewAndCommit_Amend(t *testing.T) {
+	t.Parallel()
+
+	t.Run("amends the previous commit", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir := createTestServer(t)
+		testutil.CreateFile(t, tmpDir, "file.go", "package main\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+		testutil.CreateFile(t, tmpDir, "file.go", "package main\n\nfunc main() {}\n")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-am", "Add main")
+		testutil.CreateFile(t, tmpDir, "file.go", "package main\n\nfunc main() { println() }\n")
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{
+			"directory":      tmpDir,
+			"commit_message": "Add main that prints",
+			"amend":          true,
+		}
+		result, err := s.HandleReviewAndCommit(t.Context(), request)
+		require.NoError(t, err)
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		head := testutil.RunGitCmd(t, tmpDir, "rev-parse", "HEAD")
+		assert.Contains(t, textContent.Text, "Changes amended into the previous commit successfully!\nCommit: "+head)
+		out, ok := result.StructuredContent.(ReviewOutput)
+		require.True(t, ok)
+		assert.True(t, out.Amended)
+		assert.Equal(t, head, out.CommitHash)
+
+		assert.Equal(t, "2", testutil.RunGitCmd(t, tmpDir, "rev-list", "--count", "HEAD"))
+		assert.Equal(t, "Add main that prints", testutil.RunGitCmd(t, tmpDir, "log", "-1", "--format=%s"))
+	})
+
+	t.Run("no previous commit", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir := createTestServer(t)
+		testutil.CreateFile(t, tmpDir, "file.go", "package main\n")
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{
+			"directory":      tmpDir,
+			"commit_message": "Add main",
+			"amend":          true,
+		}
+		result, err := s.HandleReviewAndCommit(t.Context(), request)
+		assertInBandToolError(t, result, err, "cannot amend: no previous commit to amend")
+	})
+
+	t.Run("amend must be a boolean", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir := createTestServer(t)
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{
+			"directory":      tmpDir,
+			"commit_message": "Add main",
+			"amend":          "yes",
+		}
+		result, err := s.HandleReviewAndCommit(t.Context(), request)
+		require.ErrorIs(t, err, ErrAmendNotBool)
+		assert.Nil(t, result)
+	})
+}
+
+// readAudit returns the records of the audit log in dir.
+func readAudit(t *testing.T, dir string) []audit.Record {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, audit.FileName))
+	require.NoError(t, err)
+	var records []audit.Record
+	for line := range strings.Lines(string(data)) {
+		var rec audit.Record
+		require.NoError(t, json.Unmarshal([]byte(line), &rec))
+		records = append(records, rec)
+	}
+
+	return records
+}
+
+func TestHandleReview_AuditLog(t *testing.T) {
+	t.Parallel()
+
+	t.Run("records the decision without the diff or comments", func(t *testing.T) {
+		t.Parallel()
+		reviewer, _ := newPromptCapturingReviewer(t, false, "Rename the helper.")
+		scanner, err := security.New("")
+		require.NoError(t, err)
+		s := newForTesting(config.NewTestConfig(), testutil.NewTestLogger(), reviewer, scanner)
+		auditDir := t.TempDir()
+		s.audit, err = audit.New(auditDir)
+		require.NoError(t, err)
+
+		tmpDir := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nfunc helperSentinel() {}\n")
+		testutil.CreateFile(t, tmpDir, "util.go", "package main\n")
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"directory": tmpDir}
+		result, err := s.HandleReviewOnly(t.Context(), request)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+
+		records := readAudit(t, auditDir)
+		require.Len(t, records, 1)
+		assert.Equal(t, filepath.Base(tmpDir), records[0].Repo)
+		assert.Equal(t, 2, records[0].ChangedFiles)
+		assert.Equal(t, string(review.StatusChangesRequested), records[0].Status)
+		assert.False(t, records[0].LGTM)
+		assert.Equal(t, len("Rename the helper."), records[0].CommentLength)
+		assert.False(t, records[0].Timestamp.IsZero())
+
+		data, err := os.ReadFile(filepath.Join(auditDir, audit.FileName))
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "helperSentinel")
+		assert.NotContains(t, string(data), "Rename the helper")
+	})
+
+	t.Run("records a secret scan block", func(t *testing.T) {
+		t.Parallel()
+		s, _ := createTestServer(t)
+		auditDir := t.TempDir()
+		var err error
+		s.audit, err = audit.New(auditDir)
+		require.NoError(t, err)
+
+		tmpDir := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, tmpDir, "config.go", "const token = \""+fakeSecrets.GitHubPAT()+"\"\n")
+
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"directory": tmpDir, "commit_message": "Add config"}
+		_, err = s.HandleReviewAndCommit(t.Context(), request)
+		require.NoError(t, err)
+
+		records := readAudit(t, auditDir)
+		require.Len(t, records, 1)
+		assert.Equal(t, 1, records[0].ChangedFiles)
+		assert.Equal(t, string(review.StatusChangesRequested), records[0].Status)
+		assert.False(t, records[0].LGTM)
+	})
+}
+
+func TestNew_InvalidAuditDirectory(t *testing.T) {
+	t.Parallel()
+	cfg := config.NewTestConfig()
+	cfg.Audit.Directory = "../outside"
+
+	s, err := New(cfg, testutil.NewTestLogger())
+	require.Error(t, err)
+	assert.Nil(t, s)
+	assert.Contains(t, err.Error(), "invalid audit.directory")
+}
+
+func TestHandleReviewAndCommit_CommitMessageFile(t *testing.T) {
+	t.Parallel()
+
+	call := func(t *testing.T, message string) (string, *mcp.CallToolResult) {
+		t.Helper()
+		s, tmpDir := createTestServer(t)
+		testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+		testutil.CreateFile(t, tmpDir, "msg.txt", "Add main\n\nFrom a file.\n")
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"directory": tmpDir, "commit_message": message}
+		result, err := s.HandleReviewAndCommit(t.Context(), request)
+		require.NoError(t, err)
+
+		return tmpDir, result
+	}
+
+	t.Run("reads the message from a file", func(t *testing.T) {
+		t.Parallel()
+		tmpDir, result := call(t, "@msg.txt")
+		require.False(t, result.IsError)
+		assert.Equal(t, "Add main\n\nFrom a file.", testutil.RunGitCmd(t, tmpDir, "log", "-1", "--format=%B"))
+	})
+
+	t.Run("fails before reviewing on an unreadable file", func(t *testing.T) {
+		t.Parallel()
+		_, result := call(t, "@missing.txt")
+		assertInBandToolError(t, result, nil, "cannot commit: failed to read commit message file")
+	})
+}
+
+func TestPrepareReview_ReviewIgnore(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) (*Server, func() string, string) {
+		t.Helper()
+		reviewer, lastPrompt := newPromptCapturingReviewer(t, true, "ok")
+		scanner, err := security.New("")
+		require.NoError(t, err)
+		s := newForTesting(config.NewTestConfig(), testutil.NewTestLogger(), reviewer, scanner)
+
+		tmpDir := testutil.CreateTempGitRepo(t)
+		testutil.CreateFile(t, tmpDir, git.ReviewIgnoreFile, "gen/\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+
+		return s, lastPrompt, tmpDir
+	}
+	reviewAndCommit := func(t *testing.T, s *Server, tmpDir string) *mcp.CallToolResult {
+		t.Helper()
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = map[string]any{"directory": tmpDir, "commit_message": "Add code"}
+		result, err := s.HandleReviewAndCommit(t.Context(), request)
+		require.NoError(t, err)
+		require.NotNil(t, result)
+
+		return result
+	}
+
+	t.Run("omits excluded files from review but commits them", func(t *testing.T) {
+		t.Parallel()
+		s, lastPrompt, tmpDir := setup(t)
+		testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+		testutil.CreateFile(t, tmpDir, "gen/types.pb.go", "package gen\n\nconst generated = true\n")
+
+		result := reviewAndCommit(t, s, tmpDir)
+		require.False(t, result.IsError)
+		assert.Contains(t, lastPrompt(), "main.go")
+		assert.NotContains(t, lastPrompt(), "gen/types.pb.go")
+		assert.NotContains(t, lastPrompt(), "const generated")
+		committed := testutil.RunGitCmd(t, tmpDir, "show", "--name-only", "--format=", "HEAD")
+		assert.Contains(t, committed, "gen/types.pb.go")
+		assert.Contains(t, committed, "main.go")
+	})
+
+	t.Run("reviews every file of a change that edits the exclusions", func(t *testing.T) {
+		t.Parallel()
+		s, lastPrompt, tmpDir := setup(t)
+		testutil.CreateFile(t, tmpDir, git.ReviewIgnoreFile, "gen/\nsrc/backdoor.go\n")
+		testutil.CreateFile(t, tmpDir, "src/backdoor.go", "package src\n\nconst hidden = true\n")
+		testutil.CreateFile(t, tmpDir, "gen/types.pb.go", "package gen\n")
+
+		result := reviewAndCommit(t, s, tmpDir)
+		require.False(t, result.IsError)
+		assert.Contains(t, lastPrompt(), "src/backdoor.go")
+		assert.Contains(t, lastPrompt(), "const hidden")
+		assert.Contains(t, lastPrompt(), "gen/types.pb.go", "no exclusions apply to such a change")
+	})
+
+	t.Run("ignores uncommitted exclusions", func(t *testing.T) {
+		t.Parallel()
+		s, lastPrompt, tmpDir := setup(t)
+		testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+		// Staged or not, only the committed rules count.
+		testutil.RunGitCmd(t, tmpDir, "update-index", "--assume-unchanged", git.ReviewIgnoreFile)
+		testutil.CreateFile(t, tmpDir, git.ReviewIgnoreFile, "gen/\n*.go\n")
+
+		result := reviewAndCommit(t, s, tmpDir)
+		require.False(t, result.IsError)
+		assert.Contains(t, lastPrompt(), "main.go")
+	})
+
+	t.Run("has nothing to review when every file is excluded", func(t *testing.T) {
+		t.Parallel()
+		s, _, tmpDir := setup(t)
+		testutil.CreateFile(t, tmpDir, "gen/types.pb.go", "package gen\n")
+
+		result := reviewAndCommit(t, s, tmpDir)
+		require.False(t, result.IsError)
+		textContent, ok := result.Content[0].(mcp.TextContent)
+		require.True(t, ok)
+		assert.Equal(t, "No changes to review (all changed files are excluded by .lgtmcpignore)", textContent.Text)
+		assert.Equal(t, "initial", testutil.RunGitCmd(t, tmpDir, "log", "-1", "--format=%s"))
+	})
+
+	t.Run("still scans excluded files for secrets", func(t *testing.T) {
+		t.Parallel()
+		s, _, tmpDir := setup(t)
+		testutil.CreateFile(t, tmpDir, "gen/config.go", "const token = \""+fakeSecrets.GitHubPAT()+"\"\n")
+
+		result := reviewAndCommit(t, s, tmpDir)
+		output, ok := result.StructuredContent.(ReviewOutput)
+		require.True(t, ok)
+		assert.Equal(t, review.StatusChangesRequested, output.Status)
+		assert.NotEmpty(t, output.Findings)
+	})
+}
+
+func TestHandleReviewOnly_IncludeDiff(t *testing.T) {
+	t.Parallel()
+
+	review := func(t *testing.T, s *Server, tmpDir string, includeDiff any) (*mcp.CallToolResult, error) {
+		t.Helper()
+		args := map[string]any{"directory": tmpDir}
+		if includeDiff != nil {
+			args["include_diff"] = includeDiff
+		}
+		request := mcp.CallToolRequest{}
+		request.Params.Arguments = args
+
+		return s.HandleReviewOnly(t.Context(), request)
+	}
+
+	t.Run("omitted by default", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir := createTestServer(t)
+		testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+
+		result, err := review(t, s, tmpDir, nil)
+		require.NoError(t, err)
+		require.Len(t, result.Content, 1)
+		output, ok := result.StructuredContent.(ReviewOutput)
+		require.True(t, ok)
+		assert.Empty(t, output.Diff)
+	})
+
+	t.Run("included when asked", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir := createTestServer(t)
+		testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+
+		result, err := review(t, s, tmpDir, true)
+		require.NoError(t, err)
+		require.False(t, result.IsError)
+		output, ok := result.StructuredContent.(ReviewOutput)
+		require.True(t, ok)
+		assert.Contains(t, output.Diff, "+package main")
+		assert.False(t, output.DiffTruncated)
+		require.Len(t, result.Content, 2)
+		textContent, ok := result.Content[1].(mcp.TextContent)
+		require.True(t, ok)
+		assert.True(t, strings.HasPrefix(textContent.Text, "Reviewed diff:\n\n```diff\ndiff --git a/main.go"))
+		assert.True(t, strings.HasSuffix(textContent.Text, "+package main\n```"))
+	})
+
+	t.Run("truncated when enormous", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir := createTestServer(t)
+		testutil.CreateFile(t, tmpDir, "big.txt", strings.Repeat("a line of filler text\n", 5000))
+
+		result, err := review(t, s, tmpDir, true)
+		require.NoError(t, err)
+		output, ok := result.StructuredContent.(ReviewOutput)
+		require.True(t, ok)
+		assert.True(t, output.DiffTruncated)
+		assert.LessOrEqual(t, len(output.Diff), maxResultDiffBytes)
+		assert.True(t, strings.HasSuffix(output.Diff, "+a line of filler text\n"), "cut at a line boundary")
+		textContent, ok := result.Content[1].(mcp.TextContent)
+		require.True(t, ok)
+		assert.Contains(t, textContent.Text, "truncated: showing the first")
+		assert.Contains(t, textContent.Text, "1 file changed, 5000 insertions(+)")
+	})
+
+	t.Run("rejects a non-boolean", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir := createTestServer(t)
+		result, err := review(t, s, tmpDir, "yes")
+		require.ErrorIs(t, err, ErrIncludeDiffNotBool)
+		assert.Nil(t, result)
+	})
+}
+
+func TestPrepareReview_IgnoreWhitespace(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, ignoreWhitespace bool) (*Server, string) {
+		t.Helper()
+		s, tmpDir := createTestServer(t)
+		s.config.Git.IgnoreWhitespace = ignoreWhitespace
+		testutil.CreateFile(t, tmpDir, "format.go", "package p\nfunc f() {\nreturn\n}\n")
+		testutil.CreateFile(t, tmpDir, "logic.go", "package p\nconst x = 1\n")
+		testutil.RunGitCmd(t, tmpDir, "add", ".")
+		testutil.RunGitCmd(t, tmpDir, "commit", "-m", "initial")
+		testutil.CreateFile(t, tmpDir, "format.go", "package p\nfunc f() {\n\treturn\n}\n")
+
+		return s, tmpDir
+	}
+
+	t.Run("omits whitespace-only changes from review but keeps them in the diff", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir := setup(t, true)
+		testutil.CreateFile(t, tmpDir, "logic.go", "package p\nconst x = 2\n")
+
+		rc, earlyReturn, err := s.prepareReview(t.Context(), tmpDir, reviewTarget{}, progress.NewNoOpReporter(), 4)
+		require.NoError(t, err)
+		require.Nil(t, earlyReturn)
+		assert.Equal(t, []string{"logic.go"}, rc.reviewFiles)
+		assert.NotContains(t, rc.reviewDiff, "format.go")
+		assert.ElementsMatch(t, []string{"format.go", "logic.go"}, rc.changedFiles)
+		assert.Contains(t, rc.diff, "+\treturn")
+	})
+
+	t.Run("reviews a whitespace-only change in full", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir := setup(t, true)
+
+		rc, earlyReturn, err := s.prepareReview(t.Context(), tmpDir, reviewTarget{}, progress.NewNoOpReporter(), 4)
+		require.NoError(t, err)
+		require.Nil(t, earlyReturn)
+		assert.Equal(t, rc.diff, rc.reviewDiff)
+		assert.Equal(t, []string{"format.go"}, rc.reviewFiles)
+	})
+
+	t.Run("shows whitespace changes by default", func(t *testing.T) {
+		t.Parallel()
+		s, tmpDir := setup(t, false)
+		testutil.CreateFile(t, tmpDir, "logic.go", "package p\nconst x = 2\n")
+
+		rc, earlyReturn, err := s.prepareReview(t.Context(), tmpDir, reviewTarget{}, progress.NewNoOpReporter(), 4)
+		require.NoError(t, err)
+		require.Nil(t, earlyReturn)
+		assert.Equal(t, rc.diff, rc.reviewDiff)
+		assert.ElementsMatch(t, []string{"format.go", "logic.go"}, rc.reviewFiles)
+	})
+}
@@ -181,7 +181,7 @@ index 0000000..2222222 100644
 		assert.NotEmpty(t, findings)
 
 		// Check that secrets are properly formatted.
-		formatted := security.FormatFindings(findings)
+		formatted := security.FormatFindings(findings, security.DefaultRevealChars)
 		assert.Contains(t, formatted, "potential secret")
 		assert.Contains(t, formatted, "config.yaml")
 		assert.Contains(t, formatted, "ghp...456") // Redacted secret.
@@ -380,7 +380,7 @@ DATABASE_URL=postgres://localhost/mydb`
 		require.NoError(t, err)
 		require.NotEmpty(t, findings)
 
-		formatted := security.FormatFindings(findings)
+		formatted := security.FormatFindings(findings, security.DefaultRevealChars)
 		assert.Contains(t, formatted, "potential secret")
 		assert.Contains(t, formatted, "config.env")
 	})